@@ -41,7 +41,7 @@ const (
 )
 
 const (
-	rawHeaderLen = uint16(16)
+	rawHeaderLen = uint16(20)
 	heart        = 20 * time.Second //s
 	msg          = 30 * time.Second //s
 	roomid       = 1
@@ -146,7 +146,7 @@ func startClient(key string) {
 	conn.SetReadDeadline(time.Now().Add(heart + 60*time.Second))
 
 	wr := bufio.NewWriterSize(conn, 256)
-	seqId := int32(0)
+	seqId := int64(0)
 	proto := new(grpc.Proto)
 	proto.Ver = 1
 	// auth
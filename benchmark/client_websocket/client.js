@@ -1,5 +1,5 @@
 (function(win) {
-    const rawHeaderLen = 16;
+    const rawHeaderLen = 20;
     const packetOffset = 0;
     const headerOffset = 4;
     const verOffset = 6;
@@ -37,7 +37,7 @@
                 var headerLen = dataView.getInt16(headerOffset);
                 var ver = dataView.getInt16(verOffset);
                 var op = dataView.getInt32(opOffset);
-                var seq = dataView.getInt32(seqOffset);
+                var seq = Number(dataView.getBigInt64(seqOffset));
 
                 console.log("receiveHeader: packetLen=" + packetLen, "headerLen=" + headerLen, "ver=" + ver, "op=" + op, "seq=" + seq);
 
@@ -78,7 +78,7 @@
                 headerView.setInt16(headerOffset, rawHeaderLen);
                 headerView.setInt16(verOffset, 1);
                 headerView.setInt32(opOffset, 2);
-                headerView.setInt32(seqOffset, 1);
+                headerView.setBigInt64(seqOffset, 1n);
                 ws.send(headerBuf);
                 console.log("send: heartbeat");
             }
@@ -98,7 +98,7 @@
                 headerView.setInt16(headerOffset, rawHeaderLen);
                 headerView.setInt16(verOffset, 1);
                 headerView.setInt32(opOffset, 7);
-                headerView.setInt32(seqOffset, 1);
+                headerView.setBigInt64(seqOffset, 1n);
                 ws.send(mergeArrayBuffer(headerBuf, bodyBuf));
             }
 
@@ -0,0 +1,86 @@
+package comet
+
+import (
+	"context"
+	"time"
+
+	"github.com/swanky2009/goim/comet/g"
+	"github.com/swanky2009/goim/comet/g/conf"
+	"github.com/swanky2009/goim/grpc/logic"
+	"google.golang.org/grpc"
+)
+
+// Backend authenticates connections and keeps the sessions of a comet server.
+// The standalone comet uses the logic service, an embedding service can
+// implement it in process.
+type Backend interface {
+	// Connect authenticates a handshake token and returns the session.
+	Connect(ctx context.Context, req *logic.ConnectReq) (*logic.ConnectReply, error)
+	// Disconnect removes the session of a closed connection.
+	Disconnect(ctx context.Context, req *logic.DisconnectReq) (*logic.DisconnectReply, error)
+	// Heartbeat renews the session of a connection.
+	Heartbeat(ctx context.Context, req *logic.HeartbeatReq) (*logic.HeartbeatReply, error)
+	// RenewOnline reports the room online of the server and returns the online of all servers.
+	RenewOnline(ctx context.Context, req *logic.OnlineReq) (*logic.OnlineReply, error)
+	// Receive handles a message sent by a client.
+	Receive(ctx context.Context, req *logic.ReceiveReq) (*logic.ReceiveReply, error)
+	// RoomHistory gets the messages of a room after a seq.
+	RoomHistory(ctx context.Context, req *logic.RoomHistoryReq) (*logic.RoomHistoryReply, error)
+	// JoinRoom checks a channel may join a room.
+	JoinRoom(ctx context.Context, req *logic.JoinRoomReq) (*logic.JoinRoomReply, error)
+}
+
+// logicBackend the backend served by the logic grpc service.
+type logicBackend struct {
+	client logic.LogicClient
+}
+
+// NewLogicBackend dial the logic service found by discovery.
+func NewLogicBackend(c *conf.RPCClient) Backend {
+	return &logicBackend{client: newLogicClient(c)}
+}
+
+func newLogicClient(c *conf.RPCClient) logic.LogicClient {
+	opts := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithBalancer(grpc.RoundRobin(g.ServiceResolver)),
+		grpc.WithTimeout(time.Duration(c.Timeout)),
+		//grpc.WithCompressor(grpc.NewGZIPCompressor()),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Dial))
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, "", opts...)
+	if err != nil {
+		panic(err)
+	}
+	return logic.NewLogicClient(conn)
+}
+
+func (b *logicBackend) Connect(ctx context.Context, req *logic.ConnectReq) (*logic.ConnectReply, error) {
+	return b.client.Connect(ctx, req)
+}
+
+func (b *logicBackend) Disconnect(ctx context.Context, req *logic.DisconnectReq) (*logic.DisconnectReply, error) {
+	return b.client.Disconnect(ctx, req)
+}
+
+func (b *logicBackend) Heartbeat(ctx context.Context, req *logic.HeartbeatReq) (*logic.HeartbeatReply, error) {
+	return b.client.Heartbeat(ctx, req)
+}
+
+func (b *logicBackend) RenewOnline(ctx context.Context, req *logic.OnlineReq) (*logic.OnlineReply, error) {
+	return b.client.RenewOnline(ctx, req)
+}
+
+func (b *logicBackend) Receive(ctx context.Context, req *logic.ReceiveReq) (*logic.ReceiveReply, error) {
+	return b.client.Receive(ctx, req)
+}
+
+func (b *logicBackend) RoomHistory(ctx context.Context, req *logic.RoomHistoryReq) (*logic.RoomHistoryReply, error) {
+	return b.client.RoomHistory(ctx, req)
+}
+
+func (b *logicBackend) JoinRoom(ctx context.Context, req *logic.JoinRoomReq) (*logic.JoinRoomReply, error) {
+	return b.client.JoinRoom(ctx, req)
+}
@@ -2,10 +2,10 @@ package comet
 
 import (
 	"sync"
-	"sync/atomic"
 
 	"github.com/swanky2009/goim/comet/g/conf"
 	grpc "github.com/swanky2009/goim/grpc/comet"
+	"github.com/swanky2009/goim/pkg/hash"
 )
 
 // Bucket is a channel holder.
@@ -14,9 +14,8 @@ type Bucket struct {
 	cLock sync.RWMutex        // protect the channels for chs
 	chs   map[string]*Channel // map sub key to a channel
 	// room
-	rooms       map[string]*Room // bucket room channels
-	routines    []chan *grpc.BroadcastRoomReq
-	routinesNum uint64
+	rooms    map[string]*Room // bucket room channels
+	routines []chan *grpc.BroadcastRoomReq
 
 	ipCnts map[string]int32
 }
@@ -64,8 +63,21 @@ func (b *Bucket) RoomsCount() (res map[string]int32) {
 	return
 }
 
+// AppsCount get the channels of the apps.
+func (b *Bucket) AppsCount() (res map[string]int32) {
+	b.cLock.RLock()
+	res = make(map[string]int32)
+	for _, ch := range b.chs {
+		res[ch.App]++
+	}
+	b.cLock.RUnlock()
+	return
+}
+
 // ChangeRoom change ro room
 func (b *Bucket) ChangeRoom(nrid string, ch *Channel) (err error) {
+	ch.rmutex.Lock()
+	defer ch.rmutex.Unlock()
 	var (
 		nroom *Room
 		ok    bool
@@ -95,6 +107,25 @@ func (b *Bucket) ChangeRoom(nrid string, ch *Channel) (err error) {
 	return
 }
 
+// KickRoom takes the channel out of the room, false if it is closed or in
+// another room.
+func (b *Bucket) KickRoom(rid string, ch *Channel) bool {
+	ch.rmutex.Lock()
+	defer ch.rmutex.Unlock()
+	b.cLock.Lock()
+	room := ch.Room
+	if b.chs[ch.Key] != ch || room == nil || room.ID != rid {
+		b.cLock.Unlock()
+		return false
+	}
+	ch.Room = nil
+	b.cLock.Unlock()
+	if room.Del(ch) {
+		b.DelRoom(room)
+	}
+	return true
+}
+
 // Put put a channel according with sub key.
 func (b *Bucket) Put(rid string, ch *Channel) (err error) {
 	var (
@@ -157,15 +188,16 @@ func (b *Bucket) Channel(key string) (ch *Channel) {
 	return
 }
 
-// Broadcast push msgs to all channels in the bucket.
-func (b *Bucket) Broadcast(p *grpc.Proto, op int32, platform string) {
+// Broadcast push msgs to the channels of the app in the bucket, empty is the
+// default app.
+func (b *Bucket) Broadcast(id string, p *grpc.Proto, op int32, platform, app string) {
 	var ch *Channel
 	b.cLock.RLock()
 	for _, ch = range b.chs {
-		if !ch.NeedPush(op, platform) {
+		if ch.App != app || !ch.NeedPush(op, platform) {
 			continue
 		}
-		ch.Push(p)
+		ch.PushMsg(id, p)
 	}
 	b.cLock.RUnlock()
 }
@@ -186,10 +218,10 @@ func (b *Bucket) DelRoom(room *Room) {
 	room.Close()
 }
 
-// BroadcastRoom broadcast a message to specified room
+// BroadcastRoom broadcast a message to specified room, the messages of a
+// room are pushed by one routine in order.
 func (b *Bucket) BroadcastRoom(arg *grpc.BroadcastRoomReq) {
-	num := atomic.AddUint64(&b.routinesNum, 1) % b.c.RoutineAmount
-	b.routines[num] <- arg
+	b.routines[hash.Index(arg.RoomID, int(b.c.RoutineAmount))] <- arg
 }
 
 // Rooms get all room id where online number > 0.
@@ -241,7 +273,7 @@ func (b *Bucket) roomproc(c chan *grpc.BroadcastRoomReq) {
 	for {
 		arg := <-c
 		if room := b.Room(arg.RoomID); room != nil {
-			room.Push(arg.Proto)
+			room.PushMsg(arg.MsgID, arg.Proto)
 		}
 	}
 }
@@ -0,0 +1,80 @@
+package comet
+
+import (
+	"testing"
+
+	"github.com/swanky2009/goim/comet/g/conf"
+	grpc "github.com/swanky2009/goim/grpc/comet"
+)
+
+func TestBucketBroadcastApp(t *testing.T) {
+	b := NewBucket(&conf.Bucket{Channel: 4, Room: 4, RoutineAmount: 1, RoutineSize: 4})
+	chs := make(map[string]*Channel)
+	for _, app := range []string{"", "shop", "game"} {
+		ch := NewChannel(1, 4)
+		ch.Key, ch.App = app+"://key", app
+		if err := b.Put("", ch); err != nil {
+			t.Fatal(err)
+		}
+		chs[app] = ch
+	}
+	if counts := b.AppsCount(); len(counts) != 3 || counts["shop"] != 1 {
+		t.Fatalf("apps count %v", counts)
+	}
+	b.Broadcast("m1", &grpc.Proto{Op: 1}, 1, "", "shop")
+	b.Broadcast("m2", &grpc.Proto{Op: 1}, 1, "", "")
+	// the default app does not reach the others
+	for app, want := range map[string]int{"": 1, "shop": 1, "game": 0} {
+		if n := len(chs[app].signal); n != want {
+			t.Fatalf("app %q pushed %d want %d", app, n, want)
+		}
+	}
+}
+
+func TestBucketKickRoom(t *testing.T) {
+	b := NewBucket(&conf.Bucket{Channel: 4, Room: 4, RoutineAmount: 1, RoutineSize: 4})
+	ch := NewChannel(1, 4)
+	ch.Key = "key"
+	if err := b.Put("room1", ch); err != nil {
+		t.Fatal(err)
+	}
+	if b.KickRoom("room2", ch) {
+		t.Fatal("kicked out of another room")
+	}
+	if !b.KickRoom("room1", ch) || ch.Room != nil || b.Room("room1") != nil {
+		t.Fatalf("not kicked room:%v", ch.Room)
+	}
+	if b.KickRoom("room1", ch) {
+		t.Fatal("kicked twice")
+	}
+	// a closed channel is left to its bucket
+	if err := b.ChangeRoom("room1", ch); err != nil {
+		t.Fatal(err)
+	}
+	b.Del(ch)
+	if b.KickRoom("room1", ch) {
+		t.Fatal("closed channel kicked")
+	}
+}
+
+func TestBucketKickRoomConcurrent(t *testing.T) {
+	b := NewBucket(&conf.Bucket{Channel: 4, Room: 4, RoutineAmount: 1, RoutineSize: 4})
+	ch := NewChannel(1, 4)
+	ch.Key = "key"
+	if err := b.Put("room1", ch); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan bool)
+	go func() {
+		done <- b.KickRoom("room1", ch)
+	}()
+	// the reader goroutine reads the room while logic kicks it
+	for i := 0; i < 100; i++ {
+		if id := ch.RoomID(); id != "" && id != "room1" {
+			t.Fatalf("room %q", id)
+		}
+	}
+	if !<-done || ch.JoinedRoom() != nil {
+		t.Fatal("not kicked")
+	}
+}
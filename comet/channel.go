@@ -7,10 +7,13 @@ import (
 	"github.com/swanky2009/goim/pkg/bufio"
 )
 
+// _msgIDs the latest message ids kept per channel to drop duplicates.
+const _msgIDs = 64
+
 // Channel used by message pusher send msg to write goroutine.
 type Channel struct {
-	Room     *Room
-	CliProto Ring
+	CliProto Ring  // first for the 64-bit alignment of its atomic positions
+	Room     *Room // written under rmutex, read by JoinedRoom
 	signal   chan *grpc.Proto
 	Writer   bufio.Writer
 	Reader   bufio.Reader
@@ -21,8 +24,18 @@ type Channel struct {
 	Key      string
 	IP       string
 	Platform string
+	App      string // the app of the key and room, broadcasts of other apps are not pushed
 	watchOps map[int32]struct{}
 	mutex    sync.RWMutex
+	rmutex   sync.Mutex // the room changes of the client and the kicks of logic
+	msgs     *msgRing   // allocated on the first message with an id
+}
+
+// msgRing the latest message ids pushed to a channel.
+type msgRing struct {
+	ids [_msgIDs]string
+	idx int
+	set map[string]struct{}
 }
 
 // NewChannel new a channel.
@@ -34,6 +47,24 @@ func NewChannel(cli, svr int) *Channel {
 	return c
 }
 
+// JoinedRoom returns the room of the channel, nil if none. The room is changed
+// by the client and kicked out by logic on other goroutines, so it is read
+// once through here.
+func (c *Channel) JoinedRoom() (r *Room) {
+	c.rmutex.Lock()
+	r = c.Room
+	c.rmutex.Unlock()
+	return
+}
+
+// RoomID returns the id of the room of the channel, "" if none.
+func (c *Channel) RoomID() string {
+	if r := c.JoinedRoom(); r != nil {
+		return r.ID
+	}
+	return ""
+}
+
 // Watch watch a operation.
 func (c *Channel) Watch(accepts ...int32) {
 	c.mutex.Lock()
@@ -80,6 +111,37 @@ func (c *Channel) Push(p *grpc.Proto) (err error) {
 	return
 }
 
+// PushMsg pushes a message once, a message with an id pushed before is
+// dropped, a message without an id is always pushed. Only the messages the
+// caller or a retry gives an id are kept.
+func (c *Channel) PushMsg(id string, p *grpc.Proto) (err error) {
+	if id != "" && c.seen(id) {
+		return
+	}
+	return c.Push(p)
+}
+
+// seen reports whether the message id was pushed, and keeps it among the latest ids.
+func (c *Channel) seen(id string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	r := c.msgs
+	if r == nil {
+		r = &msgRing{set: make(map[string]struct{})}
+		c.msgs = r
+	}
+	if _, ok := r.set[id]; ok {
+		return true
+	}
+	if old := r.ids[r.idx]; old != "" {
+		delete(r.set, old)
+	}
+	r.ids[r.idx] = id
+	r.set[id] = struct{}{}
+	r.idx = (r.idx + 1) % _msgIDs
+	return false
+}
+
 // Ready check the channel ready or close?
 func (c *Channel) Ready() *grpc.Proto {
 	return <-c.signal
@@ -0,0 +1,35 @@
+package comet
+
+import (
+	"strconv"
+	"testing"
+
+	grpc "github.com/swanky2009/goim/grpc/comet"
+)
+
+func TestChannelPushMsg(t *testing.T) {
+	var (
+		ch = NewChannel(1, _msgIDs*2)
+		p  = &grpc.Proto{Op: 1000}
+	)
+	ch.PushMsg("", p)
+	ch.PushMsg("", p)
+	if ch.msgs != nil {
+		t.Fatal("ids kept for the messages without one")
+	}
+	ch.PushMsg("m1", p)
+	ch.PushMsg("m1", p)
+	if n := len(ch.signal); n != 3 {
+		t.Fatalf("pushed %d want 3", n)
+	}
+	// the oldest ids are forgotten
+	for i := 0; i < _msgIDs; i++ {
+		ch.seen(strconv.Itoa(i))
+	}
+	if ch.seen("m1") {
+		t.Fatal("m1 is kept beyond the latest ids")
+	}
+	if !ch.seen(strconv.Itoa(_msgIDs - 1)) {
+		t.Fatal("latest id is forgotten")
+	}
+}
@@ -20,6 +20,9 @@ func main() {
 		srv    *comet.Server
 		rpcSrv *grpc.Server
 	)
+	// load config, logger, discovery and metrics
+	g.Init()
+
 	// Mechanical domain.
 	errc := make(chan error)
 
@@ -171,6 +171,23 @@ func LoadConf(curPath string) (*Config, error) {
 	return conf, nil
 }
 
+// Default returns a config with the defaults of a standalone comet, used when
+// the comet server is embedded into another service.
+func Default() *Config {
+	conf := &Config{
+		ServiceName:  "goim-comet",
+		RunMode:      "prod",
+		Discovery:    new(DiscoveryConf),
+		TCP:          new(TCP),
+		WebSocket:    new(WebSocket),
+		Timer:        &Timer{Timer: 32, TimerSize: 2048},
+		ProtoSection: &ProtoSection{HandshakeTimeout: xtime.Duration(8 * time.Second), WriteTimeout: xtime.Duration(8 * time.Second)},
+		Bucket:       &Bucket{Size: 32, Channel: 1024, Room: 1024, RoutineAmount: 32, RoutineSize: 1024},
+	}
+	conf.fix()
+	return conf
+}
+
 func (c *Config) fix() {
 	if c.Env == nil {
 		c.Env = new(Env)
@@ -12,6 +12,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/openzipkin/zipkin-go/reporter"
+	"github.com/sirupsen/logrus"
 	"github.com/swanky2009/goim/comet/g/conf"
 )
 
@@ -23,14 +24,18 @@ const (
 var (
 	Conf *conf.Config
 
-	Logger logger
+	// Logger and StatMetrics default to stderr logging and discarded metrics,
+	// so the comet package can be embedded without calling Init.
+	Logger = logger{logrus.New()}
 
-	StatMetrics *Metrics
+	StatMetrics = discardMetrics()
 
 	zipkinReporter reporter.Reporter
 )
 
-func init() {
+// Init loads the comet config next to the binary, then sets up the logger,
+// discovery and metrics of a standalone comet process.
+func Init() {
 	curPath := GetCurrentDir()
 
 	SetPid(curPath)
@@ -0,0 +1,37 @@
+package g
+
+import (
+	"github.com/go-kit/kit/metrics"
+)
+
+type discardCounter struct{}
+
+func (c discardCounter) With(...string) metrics.Counter { return c }
+func (c discardCounter) Add(float64)                    {}
+
+type discardGauge struct{}
+
+func (g discardGauge) With(...string) metrics.Gauge { return g }
+func (g discardGauge) Set(float64)                  {}
+func (g discardGauge) Add(float64)                  {}
+
+type discardHistogram struct{}
+
+func (h discardHistogram) With(...string) metrics.Histogram { return h }
+func (h discardHistogram) Observe(float64)                  {}
+
+// discardMetrics metrics which drop every observation, used until Init registers the prometheus ones.
+func discardMetrics() *Metrics {
+	return &Metrics{
+		Online:           discardGauge{},
+		TcpOnline:        discardGauge{},
+		WsOnline:         discardGauge{},
+		AllMsg:           discardCounter{},
+		PushMsg:          discardCounter{},
+		BroadcastMsg:     discardCounter{},
+		BroadcastRoomMsg: discardCounter{},
+		SpeedMsgSecond:   discardGauge{},
+		BucketChannels:   discardHistogram{},
+		BucketRooms:      discardHistogram{},
+	}
+}
@@ -74,7 +74,13 @@ func (s *server) PushMsg(ctx context.Context, req *pb.PushMsgReq) (reply *pb.Pus
 			if !channel.NeedPush(req.ProtoOp, "") {
 				continue
 			}
-			if err = channel.Push(req.Proto); err != nil {
+			p := req.Proto
+			if req.ProtoOp == pb.OpRoomKick {
+				if p = s.srv.KickRoom(channel, p); p == nil {
+					continue
+				}
+			}
+			if err = channel.PushMsg(req.MsgID, p); err != nil {
 				return
 			}
 			// increase push stat
@@ -94,7 +100,7 @@ func (s *server) Broadcast(ctx context.Context, req *pb.BroadcastReq) (*pb.Broad
 
 	go func() {
 		for _, bucket := range s.srv.Buckets() {
-			bucket.Broadcast(req.GetProto(), req.ProtoOp, req.Platform)
+			bucket.Broadcast(req.MsgID, req.GetProto(), req.ProtoOp, req.Platform, req.App)
 			if req.Speed > 0 {
 				t := bucket.ChannelCount() / int(req.Speed)
 				time.Sleep(time.Duration(t) * time.Second)
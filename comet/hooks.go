@@ -0,0 +1,35 @@
+package comet
+
+import (
+	grpc "github.com/swanky2009/goim/grpc/comet"
+)
+
+// Hooks is notified of the channel lifecycle.
+// Hooks are called from the connection goroutine, they must not block.
+type Hooks interface {
+	// OnConnect called after a channel is authenticated and put into its bucket.
+	OnConnect(ch *Channel)
+	// OnMessage called for every operation sent by the client, except heartbeats.
+	// p.Body is in the read buffer of the connection and only valid during the
+	// call, copy it to keep it.
+	OnMessage(ch *Channel, p *grpc.Proto)
+	// OnRoomChange called after a channel changed from one room to another, empty means no room.
+	OnRoomChange(ch *Channel, from, to string)
+	// OnDisconnect called after a channel is removed from its bucket.
+	OnDisconnect(ch *Channel)
+}
+
+// NopHooks ignores all events, embed it to implement only some hooks.
+type NopHooks struct{}
+
+// OnConnect .
+func (NopHooks) OnConnect(ch *Channel) {}
+
+// OnMessage .
+func (NopHooks) OnMessage(ch *Channel, p *grpc.Proto) {}
+
+// OnRoomChange .
+func (NopHooks) OnRoomChange(ch *Channel, from, to string) {}
+
+// OnDisconnect .
+func (NopHooks) OnDisconnect(ch *Channel) {}
@@ -2,18 +2,22 @@ package comet
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
+
 	"github.com/swanky2009/goim/comet/g"
 	model "github.com/swanky2009/goim/grpc/comet"
 	logic "github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/pkg/bytes"
 	"github.com/swanky2009/goim/pkg/strings"
 )
 
-// Connect .
-func (s *Server) Connect(p *model.Proto, cookie string) (mid int64, key, rid, platform string, accepts []int32, err error) {
+// Connect authenticates the channel by logic, the key and room are scoped to its app.
+func (s *Server) Connect(p *model.Proto, cookie string) (mid int64, key, rid, platform string, accepts []int32, app string, err error) {
 	var (
 		reply *logic.ConnectReply
 	)
-	if reply, err = s.rpcClient.Connect(context.Background(), &logic.ConnectReq{
+	if reply, err = s.backend.Connect(context.Background(), &logic.ConnectReq{
 		Server:    s.serverID,
 		ServerKey: s.NextKey(),
 		Cookie:    cookie,
@@ -21,81 +25,248 @@ func (s *Server) Connect(p *model.Proto, cookie string) (mid int64, key, rid, pl
 	}); err != nil {
 		return
 	}
-	return reply.Mid, reply.Key, reply.RoomID, reply.Platform, reply.Accepts, nil
+	return reply.Mid, reply.Key, reply.RoomID, reply.Platform, reply.Accepts, reply.App, nil
 }
 
-// Disconnect .
-func (s *Server) Disconnect(mid int64, key string) (err error) {
-	_, err = s.rpcClient.Disconnect(context.Background(), &logic.DisconnectReq{
-		Mid:    mid,
+// Disconnect tells logic the channel is closed and leaves its room.
+func (s *Server) Disconnect(ch *Channel) (err error) {
+	_, err = s.backend.Disconnect(context.Background(), &logic.DisconnectReq{
+		Mid:    ch.Mid,
 		Server: s.serverID,
-		Key:    key,
+		Key:    ch.Key,
+		Room:   ch.RoomID(),
 	})
 	return
 }
 
-// Heartbeat .
-func (s *Server) Heartbeat(mid int64, key string) (err error) {
-	_, err = s.rpcClient.Heartbeat(context.Background(), &logic.HeartbeatReq{
-		Mid:    mid,
-		Server: s.serverID,
-		Key:    key,
+// Heartbeat renews the session of the channel with its room and platform.
+func (s *Server) Heartbeat(ch *Channel) (err error) {
+	return s.heartbeat(ch, false)
+}
+
+// Connected tells logic the channel is put into its bucket, so the messages
+// kept for the mid while offline reach it.
+func (s *Server) Connected(ch *Channel) {
+	if err := s.heartbeat(ch, true); err != nil {
+		g.Logger.Errorf("key: %s connected heartbeat error(%v)", ch.Key, err)
+	}
+}
+
+func (s *Server) heartbeat(ch *Channel, connected bool) (err error) {
+	_, err = s.backend.Heartbeat(context.Background(), &logic.HeartbeatReq{
+		Mid:       ch.Mid,
+		Server:    s.serverID,
+		Key:       ch.Key,
+		Room:      ch.RoomID(),
+		Platform:  ch.Platform,
+		Connected: connected,
 	})
 	return
 }
 
-// RenewOnline .
-func (s *Server) RenewOnline(serverID string, rommCount map[string]int32) (allRoom map[string]int32, err error) {
+// RenewOnline reports the channels of the rooms and the apps.
+func (s *Server) RenewOnline(serverID string, rommCount, appCount map[string]int32) (allRoom map[string]int32, err error) {
 	var (
 		reply *logic.OnlineReply
 	)
-	if reply, err = s.rpcClient.RenewOnline(context.Background(), &logic.OnlineReq{
+	if reply, err = s.backend.RenewOnline(context.Background(), &logic.OnlineReq{
 		Server:    s.serverID,
 		RoomCount: rommCount,
+		AppCount:  appCount,
 	}); err != nil {
 		return
 	}
 	return reply.AllRoomCount, nil
 }
 
-// Report message to logic.
-func (s *Server) Report(mid int64, room string, p *model.Proto) (rp *model.Proto, err error) {
-	if _, err = s.rpcClient.Receive(context.Background(), &logic.ReceiveReq{
-		Mid:  mid,
-		Op:   p.Op,
-		Room: room,
-		Msg:  p.Body,
+// Report message to logic, a message refused by logic has the code and
+// message of the reason.
+func (s *Server) Report(ch *Channel, p *model.Proto) (code int32, msg string, err error) {
+	var reply *logic.ReceiveReply
+	if reply, err = s.backend.Receive(context.Background(), &logic.ReceiveReq{
+		Mid:      ch.Mid,
+		Key:      ch.Key,
+		Server:   s.serverID,
+		Platform: ch.Platform,
+		Op:       p.Op,
+		Room:     ch.RoomID(),
+		// the body is in the read buffer of the connection, overwritten by
+		// the next proto while an in-process backend may keep it
+		Msg: append([]byte(nil), p.Body...),
+	}); err != nil {
+		return
+	}
+	return reply.Code, reply.Message, nil
+}
+
+// JoinRoom checks the channel may join the room, a join refused by logic
+// has the code and message of the reason, else joined is the room scoped to
+// the app of the channel. Leaving a room is not checked, but logic is told.
+func (s *Server) JoinRoom(ch *Channel, room string) (joined string, code int32, msg string, err error) {
+	var (
+		from  = ch.RoomID()
+		reply *logic.JoinRoomReply
+	)
+	if room == "" && from == "" {
+		return
+	}
+	if reply, err = s.backend.JoinRoom(context.Background(), &logic.JoinRoomReq{
+		Mid:    ch.Mid,
+		Key:    ch.Key,
+		Server: s.serverID,
+		Room:   room,
+		From:   from,
+	}); err != nil {
+		return
+	}
+	if joined = reply.Room; joined == "" {
+		// a logic not scoping the rooms
+		joined = room
+	}
+	return joined, reply.Code, reply.Message, nil
+}
+
+// kickBody the json body of OpRoomKick, the room is dropped before the kick
+// is pushed to the client.
+type kickBody struct {
+	Room    string `json:"room,omitempty"`
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+// KickRoom takes the channel out of the room of a kick pushed by logic, and
+// returns the kick pushed to the client. A channel already in another room is
+// left alone, nil.
+func (s *Server) KickRoom(ch *Channel, p *model.Proto) *model.Proto {
+	var kick kickBody
+	if err := json.Unmarshal(p.Body, &kick); err != nil || kick.Room == "" {
+		g.Logger.Errorf("key: %s room kick %s error(%v)", ch.Key, p.Body, err)
+		return nil
+	}
+	if !s.Bucket(ch.Key).KickRoom(kick.Room, ch) {
+		return nil
+	}
+	s.hooks.OnRoomChange(ch, kick.Room, "")
+	g.Logger.Infof("key: %s mid:%d kicked out of room:%s code:%d", ch.Key, ch.Mid, kick.Room, kick.Code)
+	kick.Room = ""
+	body, _ := json.Marshal(&kick)
+	return &model.Proto{Ver: p.Ver, Op: p.Op, Seq: p.Seq, Body: body}
+}
+
+// rejectBody the json body of OpReject.
+type rejectBody struct {
+	Op      int32  `json:"op"`
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+// reject replies the proto refused with OpReject.
+func reject(p *model.Proto, code int32, msg string) {
+	p.Body, _ = json.Marshal(&rejectBody{Op: p.Op, Code: code, Message: msg})
+	p.Op = model.OpReject
+}
+
+// RoomHistory gets the messages of the channel room after the seq from logic,
+// and writes them as protos into a reply body. The messages which do not fit
+// in MaxBodySize are left to the next request from the last seq, the first
+// message is always written so that the client moves on.
+func (s *Server) RoomHistory(ch *Channel, since []byte) (body []byte, err error) {
+	var (
+		sinceSeq int64
+		reply    *logic.RoomHistoryReply
+		room     = ch.RoomID()
+	)
+	if room == "" {
+		return
+	}
+	if len(since) > 0 {
+		if sinceSeq, err = strconv.ParseInt(string(since), 10, 64); err != nil {
+			return
+		}
+	}
+	if reply, err = s.backend.RoomHistory(context.Background(), &logic.RoomHistoryReq{
+		Room:     room,
+		SinceSeq: sinceSeq,
 	}); err != nil {
 		return
 	}
-	return nil, nil
+	w := bytes.NewWriterSize(int(model.MaxBodySize))
+	for _, m := range reply.Msgs {
+		if w.Len() > 0 && int32(w.Len())+model.RawHeaderSize+int32(len(m.Msg)) > model.MaxBodySize {
+			break
+		}
+		p := &model.Proto{Op: m.Op, Seq: m.Seq, Body: m.Msg}
+		p.WriteTo(w)
+	}
+	return w.Buffer(), nil
+}
+
+// detach copies the body of a reply echoing the request out of the read
+// buffer, the next proto is read into it before the reply is written.
+func detach(p *model.Proto) {
+	p.Body = append([]byte(nil), p.Body...)
 }
 
 // Operate .
 func (s *Server) Operate(p *model.Proto, ch *Channel, b *Bucket) (err error) {
+	var (
+		code int32
+		msg  string
+	)
+	s.hooks.OnMessage(ch, p)
 	switch {
 	case p.Op == model.OpSendMsg:
-		// TODO report a message
-		_, err = s.Report(ch.Mid, ch.Room.ID, p)
+		if code, msg, err = s.Report(ch, p); code != 0 {
+			reject(p, code, msg)
+			break
+		}
 		p.Op = model.OpSendMsgReply
 		p.Body = []byte("send message ok")
+	case p.Op == model.OpDelivered || p.Op == model.OpRead:
+		// receipts are aggregated by logic
+		_, _, err = s.Report(ch, p)
+		p.Op++
+		p.Body = nil
 	case p.Op >= model.MinBusinessOp && p.Op <= model.MaxBusinessOp:
-		// TODO Business message
+		// business message, routed upstream by logic
+		if code, msg, err = s.Report(ch, p); code != 0 {
+			reject(p, code, msg)
+			break
+		}
 		p.Body = nil
 	case p.Op == model.OpChangeRoom:
-		err = b.ChangeRoom(string(p.Body), ch)
+		var (
+			from = ch.RoomID()
+			to   = string(p.Body)
+		)
+		if to, code, msg, err = s.JoinRoom(ch, to); err != nil {
+			break
+		}
+		if code != 0 {
+			reject(p, code, msg)
+			break
+		}
+		if err = b.ChangeRoom(to, ch); err == nil {
+			s.hooks.OnRoomChange(ch, from, to)
+		}
+		detach(p)
 		p.Op = model.OpChangeRoomReply
 	case p.Op == model.OpRegister:
 		ops, err := strings.SplitInt32s(string(p.Body), ",")
 		if err == nil {
 			ch.Watch(ops...)
 		}
+		detach(p)
 		p.Op = model.OpRegisterReply
+	case p.Op == model.OpRoomHistory:
+		p.Body, err = s.RoomHistory(ch, p.Body)
+		p.Op = model.OpRoomHistoryReply
 	case p.Op == model.OpUnregister:
 		ops, err := strings.SplitInt32s(string(p.Body), ",")
 		if err == nil {
 			ch.UnWatch(ops...)
 		}
+		detach(p)
 		p.Op = model.OpUnregisterReply
 	default:
 		err = g.ErrOperation
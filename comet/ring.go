@@ -1,11 +1,14 @@
 package comet
 
 import (
+	"sync/atomic"
+
 	"github.com/swanky2009/goim/comet/g"
 	grpc "github.com/swanky2009/goim/grpc/comet"
 )
 
-// Ring .
+// Ring a ring of one reader and one writer goroutine, the positions are
+// published atomically.
 type Ring struct {
 	// read
 	rp   uint64
@@ -45,40 +48,42 @@ func (r *Ring) init(num uint64) {
 
 // Get .
 func (r *Ring) Get() (proto *grpc.Proto, err error) {
-	if r.rp == r.wp {
+	rp := atomic.LoadUint64(&r.rp)
+	if rp == atomic.LoadUint64(&r.wp) {
 		return nil, g.ErrRingEmpty
 	}
-	proto = &r.data[r.rp&r.mask]
+	proto = &r.data[rp&r.mask]
 	return
 }
 
 // GetAdv .
 func (r *Ring) GetAdv() {
-	r.rp++
+	rp := atomic.AddUint64(&r.rp, 1)
 
-	g.Logger.Debugf("ring rp: %d, idx: %d", r.rp, r.rp&r.mask)
+	g.Logger.Debugf("ring rp: %d, idx: %d", rp, rp&r.mask)
 }
 
 // Set .
 func (r *Ring) Set() (proto *grpc.Proto, err error) {
-	if r.wp-r.rp >= r.num {
+	wp := atomic.LoadUint64(&r.wp)
+	if wp-atomic.LoadUint64(&r.rp) >= r.num {
 		return nil, g.ErrRingFull
 	}
-	proto = &r.data[r.wp&r.mask]
+	proto = &r.data[wp&r.mask]
 	return
 }
 
 // SetAdv .
 func (r *Ring) SetAdv() {
-	r.wp++
+	wp := atomic.AddUint64(&r.wp, 1)
 
-	g.Logger.Debugf("ring wp: %d, idx: %d", r.wp, r.wp&r.mask)
+	g.Logger.Debugf("ring wp: %d, idx: %d", wp, wp&r.mask)
 }
 
 // Reset .
 func (r *Ring) Reset() {
-	r.rp = 0
-	r.wp = 0
+	atomic.StoreUint64(&r.rp, 0)
+	atomic.StoreUint64(&r.wp, 0)
 	// prevent pad compiler optimization
 	// r.pad = [40]byte{}
 }
@@ -66,9 +66,14 @@ func (r *Room) Del(ch *Channel) bool {
 
 // Push push msg to the room, if chan full discard it.
 func (r *Room) Push(p *grpc.Proto) {
+	r.PushMsg("", p)
+}
+
+// PushMsg push a message once to the room, see Channel.PushMsg.
+func (r *Room) PushMsg(id string, p *grpc.Proto) {
 	r.rLock.RLock()
 	for ch := r.next; ch != nil; ch = ch.Next {
-		ch.Push(p)
+		ch.PushMsg(id, p)
 	}
 	r.rLock.RUnlock()
 }
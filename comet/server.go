@@ -1,19 +1,17 @@
 package comet
 
 import (
-	"context"
 	"fmt"
 	"math/rand"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/swanky2009/goim/comet/g"
 	"github.com/swanky2009/goim/comet/g/conf"
-	"github.com/swanky2009/goim/grpc/logic"
 	"github.com/swanky2009/goim/pkg/hash"
 	"github.com/swanky2009/goim/pkg/ip"
 	"github.com/zhenjl/cityhash"
-	"google.golang.org/grpc"
 	_ "google.golang.org/grpc/encoding/gzip"
 )
 
@@ -34,25 +32,36 @@ type Server struct {
 	buckets   []*Bucket // subkey bucket
 	bucketIdx uint32
 
-	serverID  string
-	rpcClient logic.LogicClient
+	serverID string
+	backend  Backend
+	hooks    Hooks
+
+	closeOnce sync.Once
+	done      chan struct{}
 }
 
-func newLogicClient(c *conf.RPCClient) logic.LogicClient {
-	opts := []grpc.DialOption{
-		grpc.WithInsecure(),
-		grpc.WithBalancer(grpc.RoundRobin(g.ServiceResolver)),
-		grpc.WithTimeout(time.Duration(c.Timeout)),
-		//grpc.WithCompressor(grpc.NewGZIPCompressor()),
+// Option configures an embedded Server.
+type Option func(s *Server)
+
+// WithBackend use the backend instead of dialing the logic service.
+func WithBackend(b Backend) Option {
+	return func(s *Server) {
+		s.backend = b
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Dial))
-	defer cancel()
+}
 
-	conn, err := grpc.DialContext(ctx, "", opts...)
-	if err != nil {
-		panic(err)
+// WithHooks set the hooks notified of the channel lifecycle.
+func WithHooks(h Hooks) Option {
+	return func(s *Server) {
+		s.hooks = h
+	}
+}
+
+// WithServerID set the server id reported to the backend, default is sha1 of the rpc server address.
+func WithServerID(id string) Option {
+	return func(s *Server) {
+		s.serverID = id
 	}
-	return logic.NewLogicClient(conn)
 }
 
 // serverID sha1(host:port)
@@ -67,13 +76,22 @@ func getServerID(c *conf.RPCServer) string {
 	return hash.Sha1s(fmt.Sprintf("%s:%s", host, port))
 }
 
-// NewServer returns a new Server.
-func NewServer(c *conf.Config) *Server {
+// NewServer returns a new Server, by default it authenticates with the logic service.
+func NewServer(c *conf.Config, opts ...Option) *Server {
 	s := &Server{
-		c:         c,
-		round:     NewRound(c),
-		rpcClient: newLogicClient(c.RPCClient),
-		serverID:  getServerID(c.RPCServer),
+		c:     c,
+		round: NewRound(c),
+		hooks: NopHooks{},
+		done:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.backend == nil {
+		s.backend = NewLogicBackend(c.RPCClient)
+	}
+	if s.serverID == "" {
+		s.serverID = getServerID(c.RPCServer)
 	}
 
 	// init bucket
@@ -114,24 +132,41 @@ func (s *Server) RandServerHearbeat() time.Duration {
 	return time.Duration(_minSrvHeartbeatSecond+rand.Intn(_maxSrvHeartbeatSecond-_minSrvHeartbeatSecond)) * time.Second
 }
 
+// ServerID the server id reported to the backend.
+func (s *Server) ServerID() string {
+	return s.serverID
+}
+
 // Close close the server.
 func (s *Server) Close() (err error) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
 	return
 }
 
 func (s *Server) onlineproc() {
 	for {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
 		var (
 			allRoomsCount map[string]int32
 			err           error
 		)
 		roomCount := make(map[string]int32)
+		appCount := make(map[string]int32)
 		for _, bucket := range s.buckets {
 			for roomID, count := range bucket.RoomsCount() {
 				roomCount[roomID] += count
 			}
+			for app, count := range bucket.AppsCount() {
+				appCount[app] += count
+			}
 		}
-		if allRoomsCount, err = s.RenewOnline(s.serverID, roomCount); err != nil {
+		if allRoomsCount, err = s.RenewOnline(s.serverID, roomCount, appCount); err != nil {
 			time.Sleep(time.Duration(s.c.OnlineTick))
 			continue
 		}
@@ -116,10 +116,12 @@ func (s *Server) ServeTCP(conn *net.TCPConn, rp, wp *bytes.Pool, tr *xtime.Timer
 	// must not setadv, only used in auth
 	step = 1
 	if p, err = ch.CliProto.Set(); err == nil {
-		if ch.Mid, ch.Key, rid, ch.Platform, accepts, err = s.authTCP(rr, wr, p); err == nil {
+		if ch.Mid, ch.Key, rid, ch.Platform, accepts, ch.App, err = s.authTCP(rr, wr, p); err == nil {
 			ch.Watch(accepts...)
 			b = s.Bucket(ch.Key)
-			err = b.Put(rid, ch)
+			if err = b.Put(rid, ch); err == nil {
+				s.hooks.OnConnect(ch)
+			}
 
 			g.Logger.Debugf("tcp connnected key:%s mid:%d proto:%+v", ch.Key, ch.Mid, p)
 		}
@@ -140,6 +142,9 @@ func (s *Server) ServeTCP(conn *net.TCPConn, rp, wp *bytes.Pool, tr *xtime.Timer
 	g.StatMetrics.IncrTcpOnline()
 	// hanshake ok start dispatch goroutine
 	go s.dispatchTCP(conn, wr, wp, wb, ch)
+	// before the first proto is read, which may change the room, and with
+	// the dispatch started, which writes the messages kept for the mid
+	s.Connected(ch)
 	serverHeartbeat := s.RandServerHearbeat()
 	for {
 		if p, err = ch.CliProto.Set(); err != nil {
@@ -160,7 +165,7 @@ func (s *Server) ServeTCP(conn *net.TCPConn, rp, wp *bytes.Pool, tr *xtime.Timer
 			p.Op = grpc.OpHeartbeatReply
 			// last server heartbeat
 			if now := time.Now(); now.Sub(lastHb) > serverHeartbeat {
-				if err = s.Heartbeat(ch.Mid, ch.Key); err == nil {
+				if err = s.Heartbeat(ch); err == nil {
 					lastHb = now
 				} else {
 					err = nil
@@ -189,11 +194,12 @@ func (s *Server) ServeTCP(conn *net.TCPConn, rp, wp *bytes.Pool, tr *xtime.Timer
 		g.Logger.Errorf("key: %s server tcp failed error(%v)", ch.Key, err)
 	}
 	b.Del(ch)
+	s.hooks.OnDisconnect(ch)
 	tr.Del(trd)
 	rp.Put(rb)
 	conn.Close()
 	ch.Close()
-	if err = s.Disconnect(ch.Mid, ch.Key); err != nil {
+	if err = s.Disconnect(ch); err != nil {
 		g.Logger.Errorf("key: %s operator do disconnect error(%v)", ch.Key, err)
 	}
 	g.Logger.Debugf("tcp disconnected key: %s mid:%d", ch.Key, ch.Mid)
@@ -232,8 +238,8 @@ func (s *Server) dispatchTCP(conn *net.TCPConn, wr *bufio.Writer, wp *bytes.Pool
 				g.Logger.Debugf("key: %s start write client proto(%v)", ch.Key, p)
 
 				if p.Op == grpc.OpHeartbeatReply {
-					if ch.Room != nil {
-						online = ch.Room.OnlineNum()
+					if room := ch.JoinedRoom(); room != nil {
+						online = room.OnlineNum()
 					}
 					if err = p.WriteTCPHeart(wr, online); err != nil {
 						goto failed
@@ -276,7 +282,7 @@ failed:
 }
 
 // auth for goim handshake with client, use rsa & aes.
-func (s *Server) authTCP(rr *bufio.Reader, wr *bufio.Writer, p *grpc.Proto) (mid int64, key string, rid string, platform string, accepts []int32, err error) {
+func (s *Server) authTCP(rr *bufio.Reader, wr *bufio.Writer, p *grpc.Proto) (mid int64, key string, rid string, platform string, accepts []int32, app string, err error) {
 	for {
 		if err = p.ReadTCP(rr); err != nil {
 			return
@@ -287,7 +293,7 @@ func (s *Server) authTCP(rr *bufio.Reader, wr *bufio.Writer, p *grpc.Proto) (mid
 			g.Logger.Errorf("tcp request operation(%d) not auth", p.Op)
 		}
 	}
-	if mid, key, rid, platform, accepts, err = s.Connect(p, ""); err != nil {
+	if mid, key, rid, platform, accepts, app, err = s.Connect(p, ""); err != nil {
 		g.Logger.Errorf("authTCP.Connect(key:%v).err(%v)", key, err)
 		return
 	}
@@ -0,0 +1,234 @@
+package comet
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swanky2009/goim/comet/g/conf"
+	grpc "github.com/swanky2009/goim/grpc/comet"
+	"github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/pkg/bufio"
+)
+
+type testBackend struct {
+	mu         sync.Mutex
+	received   []*logic.ReceiveReq
+	disconnect chan *logic.DisconnectReq
+}
+
+func (b *testBackend) Connect(ctx context.Context, req *logic.ConnectReq) (*logic.ConnectReply, error) {
+	return &logic.ConnectReply{Mid: 1, Key: req.ServerKey, RoomID: "live://1000", Platform: "web", Accepts: []int32{1000}}, nil
+}
+
+func (b *testBackend) Disconnect(ctx context.Context, req *logic.DisconnectReq) (*logic.DisconnectReply, error) {
+	b.disconnect <- req
+	return &logic.DisconnectReply{Has: true}, nil
+}
+
+func (b *testBackend) Heartbeat(ctx context.Context, req *logic.HeartbeatReq) (*logic.HeartbeatReply, error) {
+	return &logic.HeartbeatReply{}, nil
+}
+
+func (b *testBackend) RenewOnline(ctx context.Context, req *logic.OnlineReq) (*logic.OnlineReply, error) {
+	return &logic.OnlineReply{AllRoomCount: req.RoomCount}, nil
+}
+
+func (b *testBackend) Receive(ctx context.Context, req *logic.ReceiveReq) (*logic.ReceiveReply, error) {
+	b.mu.Lock()
+	b.received = append(b.received, req)
+	b.mu.Unlock()
+	if string(req.Msg) == "spam" {
+		return &logic.ReceiveReply{Code: 3, Message: "the room is muted"}, nil
+	}
+	return &logic.ReceiveReply{}, nil
+}
+
+func (b *testBackend) JoinRoom(ctx context.Context, req *logic.JoinRoomReq) (*logic.JoinRoomReply, error) {
+	if req.Room == "live://full" {
+		return &logic.JoinRoomReply{Code: 2, Message: "the room is full"}, nil
+	}
+	return &logic.JoinRoomReply{}, nil
+}
+
+func (b *testBackend) RoomHistory(ctx context.Context, req *logic.RoomHistoryReq) (*logic.RoomHistoryReply, error) {
+	reply := new(logic.RoomHistoryReply)
+	for seq := req.SinceSeq + 1; seq <= 3; seq++ {
+		reply.Msgs = append(reply.Msgs, &logic.RoomMsg{Seq: seq, Op: 1000, Msg: []byte(req.Room)})
+	}
+	return reply, nil
+}
+
+type testHooks struct {
+	events chan string
+}
+
+func (h *testHooks) OnConnect(ch *Channel)                     { h.events <- "connect" }
+func (h *testHooks) OnMessage(ch *Channel, p *grpc.Proto)      { h.events <- "message" }
+func (h *testHooks) OnRoomChange(ch *Channel, from, to string) { h.events <- "room:" + from + ">" + to }
+func (h *testHooks) OnDisconnect(ch *Channel)                  { h.events <- "disconnect" }
+
+func expectEvent(t *testing.T, events chan string, want string) {
+	select {
+	case got := <-events:
+		if got != want {
+			t.Fatalf("event got %s want %s", got, want)
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatalf("event %s timeout", want)
+	}
+}
+
+func TestEmbeddedServer(t *testing.T) {
+	var (
+		backend = &testBackend{disconnect: make(chan *logic.DisconnectReq, 1)}
+		hooks   = &testHooks{events: make(chan string, 10)}
+		s       = NewServer(conf.Default(), WithBackend(backend), WithHooks(hooks), WithServerID("test_server"))
+	)
+	defer s.Close()
+	lis, err := net.ListenTCP("tcp4", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go acceptTCP(s, lis)
+
+	conn, err := net.Dial("tcp4", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		rd = bufio.NewReader(conn)
+		wr = bufio.NewWriter(conn)
+		p  = new(grpc.Proto)
+	)
+	write := func(op int32, body string) {
+		p := &grpc.Proto{Ver: 1, Op: op, Body: []byte(body)}
+		if err := p.WriteTCP(wr); err != nil {
+			t.Fatal(err)
+		}
+		if err := wr.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(op int32) {
+		if err := p.ReadTCP(rd); err != nil {
+			t.Fatal(err)
+		}
+		if p.Op != op {
+			t.Fatalf("op got %d want %d", p.Op, op)
+		}
+	}
+	// auth
+	write(grpc.OpAuth, "token")
+	read(grpc.OpAuthReply)
+	expectEvent(t, hooks.events, "connect")
+	// message
+	write(grpc.OpSendMsg, "hello")
+	read(grpc.OpSendMsgReply)
+	expectEvent(t, hooks.events, "message")
+	backend.mu.Lock()
+	if len(backend.received) != 1 || string(backend.received[0].Msg) != "hello" || backend.received[0].Room != "live://1000" {
+		t.Fatalf("received %v", backend.received)
+	}
+	backend.mu.Unlock()
+	// message refused
+	write(grpc.OpSendMsg, "spam")
+	read(grpc.OpReject)
+	expectEvent(t, hooks.events, "message")
+	if string(p.Body) != `{"op":4,"code":3,"message":"the room is muted"}` {
+		t.Fatalf("reject body %s", p.Body)
+	}
+	// receipt
+	write(grpc.OpRead, "msg1")
+	read(grpc.OpReadReply)
+	expectEvent(t, hooks.events, "message")
+	backend.mu.Lock()
+	if len(backend.received) != 3 || backend.received[2].Op != grpc.OpRead || string(backend.received[2].Msg) != "msg1" {
+		t.Fatalf("received %v", backend.received)
+	}
+	backend.mu.Unlock()
+	// change to a full room refused
+	write(grpc.OpChangeRoom, "live://full")
+	read(grpc.OpReject)
+	expectEvent(t, hooks.events, "message")
+	if string(p.Body) != `{"op":12,"code":2,"message":"the room is full"}` {
+		t.Fatalf("reject body %s", p.Body)
+	}
+	// change room
+	write(grpc.OpChangeRoom, "live://1001")
+	read(grpc.OpChangeRoomReply)
+	expectEvent(t, hooks.events, "message")
+	expectEvent(t, hooks.events, "room:live://1000>live://1001")
+	// push from the server
+	for _, b := range s.Buckets() {
+		if room := b.Room("live://1001"); room != nil {
+			room.Push(&grpc.Proto{Ver: 1, Op: 1000, Body: []byte("push")})
+		}
+	}
+	read(1000)
+	if string(p.Body) != "push" {
+		t.Fatalf("push body %s", p.Body)
+	}
+	// room history after seq 1
+	write(grpc.OpRoomHistory, "1")
+	read(grpc.OpRoomHistoryReply)
+	expectEvent(t, hooks.events, "message")
+	hrd := bufio.NewReaderSize(bytes.NewReader(p.Body), len(p.Body))
+	for seq := int64(2); seq <= 3; seq++ {
+		m := new(grpc.Proto)
+		if err := m.ReadTCP(hrd); err != nil {
+			t.Fatal(err)
+		}
+		if m.Seq != seq || m.Op != 1000 || string(m.Body) != "live://1001" {
+			t.Fatalf("history %d: %v", seq, m)
+		}
+	}
+	// disconnect
+	conn.Close()
+	expectEvent(t, hooks.events, "disconnect")
+	select {
+	case req := <-backend.disconnect:
+		if req.Server != "test_server" || req.Mid != 1 {
+			t.Fatalf("disconnect %v", req)
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatal("disconnect timeout")
+	}
+}
+
+func TestRoomHistoryLargeMsg(t *testing.T) {
+	var (
+		s  = NewServer(conf.Default(), WithBackend(&testBackend{}), WithServerID("test_server"))
+		ch = NewChannel(1, 1)
+	)
+	defer s.Close()
+	// a message of the max body does not fit with its header, it is still
+	// replied so the client moves on
+	ch.Room = NewRoom(string(bytes.Repeat([]byte("r"), int(grpc.MaxBodySize))))
+	body, err := s.RoomHistory(ch, []byte("2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := new(grpc.Proto)
+	if err = m.ReadTCP(bufio.NewReaderSize(bytes.NewReader(body), len(body))); err != nil {
+		t.Fatal(err)
+	}
+	if m.Seq != 3 || len(m.Body) != int(grpc.MaxBodySize) {
+		t.Fatalf("history %d: %d bytes", m.Seq, len(m.Body))
+	}
+	// and the reply carrying it is read by the client
+	reply := &grpc.Proto{Op: grpc.OpRoomHistoryReply, Body: body}
+	var buf bytes.Buffer
+	wr := bufio.NewWriter(&buf)
+	if err = reply.WriteTCP(wr); err != nil {
+		t.Fatal(err)
+	}
+	wr.Flush()
+	if err = reply.ReadTCP(bufio.NewReaderSize(&buf, buf.Len())); err != nil {
+		t.Fatal(err)
+	}
+}
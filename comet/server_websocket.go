@@ -196,10 +196,12 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 	// must not setadv, only used in auth
 	step = 3
 	if p, err = ch.CliProto.Set(); err == nil {
-		if ch.Mid, ch.Key, rid, ch.Platform, accepts, err = s.authWebsocket(ws, p, req.Header.Get("Cookie")); err == nil {
+		if ch.Mid, ch.Key, rid, ch.Platform, accepts, ch.App, err = s.authWebsocket(ws, p, req.Header.Get("Cookie")); err == nil {
 			ch.Watch(accepts...)
 			b = s.Bucket(ch.Key)
-			err = b.Put(rid, ch)
+			if err = b.Put(rid, ch); err == nil {
+				s.hooks.OnConnect(ch)
+			}
 
 			g.Logger.Debugf("websocket connnected key:%s mid:%d proto:%+v", ch.Key, ch.Mid, p)
 		}
@@ -224,6 +226,9 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 	g.StatMetrics.IncrWsOnline()
 
 	go s.dispatchWebsocket(ws, wp, wb, ch)
+	// before the first proto is read, which may change the room, and with
+	// the dispatch started, which writes the messages kept for the mid
+	s.Connected(ch)
 	serverHeartbeat := s.RandServerHearbeat()
 	for {
 		if p, err = ch.CliProto.Set(); err != nil {
@@ -244,7 +249,7 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 			p.Op = grpc.OpHeartbeatReply
 			// last server heartbeat
 			if now := time.Now(); now.Sub(lastHB) > serverHeartbeat {
-				if err = s.Heartbeat(ch.Mid, ch.Key); err == nil {
+				if err = s.Heartbeat(ch); err == nil {
 					lastHB = now
 				} else {
 					err = nil
@@ -269,11 +274,12 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 		g.Logger.Errorf("key: %s server ws failed error(%v)", ch.Key, err)
 	}
 	b.Del(ch)
+	s.hooks.OnDisconnect(ch)
 	tr.Del(trd)
 	ws.Close()
 	ch.Close()
 	rp.Put(rb)
-	if err = s.Disconnect(ch.Mid, ch.Key); err != nil {
+	if err = s.Disconnect(ch); err != nil {
 		g.Logger.Errorf("key: %s operator do disconnect error(%v)", ch.Key, err)
 	}
 	g.Logger.Debugf("websocket disconnected key: %s mid:%d", ch.Key, ch.Mid)
@@ -315,8 +321,8 @@ func (s *Server) dispatchWebsocket(ws *websocket.Conn, wp *bytes.Pool, wb *bytes
 				g.Logger.Debugf("key: %s start write client proto(%v)", ch.Key, p)
 
 				if p.Op == grpc.OpHeartbeatReply {
-					if ch.Room != nil {
-						online = ch.Room.OnlineNum()
+					if room := ch.JoinedRoom(); room != nil {
+						online = room.OnlineNum()
 					}
 					if err = p.WriteWebsocketHeart(ws, online); err != nil {
 						goto failed
@@ -362,7 +368,7 @@ failed:
 }
 
 // auth for goim handshake with client, use rsa & aes.
-func (s *Server) authWebsocket(ws *websocket.Conn, p *grpc.Proto, cookie string) (mid int64, key string, rid string, platform string, accepts []int32, err error) {
+func (s *Server) authWebsocket(ws *websocket.Conn, p *grpc.Proto, cookie string) (mid int64, key string, rid string, platform string, accepts []int32, app string, err error) {
 	for {
 		if err = p.ReadWebsocket(ws); err != nil {
 			return
@@ -373,7 +379,7 @@ func (s *Server) authWebsocket(ws *websocket.Conn, p *grpc.Proto, cookie string)
 			g.Logger.Errorf("ws request operation(%d) not auth", p.Op)
 		}
 	}
-	if mid, key, rid, platform, accepts, err = s.Connect(p, cookie); err != nil {
+	if mid, key, rid, platform, accepts, app, err = s.Connect(p, cookie); err != nil {
 		return
 	}
 	p.Op = grpc.OpAuthReply
@@ -24,13 +24,12 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
-//
 // v1.0.0
 // protocol
 type Proto struct {
 	Ver                  int32    `protobuf:"varint,1,opt,name=ver,proto3" json:"ver"`
 	Op                   int32    `protobuf:"varint,2,opt,name=op,proto3" json:"op"`
-	Seq                  int32    `protobuf:"varint,3,opt,name=seq,proto3" json:"seq"`
+	Seq                  int64    `protobuf:"varint,3,opt,name=seq,proto3" json:"seq"`
 	Body                 []byte   `protobuf:"bytes,4,opt,name=body,proto3" json:"body"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -41,7 +40,7 @@ func (m *Proto) Reset()         { *m = Proto{} }
 func (m *Proto) String() string { return proto.CompactTextString(m) }
 func (*Proto) ProtoMessage()    {}
 func (*Proto) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_557c4b2bc7cfc8e4, []int{0}
+	return fileDescriptor_api_49ca4579c371c1d9, []int{0}
 }
 func (m *Proto) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -84,7 +83,7 @@ func (m *Proto) GetOp() int32 {
 	return 0
 }
 
-func (m *Proto) GetSeq() int32 {
+func (m *Proto) GetSeq() int64 {
 	if m != nil {
 		return m.Seq
 	}
@@ -108,7 +107,7 @@ func (m *Empty) Reset()         { *m = Empty{} }
 func (m *Empty) String() string { return proto.CompactTextString(m) }
 func (*Empty) ProtoMessage()    {}
 func (*Empty) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_557c4b2bc7cfc8e4, []int{1}
+	return fileDescriptor_api_49ca4579c371c1d9, []int{1}
 }
 func (m *Empty) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -141,6 +140,7 @@ type PushMsgReq struct {
 	Keys                 []string `protobuf:"bytes,1,rep,name=keys" json:"keys,omitempty"`
 	ProtoOp              int32    `protobuf:"varint,3,opt,name=protoOp,proto3" json:"protoOp,omitempty"`
 	Proto                *Proto   `protobuf:"bytes,2,opt,name=proto" json:"proto,omitempty"`
+	MsgID                string   `protobuf:"bytes,4,opt,name=msgID,proto3" json:"msgID,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -150,7 +150,7 @@ func (m *PushMsgReq) Reset()         { *m = PushMsgReq{} }
 func (m *PushMsgReq) String() string { return proto.CompactTextString(m) }
 func (*PushMsgReq) ProtoMessage()    {}
 func (*PushMsgReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_557c4b2bc7cfc8e4, []int{2}
+	return fileDescriptor_api_49ca4579c371c1d9, []int{2}
 }
 func (m *PushMsgReq) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -200,6 +200,13 @@ func (m *PushMsgReq) GetProto() *Proto {
 	return nil
 }
 
+func (m *PushMsgReq) GetMsgID() string {
+	if m != nil {
+		return m.MsgID
+	}
+	return ""
+}
+
 type PushMsgReply struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -210,7 +217,7 @@ func (m *PushMsgReply) Reset()         { *m = PushMsgReply{} }
 func (m *PushMsgReply) String() string { return proto.CompactTextString(m) }
 func (*PushMsgReply) ProtoMessage()    {}
 func (*PushMsgReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_557c4b2bc7cfc8e4, []int{3}
+	return fileDescriptor_api_49ca4579c371c1d9, []int{3}
 }
 func (m *PushMsgReply) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -244,6 +251,8 @@ type BroadcastReq struct {
 	Proto                *Proto   `protobuf:"bytes,2,opt,name=proto" json:"proto,omitempty"`
 	Speed                int32    `protobuf:"varint,3,opt,name=speed,proto3" json:"speed,omitempty"`
 	Platform             string   `protobuf:"bytes,4,opt,name=platform,proto3" json:"platform,omitempty"`
+	MsgID                string   `protobuf:"bytes,5,opt,name=msgID,proto3" json:"msgID,omitempty"`
+	App                  string   `protobuf:"bytes,6,opt,name=app,proto3" json:"app,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -253,7 +262,7 @@ func (m *BroadcastReq) Reset()         { *m = BroadcastReq{} }
 func (m *BroadcastReq) String() string { return proto.CompactTextString(m) }
 func (*BroadcastReq) ProtoMessage()    {}
 func (*BroadcastReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_557c4b2bc7cfc8e4, []int{4}
+	return fileDescriptor_api_49ca4579c371c1d9, []int{4}
 }
 func (m *BroadcastReq) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -310,6 +319,20 @@ func (m *BroadcastReq) GetPlatform() string {
 	return ""
 }
 
+func (m *BroadcastReq) GetMsgID() string {
+	if m != nil {
+		return m.MsgID
+	}
+	return ""
+}
+
+func (m *BroadcastReq) GetApp() string {
+	if m != nil {
+		return m.App
+	}
+	return ""
+}
+
 type BroadcastReply struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -320,7 +343,7 @@ func (m *BroadcastReply) Reset()         { *m = BroadcastReply{} }
 func (m *BroadcastReply) String() string { return proto.CompactTextString(m) }
 func (*BroadcastReply) ProtoMessage()    {}
 func (*BroadcastReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_557c4b2bc7cfc8e4, []int{5}
+	return fileDescriptor_api_49ca4579c371c1d9, []int{5}
 }
 func (m *BroadcastReply) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -352,6 +375,7 @@ var xxx_messageInfo_BroadcastReply proto.InternalMessageInfo
 type BroadcastRoomReq struct {
 	RoomID               string   `protobuf:"bytes,1,opt,name=roomID,proto3" json:"roomID,omitempty"`
 	Proto                *Proto   `protobuf:"bytes,2,opt,name=proto" json:"proto,omitempty"`
+	MsgID                string   `protobuf:"bytes,3,opt,name=msgID,proto3" json:"msgID,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -361,7 +385,7 @@ func (m *BroadcastRoomReq) Reset()         { *m = BroadcastRoomReq{} }
 func (m *BroadcastRoomReq) String() string { return proto.CompactTextString(m) }
 func (*BroadcastRoomReq) ProtoMessage()    {}
 func (*BroadcastRoomReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_557c4b2bc7cfc8e4, []int{6}
+	return fileDescriptor_api_49ca4579c371c1d9, []int{6}
 }
 func (m *BroadcastRoomReq) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -404,6 +428,13 @@ func (m *BroadcastRoomReq) GetProto() *Proto {
 	return nil
 }
 
+func (m *BroadcastRoomReq) GetMsgID() string {
+	if m != nil {
+		return m.MsgID
+	}
+	return ""
+}
+
 type BroadcastRoomReply struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -414,7 +445,7 @@ func (m *BroadcastRoomReply) Reset()         { *m = BroadcastRoomReply{} }
 func (m *BroadcastRoomReply) String() string { return proto.CompactTextString(m) }
 func (*BroadcastRoomReply) ProtoMessage()    {}
 func (*BroadcastRoomReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_557c4b2bc7cfc8e4, []int{7}
+	return fileDescriptor_api_49ca4579c371c1d9, []int{7}
 }
 func (m *BroadcastRoomReply) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -453,7 +484,7 @@ func (m *RoomsReq) Reset()         { *m = RoomsReq{} }
 func (m *RoomsReq) String() string { return proto.CompactTextString(m) }
 func (*RoomsReq) ProtoMessage()    {}
 func (*RoomsReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_557c4b2bc7cfc8e4, []int{8}
+	return fileDescriptor_api_49ca4579c371c1d9, []int{8}
 }
 func (m *RoomsReq) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -492,7 +523,7 @@ type RoomsReply struct {
 func (m *RoomsReply) Reset()      { *m = RoomsReply{} }
 func (*RoomsReply) ProtoMessage() {}
 func (*RoomsReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_api_557c4b2bc7cfc8e4, []int{9}
+	return fileDescriptor_api_49ca4579c371c1d9, []int{9}
 }
 func (m *RoomsReply) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -899,6 +930,12 @@ func (m *PushMsgReq) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintApi(dAtA, i, uint64(m.ProtoOp))
 	}
+	if len(m.MsgID) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintApi(dAtA, i, uint64(len(m.MsgID)))
+		i += copy(dAtA[i:], m.MsgID)
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -967,6 +1004,18 @@ func (m *BroadcastReq) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintApi(dAtA, i, uint64(len(m.Platform)))
 		i += copy(dAtA[i:], m.Platform)
 	}
+	if len(m.MsgID) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintApi(dAtA, i, uint64(len(m.MsgID)))
+		i += copy(dAtA[i:], m.MsgID)
+	}
+	if len(m.App) > 0 {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintApi(dAtA, i, uint64(len(m.App)))
+		i += copy(dAtA[i:], m.App)
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -1025,6 +1074,12 @@ func (m *BroadcastRoomReq) MarshalTo(dAtA []byte) (int, error) {
 		}
 		i += n3
 	}
+	if len(m.MsgID) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintApi(dAtA, i, uint64(len(m.MsgID)))
+		i += copy(dAtA[i:], m.MsgID)
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -1171,6 +1226,10 @@ func (m *PushMsgReq) Size() (n int) {
 	if m.ProtoOp != 0 {
 		n += 1 + sovApi(uint64(m.ProtoOp))
 	}
+	l = len(m.MsgID)
+	if l > 0 {
+		n += 1 + l + sovApi(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1203,6 +1262,14 @@ func (m *BroadcastReq) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovApi(uint64(l))
 	}
+	l = len(m.MsgID)
+	if l > 0 {
+		n += 1 + l + sovApi(uint64(l))
+	}
+	l = len(m.App)
+	if l > 0 {
+		n += 1 + l + sovApi(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1229,6 +1296,10 @@ func (m *BroadcastRoomReq) Size() (n int) {
 		l = m.Proto.Size()
 		n += 1 + l + sovApi(uint64(l))
 	}
+	l = len(m.MsgID)
+	if l > 0 {
+		n += 1 + l + sovApi(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1364,7 +1435,7 @@ func (m *Proto) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Seq |= (int32(b) & 0x7F) << shift
+				m.Seq |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1583,6 +1654,35 @@ func (m *PushMsgReq) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MsgID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowApi
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthApi
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MsgID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipApi(dAtA[iNdEx:])
@@ -1785,6 +1885,64 @@ func (m *BroadcastReq) Unmarshal(dAtA []byte) error {
 			}
 			m.Platform = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MsgID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowApi
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthApi
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MsgID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field App", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowApi
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthApi
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.App = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipApi(dAtA[iNdEx:])
@@ -1949,6 +2107,35 @@ func (m *BroadcastRoomReq) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MsgID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowApi
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthApi
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MsgID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipApi(dAtA[iNdEx:])
@@ -2338,42 +2525,42 @@ var (
 	ErrIntOverflowApi   = fmt.Errorf("proto: integer overflow")
 )
 
-func init() { proto.RegisterFile("api.proto", fileDescriptor_api_557c4b2bc7cfc8e4) }
-
-var fileDescriptor_api_557c4b2bc7cfc8e4 = []byte{
-	// 529 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x52, 0xcd, 0x6e, 0xd3, 0x40,
-	0x10, 0xee, 0x3a, 0x76, 0x7e, 0xa6, 0xa1, 0x0a, 0xab, 0x28, 0x32, 0x56, 0x95, 0x04, 0x5f, 0xc8,
-	0x81, 0xa6, 0x52, 0x10, 0xa2, 0x2a, 0x27, 0x52, 0x7a, 0xe0, 0x50, 0x11, 0x2d, 0x37, 0x6e, 0x4e,
-	0xb2, 0x75, 0xa3, 0xda, 0xdd, 0xb5, 0xd7, 0xa9, 0xe4, 0x33, 0xe2, 0x1d, 0x7a, 0xe4, 0x71, 0x38,
-	0xf2, 0x04, 0x11, 0x0a, 0xb7, 0x3c, 0x05, 0xda, 0xb1, 0x49, 0x62, 0x08, 0x55, 0x2e, 0xab, 0xf9,
-	0xe6, 0xef, 0xfb, 0x76, 0x66, 0xa0, 0xe6, 0xc9, 0x59, 0x5f, 0xc6, 0x22, 0x11, 0x14, 0x7c, 0x31,
-	0x0b, 0xfb, 0x13, 0x11, 0xf2, 0xc4, 0x39, 0xf1, 0x67, 0xc9, 0xcd, 0x7c, 0xac, 0xd1, 0xa9, 0x2f,
-	0x7c, 0x71, 0x8a, 0x29, 0xe3, 0xf9, 0x35, 0x22, 0x04, 0x68, 0x65, 0xa5, 0xae, 0x02, 0x6b, 0x84,
-	0x3d, 0x9e, 0x41, 0xe9, 0x9e, 0xc7, 0x36, 0xe9, 0x92, 0x9e, 0x35, 0xac, 0xac, 0x16, 0x1d, 0x0d,
-	0x99, 0x7e, 0x68, 0x0b, 0x0c, 0x21, 0x6d, 0x03, 0x23, 0xe5, 0xd5, 0xa2, 0x63, 0x08, 0xc9, 0x0c,
-	0x21, 0x75, 0x89, 0xe2, 0x91, 0x5d, 0xda, 0x94, 0x28, 0x1e, 0x31, 0xfd, 0xd0, 0x63, 0x30, 0xc7,
-	0x62, 0x9a, 0xda, 0x66, 0x97, 0xf4, 0xea, 0xc3, 0xea, 0x6a, 0xd1, 0x41, 0xcc, 0xf0, 0x75, 0x2b,
-	0x60, 0x5d, 0x86, 0x32, 0x49, 0xdd, 0x09, 0xc0, 0x68, 0xae, 0x6e, 0xae, 0x94, 0xcf, 0x78, 0x44,
-	0x29, 0x98, 0xb7, 0x3c, 0x55, 0x36, 0xe9, 0x96, 0x7a, 0x35, 0x86, 0x36, 0x7d, 0x01, 0x16, 0x0a,
-	0x45, 0xfa, 0xc3, 0xc1, 0xd3, 0xfe, 0xe6, 0xab, 0x7d, 0x14, 0xce, 0xb2, 0x38, 0xb5, 0xa1, 0x82,
-	0xc6, 0x47, 0x99, 0x09, 0x62, 0x7f, 0xa0, 0x7b, 0x04, 0xf5, 0x35, 0x89, 0x0c, 0x52, 0xf7, 0x0b,
-	0x81, 0xfa, 0x30, 0x16, 0xde, 0x74, 0xe2, 0xa9, 0x44, 0xf3, 0x6e, 0x95, 0x92, 0x42, 0xe9, 0xfe,
-	0xec, 0x4d, 0xb0, 0x94, 0xe4, 0x7c, 0x9a, 0x73, 0x67, 0x80, 0x3a, 0x50, 0x95, 0x81, 0x97, 0x5c,
-	0x8b, 0x38, 0xc4, 0x49, 0xd4, 0xd8, 0x1a, 0xbb, 0x0d, 0x38, 0xda, 0x12, 0xa1, 0x75, 0x7d, 0x82,
-	0xc6, 0xc6, 0x23, 0x44, 0xa8, 0xa5, 0xb5, 0xa0, 0x1c, 0x0b, 0x11, 0x7e, 0x78, 0x8f, 0xca, 0x6a,
-	0x2c, 0x47, 0x7b, 0x0b, 0x73, 0x9b, 0x40, 0xff, 0x6a, 0xaa, 0xa9, 0x00, 0xaa, 0x1a, 0x28, 0xc6,
-	0x23, 0xf7, 0x2b, 0x01, 0xc8, 0x81, 0x0c, 0x52, 0xfa, 0x06, 0x2c, 0xcd, 0x91, 0x6d, 0xe1, 0x70,
-	0xf0, 0x7c, 0xbb, 0xf3, 0x26, 0x2d, 0x33, 0x2f, 0xef, 0x92, 0x38, 0x65, 0x59, 0xbe, 0x73, 0x96,
-	0xb7, 0x41, 0x27, 0x6d, 0x40, 0xe9, 0x96, 0xa7, 0xb9, 0x6a, 0x6d, 0xea, 0x11, 0xdd, 0x7b, 0xc1,
-	0x9c, 0xa3, 0xe4, 0x2a, 0xcb, 0xc0, 0xb9, 0x71, 0x46, 0xce, 0xcd, 0x87, 0x6f, 0x9d, 0x83, 0xc1,
-	0xca, 0x00, 0xeb, 0x42, 0xd3, 0xd0, 0x97, 0x60, 0x8e, 0x66, 0x77, 0x3e, 0x2d, 0xfc, 0x0a, 0x0f,
-	0xc6, 0xf9, 0xd7, 0x45, 0x4f, 0xc0, 0xba, 0x08, 0x84, 0xe2, 0x7b, 0xa6, 0xbf, 0x85, 0x4a, 0x7e,
-	0x0d, 0xb4, 0x55, 0x98, 0xda, 0xfa, 0x0e, 0x1d, 0x7b, 0xa7, 0x5f, 0x0f, 0xe7, 0x1d, 0xd4, 0xd6,
-	0xd3, 0xa4, 0x85, 0xb4, 0xed, 0x83, 0x72, 0x9c, 0xff, 0x44, 0x74, 0x8b, 0x2b, 0x78, 0x52, 0x58,
-	0x08, 0x3d, 0xde, 0x9d, 0x9c, 0x1d, 0x80, 0xd3, 0x7e, 0x24, 0xaa, 0xdb, 0xbd, 0x06, 0x0b, 0xa7,
-	0x4e, 0x9b, 0x3b, 0x16, 0x15, 0x39, 0xad, 0xdd, 0xeb, 0x1b, 0xb6, 0xbe, 0x2f, 0xdb, 0xe4, 0xc7,
-	0xb2, 0x4d, 0x7e, 0x2e, 0xdb, 0xe4, 0xe1, 0x57, 0xfb, 0xe0, 0xb3, 0xe9, 0xc7, 0x72, 0x32, 0x2e,
-	0xe3, 0xd5, 0xbc, 0xfa, 0x1d, 0x00, 0x00, 0xff, 0xff, 0x46, 0xef, 0x03, 0xca, 0x5d, 0x04, 0x00,
-	0x00,
+func init() { proto.RegisterFile("api.proto", fileDescriptor_api_49ca4579c371c1d9) }
+
+var fileDescriptor_api_49ca4579c371c1d9 = []byte{
+	// 535 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0xc1, 0x6e, 0xd3, 0x40,
+	0x10, 0x86, 0x59, 0xdb, 0x9b, 0xc4, 0xd3, 0x52, 0x85, 0x25, 0x8a, 0x8c, 0x55, 0x25, 0xc1, 0x17,
+	0x72, 0x80, 0x1c, 0x82, 0x10, 0x55, 0x39, 0x91, 0xb6, 0x87, 0x1e, 0x2a, 0xa2, 0x3d, 0x72, 0x73,
+	0x9b, 0xc5, 0x44, 0xb5, 0xbb, 0x6b, 0xaf, 0x5b, 0xc9, 0x0f, 0xc0, 0x3b, 0x70, 0xe4, 0xce, 0x8b,
+	0x70, 0xe4, 0x09, 0x22, 0x94, 0x63, 0x9e, 0x02, 0xed, 0xae, 0x89, 0x6d, 0x08, 0xa8, 0x5c, 0x56,
+	0xf3, 0xcf, 0xcc, 0xee, 0x7c, 0x33, 0x3b, 0xe0, 0x86, 0x62, 0x39, 0x11, 0x19, 0xcf, 0x39, 0x81,
+	0x88, 0x2f, 0x93, 0xc9, 0x15, 0x4f, 0x58, 0xee, 0x43, 0xc4, 0x23, 0x6e, 0xfc, 0x81, 0x04, 0x3c,
+	0xd7, 0x09, 0x4f, 0xc0, 0xbe, 0x63, 0x99, 0x87, 0x46, 0x68, 0x8c, 0x67, 0xed, 0xcd, 0x6a, 0xa8,
+	0x24, 0x55, 0x07, 0xe9, 0x83, 0xc5, 0x85, 0x67, 0xe9, 0x48, 0x6b, 0xb3, 0x1a, 0x5a, 0x5c, 0x50,
+	0x8b, 0x0b, 0x75, 0x45, 0xb2, 0xd4, 0xb3, 0x47, 0x68, 0x6c, 0x9b, 0x2b, 0x92, 0xa5, 0x54, 0x1d,
+	0xe4, 0x10, 0x9c, 0x4b, 0xbe, 0x28, 0x3c, 0x67, 0x84, 0xc6, 0xfb, 0xb3, 0xce, 0x66, 0x35, 0xd4,
+	0x9a, 0xea, 0x33, 0x68, 0x03, 0x3e, 0x4b, 0x44, 0x5e, 0x04, 0x05, 0xc0, 0xfc, 0x56, 0x7e, 0xbc,
+	0x90, 0x11, 0x65, 0x29, 0x21, 0xe0, 0x5c, 0xb3, 0x42, 0x7a, 0x68, 0x64, 0x8f, 0x5d, 0xaa, 0x6d,
+	0xf2, 0x0c, 0xb0, 0x06, 0xd5, 0xe5, 0xf7, 0xa6, 0x8f, 0x26, 0x55, 0x1f, 0x13, 0x0d, 0x4e, 0x4d,
+	0x9c, 0x78, 0xd0, 0xd6, 0xc6, 0x3b, 0xa1, 0x81, 0x30, 0xfd, 0x25, 0x49, 0x0f, 0x70, 0x22, 0xa3,
+	0xf3, 0x53, 0x0d, 0xe3, 0x52, 0x23, 0x82, 0x03, 0xd8, 0xdf, 0x96, 0x16, 0x71, 0x11, 0x7c, 0x45,
+	0xb0, 0x3f, 0xcb, 0x78, 0xb8, 0xb8, 0x0a, 0x65, 0xae, 0x68, 0x6a, 0x0f, 0xa2, 0xe6, 0x83, 0xf7,
+	0x66, 0xea, 0x01, 0x96, 0x82, 0xb1, 0x45, 0x49, 0x64, 0x04, 0xf1, 0xa1, 0x23, 0xe2, 0x30, 0xff,
+	0xc0, 0xb3, 0xa4, 0x44, 0xda, 0xea, 0x8a, 0x15, 0xd7, 0x58, 0x49, 0x17, 0xec, 0x50, 0x08, 0xaf,
+	0xa5, 0x7d, 0xca, 0x0c, 0xba, 0x70, 0x50, 0x83, 0x55, 0xfc, 0x4b, 0xe8, 0x56, 0x1e, 0xce, 0x13,
+	0xd5, 0x42, 0x1f, 0x5a, 0x19, 0xe7, 0xc9, 0xf9, 0xa9, 0xee, 0xc0, 0xa5, 0xa5, 0xfa, 0xaf, 0x06,
+	0x0c, 0x8e, 0x5d, 0x1f, 0x5d, 0x0f, 0xc8, 0x6f, 0xa5, 0x14, 0x00, 0x40, 0x47, 0x09, 0x49, 0x59,
+	0x1a, 0x7c, 0x42, 0x00, 0xa5, 0x10, 0x71, 0x41, 0x5e, 0x03, 0x56, 0x95, 0xcd, 0xcf, 0xee, 0x4d,
+	0x9f, 0xd6, 0xeb, 0x55, 0x69, 0xc6, 0x3c, 0xbb, 0xc9, 0xb3, 0x82, 0x9a, 0x7c, 0xff, 0x08, 0xa0,
+	0x72, 0xaa, 0x31, 0x5c, 0xb3, 0xa2, 0xec, 0x45, 0x99, 0x8a, 0xef, 0x2e, 0x8c, 0x6f, 0x99, 0x6e,
+	0xa4, 0x43, 0x8d, 0x38, 0xb6, 0x8e, 0xd0, 0xb1, 0xf3, 0xf9, 0xcb, 0xf0, 0xc1, 0x74, 0x63, 0x01,
+	0x3e, 0x51, 0x65, 0xc8, 0x73, 0x70, 0xe6, 0xcb, 0x9b, 0x88, 0x34, 0x7a, 0xd5, 0x4b, 0xe8, 0xff,
+	0xe9, 0x22, 0x2f, 0x00, 0x9f, 0xc4, 0x5c, 0xb2, 0x7b, 0xa6, 0xbf, 0x81, 0x76, 0xb9, 0x4b, 0xa4,
+	0xdf, 0x98, 0xe5, 0x76, 0xb7, 0x7d, 0x6f, 0xa7, 0x5f, 0x0d, 0xe7, 0x2d, 0xb8, 0xdb, 0x69, 0x92,
+	0x46, 0x5a, 0x7d, 0x1d, 0x7d, 0xff, 0x2f, 0x11, 0xf5, 0xc4, 0x05, 0x3c, 0x6c, 0x7c, 0x08, 0x39,
+	0xdc, 0x9d, 0x6c, 0xd6, 0xc2, 0x1f, 0xfc, 0x23, 0xaa, 0x9e, 0x7b, 0x05, 0x58, 0x09, 0x49, 0x7a,
+	0x3b, 0x3e, 0x2a, 0xf5, 0xfb, 0xbb, 0xbf, 0x6f, 0xf6, 0xf8, 0xdb, 0x7a, 0x80, 0xbe, 0xaf, 0x07,
+	0xe8, 0xc7, 0x7a, 0x80, 0xde, 0x63, 0x1d, 0xbf, 0x6c, 0xe9, 0x45, 0x7a, 0xf9, 0x73, 0x00, 0x94,
+	0x73, 0xa0, 0x22, 0x8b, 0x04, 0x00, 0x00,
 }
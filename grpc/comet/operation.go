@@ -49,6 +49,28 @@ const (
 	// OpUnregisterReply unregister operation reply
 	OpUnregisterReply = int32(17)
 
+	// OpRoomHistory get the room messages after the seq in body
+	OpRoomHistory = int32(18)
+	// OpRoomHistoryReply room history reply, the body is the messages as protos
+	OpRoomHistoryReply = int32(19)
+
+	// OpDelivered delivered receipt, the body is the message id
+	OpDelivered = int32(20)
+	// OpDeliveredReply delivered receipt reply
+	OpDeliveredReply = int32(21)
+	// OpRead read receipt, the body is the message id
+	OpRead = int32(22)
+	// OpReadReply read receipt reply
+	OpReadReply = int32(23)
+	// OpReceipt receipt pushed to the sender of the message
+	OpReceipt = int32(24)
+	// OpReject a message or a room change refused by logic, the body is json
+	// of the op refused, the code and the message of the reason
+	OpReject = int32(25)
+	// OpRoomKick a channel taken out of its room by logic, the body is json of
+	// the code and the message of the reason
+	OpRoomKick = int32(26)
+
 	// MinBusinessOp min business operation
 	MinBusinessOp = 100
 	// MaxBusinessOp max business operation
@@ -12,6 +12,8 @@ import (
 const (
 	// MaxBodySize max proto body size
 	MaxBodySize = int32(1 << 12)
+	// RawHeaderSize the header size of a proto written by WriteTo.
+	RawHeaderSize = int32(_rawHeaderSize)
 )
 
 const (
@@ -20,10 +22,12 @@ const (
 	_headerSize    = 2
 	_verSize       = 2
 	_opSize        = 4
-	_seqSize       = 4
+	_seqSize       = 8
 	_heartSize     = 4
 	_rawHeaderSize = _packSize + _headerSize + _verSize + _opSize + _seqSize
-	_maxPackSize   = MaxBodySize + int32(_rawHeaderSize)
+	// a room history reply carries at least one message, the largest with
+	// its own header
+	_maxPackSize = MaxBodySize + int32(_rawHeaderSize)*2
 	// offset
 	_packOffset   = 0
 	_headerOffset = _packOffset + _packSize
@@ -57,7 +61,7 @@ func (p *Proto) WriteTo(b *bytes.Writer) {
 	binary.BigEndian.PutInt16(buf[_headerOffset:], int16(_rawHeaderSize))
 	binary.BigEndian.PutInt16(buf[_verOffset:], int16(p.Ver))
 	binary.BigEndian.PutInt32(buf[_opOffset:], p.Op)
-	binary.BigEndian.PutInt32(buf[_seqOffset:], p.Seq)
+	binary.BigEndian.PutInt64(buf[_seqOffset:], p.Seq)
 	if p.Body != nil {
 		b.Write(p.Body)
 	}
@@ -78,7 +82,7 @@ func (p *Proto) ReadTCP(rr *bufio.Reader) (err error) {
 	headerLen = binary.BigEndian.Int16(buf[_headerOffset:_verOffset])
 	p.Ver = int32(binary.BigEndian.Int16(buf[_verOffset:_opOffset]))
 	p.Op = binary.BigEndian.Int32(buf[_opOffset:_seqOffset])
-	p.Seq = binary.BigEndian.Int32(buf[_seqOffset:])
+	p.Seq = binary.BigEndian.Int64(buf[_seqOffset:])
 	if packLen > _maxPackSize {
 		return ErrProtoPackLen
 	}
@@ -112,7 +116,7 @@ func (p *Proto) WriteTCP(wr *bufio.Writer) (err error) {
 	binary.BigEndian.PutInt16(buf[_headerOffset:], int16(_rawHeaderSize))
 	binary.BigEndian.PutInt16(buf[_verOffset:], int16(p.Ver))
 	binary.BigEndian.PutInt32(buf[_opOffset:], p.Op)
-	binary.BigEndian.PutInt32(buf[_seqOffset:], p.Seq)
+	binary.BigEndian.PutInt64(buf[_seqOffset:], p.Seq)
 	if p.Body != nil {
 		_, err = wr.Write(p.Body)
 	}
@@ -134,7 +138,7 @@ func (p *Proto) WriteTCPHeart(wr *bufio.Writer, online int32) (err error) {
 	binary.BigEndian.PutInt16(buf[_headerOffset:], int16(_rawHeaderSize))
 	binary.BigEndian.PutInt16(buf[_verOffset:], int16(p.Ver))
 	binary.BigEndian.PutInt32(buf[_opOffset:], p.Op)
-	binary.BigEndian.PutInt32(buf[_seqOffset:], p.Seq)
+	binary.BigEndian.PutInt64(buf[_seqOffset:], p.Seq)
 	// body
 	binary.BigEndian.PutInt32(buf[_heartOffset:], online)
 	return
@@ -158,7 +162,7 @@ func (p *Proto) ReadWebsocket(ws *websocket.Conn) (err error) {
 	headerLen = binary.BigEndian.Int16(buf[_headerOffset:_verOffset])
 	p.Ver = int32(binary.BigEndian.Int16(buf[_verOffset:_opOffset]))
 	p.Op = binary.BigEndian.Int32(buf[_opOffset:_seqOffset])
-	p.Seq = binary.BigEndian.Int32(buf[_seqOffset:])
+	p.Seq = binary.BigEndian.Int64(buf[_seqOffset:])
 	if packLen > _maxPackSize {
 		return ErrProtoPackLen
 	}
@@ -194,7 +198,7 @@ func (p *Proto) WriteWebsocket(ws *websocket.Conn) (err error) {
 	binary.BigEndian.PutInt16(buf[_headerOffset:], int16(_rawHeaderSize))
 	binary.BigEndian.PutInt16(buf[_verOffset:], int16(p.Ver))
 	binary.BigEndian.PutInt32(buf[_opOffset:], p.Op)
-	binary.BigEndian.PutInt32(buf[_seqOffset:], p.Seq)
+	binary.BigEndian.PutInt64(buf[_seqOffset:], p.Seq)
 	if p.Body != nil {
 		err = ws.WriteBody(p.Body)
 	}
@@ -220,7 +224,7 @@ func (p *Proto) WriteWebsocketHeart(wr *websocket.Conn, online int32) (err error
 	binary.BigEndian.PutInt16(buf[_headerOffset:], int16(_rawHeaderSize))
 	binary.BigEndian.PutInt16(buf[_verOffset:], int16(p.Ver))
 	binary.BigEndian.PutInt32(buf[_opOffset:], p.Op)
-	binary.BigEndian.PutInt32(buf[_seqOffset:], p.Seq)
+	binary.BigEndian.PutInt64(buf[_seqOffset:], p.Seq)
 	// proto body
 	binary.BigEndian.PutInt32(buf[_heartOffset:], online)
 	return
@@ -51,18 +51,48 @@ func (PushMsg_Type) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_00212fb1f9d3bf1c, []int{0, 0}
 }
 
+type PushMsg_Priority int32
+
+const (
+	PushMsg_NORMAL PushMsg_Priority = 0
+	PushMsg_HIGH   PushMsg_Priority = 1
+)
+
+var PushMsg_Priority_name = map[int32]string{
+	0: "NORMAL",
+	1: "HIGH",
+}
+
+var PushMsg_Priority_value = map[string]int32{
+	"NORMAL": 0,
+	"HIGH":   1,
+}
+
+func (x PushMsg_Priority) String() string {
+	return proto.EnumName(PushMsg_Priority_name, int32(x))
+}
+
+func (PushMsg_Priority) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{0, 1}
+}
+
 type PushMsg struct {
-	Type                 PushMsg_Type `protobuf:"varint,1,opt,name=type,proto3,enum=goim.logic.PushMsg_Type" json:"type,omitempty"`
-	Operation            int32        `protobuf:"varint,2,opt,name=operation,proto3" json:"operation,omitempty"`
-	Server               string       `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
-	Keys                 []string     `protobuf:"bytes,4,rep,name=keys,proto3" json:"keys,omitempty"`
-	Room                 string       `protobuf:"bytes,5,opt,name=room,proto3" json:"room,omitempty"`
-	Speed                int32        `protobuf:"varint,6,opt,name=speed,proto3" json:"speed,omitempty"`
-	Platform             string       `protobuf:"bytes,7,opt,name=platform,proto3" json:"platform,omitempty"`
-	Msg                  []byte       `protobuf:"bytes,8,opt,name=msg,proto3" json:"msg,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
-	XXX_unrecognized     []byte       `json:"-"`
-	XXX_sizecache        int32        `json:"-"`
+	Type                 PushMsg_Type     `protobuf:"varint,1,opt,name=type,proto3,enum=goim.logic.PushMsg_Type" json:"type,omitempty"`
+	Operation            int32            `protobuf:"varint,2,opt,name=operation,proto3" json:"operation,omitempty"`
+	Server               string           `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	Keys                 []string         `protobuf:"bytes,4,rep,name=keys,proto3" json:"keys,omitempty"`
+	Room                 string           `protobuf:"bytes,5,opt,name=room,proto3" json:"room,omitempty"`
+	Speed                int32            `protobuf:"varint,6,opt,name=speed,proto3" json:"speed,omitempty"`
+	Platform             string           `protobuf:"bytes,7,opt,name=platform,proto3" json:"platform,omitempty"`
+	Msg                  []byte           `protobuf:"bytes,8,opt,name=msg,proto3" json:"msg,omitempty"`
+	Seq                  int64            `protobuf:"varint,9,opt,name=seq,proto3" json:"seq,omitempty"`
+	Expire               int64            `protobuf:"varint,10,opt,name=expire,proto3" json:"expire,omitempty"`
+	Priority             PushMsg_Priority `protobuf:"varint,11,opt,name=priority,proto3,enum=goim.logic.PushMsg_Priority" json:"priority,omitempty"`
+	MsgID                string           `protobuf:"bytes,12,opt,name=msgID,proto3" json:"msgID,omitempty"`
+	App                  string           `protobuf:"bytes,13,opt,name=app,proto3" json:"app,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
 }
 
 func (m *PushMsg) Reset()         { *m = PushMsg{} }
@@ -146,6 +176,41 @@ func (m *PushMsg) GetMsg() []byte {
 	return nil
 }
 
+func (m *PushMsg) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *PushMsg) GetExpire() int64 {
+	if m != nil {
+		return m.Expire
+	}
+	return 0
+}
+
+func (m *PushMsg) GetPriority() PushMsg_Priority {
+	if m != nil {
+		return m.Priority
+	}
+	return PushMsg_NORMAL
+}
+
+func (m *PushMsg) GetMsgID() string {
+	if m != nil {
+		return m.MsgID
+	}
+	return ""
+}
+
+func (m *PushMsg) GetApp() string {
+	if m != nil {
+		return m.App
+	}
+	return ""
+}
+
 type CloseReply struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -339,6 +404,7 @@ type ConnectReply struct {
 	RoomID               string   `protobuf:"bytes,3,opt,name=roomID,proto3" json:"roomID,omitempty"`
 	Platform             string   `protobuf:"bytes,4,opt,name=platform,proto3" json:"platform,omitempty"`
 	Accepts              []int32  `protobuf:"varint,5,rep,packed,name=accepts,proto3" json:"accepts,omitempty"`
+	App                  string   `protobuf:"bytes,6,opt,name=app,proto3" json:"app,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -404,10 +470,18 @@ func (m *ConnectReply) GetAccepts() []int32 {
 	return nil
 }
 
+func (m *ConnectReply) GetApp() string {
+	if m != nil {
+		return m.App
+	}
+	return ""
+}
+
 type DisconnectReq struct {
 	Mid                  int64    `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
 	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
 	Server               string   `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	Room                 string   `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -459,6 +533,13 @@ func (m *DisconnectReq) GetServer() string {
 	return ""
 }
 
+func (m *DisconnectReq) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
 type DisconnectReply struct {
 	Has                  bool     `protobuf:"varint,1,opt,name=has,proto3" json:"has,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -499,9 +580,14 @@ func (m *DisconnectReply) GetHas() bool {
 }
 
 type HeartbeatReq struct {
-	Mid                  int64    `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
-	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Server               string   `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	Mid      int64  `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
+	Key      string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Server   string `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	Room     string `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`
+	Platform string `protobuf:"bytes,5,opt,name=platform,proto3" json:"platform,omitempty"`
+	// connected the channel is just put into its bucket, the offline
+	// messages of the mid are delivered to it
+	Connected            bool     `protobuf:"varint,6,opt,name=connected,proto3" json:"connected,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -553,6 +639,27 @@ func (m *HeartbeatReq) GetServer() string {
 	return ""
 }
 
+func (m *HeartbeatReq) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *HeartbeatReq) GetPlatform() string {
+	if m != nil {
+		return m.Platform
+	}
+	return ""
+}
+
+func (m *HeartbeatReq) GetConnected() bool {
+	if m != nil {
+		return m.Connected
+	}
+	return false
+}
+
 type HeartbeatReply struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -587,6 +694,7 @@ var xxx_messageInfo_HeartbeatReply proto.InternalMessageInfo
 type OnlineReq struct {
 	Server               string           `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
 	RoomCount            map[string]int32 `protobuf:"bytes,2,rep,name=roomCount,proto3" json:"roomCount,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	AppCount             map[string]int32 `protobuf:"bytes,3,rep,name=appCount,proto3" json:"appCount,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
 	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
 	XXX_unrecognized     []byte           `json:"-"`
 	XXX_sizecache        int32            `json:"-"`
@@ -631,6 +739,13 @@ func (m *OnlineReq) GetRoomCount() map[string]int32 {
 	return nil
 }
 
+func (m *OnlineReq) GetAppCount() map[string]int32 {
+	if m != nil {
+		return m.AppCount
+	}
+	return nil
+}
+
 type OnlineReply struct {
 	AllRoomCount         map[string]int32 `protobuf:"bytes,1,rep,name=allRoomCount,proto3" json:"allRoomCount,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
 	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
@@ -675,6 +790,9 @@ type ReceiveReq struct {
 	Op                   int32    `protobuf:"varint,2,opt,name=op,proto3" json:"op,omitempty"`
 	Room                 string   `protobuf:"bytes,3,opt,name=room,proto3" json:"room,omitempty"`
 	Msg                  []byte   `protobuf:"bytes,4,opt,name=msg,proto3" json:"msg,omitempty"`
+	Key                  string   `protobuf:"bytes,5,opt,name=key,proto3" json:"key,omitempty"`
+	Server               string   `protobuf:"bytes,6,opt,name=server,proto3" json:"server,omitempty"`
+	Platform             string   `protobuf:"bytes,7,opt,name=platform,proto3" json:"platform,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -733,7 +851,32 @@ func (m *ReceiveReq) GetMsg() []byte {
 	return nil
 }
 
+func (m *ReceiveReq) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ReceiveReq) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *ReceiveReq) GetPlatform() string {
+	if m != nil {
+		return m.Platform
+	}
+	return ""
+}
+
+// ReceiveReply a message rejected has the code and message of the reason,
+// replied to the client with OpReject.
 type ReceiveReply struct {
+	Code                 int32    `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -764,205 +907,1889 @@ func (m *ReceiveReply) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_ReceiveReply proto.InternalMessageInfo
 
-func init() {
-	proto.RegisterEnum("goim.logic.PushMsg_Type", PushMsg_Type_name, PushMsg_Type_value)
-	proto.RegisterType((*PushMsg)(nil), "goim.logic.PushMsg")
-	proto.RegisterType((*CloseReply)(nil), "goim.logic.CloseReply")
-	proto.RegisterType((*CloseReq)(nil), "goim.logic.CloseReq")
-	proto.RegisterType((*PingReply)(nil), "goim.logic.PingReply")
-	proto.RegisterType((*PingReq)(nil), "goim.logic.PingReq")
-	proto.RegisterType((*ConnectReq)(nil), "goim.logic.ConnectReq")
-	proto.RegisterType((*ConnectReply)(nil), "goim.logic.ConnectReply")
-	proto.RegisterType((*DisconnectReq)(nil), "goim.logic.DisconnectReq")
-	proto.RegisterType((*DisconnectReply)(nil), "goim.logic.DisconnectReply")
-	proto.RegisterType((*HeartbeatReq)(nil), "goim.logic.HeartbeatReq")
-	proto.RegisterType((*HeartbeatReply)(nil), "goim.logic.HeartbeatReply")
-	proto.RegisterType((*OnlineReq)(nil), "goim.logic.OnlineReq")
-	proto.RegisterMapType((map[string]int32)(nil), "goim.logic.OnlineReq.RoomCountEntry")
-	proto.RegisterType((*OnlineReply)(nil), "goim.logic.OnlineReply")
-	proto.RegisterMapType((map[string]int32)(nil), "goim.logic.OnlineReply.AllRoomCountEntry")
-	proto.RegisterType((*ReceiveReq)(nil), "goim.logic.ReceiveReq")
-	proto.RegisterType((*ReceiveReply)(nil), "goim.logic.ReceiveReply")
+func (m *ReceiveReply) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
 }
 
-func init() { proto.RegisterFile("api.proto", fileDescriptor_00212fb1f9d3bf1c) }
-
-var fileDescriptor_00212fb1f9d3bf1c = []byte{
-	// 710 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0xcd, 0x6e, 0xd3, 0x40,
-	0x10, 0xae, 0x63, 0xbb, 0x89, 0x27, 0x69, 0x08, 0x4b, 0x5b, 0x16, 0x53, 0x89, 0xc8, 0x70, 0x48,
-	0x25, 0x94, 0x43, 0x10, 0x12, 0x2a, 0x20, 0x94, 0x26, 0x48, 0x85, 0x52, 0xa5, 0xda, 0x96, 0x0b,
-	0x37, 0xd7, 0x5d, 0x5c, 0x2b, 0x8e, 0x77, 0x6b, 0xbb, 0x41, 0xbe, 0x71, 0xe0, 0x21, 0x38, 0x22,
-	0x71, 0xe4, 0xca, 0x03, 0xa2, 0x5d, 0xff, 0xd3, 0x14, 0x21, 0xb8, 0xcd, 0xcc, 0xce, 0xef, 0x37,
-	0xf3, 0xd9, 0x60, 0xd8, 0xdc, 0x1b, 0xf2, 0x90, 0xc5, 0x0c, 0x81, 0xcb, 0xbc, 0xc5, 0xd0, 0x67,
-	0xae, 0xe7, 0x98, 0xe0, 0x32, 0x97, 0xa5, 0x76, 0xeb, 0x4b, 0x03, 0x9a, 0xc7, 0x57, 0xd1, 0xc5,
-	0x51, 0xe4, 0xa2, 0xc7, 0xa0, 0xc5, 0x09, 0xa7, 0x58, 0xe9, 0x2b, 0x83, 0xee, 0x08, 0x0f, 0xcb,
-	0x90, 0x61, 0xe6, 0x32, 0x3c, 0x4d, 0x38, 0x25, 0xd2, 0x0b, 0xed, 0x80, 0xc1, 0x38, 0x0d, 0xed,
-	0xd8, 0x63, 0x01, 0x6e, 0xf4, 0x95, 0x81, 0x4e, 0x4a, 0x03, 0xda, 0x86, 0xf5, 0x88, 0x86, 0x4b,
-	0x1a, 0x62, 0xb5, 0xaf, 0x0c, 0x0c, 0x92, 0x69, 0x08, 0x81, 0x36, 0xa7, 0x49, 0x84, 0xb5, 0xbe,
-	0x3a, 0x30, 0x88, 0x94, 0x85, 0x2d, 0x64, 0x6c, 0x81, 0x75, 0xe9, 0x29, 0x65, 0xb4, 0x09, 0x7a,
-	0xc4, 0x29, 0x3d, 0xc7, 0xeb, 0x32, 0x73, 0xaa, 0x20, 0x13, 0x5a, 0xdc, 0xb7, 0xe3, 0x8f, 0x2c,
-	0x5c, 0xe0, 0xa6, 0xf4, 0x2e, 0x74, 0xd4, 0x03, 0x75, 0x11, 0xb9, 0xb8, 0xd5, 0x57, 0x06, 0x1d,
-	0x22, 0x44, 0x6b, 0x17, 0x34, 0xd1, 0x2f, 0x6a, 0x81, 0x76, 0xfc, 0xfe, 0xe4, 0xa0, 0xb7, 0x26,
-	0x24, 0x32, 0x9b, 0x1d, 0xf5, 0x14, 0xb4, 0x01, 0xc6, 0x3e, 0x99, 0x8d, 0xa7, 0x93, 0xf1, 0xc9,
-	0x69, 0xaf, 0x61, 0x75, 0x00, 0x26, 0x3e, 0x8b, 0x28, 0xa1, 0xdc, 0x4f, 0x2c, 0x80, 0x56, 0xa6,
-	0x5d, 0x5a, 0x6d, 0x30, 0x8e, 0xbd, 0xc0, 0x4d, 0x1f, 0x0c, 0x68, 0xa6, 0xca, 0xa5, 0xb5, 0x04,
-	0x98, 0xb0, 0x20, 0xa0, 0x4e, 0x4c, 0xe8, 0x65, 0x65, 0x5c, 0xa5, 0x36, 0xee, 0x0e, 0x18, 0xa9,
-	0x74, 0x48, 0x13, 0x09, 0x92, 0x41, 0x4a, 0x83, 0x88, 0x72, 0x18, 0x9b, 0x7b, 0x34, 0x07, 0x29,
-	0xd5, 0xc4, 0xf0, 0x31, 0x9b, 0xd3, 0x00, 0x6b, 0x72, 0x98, 0x54, 0xd9, 0xd3, 0xbe, 0x7e, 0x7b,
-	0xb0, 0x66, 0x7d, 0x56, 0xa0, 0x53, 0x14, 0xe6, 0x7e, 0x22, 0xe7, 0xf6, 0xce, 0x65, 0x5d, 0x95,
-	0x08, 0x51, 0x58, 0xe6, 0x45, 0x39, 0x21, 0x8a, 0x42, 0x02, 0xd5, 0x37, 0xd3, 0xbc, 0x50, 0xaa,
-	0xd5, 0xf0, 0xd4, 0x7e, 0xc3, 0x13, 0x43, 0xd3, 0x76, 0x1c, 0xca, 0xe3, 0x08, 0xeb, 0x7d, 0x75,
-	0xa0, 0x93, 0x5c, 0xb5, 0x0e, 0x61, 0x63, 0xea, 0x45, 0x4e, 0x39, 0xfd, 0x5f, 0xb6, 0xb0, 0xea,
-	0x20, 0xac, 0x87, 0x70, 0xab, 0x9a, 0x2c, 0x9b, 0xe8, 0xc2, 0x8e, 0x64, 0xba, 0x16, 0x11, 0xa2,
-	0xf5, 0x16, 0x3a, 0x07, 0xd4, 0x0e, 0xe3, 0x33, 0x6a, 0xff, 0x77, 0xc1, 0x1e, 0x74, 0x2b, 0xb9,
-	0xc4, 0x56, 0x7f, 0x28, 0x60, 0xcc, 0x02, 0xdf, 0x0b, 0xe8, 0x9f, 0x56, 0xb9, 0x0f, 0x86, 0x40,
-	0x6d, 0xc2, 0xae, 0x82, 0x18, 0x37, 0xfa, 0xea, 0xa0, 0x3d, 0x7a, 0x54, 0xa5, 0x48, 0x91, 0x61,
-	0x48, 0x72, 0xb7, 0xd7, 0x41, 0x1c, 0x26, 0xa4, 0x0c, 0x33, 0x5f, 0x40, 0xb7, 0xfe, 0x98, 0xf7,
-	0xad, 0x94, 0x7d, 0x6f, 0x82, 0xbe, 0xb4, 0xfd, 0x2b, 0x9a, 0x71, 0x2a, 0x55, 0xf6, 0x1a, 0xcf,
-	0x94, 0xec, 0x00, 0xbe, 0x2b, 0xd0, 0xce, 0x6b, 0x09, 0xb4, 0x8e, 0xa0, 0x63, 0xfb, 0x7e, 0x91,
-	0x16, 0x2b, 0xb2, 0xb5, 0xdd, 0x55, 0xad, 0x71, 0x3f, 0x19, 0x8e, 0x2b, 0xbe, 0x69, 0x7f, 0xb5,
-	0x70, 0xf3, 0x15, 0xdc, 0xbe, 0xe6, 0xf2, 0x0f, 0x5d, 0x9e, 0x02, 0x10, 0xea, 0x50, 0x6f, 0x49,
-	0x57, 0xef, 0xab, 0x0b, 0x0d, 0xc6, 0xb3, 0xe0, 0x06, 0xe3, 0xc5, 0x37, 0x40, 0xad, 0x7c, 0x03,
-	0x32, 0x46, 0x6b, 0x25, 0xa3, 0xbb, 0xd0, 0x29, 0xb2, 0x72, 0x3f, 0x19, 0xfd, 0x54, 0x41, 0x7f,
-	0x27, 0x46, 0x44, 0x23, 0xd0, 0x04, 0x33, 0xd1, 0x9d, 0xda, 0x57, 0x2b, 0xe5, 0xaa, 0xb9, 0x75,
-	0xdd, 0x28, 0x90, 0x7b, 0x0a, 0xba, 0xa4, 0x39, 0xda, 0xac, 0xbe, 0xe7, 0xcc, 0x37, 0xb7, 0x57,
-	0x58, 0x45, 0xd8, 0x73, 0x68, 0x66, 0x04, 0x44, 0x75, 0x97, 0x82, 0x10, 0x26, 0x5e, 0x69, 0x17,
-	0xc1, 0x53, 0x80, 0xf2, 0xdc, 0xd1, 0xbd, 0xaa, 0x5f, 0x8d, 0x53, 0xe6, 0xfd, 0x9b, 0x9e, 0x44,
-	0x96, 0x31, 0x18, 0xc5, 0x0d, 0xa3, 0x5a, 0xb1, 0x2a, 0x4d, 0x4c, 0xf3, 0x86, 0x17, 0x91, 0xe2,
-	0x25, 0xb4, 0x09, 0x0d, 0xe8, 0xa7, 0xf4, 0x36, 0xd0, 0xd6, 0xca, 0x53, 0x36, 0xef, 0xde, 0x70,
-	0x46, 0x02, 0x84, 0x6c, 0x13, 0x75, 0x10, 0xca, 0xa5, 0xd7, 0x41, 0xa8, 0xae, 0x6d, 0xbf, 0xf9,
-	0x41, 0x97, 0xd6, 0xb3, 0x75, 0xf9, 0x13, 0x7a, 0xf2, 0x2b, 0x00, 0x00, 0xff, 0xff, 0x4a, 0x7d,
-	0x46, 0xcb, 0xa9, 0x06, 0x00, 0x00,
+func (m *ReceiveReply) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// LogicClient is the client API for Logic service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type LogicClient interface {
-	// Ping Service
-	Ping(ctx context.Context, in *PingReq, opts ...grpc.CallOption) (*PingReply, error)
-	// Close Service
-	Close(ctx context.Context, in *CloseReq, opts ...grpc.CallOption) (*CloseReply, error)
-	// Connect
-	Connect(ctx context.Context, in *ConnectReq, opts ...grpc.CallOption) (*ConnectReply, error)
-	// Disconnect
-	Disconnect(ctx context.Context, in *DisconnectReq, opts ...grpc.CallOption) (*DisconnectReply, error)
-	// Heartbeat
-	Heartbeat(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*HeartbeatReply, error)
-	// RenewOnline
-	RenewOnline(ctx context.Context, in *OnlineReq, opts ...grpc.CallOption) (*OnlineReply, error)
-	// Receive
-	Receive(ctx context.Context, in *ReceiveReq, opts ...grpc.CallOption) (*ReceiveReply, error)
+// JoinRoomReq checks a channel may join the room, and takes it out of the
+// room it leaves.
+type JoinRoomReq struct {
+	Mid                  int64    `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Server               string   `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	Room                 string   `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`
+	From                 string   `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-type logicClient struct {
-	cc *grpc.ClientConn
+func (m *JoinRoomReq) Reset()         { *m = JoinRoomReq{} }
+func (m *JoinRoomReq) String() string { return proto.CompactTextString(m) }
+func (*JoinRoomReq) ProtoMessage()    {}
+func (*JoinRoomReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{15}
 }
 
-func NewLogicClient(cc *grpc.ClientConn) LogicClient {
-	return &logicClient{cc}
+func (m *JoinRoomReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_JoinRoomReq.Unmarshal(m, b)
 }
-
-func (c *logicClient) Ping(ctx context.Context, in *PingReq, opts ...grpc.CallOption) (*PingReply, error) {
-	out := new(PingReply)
-	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Ping", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+func (m *JoinRoomReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_JoinRoomReq.Marshal(b, m, deterministic)
+}
+func (m *JoinRoomReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JoinRoomReq.Merge(m, src)
+}
+func (m *JoinRoomReq) XXX_Size() int {
+	return xxx_messageInfo_JoinRoomReq.Size(m)
+}
+func (m *JoinRoomReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_JoinRoomReq.DiscardUnknown(m)
 }
 
-func (c *logicClient) Close(ctx context.Context, in *CloseReq, opts ...grpc.CallOption) (*CloseReply, error) {
-	out := new(CloseReply)
-	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Close", in, out, opts...)
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_JoinRoomReq proto.InternalMessageInfo
+
+func (m *JoinRoomReq) GetMid() int64 {
+	if m != nil {
+		return m.Mid
 	}
-	return out, nil
+	return 0
 }
 
-func (c *logicClient) Connect(ctx context.Context, in *ConnectReq, opts ...grpc.CallOption) (*ConnectReply, error) {
-	out := new(ConnectReply)
-	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Connect", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *JoinRoomReq) GetKey() string {
+	if m != nil {
+		return m.Key
 	}
-	return out, nil
+	return ""
 }
 
-func (c *logicClient) Disconnect(ctx context.Context, in *DisconnectReq, opts ...grpc.CallOption) (*DisconnectReply, error) {
-	out := new(DisconnectReply)
-	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Disconnect", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *JoinRoomReq) GetServer() string {
+	if m != nil {
+		return m.Server
 	}
-	return out, nil
+	return ""
 }
 
-func (c *logicClient) Heartbeat(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*HeartbeatReply, error) {
-	out := new(HeartbeatReply)
-	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Heartbeat", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *JoinRoomReq) GetRoom() string {
+	if m != nil {
+		return m.Room
 	}
-	return out, nil
+	return ""
 }
 
-func (c *logicClient) RenewOnline(ctx context.Context, in *OnlineReq, opts ...grpc.CallOption) (*OnlineReply, error) {
-	out := new(OnlineReply)
-	err := c.cc.Invoke(ctx, "/goim.logic.Logic/RenewOnline", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *JoinRoomReq) GetFrom() string {
+	if m != nil {
+		return m.From
 	}
-	return out, nil
+	return ""
 }
 
-func (c *logicClient) Receive(ctx context.Context, in *ReceiveReq, opts ...grpc.CallOption) (*ReceiveReply, error) {
-	out := new(ReceiveReply)
-	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Receive", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+// JoinRoomReply a join refused has the code and message of the reason.
+type JoinRoomReply struct {
+	Code                 int32    `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Room                 string   `protobuf:"bytes,3,opt,name=room,proto3" json:"room,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-// LogicServer is the server API for Logic service.
-type LogicServer interface {
-	// Ping Service
-	Ping(context.Context, *PingReq) (*PingReply, error)
-	// Close Service
-	Close(context.Context, *CloseReq) (*CloseReply, error)
-	// Connect
-	Connect(context.Context, *ConnectReq) (*ConnectReply, error)
-	// Disconnect
-	Disconnect(context.Context, *DisconnectReq) (*DisconnectReply, error)
-	// Heartbeat
-	Heartbeat(context.Context, *HeartbeatReq) (*HeartbeatReply, error)
-	// RenewOnline
-	RenewOnline(context.Context, *OnlineReq) (*OnlineReply, error)
-	// Receive
-	Receive(context.Context, *ReceiveReq) (*ReceiveReply, error)
+func (m *JoinRoomReply) Reset()         { *m = JoinRoomReply{} }
+func (m *JoinRoomReply) String() string { return proto.CompactTextString(m) }
+func (*JoinRoomReply) ProtoMessage()    {}
+func (*JoinRoomReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{16}
 }
 
-func RegisterLogicServer(s *grpc.Server, srv LogicServer) {
-	s.RegisterService(&_Logic_serviceDesc, srv)
+func (m *JoinRoomReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_JoinRoomReply.Unmarshal(m, b)
+}
+func (m *JoinRoomReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_JoinRoomReply.Marshal(b, m, deterministic)
+}
+func (m *JoinRoomReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JoinRoomReply.Merge(m, src)
+}
+func (m *JoinRoomReply) XXX_Size() int {
+	return xxx_messageInfo_JoinRoomReply.Size(m)
+}
+func (m *JoinRoomReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_JoinRoomReply.DiscardUnknown(m)
 }
 
-func _Logic_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PingReq)
-	if err := dec(in); err != nil {
-		return nil, err
+var xxx_messageInfo_JoinRoomReply proto.InternalMessageInfo
+
+func (m *JoinRoomReply) GetCode() int32 {
+	if m != nil {
+		return m.Code
 	}
-	if interceptor == nil {
+	return 0
+}
+
+func (m *JoinRoomReply) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *JoinRoomReply) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+// RoomMsg a message kept in the room history.
+type RoomMsg struct {
+	Seq                  int64    `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Op                   int32    `protobuf:"varint,2,opt,name=op,proto3" json:"op,omitempty"`
+	Msg                  []byte   `protobuf:"bytes,3,opt,name=msg,proto3" json:"msg,omitempty"`
+	Ts                   int64    `protobuf:"varint,4,opt,name=ts,proto3" json:"ts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RoomMsg) Reset()         { *m = RoomMsg{} }
+func (m *RoomMsg) String() string { return proto.CompactTextString(m) }
+func (*RoomMsg) ProtoMessage()    {}
+func (*RoomMsg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{17}
+}
+
+func (m *RoomMsg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RoomMsg.Unmarshal(m, b)
+}
+func (m *RoomMsg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RoomMsg.Marshal(b, m, deterministic)
+}
+func (m *RoomMsg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RoomMsg.Merge(m, src)
+}
+func (m *RoomMsg) XXX_Size() int {
+	return xxx_messageInfo_RoomMsg.Size(m)
+}
+func (m *RoomMsg) XXX_DiscardUnknown() {
+	xxx_messageInfo_RoomMsg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RoomMsg proto.InternalMessageInfo
+
+func (m *RoomMsg) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *RoomMsg) GetOp() int32 {
+	if m != nil {
+		return m.Op
+	}
+	return 0
+}
+
+func (m *RoomMsg) GetMsg() []byte {
+	if m != nil {
+		return m.Msg
+	}
+	return nil
+}
+
+func (m *RoomMsg) GetTs() int64 {
+	if m != nil {
+		return m.Ts
+	}
+	return 0
+}
+
+type RoomHistoryReq struct {
+	Room                 string   `protobuf:"bytes,1,opt,name=room,proto3" json:"room,omitempty"`
+	SinceSeq             int64    `protobuf:"varint,2,opt,name=sinceSeq,proto3" json:"sinceSeq,omitempty"`
+	Count                int32    `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RoomHistoryReq) Reset()         { *m = RoomHistoryReq{} }
+func (m *RoomHistoryReq) String() string { return proto.CompactTextString(m) }
+func (*RoomHistoryReq) ProtoMessage()    {}
+func (*RoomHistoryReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{18}
+}
+
+func (m *RoomHistoryReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RoomHistoryReq.Unmarshal(m, b)
+}
+func (m *RoomHistoryReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RoomHistoryReq.Marshal(b, m, deterministic)
+}
+func (m *RoomHistoryReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RoomHistoryReq.Merge(m, src)
+}
+func (m *RoomHistoryReq) XXX_Size() int {
+	return xxx_messageInfo_RoomHistoryReq.Size(m)
+}
+func (m *RoomHistoryReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_RoomHistoryReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RoomHistoryReq proto.InternalMessageInfo
+
+func (m *RoomHistoryReq) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *RoomHistoryReq) GetSinceSeq() int64 {
+	if m != nil {
+		return m.SinceSeq
+	}
+	return 0
+}
+
+func (m *RoomHistoryReq) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type RoomHistoryReply struct {
+	Msgs                 []*RoomMsg `protobuf:"bytes,1,rep,name=msgs,proto3" json:"msgs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *RoomHistoryReply) Reset()         { *m = RoomHistoryReply{} }
+func (m *RoomHistoryReply) String() string { return proto.CompactTextString(m) }
+func (*RoomHistoryReply) ProtoMessage()    {}
+func (*RoomHistoryReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{19}
+}
+
+func (m *RoomHistoryReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RoomHistoryReply.Unmarshal(m, b)
+}
+func (m *RoomHistoryReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RoomHistoryReply.Marshal(b, m, deterministic)
+}
+func (m *RoomHistoryReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RoomHistoryReply.Merge(m, src)
+}
+func (m *RoomHistoryReply) XXX_Size() int {
+	return xxx_messageInfo_RoomHistoryReply.Size(m)
+}
+func (m *RoomHistoryReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RoomHistoryReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RoomHistoryReply proto.InternalMessageInfo
+
+func (m *RoomHistoryReply) GetMsgs() []*RoomMsg {
+	if m != nil {
+		return m.Msgs
+	}
+	return nil
+}
+
+// UpstreamMsg a message sent by a client, routed to business systems.
+type UpstreamMsg struct {
+	Mid                  int64    `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Room                 string   `protobuf:"bytes,3,opt,name=room,proto3" json:"room,omitempty"`
+	Platform             string   `protobuf:"bytes,4,opt,name=platform,proto3" json:"platform,omitempty"`
+	Server               string   `protobuf:"bytes,5,opt,name=server,proto3" json:"server,omitempty"`
+	Op                   int32    `protobuf:"varint,6,opt,name=op,proto3" json:"op,omitempty"`
+	Msg                  []byte   `protobuf:"bytes,7,opt,name=msg,proto3" json:"msg,omitempty"`
+	Timestamp            int64    `protobuf:"varint,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpstreamMsg) Reset()         { *m = UpstreamMsg{} }
+func (m *UpstreamMsg) String() string { return proto.CompactTextString(m) }
+func (*UpstreamMsg) ProtoMessage()    {}
+func (*UpstreamMsg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{20}
+}
+
+func (m *UpstreamMsg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpstreamMsg.Unmarshal(m, b)
+}
+func (m *UpstreamMsg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpstreamMsg.Marshal(b, m, deterministic)
+}
+func (m *UpstreamMsg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpstreamMsg.Merge(m, src)
+}
+func (m *UpstreamMsg) XXX_Size() int {
+	return xxx_messageInfo_UpstreamMsg.Size(m)
+}
+func (m *UpstreamMsg) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpstreamMsg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpstreamMsg proto.InternalMessageInfo
+
+func (m *UpstreamMsg) GetMid() int64 {
+	if m != nil {
+		return m.Mid
+	}
+	return 0
+}
+
+func (m *UpstreamMsg) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *UpstreamMsg) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *UpstreamMsg) GetPlatform() string {
+	if m != nil {
+		return m.Platform
+	}
+	return ""
+}
+
+func (m *UpstreamMsg) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *UpstreamMsg) GetOp() int32 {
+	if m != nil {
+		return m.Op
+	}
+	return 0
+}
+
+func (m *UpstreamMsg) GetMsg() []byte {
+	if m != nil {
+		return m.Msg
+	}
+	return nil
+}
+
+func (m *UpstreamMsg) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+type UpstreamReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpstreamReply) Reset()         { *m = UpstreamReply{} }
+func (m *UpstreamReply) String() string { return proto.CompactTextString(m) }
+func (*UpstreamReply) ProtoMessage()    {}
+func (*UpstreamReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{21}
+}
+
+func (m *UpstreamReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpstreamReply.Unmarshal(m, b)
+}
+func (m *UpstreamReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpstreamReply.Marshal(b, m, deterministic)
+}
+func (m *UpstreamReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpstreamReply.Merge(m, src)
+}
+func (m *UpstreamReply) XXX_Size() int {
+	return xxx_messageInfo_UpstreamReply.Size(m)
+}
+func (m *UpstreamReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpstreamReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpstreamReply proto.InternalMessageInfo
+
+// Session an online key of a mid.
+type Session struct {
+	Mid                  int64    `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Server               string   `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	Platform             string   `protobuf:"bytes,4,opt,name=platform,proto3" json:"platform,omitempty"`
+	Room                 string   `protobuf:"bytes,5,opt,name=room,proto3" json:"room,omitempty"`
+	ConnectTime          int64    `protobuf:"varint,6,opt,name=connectTime,proto3" json:"connectTime,omitempty"`
+	HeartbeatTime        int64    `protobuf:"varint,7,opt,name=heartbeatTime,proto3" json:"heartbeatTime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Session) Reset()         { *m = Session{} }
+func (m *Session) String() string { return proto.CompactTextString(m) }
+func (*Session) ProtoMessage()    {}
+func (*Session) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{22}
+}
+
+func (m *Session) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Session.Unmarshal(m, b)
+}
+func (m *Session) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Session.Marshal(b, m, deterministic)
+}
+func (m *Session) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Session.Merge(m, src)
+}
+func (m *Session) XXX_Size() int {
+	return xxx_messageInfo_Session.Size(m)
+}
+func (m *Session) XXX_DiscardUnknown() {
+	xxx_messageInfo_Session.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Session proto.InternalMessageInfo
+
+func (m *Session) GetMid() int64 {
+	if m != nil {
+		return m.Mid
+	}
+	return 0
+}
+
+func (m *Session) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *Session) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *Session) GetPlatform() string {
+	if m != nil {
+		return m.Platform
+	}
+	return ""
+}
+
+func (m *Session) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *Session) GetConnectTime() int64 {
+	if m != nil {
+		return m.ConnectTime
+	}
+	return 0
+}
+
+func (m *Session) GetHeartbeatTime() int64 {
+	if m != nil {
+		return m.HeartbeatTime
+	}
+	return 0
+}
+
+type PresenceReq struct {
+	Mids                 []int64  `protobuf:"varint,1,rep,packed,name=mids,proto3" json:"mids,omitempty"`
+	App                  string   `protobuf:"bytes,2,opt,name=app,proto3" json:"app,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PresenceReq) Reset()         { *m = PresenceReq{} }
+func (m *PresenceReq) String() string { return proto.CompactTextString(m) }
+func (*PresenceReq) ProtoMessage()    {}
+func (*PresenceReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{23}
+}
+
+func (m *PresenceReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PresenceReq.Unmarshal(m, b)
+}
+func (m *PresenceReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PresenceReq.Marshal(b, m, deterministic)
+}
+func (m *PresenceReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PresenceReq.Merge(m, src)
+}
+func (m *PresenceReq) XXX_Size() int {
+	return xxx_messageInfo_PresenceReq.Size(m)
+}
+func (m *PresenceReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_PresenceReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PresenceReq proto.InternalMessageInfo
+
+func (m *PresenceReq) GetMids() []int64 {
+	if m != nil {
+		return m.Mids
+	}
+	return nil
+}
+
+func (m *PresenceReq) GetApp() string {
+	if m != nil {
+		return m.App
+	}
+	return ""
+}
+
+type PresenceReply struct {
+	Sessions             []*Session `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *PresenceReply) Reset()         { *m = PresenceReply{} }
+func (m *PresenceReply) String() string { return proto.CompactTextString(m) }
+func (*PresenceReply) ProtoMessage()    {}
+func (*PresenceReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{24}
+}
+
+func (m *PresenceReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PresenceReply.Unmarshal(m, b)
+}
+func (m *PresenceReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PresenceReply.Marshal(b, m, deterministic)
+}
+func (m *PresenceReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PresenceReply.Merge(m, src)
+}
+func (m *PresenceReply) XXX_Size() int {
+	return xxx_messageInfo_PresenceReply.Size(m)
+}
+func (m *PresenceReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PresenceReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PresenceReply proto.InternalMessageInfo
+
+func (m *PresenceReply) GetSessions() []*Session {
+	if m != nil {
+		return m.Sessions
+	}
+	return nil
+}
+
+type OnlineMidsReq struct {
+	Mids                 []int64  `protobuf:"varint,1,rep,packed,name=mids,proto3" json:"mids,omitempty"`
+	App                  string   `protobuf:"bytes,2,opt,name=app,proto3" json:"app,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OnlineMidsReq) Reset()         { *m = OnlineMidsReq{} }
+func (m *OnlineMidsReq) String() string { return proto.CompactTextString(m) }
+func (*OnlineMidsReq) ProtoMessage()    {}
+func (*OnlineMidsReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{25}
+}
+
+func (m *OnlineMidsReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OnlineMidsReq.Unmarshal(m, b)
+}
+func (m *OnlineMidsReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OnlineMidsReq.Marshal(b, m, deterministic)
+}
+func (m *OnlineMidsReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OnlineMidsReq.Merge(m, src)
+}
+func (m *OnlineMidsReq) XXX_Size() int {
+	return xxx_messageInfo_OnlineMidsReq.Size(m)
+}
+func (m *OnlineMidsReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_OnlineMidsReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OnlineMidsReq proto.InternalMessageInfo
+
+func (m *OnlineMidsReq) GetMids() []int64 {
+	if m != nil {
+		return m.Mids
+	}
+	return nil
+}
+
+func (m *OnlineMidsReq) GetApp() string {
+	if m != nil {
+		return m.App
+	}
+	return ""
+}
+
+type OnlineMidsReply struct {
+	Mids                 []int64  `protobuf:"varint,1,rep,packed,name=mids,proto3" json:"mids,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OnlineMidsReply) Reset()         { *m = OnlineMidsReply{} }
+func (m *OnlineMidsReply) String() string { return proto.CompactTextString(m) }
+func (*OnlineMidsReply) ProtoMessage()    {}
+func (*OnlineMidsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{26}
+}
+
+func (m *OnlineMidsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OnlineMidsReply.Unmarshal(m, b)
+}
+func (m *OnlineMidsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OnlineMidsReply.Marshal(b, m, deterministic)
+}
+func (m *OnlineMidsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OnlineMidsReply.Merge(m, src)
+}
+func (m *OnlineMidsReply) XXX_Size() int {
+	return xxx_messageInfo_OnlineMidsReply.Size(m)
+}
+func (m *OnlineMidsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_OnlineMidsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OnlineMidsReply proto.InternalMessageInfo
+
+func (m *OnlineMidsReply) GetMids() []int64 {
+	if m != nil {
+		return m.Mids
+	}
+	return nil
+}
+
+// PushOptions options of a push, the zero value is a normal push without ttl.
+type PushOptions struct {
+	Ttl                  int64    `protobuf:"varint,1,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	Priority             string   `protobuf:"bytes,2,opt,name=priority,proto3" json:"priority,omitempty"`
+	MsgID                string   `protobuf:"bytes,3,opt,name=msgID,proto3" json:"msgID,omitempty"`
+	From                 int64    `protobuf:"varint,4,opt,name=from,proto3" json:"from,omitempty"`
+	App                  string   `protobuf:"bytes,5,opt,name=app,proto3" json:"app,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PushOptions) Reset()         { *m = PushOptions{} }
+func (m *PushOptions) String() string { return proto.CompactTextString(m) }
+func (*PushOptions) ProtoMessage()    {}
+func (*PushOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{27}
+}
+
+func (m *PushOptions) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushOptions.Unmarshal(m, b)
+}
+func (m *PushOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushOptions.Marshal(b, m, deterministic)
+}
+func (m *PushOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushOptions.Merge(m, src)
+}
+func (m *PushOptions) XXX_Size() int {
+	return xxx_messageInfo_PushOptions.Size(m)
+}
+func (m *PushOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushOptions proto.InternalMessageInfo
+
+func (m *PushOptions) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+func (m *PushOptions) GetPriority() string {
+	if m != nil {
+		return m.Priority
+	}
+	return ""
+}
+
+func (m *PushOptions) GetMsgID() string {
+	if m != nil {
+		return m.MsgID
+	}
+	return ""
+}
+
+func (m *PushOptions) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *PushOptions) GetApp() string {
+	if m != nil {
+		return m.App
+	}
+	return ""
+}
+
+type PushKeysReq struct {
+	Op                   int32        `protobuf:"varint,1,opt,name=op,proto3" json:"op,omitempty"`
+	Keys                 []string     `protobuf:"bytes,2,rep,name=keys,proto3" json:"keys,omitempty"`
+	Msg                  []byte       `protobuf:"bytes,3,opt,name=msg,proto3" json:"msg,omitempty"`
+	Options              *PushOptions `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *PushKeysReq) Reset()         { *m = PushKeysReq{} }
+func (m *PushKeysReq) String() string { return proto.CompactTextString(m) }
+func (*PushKeysReq) ProtoMessage()    {}
+func (*PushKeysReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{28}
+}
+
+func (m *PushKeysReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushKeysReq.Unmarshal(m, b)
+}
+func (m *PushKeysReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushKeysReq.Marshal(b, m, deterministic)
+}
+func (m *PushKeysReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushKeysReq.Merge(m, src)
+}
+func (m *PushKeysReq) XXX_Size() int {
+	return xxx_messageInfo_PushKeysReq.Size(m)
+}
+func (m *PushKeysReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushKeysReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushKeysReq proto.InternalMessageInfo
+
+func (m *PushKeysReq) GetOp() int32 {
+	if m != nil {
+		return m.Op
+	}
+	return 0
+}
+
+func (m *PushKeysReq) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func (m *PushKeysReq) GetMsg() []byte {
+	if m != nil {
+		return m.Msg
+	}
+	return nil
+}
+
+func (m *PushKeysReq) GetOptions() *PushOptions {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+type PushMidsReq struct {
+	Op                   int32        `protobuf:"varint,1,opt,name=op,proto3" json:"op,omitempty"`
+	Mids                 []int64      `protobuf:"varint,2,rep,packed,name=mids,proto3" json:"mids,omitempty"`
+	Msg                  []byte       `protobuf:"bytes,3,opt,name=msg,proto3" json:"msg,omitempty"`
+	Options              *PushOptions `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *PushMidsReq) Reset()         { *m = PushMidsReq{} }
+func (m *PushMidsReq) String() string { return proto.CompactTextString(m) }
+func (*PushMidsReq) ProtoMessage()    {}
+func (*PushMidsReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{29}
+}
+
+func (m *PushMidsReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushMidsReq.Unmarshal(m, b)
+}
+func (m *PushMidsReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushMidsReq.Marshal(b, m, deterministic)
+}
+func (m *PushMidsReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushMidsReq.Merge(m, src)
+}
+func (m *PushMidsReq) XXX_Size() int {
+	return xxx_messageInfo_PushMidsReq.Size(m)
+}
+func (m *PushMidsReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushMidsReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushMidsReq proto.InternalMessageInfo
+
+func (m *PushMidsReq) GetOp() int32 {
+	if m != nil {
+		return m.Op
+	}
+	return 0
+}
+
+func (m *PushMidsReq) GetMids() []int64 {
+	if m != nil {
+		return m.Mids
+	}
+	return nil
+}
+
+func (m *PushMidsReq) GetMsg() []byte {
+	if m != nil {
+		return m.Msg
+	}
+	return nil
+}
+
+func (m *PushMidsReq) GetOptions() *PushOptions {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+type PushRoomReq struct {
+	Op                   int32        `protobuf:"varint,1,opt,name=op,proto3" json:"op,omitempty"`
+	Room                 string       `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`
+	Msg                  []byte       `protobuf:"bytes,3,opt,name=msg,proto3" json:"msg,omitempty"`
+	Options              *PushOptions `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *PushRoomReq) Reset()         { *m = PushRoomReq{} }
+func (m *PushRoomReq) String() string { return proto.CompactTextString(m) }
+func (*PushRoomReq) ProtoMessage()    {}
+func (*PushRoomReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{30}
+}
+
+func (m *PushRoomReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushRoomReq.Unmarshal(m, b)
+}
+func (m *PushRoomReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushRoomReq.Marshal(b, m, deterministic)
+}
+func (m *PushRoomReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushRoomReq.Merge(m, src)
+}
+func (m *PushRoomReq) XXX_Size() int {
+	return xxx_messageInfo_PushRoomReq.Size(m)
+}
+func (m *PushRoomReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushRoomReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushRoomReq proto.InternalMessageInfo
+
+func (m *PushRoomReq) GetOp() int32 {
+	if m != nil {
+		return m.Op
+	}
+	return 0
+}
+
+func (m *PushRoomReq) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *PushRoomReq) GetMsg() []byte {
+	if m != nil {
+		return m.Msg
+	}
+	return nil
+}
+
+func (m *PushRoomReq) GetOptions() *PushOptions {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+type PushAllReq struct {
+	Op                   int32        `protobuf:"varint,1,opt,name=op,proto3" json:"op,omitempty"`
+	Speed                int32        `protobuf:"varint,2,opt,name=speed,proto3" json:"speed,omitempty"`
+	Platform             string       `protobuf:"bytes,3,opt,name=platform,proto3" json:"platform,omitempty"`
+	Msg                  []byte       `protobuf:"bytes,4,opt,name=msg,proto3" json:"msg,omitempty"`
+	Options              *PushOptions `protobuf:"bytes,5,opt,name=options,proto3" json:"options,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *PushAllReq) Reset()         { *m = PushAllReq{} }
+func (m *PushAllReq) String() string { return proto.CompactTextString(m) }
+func (*PushAllReq) ProtoMessage()    {}
+func (*PushAllReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{31}
+}
+
+func (m *PushAllReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushAllReq.Unmarshal(m, b)
+}
+func (m *PushAllReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushAllReq.Marshal(b, m, deterministic)
+}
+func (m *PushAllReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushAllReq.Merge(m, src)
+}
+func (m *PushAllReq) XXX_Size() int {
+	return xxx_messageInfo_PushAllReq.Size(m)
+}
+func (m *PushAllReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushAllReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushAllReq proto.InternalMessageInfo
+
+func (m *PushAllReq) GetOp() int32 {
+	if m != nil {
+		return m.Op
+	}
+	return 0
+}
+
+func (m *PushAllReq) GetSpeed() int32 {
+	if m != nil {
+		return m.Speed
+	}
+	return 0
+}
+
+func (m *PushAllReq) GetPlatform() string {
+	if m != nil {
+		return m.Platform
+	}
+	return ""
+}
+
+func (m *PushAllReq) GetMsg() []byte {
+	if m != nil {
+		return m.Msg
+	}
+	return nil
+}
+
+func (m *PushAllReq) GetOptions() *PushOptions {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+// PushReply the result of a push.
+type PushReply struct {
+	OnlineKeys           []string `protobuf:"bytes,1,rep,name=onlineKeys,proto3" json:"onlineKeys,omitempty"`
+	OfflineKeys          []string `protobuf:"bytes,2,rep,name=offlineKeys,proto3" json:"offlineKeys,omitempty"`
+	OnlineMids           []int64  `protobuf:"varint,3,rep,packed,name=onlineMids,proto3" json:"onlineMids,omitempty"`
+	OfflineMids          []int64  `protobuf:"varint,4,rep,packed,name=offlineMids,proto3" json:"offlineMids,omitempty"`
+	StoredMids           []int64  `protobuf:"varint,5,rep,packed,name=storedMids,proto3" json:"storedMids,omitempty"`
+	Enqueued             int32    `protobuf:"varint,6,opt,name=enqueued,proto3" json:"enqueued,omitempty"`
+	Seq                  int64    `protobuf:"varint,7,opt,name=seq,proto3" json:"seq,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PushReply) Reset()         { *m = PushReply{} }
+func (m *PushReply) String() string { return proto.CompactTextString(m) }
+func (*PushReply) ProtoMessage()    {}
+func (*PushReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{32}
+}
+
+func (m *PushReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushReply.Unmarshal(m, b)
+}
+func (m *PushReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushReply.Marshal(b, m, deterministic)
+}
+func (m *PushReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushReply.Merge(m, src)
+}
+func (m *PushReply) XXX_Size() int {
+	return xxx_messageInfo_PushReply.Size(m)
+}
+func (m *PushReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushReply proto.InternalMessageInfo
+
+func (m *PushReply) GetOnlineKeys() []string {
+	if m != nil {
+		return m.OnlineKeys
+	}
+	return nil
+}
+
+func (m *PushReply) GetOfflineKeys() []string {
+	if m != nil {
+		return m.OfflineKeys
+	}
+	return nil
+}
+
+func (m *PushReply) GetOnlineMids() []int64 {
+	if m != nil {
+		return m.OnlineMids
+	}
+	return nil
+}
+
+func (m *PushReply) GetOfflineMids() []int64 {
+	if m != nil {
+		return m.OfflineMids
+	}
+	return nil
+}
+
+func (m *PushReply) GetStoredMids() []int64 {
+	if m != nil {
+		return m.StoredMids
+	}
+	return nil
+}
+
+func (m *PushReply) GetEnqueued() int32 {
+	if m != nil {
+		return m.Enqueued
+	}
+	return 0
+}
+
+func (m *PushReply) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+// PushStreamReq a push of a stream, exactly one of the pushes is set, the id
+// is echoed by its reply.
+type PushStreamReq struct {
+	Id                   int64        `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Keys                 *PushKeysReq `protobuf:"bytes,2,opt,name=keys,proto3" json:"keys,omitempty"`
+	Mids                 *PushMidsReq `protobuf:"bytes,3,opt,name=mids,proto3" json:"mids,omitempty"`
+	Room                 *PushRoomReq `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`
+	All                  *PushAllReq  `protobuf:"bytes,5,opt,name=all,proto3" json:"all,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *PushStreamReq) Reset()         { *m = PushStreamReq{} }
+func (m *PushStreamReq) String() string { return proto.CompactTextString(m) }
+func (*PushStreamReq) ProtoMessage()    {}
+func (*PushStreamReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{33}
+}
+
+func (m *PushStreamReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushStreamReq.Unmarshal(m, b)
+}
+func (m *PushStreamReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushStreamReq.Marshal(b, m, deterministic)
+}
+func (m *PushStreamReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushStreamReq.Merge(m, src)
+}
+func (m *PushStreamReq) XXX_Size() int {
+	return xxx_messageInfo_PushStreamReq.Size(m)
+}
+func (m *PushStreamReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushStreamReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushStreamReq proto.InternalMessageInfo
+
+func (m *PushStreamReq) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *PushStreamReq) GetKeys() *PushKeysReq {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func (m *PushStreamReq) GetMids() *PushMidsReq {
+	if m != nil {
+		return m.Mids
+	}
+	return nil
+}
+
+func (m *PushStreamReq) GetRoom() *PushRoomReq {
+	if m != nil {
+		return m.Room
+	}
+	return nil
+}
+
+func (m *PushStreamReq) GetAll() *PushAllReq {
+	if m != nil {
+		return m.All
+	}
+	return nil
+}
+
+// PushStreamReply the result of a push of a stream, in the order pushed. A
+// push failed has the grpc status code and message of the unary rpc, and
+// the seconds to retry after if beyond a rate limit.
+type PushStreamReply struct {
+	Id                   int64      `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Code                 int32      `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message              string     `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Reply                *PushReply `protobuf:"bytes,4,opt,name=reply,proto3" json:"reply,omitempty"`
+	RetryAfter           int64      `protobuf:"varint,5,opt,name=retryAfter,proto3" json:"retryAfter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *PushStreamReply) Reset()         { *m = PushStreamReply{} }
+func (m *PushStreamReply) String() string { return proto.CompactTextString(m) }
+func (*PushStreamReply) ProtoMessage()    {}
+func (*PushStreamReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{34}
+}
+
+func (m *PushStreamReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushStreamReply.Unmarshal(m, b)
+}
+func (m *PushStreamReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushStreamReply.Marshal(b, m, deterministic)
+}
+func (m *PushStreamReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushStreamReply.Merge(m, src)
+}
+func (m *PushStreamReply) XXX_Size() int {
+	return xxx_messageInfo_PushStreamReply.Size(m)
+}
+func (m *PushStreamReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushStreamReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushStreamReply proto.InternalMessageInfo
+
+func (m *PushStreamReply) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *PushStreamReply) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *PushStreamReply) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *PushStreamReply) GetReply() *PushReply {
+	if m != nil {
+		return m.Reply
+	}
+	return nil
+}
+
+func (m *PushStreamReply) GetRetryAfter() int64 {
+	if m != nil {
+		return m.RetryAfter
+	}
+	return 0
+}
+
+type OnlineTopReq struct {
+	Type                 string   `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Limit                int64    `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OnlineTopReq) Reset()         { *m = OnlineTopReq{} }
+func (m *OnlineTopReq) String() string { return proto.CompactTextString(m) }
+func (*OnlineTopReq) ProtoMessage()    {}
+func (*OnlineTopReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{35}
+}
+
+func (m *OnlineTopReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OnlineTopReq.Unmarshal(m, b)
+}
+func (m *OnlineTopReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OnlineTopReq.Marshal(b, m, deterministic)
+}
+func (m *OnlineTopReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OnlineTopReq.Merge(m, src)
+}
+func (m *OnlineTopReq) XXX_Size() int {
+	return xxx_messageInfo_OnlineTopReq.Size(m)
+}
+func (m *OnlineTopReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_OnlineTopReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OnlineTopReq proto.InternalMessageInfo
+
+func (m *OnlineTopReq) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *OnlineTopReq) GetLimit() int64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type OnlineTopReply struct {
+	Addrs                []string `protobuf:"bytes,1,rep,name=addrs,proto3" json:"addrs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OnlineTopReply) Reset()         { *m = OnlineTopReply{} }
+func (m *OnlineTopReply) String() string { return proto.CompactTextString(m) }
+func (*OnlineTopReply) ProtoMessage()    {}
+func (*OnlineTopReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{36}
+}
+
+func (m *OnlineTopReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OnlineTopReply.Unmarshal(m, b)
+}
+func (m *OnlineTopReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OnlineTopReply.Marshal(b, m, deterministic)
+}
+func (m *OnlineTopReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OnlineTopReply.Merge(m, src)
+}
+func (m *OnlineTopReply) XXX_Size() int {
+	return xxx_messageInfo_OnlineTopReply.Size(m)
+}
+func (m *OnlineTopReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_OnlineTopReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OnlineTopReply proto.InternalMessageInfo
+
+func (m *OnlineTopReply) GetAddrs() []string {
+	if m != nil {
+		return m.Addrs
+	}
+	return nil
+}
+
+type OnlineRoomReq struct {
+	Rooms                []string `protobuf:"bytes,1,rep,name=rooms,proto3" json:"rooms,omitempty"`
+	App                  string   `protobuf:"bytes,2,opt,name=app,proto3" json:"app,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OnlineRoomReq) Reset()         { *m = OnlineRoomReq{} }
+func (m *OnlineRoomReq) String() string { return proto.CompactTextString(m) }
+func (*OnlineRoomReq) ProtoMessage()    {}
+func (*OnlineRoomReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{37}
+}
+
+func (m *OnlineRoomReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OnlineRoomReq.Unmarshal(m, b)
+}
+func (m *OnlineRoomReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OnlineRoomReq.Marshal(b, m, deterministic)
+}
+func (m *OnlineRoomReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OnlineRoomReq.Merge(m, src)
+}
+func (m *OnlineRoomReq) XXX_Size() int {
+	return xxx_messageInfo_OnlineRoomReq.Size(m)
+}
+func (m *OnlineRoomReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_OnlineRoomReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OnlineRoomReq proto.InternalMessageInfo
+
+func (m *OnlineRoomReq) GetRooms() []string {
+	if m != nil {
+		return m.Rooms
+	}
+	return nil
+}
+
+func (m *OnlineRoomReq) GetApp() string {
+	if m != nil {
+		return m.App
+	}
+	return ""
+}
+
+type OnlineRoomReply struct {
+	RoomCount            map[string]int32 `protobuf:"bytes,1,rep,name=roomCount,proto3" json:"roomCount,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *OnlineRoomReply) Reset()         { *m = OnlineRoomReply{} }
+func (m *OnlineRoomReply) String() string { return proto.CompactTextString(m) }
+func (*OnlineRoomReply) ProtoMessage()    {}
+func (*OnlineRoomReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{38}
+}
+
+func (m *OnlineRoomReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OnlineRoomReply.Unmarshal(m, b)
+}
+func (m *OnlineRoomReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OnlineRoomReply.Marshal(b, m, deterministic)
+}
+func (m *OnlineRoomReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OnlineRoomReply.Merge(m, src)
+}
+func (m *OnlineRoomReply) XXX_Size() int {
+	return xxx_messageInfo_OnlineRoomReply.Size(m)
+}
+func (m *OnlineRoomReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_OnlineRoomReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OnlineRoomReply proto.InternalMessageInfo
+
+func (m *OnlineRoomReply) GetRoomCount() map[string]int32 {
+	if m != nil {
+		return m.RoomCount
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("goim.logic.PushMsg_Type", PushMsg_Type_name, PushMsg_Type_value)
+	proto.RegisterEnum("goim.logic.PushMsg_Priority", PushMsg_Priority_name, PushMsg_Priority_value)
+	proto.RegisterType((*PushMsg)(nil), "goim.logic.PushMsg")
+	proto.RegisterType((*CloseReply)(nil), "goim.logic.CloseReply")
+	proto.RegisterType((*CloseReq)(nil), "goim.logic.CloseReq")
+	proto.RegisterType((*PingReply)(nil), "goim.logic.PingReply")
+	proto.RegisterType((*PingReq)(nil), "goim.logic.PingReq")
+	proto.RegisterType((*ConnectReq)(nil), "goim.logic.ConnectReq")
+	proto.RegisterType((*ConnectReply)(nil), "goim.logic.ConnectReply")
+	proto.RegisterType((*DisconnectReq)(nil), "goim.logic.DisconnectReq")
+	proto.RegisterType((*DisconnectReply)(nil), "goim.logic.DisconnectReply")
+	proto.RegisterType((*HeartbeatReq)(nil), "goim.logic.HeartbeatReq")
+	proto.RegisterType((*HeartbeatReply)(nil), "goim.logic.HeartbeatReply")
+	proto.RegisterType((*OnlineReq)(nil), "goim.logic.OnlineReq")
+	proto.RegisterMapType((map[string]int32)(nil), "goim.logic.OnlineReq.AppCountEntry")
+	proto.RegisterMapType((map[string]int32)(nil), "goim.logic.OnlineReq.RoomCountEntry")
+	proto.RegisterType((*OnlineReply)(nil), "goim.logic.OnlineReply")
+	proto.RegisterMapType((map[string]int32)(nil), "goim.logic.OnlineReply.AllRoomCountEntry")
+	proto.RegisterType((*ReceiveReq)(nil), "goim.logic.ReceiveReq")
+	proto.RegisterType((*ReceiveReply)(nil), "goim.logic.ReceiveReply")
+	proto.RegisterType((*JoinRoomReq)(nil), "goim.logic.JoinRoomReq")
+	proto.RegisterType((*JoinRoomReply)(nil), "goim.logic.JoinRoomReply")
+	proto.RegisterType((*RoomMsg)(nil), "goim.logic.RoomMsg")
+	proto.RegisterType((*RoomHistoryReq)(nil), "goim.logic.RoomHistoryReq")
+	proto.RegisterType((*RoomHistoryReply)(nil), "goim.logic.RoomHistoryReply")
+	proto.RegisterType((*UpstreamMsg)(nil), "goim.logic.UpstreamMsg")
+	proto.RegisterType((*UpstreamReply)(nil), "goim.logic.UpstreamReply")
+	proto.RegisterType((*Session)(nil), "goim.logic.Session")
+	proto.RegisterType((*PresenceReq)(nil), "goim.logic.PresenceReq")
+	proto.RegisterType((*PresenceReply)(nil), "goim.logic.PresenceReply")
+	proto.RegisterType((*OnlineMidsReq)(nil), "goim.logic.OnlineMidsReq")
+	proto.RegisterType((*OnlineMidsReply)(nil), "goim.logic.OnlineMidsReply")
+	proto.RegisterType((*PushOptions)(nil), "goim.logic.PushOptions")
+	proto.RegisterType((*PushKeysReq)(nil), "goim.logic.PushKeysReq")
+	proto.RegisterType((*PushMidsReq)(nil), "goim.logic.PushMidsReq")
+	proto.RegisterType((*PushRoomReq)(nil), "goim.logic.PushRoomReq")
+	proto.RegisterType((*PushAllReq)(nil), "goim.logic.PushAllReq")
+	proto.RegisterType((*PushReply)(nil), "goim.logic.PushReply")
+	proto.RegisterType((*PushStreamReq)(nil), "goim.logic.PushStreamReq")
+	proto.RegisterType((*PushStreamReply)(nil), "goim.logic.PushStreamReply")
+	proto.RegisterType((*OnlineTopReq)(nil), "goim.logic.OnlineTopReq")
+	proto.RegisterType((*OnlineTopReply)(nil), "goim.logic.OnlineTopReply")
+	proto.RegisterType((*OnlineRoomReq)(nil), "goim.logic.OnlineRoomReq")
+	proto.RegisterType((*OnlineRoomReply)(nil), "goim.logic.OnlineRoomReply")
+	proto.RegisterMapType((map[string]int32)(nil), "goim.logic.OnlineRoomReply.RoomCountEntry")
+}
+
+func init() { proto.RegisterFile("api.proto", fileDescriptor_00212fb1f9d3bf1c) }
+
+var fileDescriptor_00212fb1f9d3bf1c = []byte{
+	// 1758 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x18, 0xc9, 0x72, 0xdb, 0x56,
+	0xd2, 0xd8, 0x44, 0xa2, 0x49, 0x4a, 0x1a, 0x8c, 0x6c, 0x43, 0xb0, 0x6a, 0x86, 0x05, 0x7b, 0x66,
+	0xe8, 0xd1, 0x94, 0x66, 0x46, 0x2e, 0x57, 0x54, 0x5e, 0xe2, 0xd0, 0x52, 0xca, 0x52, 0x6c, 0x45,
+	0xca, 0x93, 0x9c, 0x43, 0x2e, 0x29, 0x98, 0x7c, 0xa2, 0x51, 0x02, 0x09, 0x08, 0x80, 0xe4, 0xf0,
+	0x9e, 0x2f, 0x48, 0x2e, 0x4e, 0x2a, 0x87, 0x54, 0xe5, 0x94, 0x6b, 0x6e, 0x39, 0xe4, 0x1f, 0xf2,
+	0x03, 0xf9, 0x97, 0x54, 0xbf, 0x05, 0x0b, 0x05, 0xc8, 0x76, 0x1c, 0xdf, 0xd0, 0xfd, 0x7a, 0x7b,
+	0xdd, 0xfd, 0x7a, 0x01, 0x98, 0x5e, 0xe4, 0xaf, 0x45, 0x71, 0x98, 0x86, 0x16, 0x8c, 0x42, 0x7f,
+	0xbc, 0x16, 0x84, 0x23, 0x7f, 0xe0, 0xc0, 0x28, 0x1c, 0x85, 0x1c, 0xef, 0xfe, 0xa4, 0x41, 0x63,
+	0xff, 0x34, 0x79, 0xbe, 0x9b, 0x8c, 0xac, 0xff, 0x80, 0x9e, 0x4e, 0x23, 0x6a, 0x2b, 0x5d, 0xa5,
+	0x37, 0xbf, 0x6e, 0xaf, 0xe5, 0x2c, 0x6b, 0x82, 0x64, 0xed, 0x70, 0x1a, 0x51, 0xc2, 0xa8, 0xac,
+	0x15, 0x30, 0xc3, 0x88, 0xc6, 0x5e, 0xea, 0x87, 0x13, 0x5b, 0xed, 0x2a, 0x3d, 0x83, 0xe4, 0x08,
+	0xeb, 0x0a, 0xcc, 0x25, 0x34, 0x3e, 0xa3, 0xb1, 0xad, 0x75, 0x95, 0x9e, 0x49, 0x04, 0x64, 0x59,
+	0xa0, 0x1f, 0xd3, 0x69, 0x62, 0xeb, 0x5d, 0xad, 0x67, 0x12, 0xf6, 0x8d, 0xb8, 0x38, 0x0c, 0xc7,
+	0xb6, 0xc1, 0x28, 0xd9, 0xb7, 0xb5, 0x04, 0x46, 0x12, 0x51, 0x3a, 0xb4, 0xe7, 0x98, 0x64, 0x0e,
+	0x58, 0x0e, 0x34, 0xa3, 0xc0, 0x4b, 0x8f, 0xc2, 0x78, 0x6c, 0x37, 0x18, 0x75, 0x06, 0x5b, 0x8b,
+	0xa0, 0x8d, 0x93, 0x91, 0xdd, 0xec, 0x2a, 0xbd, 0x36, 0xc1, 0x4f, 0xc4, 0x24, 0xf4, 0xc4, 0x36,
+	0xbb, 0x4a, 0x4f, 0x23, 0xf8, 0x89, 0x56, 0xd1, 0x2f, 0x22, 0x3f, 0xa6, 0x36, 0x30, 0xa4, 0x80,
+	0xac, 0x0d, 0x68, 0x46, 0xb1, 0x1f, 0xc6, 0x7e, 0x3a, 0xb5, 0x5b, 0xec, 0xf6, 0x2b, 0x55, 0xb7,
+	0xdf, 0x17, 0x34, 0x24, 0xa3, 0x46, 0x3b, 0xc7, 0xc9, 0x68, 0x67, 0xcb, 0x6e, 0x33, 0x73, 0x38,
+	0x80, 0x9a, 0xbd, 0x28, 0xb2, 0x3b, 0x0c, 0x87, 0x9f, 0xee, 0x4d, 0xd0, 0xd1, 0x77, 0x56, 0x13,
+	0xf4, 0xfd, 0xa7, 0x07, 0xdb, 0x8b, 0x97, 0xf0, 0x8b, 0xec, 0xed, 0xed, 0x2e, 0x2a, 0x56, 0x07,
+	0xcc, 0x87, 0x64, 0xaf, 0xbf, 0xb5, 0xd9, 0x3f, 0x38, 0x5c, 0x54, 0xdd, 0x2e, 0x34, 0xa5, 0x22,
+	0x0b, 0x60, 0xee, 0xe3, 0x3d, 0xb2, 0xdb, 0x7f, 0xc2, 0x19, 0xb6, 0x77, 0x1e, 0x6d, 0x2f, 0x2a,
+	0x6e, 0x1b, 0x60, 0x33, 0x08, 0x13, 0x4a, 0x68, 0x14, 0x4c, 0x5d, 0x80, 0xa6, 0x80, 0x4e, 0xdc,
+	0x16, 0x98, 0xfb, 0xfe, 0x64, 0xc4, 0x0f, 0x4c, 0x68, 0x70, 0xe0, 0xc4, 0x3d, 0x03, 0xd8, 0x0c,
+	0x27, 0x13, 0x3a, 0x48, 0x09, 0x77, 0x83, 0x08, 0x8e, 0x52, 0x0a, 0xce, 0x0a, 0x98, 0xfc, 0xeb,
+	0x31, 0x9d, 0xb2, 0x90, 0x9a, 0x24, 0x47, 0x20, 0xd7, 0x20, 0x0c, 0x8f, 0x7d, 0x2a, 0x43, 0xca,
+	0x21, 0x74, 0x41, 0x1a, 0x1e, 0xd3, 0x89, 0xad, 0x33, 0xd7, 0x73, 0xe0, 0x8e, 0xfe, 0xf2, 0xfb,
+	0xbf, 0x5f, 0x72, 0xbf, 0x52, 0xa0, 0x9d, 0x29, 0x8e, 0x82, 0x29, 0x8b, 0x92, 0x3f, 0x64, 0x7a,
+	0x35, 0x82, 0x9f, 0x88, 0x39, 0xce, 0xd4, 0x69, 0xc7, 0x5c, 0x11, 0xe6, 0xc0, 0xce, 0x96, 0x54,
+	0xc4, 0xa1, 0x52, 0xf4, 0xf5, 0x99, 0xe8, 0xdb, 0xd0, 0xf0, 0x06, 0x03, 0x1a, 0xa5, 0x89, 0x6d,
+	0x74, 0xb5, 0x9e, 0x41, 0x24, 0x28, 0x63, 0x31, 0x97, 0xc7, 0xe2, 0x73, 0xe8, 0x6c, 0xf9, 0xc9,
+	0x20, 0xf7, 0xc7, 0x6b, 0x1a, 0x55, 0x97, 0xd0, 0x2c, 0x79, 0xf5, 0x3c, 0x79, 0xdd, 0xeb, 0xb0,
+	0x50, 0x54, 0x20, 0xee, 0xfd, 0xdc, 0x4b, 0x98, 0x8a, 0x26, 0xc1, 0x4f, 0xf7, 0xa5, 0x02, 0xed,
+	0x6d, 0xea, 0xc5, 0xe9, 0x33, 0xea, 0xbd, 0x0b, 0x2b, 0x4a, 0xee, 0x32, 0x66, 0xdc, 0xb5, 0x02,
+	0xa6, 0x30, 0x4f, 0x3c, 0xb1, 0x26, 0xc9, 0x11, 0xee, 0x22, 0xcc, 0x17, 0x2c, 0xc3, 0x54, 0xfa,
+	0x51, 0x05, 0x73, 0x6f, 0x12, 0xf8, 0x13, 0x7a, 0x51, 0xfe, 0x3c, 0x04, 0x13, 0x35, 0x6f, 0x86,
+	0xa7, 0x93, 0xd4, 0x56, 0xbb, 0x5a, 0xaf, 0xb5, 0x7e, 0xa3, 0xf8, 0x8e, 0x32, 0x09, 0x6b, 0x44,
+	0x92, 0x7d, 0x38, 0x49, 0xe3, 0x29, 0xc9, 0xd9, 0xac, 0x07, 0xd0, 0xf4, 0xa2, 0x88, 0x8b, 0xd0,
+	0x98, 0x88, 0xeb, 0xd5, 0x22, 0xfa, 0x51, 0x54, 0x90, 0x90, 0x31, 0x39, 0xf7, 0x60, 0xbe, 0x2c,
+	0x5d, 0xba, 0x51, 0xc9, 0xdd, 0xb8, 0x04, 0xc6, 0x99, 0x17, 0x9c, 0x52, 0x51, 0xb7, 0x38, 0x70,
+	0x47, 0xdd, 0x50, 0x9c, 0xbb, 0xd0, 0x29, 0x09, 0x7e, 0x13, 0x66, 0x91, 0xf3, 0x3f, 0x28, 0xd0,
+	0x92, 0x66, 0x62, 0xe8, 0x77, 0xa1, 0xed, 0x05, 0x41, 0x66, 0x93, 0xad, 0xb0, 0x5b, 0xdd, 0xac,
+	0xba, 0x55, 0x14, 0x4c, 0xd7, 0xfa, 0x05, 0x5a, 0x7e, 0xb7, 0x12, 0xbb, 0xf3, 0x00, 0xfe, 0x72,
+	0x8e, 0xe4, 0x0f, 0x58, 0xf9, 0xad, 0x02, 0x40, 0xe8, 0x80, 0xfa, 0x67, 0xb4, 0x3a, 0xf9, 0xe6,
+	0x41, 0x0d, 0x23, 0xc1, 0xad, 0x86, 0x51, 0x96, 0x62, 0x5a, 0x21, 0xc5, 0x44, 0xcd, 0xd5, 0x4b,
+	0x35, 0x17, 0x0d, 0x31, 0xaa, 0x52, 0x76, 0xae, 0x94, 0x2c, 0x17, 0xd4, 0x72, 0xf7, 0x1e, 0xb4,
+	0x33, 0xdb, 0xd0, 0x85, 0x16, 0xe8, 0x83, 0x70, 0xc8, 0x3b, 0x93, 0x41, 0xd8, 0x37, 0xbe, 0xf8,
+	0x31, 0x4d, 0x12, 0x6f, 0x44, 0xc5, 0x03, 0x91, 0xa0, 0x7b, 0x02, 0xad, 0x8f, 0x42, 0x7f, 0x82,
+	0x2e, 0x7a, 0x17, 0xef, 0xca, 0x02, 0xfd, 0x28, 0xce, 0xdb, 0x15, 0x7e, 0xbb, 0x9f, 0x40, 0x27,
+	0x57, 0xf9, 0xc6, 0x16, 0x57, 0xf9, 0xd6, 0xdd, 0x85, 0x06, 0x8a, 0xdb, 0xcd, 0x1b, 0x99, 0x92,
+	0x37, 0xb2, 0xd9, 0xe0, 0x88, 0x40, 0x68, 0x79, 0x20, 0xe6, 0x41, 0x4d, 0x13, 0x66, 0xb7, 0x46,
+	0xd4, 0x34, 0x71, 0x3f, 0xe5, 0xcf, 0x62, 0xdb, 0x4f, 0xd2, 0x30, 0x9e, 0xa2, 0x5f, 0xa4, 0x52,
+	0xa5, 0x5c, 0x33, 0x12, 0x7f, 0x32, 0xa0, 0x07, 0xf4, 0x84, 0x49, 0xd7, 0x48, 0x06, 0x63, 0x46,
+	0x0d, 0xc4, 0xb3, 0x64, 0x19, 0xc5, 0x00, 0xf7, 0x2e, 0x2c, 0x96, 0xe4, 0xe2, 0xe5, 0xff, 0x05,
+	0xfa, 0x38, 0x19, 0x25, 0x22, 0xd3, 0xff, 0x5a, 0xcc, 0x74, 0x71, 0x25, 0xc2, 0x08, 0xdc, 0x9f,
+	0x15, 0x68, 0x3d, 0x8d, 0x92, 0x34, 0xa6, 0x9e, 0xbc, 0xe8, 0x2b, 0x43, 0x55, 0x95, 0x87, 0x17,
+	0x75, 0x86, 0x3c, 0xb4, 0x46, 0x29, 0xb4, 0xdc, 0x85, 0x73, 0xb3, 0x2e, 0x6c, 0xe4, 0x2e, 0x5c,
+	0x01, 0x33, 0xf5, 0xc7, 0x34, 0x49, 0xbd, 0x71, 0xc4, 0xe6, 0x0a, 0x8d, 0xe4, 0x08, 0x77, 0x01,
+	0x3a, 0xd2, 0x74, 0x5e, 0x23, 0x7f, 0x51, 0xa0, 0x71, 0x40, 0x93, 0x04, 0xc7, 0x9f, 0xb7, 0xc9,
+	0xb9, 0x8b, 0x2e, 0x53, 0x35, 0x2a, 0x75, 0xa1, 0x25, 0x4a, 0xf7, 0xa1, 0x3f, 0xa6, 0xec, 0x46,
+	0x1a, 0x29, 0xa2, 0xac, 0x1b, 0xd0, 0x79, 0x2e, 0xeb, 0x39, 0xa3, 0x69, 0x30, 0x9a, 0x32, 0xd2,
+	0xbd, 0x05, 0xad, 0xfd, 0x98, 0x26, 0x74, 0x32, 0xa0, 0x22, 0x3d, 0xc6, 0xfe, 0x90, 0x07, 0x51,
+	0x23, 0xec, 0x5b, 0xf6, 0x52, 0x35, 0xef, 0xa5, 0x1f, 0x40, 0x27, 0x67, 0xc2, 0xd8, 0xff, 0x17,
+	0x9a, 0x09, 0x77, 0x42, 0x65, 0xfc, 0x85, 0x83, 0x48, 0x46, 0xe4, 0xde, 0x86, 0x0e, 0x2f, 0x7f,
+	0xbb, 0xfe, 0x30, 0x79, 0x7d, 0xc5, 0xff, 0x80, 0x85, 0x22, 0x9b, 0x78, 0x73, 0xb3, 0x8c, 0xee,
+	0x0b, 0x68, 0xe1, 0xf4, 0xb6, 0x17, 0xe1, 0x54, 0xca, 0xe4, 0xa4, 0x69, 0x20, 0xe3, 0x92, 0xa6,
+	0x01, 0xf3, 0xb6, 0x1c, 0xfd, 0x54, 0xe1, 0xed, 0x73, 0xc3, 0x9d, 0x56, 0x1c, 0xee, 0xe4, 0xfb,
+	0xe7, 0x6f, 0x8b, 0x7d, 0x4b, 0xfb, 0x8c, 0xdc, 0xbe, 0x33, 0xae, 0xf8, 0x31, 0x9d, 0xb2, 0x4b,
+	0xf1, 0x6c, 0x53, 0x8a, 0xd5, 0x94, 0xcd, 0xc1, 0x6a, 0x61, 0x0e, 0x3e, 0xff, 0x88, 0xff, 0x0f,
+	0x8d, 0x90, 0x5b, 0xce, 0xb4, 0xb5, 0xd6, 0xaf, 0xce, 0x8e, 0xa5, 0xe2, 0x62, 0x44, 0xd2, 0x49,
+	0xbd, 0xd2, 0x99, 0x15, 0x7a, 0x99, 0x8f, 0xd4, 0xb2, 0x73, 0xff, 0x34, 0xbd, 0xb2, 0xe8, 0x56,
+	0xe8, 0x65, 0x89, 0xab, 0x9e, 0xef, 0x1e, 0x6f, 0xa7, 0xf7, 0x6b, 0x05, 0x00, 0x0f, 0xb0, 0x27,
+	0x56, 0xe8, 0xcd, 0xf6, 0x08, 0xb5, 0x6e, 0x8f, 0xd0, 0xaa, 0xf7, 0x08, 0xbd, 0xd2, 0x2a, 0xe3,
+	0x35, 0xad, 0xfa, 0x4d, 0x01, 0x93, 0xb9, 0x83, 0x25, 0xe6, 0xdf, 0x00, 0x42, 0x96, 0xab, 0x98,
+	0x0d, 0x2c, 0x3d, 0x4d, 0x52, 0xc0, 0xe0, 0x0b, 0x0e, 0x8f, 0x8e, 0x32, 0x02, 0x9e, 0x13, 0x45,
+	0x54, 0x2e, 0x01, 0xe3, 0xca, 0xe6, 0x22, 0x8d, 0x14, 0x30, 0x05, 0x09, 0x8c, 0x40, 0x67, 0x04,
+	0x45, 0x14, 0x4a, 0xc0, 0x0a, 0x4d, 0x87, 0x8c, 0xc0, 0xe0, 0x12, 0x72, 0x0c, 0xba, 0x84, 0x4e,
+	0x4e, 0x4e, 0xe9, 0x69, 0xb6, 0x73, 0x65, 0xb0, 0xec, 0x3f, 0x8d, 0xac, 0xff, 0xb8, 0xbf, 0x2a,
+	0xd0, 0xc1, 0xfb, 0x1d, 0x88, 0xfa, 0xc7, 0x1c, 0x9f, 0x15, 0x3c, 0xd5, 0x1f, 0x5a, 0xab, 0x59,
+	0x82, 0x57, 0x7a, 0x4c, 0xbc, 0x0b, 0x91, 0xf9, 0xab, 0x22, 0x2b, 0xb5, 0x6a, 0x62, 0x91, 0xcc,
+	0x22, 0x5d, 0x57, 0x0b, 0x3d, 0xb9, 0x82, 0x58, 0x64, 0xa0, 0xc8, 0xb1, 0x1e, 0x68, 0x5e, 0x10,
+	0x88, 0xb8, 0x5d, 0x99, 0xa5, 0xe5, 0x49, 0x43, 0x90, 0xc4, 0xfd, 0x4e, 0x81, 0x85, 0xe2, 0x95,
+	0x30, 0x70, 0xb3, 0x97, 0x92, 0x5d, 0x5d, 0xad, 0xee, 0xea, 0x5a, 0xb9, 0xab, 0xaf, 0x82, 0x11,
+	0xa3, 0x18, 0x61, 0xe9, 0xe5, 0x73, 0x96, 0xe2, 0x21, 0xe1, 0x34, 0x18, 0x9f, 0x98, 0xa6, 0xf1,
+	0xb4, 0x7f, 0x94, 0x8a, 0x56, 0xa5, 0x91, 0x02, 0xc6, 0xdd, 0x80, 0x36, 0xaf, 0x77, 0x87, 0x61,
+	0x24, 0xaa, 0x64, 0xb6, 0xac, 0x9b, 0x62, 0x25, 0x5f, 0x02, 0x23, 0xf0, 0xc7, 0x7e, 0x2a, 0x5a,
+	0x37, 0x07, 0xdc, 0x7f, 0xc2, 0x7c, 0x81, 0x13, 0x75, 0x2d, 0x81, 0xe1, 0x0d, 0x87, 0xb1, 0x4c,
+	0x45, 0x0e, 0xb8, 0xef, 0xc9, 0x42, 0x2c, 0xdf, 0xf0, 0x12, 0x18, 0xe8, 0xc3, 0x8c, 0x8c, 0x01,
+	0x15, 0xa5, 0xf8, 0x1b, 0x05, 0x16, 0x8a, 0x9c, 0xa8, 0x62, 0xbb, 0xb8, 0x0a, 0xf0, 0x3e, 0xf0,
+	0xef, 0x8a, 0x89, 0x57, 0xd2, 0xd7, 0x2f, 0x04, 0x6f, 0x37, 0xcf, 0xaf, 0x7f, 0x69, 0x82, 0xf1,
+	0x04, 0x35, 0x5a, 0xeb, 0xa0, 0xe3, 0x36, 0x6c, 0x95, 0xda, 0x91, 0xd8, 0x8f, 0x9d, 0xcb, 0xe7,
+	0x91, 0x78, 0x8b, 0xdb, 0x60, 0xb0, 0xd5, 0xda, 0x5a, 0x2a, 0x9e, 0xcb, 0x6d, 0xdb, 0xb9, 0x52,
+	0x81, 0x45, 0xb6, 0xbb, 0xd0, 0x10, 0x4b, 0xaf, 0x55, 0x26, 0xc9, 0x56, 0x4e, 0xc7, 0xae, 0xc4,
+	0x23, 0xf3, 0x16, 0x40, 0xbe, 0x3c, 0x5a, 0xcb, 0x45, 0xba, 0xd2, 0xd6, 0xea, 0x5c, 0xab, 0x3b,
+	0x42, 0x29, 0x7d, 0x30, 0xb3, 0x15, 0xce, 0x2a, 0x29, 0x2b, 0xee, 0x9c, 0x8e, 0x53, 0x73, 0x82,
+	0x22, 0xee, 0x43, 0x8b, 0xd0, 0x09, 0x7d, 0xc1, 0x43, 0x65, 0x5d, 0xae, 0x5c, 0xc3, 0x9c, 0xab,
+	0x35, 0x7b, 0x0c, 0x3a, 0x41, 0xcc, 0xf0, 0x65, 0x27, 0xe4, 0x4b, 0x87, 0x63, 0x57, 0xe2, 0x91,
+	0xf9, 0x11, 0xb4, 0x0a, 0x53, 0xa5, 0xe5, 0xcc, 0x8e, 0x90, 0xf9, 0x18, 0xeb, 0xac, 0xd4, 0x9e,
+	0xa1, 0xa0, 0xf7, 0xa1, 0x29, 0xe7, 0x13, 0xab, 0x5c, 0x2a, 0xf2, 0x51, 0xc7, 0x59, 0xae, 0x3e,
+	0x10, 0xd1, 0xc8, 0xc7, 0x8c, 0x72, 0x34, 0x4a, 0x53, 0x8b, 0x73, 0xad, 0xee, 0x48, 0x58, 0x21,
+	0xd7, 0x83, 0xb2, 0x15, 0x85, 0x3d, 0xc5, 0x59, 0xae, 0x3e, 0x40, 0xfe, 0x3b, 0xd0, 0x94, 0x45,
+	0xd3, 0xaa, 0x2b, 0xa5, 0x4e, 0x75, 0x7d, 0x91, 0xbc, 0xcc, 0xfe, 0xba, 0xca, 0xfa, 0x0a, 0xde,
+	0xf3, 0x76, 0x17, 0x0a, 0x6d, 0x1d, 0xef, 0x06, 0xff, 0xb1, 0xd8, 0x0f, 0x02, 0xab, 0xa6, 0xee,
+	0xd6, 0x71, 0x6e, 0x03, 0xe4, 0x85, 0xb8, 0xec, 0xf3, 0x52, 0xcf, 0x71, 0xae, 0xd5, 0x1d, 0x45,
+	0xc1, 0xb4, 0xa7, 0xfc, 0x4f, 0xc1, 0x57, 0x90, 0x95, 0xbe, 0xf2, 0x2b, 0x28, 0xd6, 0x52, 0xc7,
+	0xa9, 0x39, 0x29, 0x25, 0x00, 0x73, 0xc2, 0x72, 0x5d, 0x0d, 0xab, 0x4c, 0x80, 0x2c, 0x80, 0xeb,
+	0x3b, 0xd0, 0x94, 0xcb, 0x82, 0x75, 0x1f, 0x1a, 0x5b, 0x34, 0xf0, 0x71, 0xd4, 0x2f, 0xf9, 0xb4,
+	0xb0, 0x08, 0x39, 0xcb, 0x55, 0x07, 0x4c, 0xd4, 0xc3, 0xc6, 0x67, 0x06, 0x43, 0x3f, 0x9b, 0x63,
+	0x7f, 0x70, 0x6f, 0xfd, 0x3e, 0x00, 0x72, 0x67, 0xac, 0x8f, 0xe6, 0x15, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// LogicClient is the client API for Logic service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type LogicClient interface {
+	// Ping Service
+	Ping(ctx context.Context, in *PingReq, opts ...grpc.CallOption) (*PingReply, error)
+	// Close Service
+	Close(ctx context.Context, in *CloseReq, opts ...grpc.CallOption) (*CloseReply, error)
+	// Connect
+	Connect(ctx context.Context, in *ConnectReq, opts ...grpc.CallOption) (*ConnectReply, error)
+	// Disconnect
+	Disconnect(ctx context.Context, in *DisconnectReq, opts ...grpc.CallOption) (*DisconnectReply, error)
+	// Heartbeat
+	Heartbeat(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*HeartbeatReply, error)
+	// RenewOnline
+	RenewOnline(ctx context.Context, in *OnlineReq, opts ...grpc.CallOption) (*OnlineReply, error)
+	// Receive
+	Receive(ctx context.Context, in *ReceiveReq, opts ...grpc.CallOption) (*ReceiveReply, error)
+	// RoomHistory
+	RoomHistory(ctx context.Context, in *RoomHistoryReq, opts ...grpc.CallOption) (*RoomHistoryReply, error)
+	// Presence
+	Presence(ctx context.Context, in *PresenceReq, opts ...grpc.CallOption) (*PresenceReply, error)
+	// OnlineMids
+	OnlineMids(ctx context.Context, in *OnlineMidsReq, opts ...grpc.CallOption) (*OnlineMidsReply, error)
+	// JoinRoom
+	JoinRoom(ctx context.Context, in *JoinRoomReq, opts ...grpc.CallOption) (*JoinRoomReply, error)
+	// PushKeys
+	PushKeys(ctx context.Context, in *PushKeysReq, opts ...grpc.CallOption) (*PushReply, error)
+	// PushMids
+	PushMids(ctx context.Context, in *PushMidsReq, opts ...grpc.CallOption) (*PushReply, error)
+	// PushRoom
+	PushRoom(ctx context.Context, in *PushRoomReq, opts ...grpc.CallOption) (*PushReply, error)
+	// PushAll
+	PushAll(ctx context.Context, in *PushAllReq, opts ...grpc.CallOption) (*PushReply, error)
+	// PushStream
+	PushStream(ctx context.Context, opts ...grpc.CallOption) (Logic_PushStreamClient, error)
+	// OnlineTop
+	OnlineTop(ctx context.Context, in *OnlineTopReq, opts ...grpc.CallOption) (*OnlineTopReply, error)
+	// OnlineRoom
+	OnlineRoom(ctx context.Context, in *OnlineRoomReq, opts ...grpc.CallOption) (*OnlineRoomReply, error)
+}
+
+type logicClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewLogicClient(cc *grpc.ClientConn) LogicClient {
+	return &logicClient{cc}
+}
+
+func (c *logicClient) Ping(ctx context.Context, in *PingReq, opts ...grpc.CallOption) (*PingReply, error) {
+	out := new(PingReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Ping", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) Close(ctx context.Context, in *CloseReq, opts ...grpc.CallOption) (*CloseReply, error) {
+	out := new(CloseReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Close", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) Connect(ctx context.Context, in *ConnectReq, opts ...grpc.CallOption) (*ConnectReply, error) {
+	out := new(ConnectReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Connect", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) Disconnect(ctx context.Context, in *DisconnectReq, opts ...grpc.CallOption) (*DisconnectReply, error) {
+	out := new(DisconnectReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Disconnect", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) Heartbeat(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*HeartbeatReply, error) {
+	out := new(HeartbeatReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Heartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) RenewOnline(ctx context.Context, in *OnlineReq, opts ...grpc.CallOption) (*OnlineReply, error) {
+	out := new(OnlineReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/RenewOnline", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) Receive(ctx context.Context, in *ReceiveReq, opts ...grpc.CallOption) (*ReceiveReply, error) {
+	out := new(ReceiveReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Receive", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) RoomHistory(ctx context.Context, in *RoomHistoryReq, opts ...grpc.CallOption) (*RoomHistoryReply, error) {
+	out := new(RoomHistoryReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/RoomHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) Presence(ctx context.Context, in *PresenceReq, opts ...grpc.CallOption) (*PresenceReply, error) {
+	out := new(PresenceReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Presence", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) OnlineMids(ctx context.Context, in *OnlineMidsReq, opts ...grpc.CallOption) (*OnlineMidsReply, error) {
+	out := new(OnlineMidsReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/OnlineMids", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) JoinRoom(ctx context.Context, in *JoinRoomReq, opts ...grpc.CallOption) (*JoinRoomReply, error) {
+	out := new(JoinRoomReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/JoinRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) PushKeys(ctx context.Context, in *PushKeysReq, opts ...grpc.CallOption) (*PushReply, error) {
+	out := new(PushReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/PushKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) PushMids(ctx context.Context, in *PushMidsReq, opts ...grpc.CallOption) (*PushReply, error) {
+	out := new(PushReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/PushMids", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) PushRoom(ctx context.Context, in *PushRoomReq, opts ...grpc.CallOption) (*PushReply, error) {
+	out := new(PushReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/PushRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) PushAll(ctx context.Context, in *PushAllReq, opts ...grpc.CallOption) (*PushReply, error) {
+	out := new(PushReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/PushAll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) PushStream(ctx context.Context, opts ...grpc.CallOption) (Logic_PushStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Logic_serviceDesc.Streams[0], "/goim.logic.Logic/PushStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &logicPushStreamClient{stream}
+	return x, nil
+}
+
+type Logic_PushStreamClient interface {
+	Send(*PushStreamReq) error
+	Recv() (*PushStreamReply, error)
+	grpc.ClientStream
+}
+
+type logicPushStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *logicPushStreamClient) Send(m *PushStreamReq) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *logicPushStreamClient) Recv() (*PushStreamReply, error) {
+	m := new(PushStreamReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *logicClient) OnlineTop(ctx context.Context, in *OnlineTopReq, opts ...grpc.CallOption) (*OnlineTopReply, error) {
+	out := new(OnlineTopReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/OnlineTop", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) OnlineRoom(ctx context.Context, in *OnlineRoomReq, opts ...grpc.CallOption) (*OnlineRoomReply, error) {
+	out := new(OnlineRoomReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/OnlineRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LogicServer is the server API for Logic service.
+type LogicServer interface {
+	// Ping Service
+	Ping(context.Context, *PingReq) (*PingReply, error)
+	// Close Service
+	Close(context.Context, *CloseReq) (*CloseReply, error)
+	// Connect
+	Connect(context.Context, *ConnectReq) (*ConnectReply, error)
+	// Disconnect
+	Disconnect(context.Context, *DisconnectReq) (*DisconnectReply, error)
+	// Heartbeat
+	Heartbeat(context.Context, *HeartbeatReq) (*HeartbeatReply, error)
+	// RenewOnline
+	RenewOnline(context.Context, *OnlineReq) (*OnlineReply, error)
+	// Receive
+	Receive(context.Context, *ReceiveReq) (*ReceiveReply, error)
+	// RoomHistory
+	RoomHistory(context.Context, *RoomHistoryReq) (*RoomHistoryReply, error)
+	// Presence
+	Presence(context.Context, *PresenceReq) (*PresenceReply, error)
+	// OnlineMids
+	OnlineMids(context.Context, *OnlineMidsReq) (*OnlineMidsReply, error)
+	// JoinRoom
+	JoinRoom(context.Context, *JoinRoomReq) (*JoinRoomReply, error)
+	// PushKeys
+	PushKeys(context.Context, *PushKeysReq) (*PushReply, error)
+	// PushMids
+	PushMids(context.Context, *PushMidsReq) (*PushReply, error)
+	// PushRoom
+	PushRoom(context.Context, *PushRoomReq) (*PushReply, error)
+	// PushAll
+	PushAll(context.Context, *PushAllReq) (*PushReply, error)
+	// PushStream
+	PushStream(Logic_PushStreamServer) error
+	// OnlineTop
+	OnlineTop(context.Context, *OnlineTopReq) (*OnlineTopReply, error)
+	// OnlineRoom
+	OnlineRoom(context.Context, *OnlineRoomReq) (*OnlineRoomReply, error)
+}
+
+func RegisterLogicServer(s *grpc.Server, srv LogicServer) {
+	s.RegisterService(&_Logic_serviceDesc, srv)
+}
+
+func _Logic_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
 		return srv.(LogicServer).Ping(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
@@ -1083,6 +2910,212 @@ func _Logic_Receive_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Logic_RoomHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoomHistoryReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).RoomHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/RoomHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).RoomHistory(ctx, req.(*RoomHistoryReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_Presence_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PresenceReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).Presence(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/Presence",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).Presence(ctx, req.(*PresenceReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_OnlineMids_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OnlineMidsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).OnlineMids(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/OnlineMids",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).OnlineMids(ctx, req.(*OnlineMidsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_JoinRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinRoomReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).JoinRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/JoinRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).JoinRoom(ctx, req.(*JoinRoomReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_PushKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushKeysReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).PushKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/PushKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).PushKeys(ctx, req.(*PushKeysReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_PushMids_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushMidsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).PushMids(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/PushMids",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).PushMids(ctx, req.(*PushMidsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_PushRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushRoomReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).PushRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/PushRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).PushRoom(ctx, req.(*PushRoomReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_PushAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushAllReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).PushAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/PushAll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).PushAll(ctx, req.(*PushAllReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_PushStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogicServer).PushStream(&logicPushStreamServer{stream})
+}
+
+type Logic_PushStreamServer interface {
+	Send(*PushStreamReply) error
+	Recv() (*PushStreamReq, error)
+	grpc.ServerStream
+}
+
+type logicPushStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *logicPushStreamServer) Send(m *PushStreamReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *logicPushStreamServer) Recv() (*PushStreamReq, error) {
+	m := new(PushStreamReq)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Logic_OnlineTop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OnlineTopReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).OnlineTop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/OnlineTop",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).OnlineTop(ctx, req.(*OnlineTopReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_OnlineRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OnlineRoomReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).OnlineRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/OnlineRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).OnlineRoom(ctx, req.(*OnlineRoomReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Logic_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "goim.logic.Logic",
 	HandlerType: (*LogicServer)(nil),
@@ -1115,6 +3148,119 @@ var _Logic_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Receive",
 			Handler:    _Logic_Receive_Handler,
 		},
+		{
+			MethodName: "RoomHistory",
+			Handler:    _Logic_RoomHistory_Handler,
+		},
+		{
+			MethodName: "Presence",
+			Handler:    _Logic_Presence_Handler,
+		},
+		{
+			MethodName: "OnlineMids",
+			Handler:    _Logic_OnlineMids_Handler,
+		},
+		{
+			MethodName: "JoinRoom",
+			Handler:    _Logic_JoinRoom_Handler,
+		},
+		{
+			MethodName: "PushKeys",
+			Handler:    _Logic_PushKeys_Handler,
+		},
+		{
+			MethodName: "PushMids",
+			Handler:    _Logic_PushMids_Handler,
+		},
+		{
+			MethodName: "PushRoom",
+			Handler:    _Logic_PushRoom_Handler,
+		},
+		{
+			MethodName: "PushAll",
+			Handler:    _Logic_PushAll_Handler,
+		},
+		{
+			MethodName: "OnlineTop",
+			Handler:    _Logic_OnlineTop_Handler,
+		},
+		{
+			MethodName: "OnlineRoom",
+			Handler:    _Logic_OnlineRoom_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PushStream",
+			Handler:       _Logic_PushStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "api.proto",
+}
+
+// UpstreamClient is the client API for Upstream service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type UpstreamClient interface {
+	// Deliver
+	Deliver(ctx context.Context, in *UpstreamMsg, opts ...grpc.CallOption) (*UpstreamReply, error)
+}
+
+type upstreamClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewUpstreamClient(cc *grpc.ClientConn) UpstreamClient {
+	return &upstreamClient{cc}
+}
+
+func (c *upstreamClient) Deliver(ctx context.Context, in *UpstreamMsg, opts ...grpc.CallOption) (*UpstreamReply, error) {
+	out := new(UpstreamReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Upstream/Deliver", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpstreamServer is the server API for Upstream service.
+type UpstreamServer interface {
+	// Deliver
+	Deliver(context.Context, *UpstreamMsg) (*UpstreamReply, error)
+}
+
+func RegisterUpstreamServer(s *grpc.Server, srv UpstreamServer) {
+	s.RegisterService(&_Upstream_serviceDesc, srv)
+}
+
+func _Upstream_Deliver_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpstreamMsg)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpstreamServer).Deliver(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Upstream/Deliver",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpstreamServer).Deliver(ctx, req.(*UpstreamMsg))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Upstream_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "goim.logic.Upstream",
+	HandlerType: (*UpstreamServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Deliver",
+			Handler:    _Upstream_Deliver_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
@@ -18,6 +18,8 @@ func main() {
 	// Mechanical domain.
 	errc := make(chan error)
 
+	g.Init()
+
 	// new job server
 	srv = job.New(g.Conf)
 	wg.Wrap(func() {
@@ -2,25 +2,25 @@ package job
 
 import (
 	"context"
-	"sync/atomic"
 	"time"
 
 	pb "github.com/swanky2009/goim/grpc/comet"
 	"github.com/swanky2009/goim/job/g"
 	"github.com/swanky2009/goim/job/g/conf"
+	"github.com/swanky2009/goim/pkg/hash"
 	"google.golang.org/grpc"
 )
 
-// Comet is a comet.
+// Comet is a comet, the messages of a key, a room or a platform are pushed by
+// the same worker, so they reach the comet in the order consumed.
 type Comet struct {
 	serverID      string
 	client        pb.CometClient
 	pushChan      []chan *pb.PushMsgReq
+	urgentChan    []chan *pb.PushMsgReq
 	roomChan      []chan *pb.BroadcastRoomReq
-	broadcastChan chan *pb.BroadcastReq
-	pushChanNum   uint64
-	roomChanNum   uint64
-	routineSize   uint64
+	broadcastChan []chan *pb.BroadcastReq
+	routineSize   int
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -28,20 +28,27 @@ type Comet struct {
 
 // NewComet new a comet.
 func NewComet(c *conf.Comet, addr string) *Comet {
+	return newComet(c, addr, newCometClient(c, addr))
+}
+
+func newComet(c *conf.Comet, addr string, client pb.CometClient) *Comet {
 	cmt := &Comet{
 		serverID:      addr,
-		client:        newCometClient(c, addr),
+		client:        client,
 		pushChan:      make([]chan *pb.PushMsgReq, c.RoutineSize),
+		urgentChan:    make([]chan *pb.PushMsgReq, c.RoutineSize),
 		roomChan:      make([]chan *pb.BroadcastRoomReq, c.RoutineSize),
-		broadcastChan: make(chan *pb.BroadcastReq, c.RoutineSize),
-		routineSize:   uint64(c.RoutineSize),
+		broadcastChan: make([]chan *pb.BroadcastReq, c.RoutineSize),
+		routineSize:   c.RoutineSize,
 	}
 	cmt.ctx, cmt.cancel = context.WithCancel(context.Background())
 
 	for i := 0; i < c.RoutineSize; i++ {
 		cmt.pushChan[i] = make(chan *pb.PushMsgReq, c.RoutineChan)
+		cmt.urgentChan[i] = make(chan *pb.PushMsgReq, c.RoutineChan)
 		cmt.roomChan[i] = make(chan *pb.BroadcastRoomReq, c.RoutineChan)
-		go cmt.process(cmt.pushChan[i], cmt.roomChan[i], cmt.broadcastChan)
+		cmt.broadcastChan[i] = make(chan *pb.BroadcastReq, c.RoutineChan)
+		go cmt.process(cmt.pushChan[i], cmt.urgentChan[i], cmt.roomChan[i], cmt.broadcastChan[i])
 	}
 	return cmt
 }
@@ -62,66 +69,93 @@ func newCometClient(c *conf.Comet, target string) pb.CometClient {
 	return pb.NewCometClient(conn)
 }
 
-// Push push a user message.
+// Push push a user message, the keys are split by their worker.
 func (c *Comet) Push(arg *pb.PushMsgReq) (err error) {
-	idx := atomic.AddUint64(&c.pushChanNum, 1) % c.routineSize
-	c.pushChan[idx] <- arg
+	for idx, req := range c.split(arg) {
+		if req != nil {
+			c.pushChan[idx] <- req
+		}
+	}
 	return
 }
 
+// PushUrgent push a user message before the queued ones of the workers of
+// its keys.
+func (c *Comet) PushUrgent(arg *pb.PushMsgReq) (err error) {
+	for idx, req := range c.split(arg) {
+		if req != nil {
+			c.urgentChan[idx] <- req
+		}
+	}
+	return
+}
+
+// split returns the push of every worker, with the keys of the worker.
+func (c *Comet) split(arg *pb.PushMsgReq) []*pb.PushMsgReq {
+	reqs := make([]*pb.PushMsgReq, c.routineSize)
+	for _, key := range arg.Keys {
+		idx := hash.Index(key, c.routineSize)
+		if reqs[idx] == nil {
+			reqs[idx] = &pb.PushMsgReq{ProtoOp: arg.ProtoOp, Proto: arg.Proto, MsgID: arg.MsgID}
+		}
+		reqs[idx].Keys = append(reqs[idx].Keys, key)
+	}
+	return reqs
+}
+
 // BroadcastRoom broadcast a room message.
 func (c *Comet) BroadcastRoom(arg *pb.BroadcastRoomReq) (err error) {
-	idx := atomic.AddUint64(&c.roomChanNum, 1) % c.routineSize
-	c.roomChan[idx] <- arg
+	c.roomChan[hash.Index(arg.RoomID, c.routineSize)] <- arg
 	return
 }
 
 // Broadcast broadcast a message.
 func (c *Comet) Broadcast(arg *pb.BroadcastReq) (err error) {
-	c.broadcastChan <- arg
+	c.broadcastChan[hash.Index(arg.Platform, c.routineSize)] <- arg
 	return
 }
 
-func (c *Comet) process(pushChan chan *pb.PushMsgReq, roomChan chan *pb.BroadcastRoomReq, broadcastChan chan *pb.BroadcastReq) {
+func (c *Comet) process(pushChan, urgentChan chan *pb.PushMsgReq, roomChan chan *pb.BroadcastRoomReq, broadcastChan chan *pb.BroadcastReq) {
 	var err error
 	for {
+		// urgent messages first
+		select {
+		case pushArg := <-urgentChan:
+			c.pushMsg(pushArg)
+			continue
+		default:
+		}
 		select {
+		case pushArg := <-urgentChan:
+			c.pushMsg(pushArg)
 		case broadcastArg := <-broadcastChan:
-			_, err = c.client.Broadcast(context.Background(), &pb.BroadcastReq{
-				Proto:    broadcastArg.Proto,
-				ProtoOp:  broadcastArg.ProtoOp,
-				Speed:    broadcastArg.Speed,
-				Platform: broadcastArg.Platform,
-			})
+			_, err = c.client.Broadcast(context.Background(), broadcastArg)
 			if err != nil {
 				g.Logger.Errorf("c.client.Broadcast(%v, reply) serverId:%s error(%v)", broadcastArg, c.serverID, err)
 			}
 			g.Logger.Infof("c.client.Broadcast(%v, reply) serverId:%s", broadcastArg, c.serverID)
 		case roomArg := <-roomChan:
-			_, err = c.client.BroadcastRoom(context.Background(), &pb.BroadcastRoomReq{
-				RoomID: roomArg.RoomID,
-				Proto:  roomArg.Proto,
-			})
+			_, err = c.client.BroadcastRoom(context.Background(), roomArg)
 			if err != nil {
 				g.Logger.Errorf("c.client.BroadcastRoom(%v, reply) serverId:%s error(%v)", roomArg, c.serverID, err)
 			}
 			g.Logger.Infof("c.client.BroadcastRoom(%v, reply) serverId:%s", roomArg, c.serverID)
 		case pushArg := <-pushChan:
-			_, err = c.client.PushMsg(context.Background(), &pb.PushMsgReq{
-				Keys:    pushArg.Keys,
-				Proto:   pushArg.Proto,
-				ProtoOp: pushArg.ProtoOp,
-			})
-			if err != nil {
-				g.Logger.Errorf("c.client.PushMsg(%v, reply) serverId:%s error(%v)", pushArg, c.serverID, err)
-			}
-			g.Logger.Infof("c.client.PushMsg(%v, reply) serverId:%s", pushArg, c.serverID)
+			c.pushMsg(pushArg)
 		case <-c.ctx.Done():
 			return
 		}
 	}
 }
 
+func (c *Comet) pushMsg(pushArg *pb.PushMsgReq) {
+	_, err := c.client.PushMsg(context.Background(), pushArg)
+	if err != nil {
+		g.Logger.Errorf("c.client.PushMsg(%v, reply) serverId:%s error(%v)", pushArg, c.serverID, err)
+	}
+	g.Logger.Infof("c.client.PushMsg(%v, reply) serverId:%s", pushArg, c.serverID)
+}
+
 func (c *Comet) GetRooms() map[string]bool {
 	roomsReply, err := c.client.Rooms(context.Background(), &pb.RoomsReq{})
 	if err != nil {
@@ -136,12 +170,9 @@ func (c *Comet) Close() {
 	finish := make(chan bool)
 	go func() {
 		for {
-			n := len(c.broadcastChan)
-			for _, ch := range c.pushChan {
-				n += len(ch)
-			}
-			for _, ch := range c.roomChan {
-				n += len(ch)
+			n := 0
+			for i := 0; i < c.routineSize; i++ {
+				n += len(c.pushChan[i]) + len(c.urgentChan[i]) + len(c.roomChan[i]) + len(c.broadcastChan[i])
 			}
 			if n == 0 {
 				finish <- true
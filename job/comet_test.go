@@ -0,0 +1,130 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/swanky2009/goim/grpc/comet"
+	"github.com/swanky2009/goim/job/g/conf"
+	"github.com/swanky2009/goim/pkg/hash"
+	"google.golang.org/grpc"
+)
+
+// orderClient a comet recording the seqs pushed to every key and room, a
+// push takes a random time.
+type orderClient struct {
+	pb.CometClient
+	mu   sync.Mutex
+	seqs map[string][]int64
+	n    int
+}
+
+func (c *orderClient) record(targets []string, seq int64) {
+	time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+	c.mu.Lock()
+	for _, t := range targets {
+		c.seqs[t] = append(c.seqs[t], seq)
+		c.n++
+	}
+	c.mu.Unlock()
+}
+
+func (c *orderClient) PushMsg(ctx context.Context, req *pb.PushMsgReq, opts ...grpc.CallOption) (*pb.PushMsgReply, error) {
+	c.record(req.Keys, req.Proto.Seq)
+	return &pb.PushMsgReply{}, nil
+}
+
+func (c *orderClient) BroadcastRoom(ctx context.Context, req *pb.BroadcastRoomReq, opts ...grpc.CallOption) (*pb.BroadcastRoomReply, error) {
+	c.record([]string{"room:" + req.RoomID}, req.Proto.Seq)
+	return &pb.BroadcastRoomReply{}, nil
+}
+
+func (c *orderClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+func TestCometOrder(t *testing.T) {
+	const (
+		partitions = 4
+		keys       = 16
+		msgs       = 300
+	)
+	client := &orderClient{seqs: make(map[string][]int64)}
+	cmt := newComet(&conf.Comet{RoutineSize: 8, RoutineChan: 4}, "test", client)
+	defer cmt.cancel()
+	// every partition is consumed in order by one goroutine, pushing to
+	// random keys of its own and to its room
+	var (
+		wg       sync.WaitGroup
+		expected = make([]int, partitions)
+	)
+	for p := 0; p < partitions; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(p)))
+			for seq := int64(1); seq <= msgs; seq++ {
+				var ks []string
+				for k := 0; k < keys; k++ {
+					if r.Intn(3) == 0 {
+						ks = append(ks, fmt.Sprintf("p%d-k%d", p, k))
+					}
+				}
+				proto := &pb.Proto{Op: 1, Seq: seq}
+				if len(ks) > 0 {
+					cmt.Push(&pb.PushMsgReq{Keys: ks, ProtoOp: 1, Proto: proto})
+				}
+				cmt.BroadcastRoom(&pb.BroadcastRoomReq{RoomID: fmt.Sprintf("p%d", p), Proto: proto})
+				expected[p] += len(ks) + 1
+			}
+		}(p)
+	}
+	wg.Wait()
+	total := 0
+	for _, n := range expected {
+		total += n
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for client.count() < total && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := client.count(); n != total {
+		t.Fatalf("pushed %d of %d", n, total)
+	}
+	for target, seqs := range client.seqs {
+		for i := 1; i < len(seqs); i++ {
+			if seqs[i] <= seqs[i-1] {
+				t.Fatalf("%s got seq %d after %d", target, seqs[i], seqs[i-1])
+			}
+		}
+	}
+}
+
+func TestCometSplit(t *testing.T) {
+	cmt := &Comet{routineSize: 4}
+	arg := &pb.PushMsgReq{Keys: []string{"a", "b", "c", "d", "e", "a2"}, ProtoOp: 1, MsgID: "m"}
+	n := 0
+	for idx, req := range cmt.split(arg) {
+		if req == nil {
+			continue
+		}
+		for _, key := range req.Keys {
+			if i := hash.Index(key, cmt.routineSize); i != idx {
+				t.Fatalf("key %s on worker %d, want %d", key, idx, i)
+			}
+			n++
+		}
+		if req.MsgID != "m" || req.ProtoOp != 1 {
+			t.Fatalf("req %+v", req)
+		}
+	}
+	if n != len(arg.Keys) {
+		t.Fatalf("split %d keys of %d", n, len(arg.Keys))
+	}
+}
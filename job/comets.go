@@ -89,12 +89,17 @@ func (this *Comets) SyncComets(c *conf.Comet) {
 	}
 }
 
-// push a message to a batch of subkeys
-func (this *Comets) Push(serverId string, args *pb.PushMsgReq) {
+// push a message to a batch of subkeys, urgent messages skip the queued ones
+func (this *Comets) Push(serverId string, args *pb.PushMsgReq, urgent bool) {
 
 	if c, ok := this.cometServiceMap[serverId]; ok {
-
-		if err := c.Push(args); err != nil {
+		var err error
+		if urgent {
+			err = c.PushUrgent(args)
+		} else {
+			err = c.Push(args)
+		}
+		if err != nil {
 
 			g.Logger.Errorf("c.Push(%v) serverId:%s error(%v)", args, serverId, err)
 
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/swanky2009/goim/pkg/bus"
 	xtime "github.com/swanky2009/goim/pkg/time"
 	"gopkg.in/yaml.v2"
 )
@@ -29,6 +30,7 @@ type Config struct {
 	LogPath       string
 	Env           *Env
 	Kafka         *Kafka
+	Bus           *bus.Config
 	Discovery     *DiscoveryConf
 	Comet         *Comet
 	Zipkin        *zipkinConf
@@ -45,7 +47,7 @@ type Comet struct {
 	RoutineSize int
 }
 
-// Kafka is kafka config.
+// Kafka is kafka config, the bus if it is not configured.
 type Kafka struct {
 	Topic   string
 	Group   string
@@ -129,6 +131,12 @@ func (c *Config) fix() {
 	if c.Comet != nil {
 		c.Comet.fix()
 	}
+	if c.Bus == nil && c.Kafka != nil {
+		c.Bus = &bus.Config{Kind: bus.KindKafka, Topic: c.Kafka.Topic, Group: c.Kafka.Group, Addrs: c.Kafka.Brokers}
+	}
+	if c.Bus != nil {
+		c.Bus.Fix()
+	}
 
 }
 
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/sirupsen/logrus"
 	"github.com/swanky2009/goim/job/g/conf"
 )
 
@@ -22,12 +23,16 @@ const (
 var (
 	Conf *conf.Config
 
-	Logger logger
+	// Logger and MetricsStat default to stderr logging and discarded metrics,
+	// so the job package can be used without calling Init.
+	Logger = logger{logrus.New()}
 
-	MetricsStat *Metrics
+	MetricsStat = discardMetrics()
 )
 
-func init() {
+// Init loads the job config next to the binary, then sets up the logger,
+// discovery and metrics of a job process.
+func Init() {
 	curPath := GetCurrentDir()
 
 	SetPid(curPath)
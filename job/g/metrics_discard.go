@@ -0,0 +1,32 @@
+package g
+
+import (
+	"github.com/go-kit/kit/metrics"
+)
+
+type discardCounter struct{}
+
+func (c discardCounter) With(...string) metrics.Counter { return c }
+func (c discardCounter) Add(float64)                    {}
+
+type discardGauge struct{}
+
+func (g discardGauge) With(...string) metrics.Gauge { return g }
+func (g discardGauge) Set(float64)                  {}
+func (g discardGauge) Add(float64)                  {}
+
+// discardMetrics metrics which drop every observation, used until Init registers the prometheus ones.
+func discardMetrics() *Metrics {
+	return &Metrics{
+		AllMsg:                 discardCounter{},
+		PushMsg:                discardCounter{},
+		BroadcastMsg:           discardCounter{},
+		BroadcastRoomMsg:       discardCounter{},
+		PushMsgFailed:          discardCounter{},
+		BroadcastMsgFailed:     discardCounter{},
+		BroadcastRoomMsgFailed: discardCounter{},
+		ExpiredMsg:             discardCounter{},
+		ActiveRoomCount:        discardGauge{},
+		CometNodes:             discardGauge{},
+	}
+}
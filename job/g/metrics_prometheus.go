@@ -17,6 +17,7 @@ type Metrics struct {
 	PushMsgFailed          metrics.Counter
 	BroadcastMsgFailed     metrics.Counter
 	BroadcastRoomMsgFailed metrics.Counter
+	ExpiredMsg             metrics.Counter
 
 	// room
 	ActiveRoomCount metrics.Gauge
@@ -72,6 +73,12 @@ func MetricsInstrumenting() *Metrics {
 		Name:      "broadcastroommsg_failed",
 		Help:      "Number of broadcastroom messages failed.",
 	}, fieldKeys)
+	ExpiredMsg := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "expiredmsg",
+		Help:      "Number of messages dropped for expired.",
+	}, fieldKeys)
 
 	ActiveRoomCount := kitprometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
 		Namespace: namespace,
@@ -95,6 +102,7 @@ func MetricsInstrumenting() *Metrics {
 		PushMsgFailed,
 		BroadcastMsgFailed,
 		BroadcastRoomMsgFailed,
+		ExpiredMsg,
 		ActiveRoomCount,
 		CometNodes,
 	}
@@ -127,6 +135,10 @@ func (s *Metrics) IncrBroadcastRoomMsgFailed() {
 	s.BroadcastRoomMsgFailed.Add(1)
 }
 
+func (s *Metrics) IncrExpiredMsg() {
+	s.ExpiredMsg.Add(1)
+}
+
 func (s *Metrics) SetActiveRoomCount(n float64) {
 	s.ActiveRoomCount.Set(n)
 }
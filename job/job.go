@@ -7,14 +7,13 @@ import (
 	pb "github.com/swanky2009/goim/grpc/logic"
 	"github.com/swanky2009/goim/job/g"
 	"github.com/swanky2009/goim/job/g/conf"
-
-	cluster "github.com/bsm/sarama-cluster"
+	"github.com/swanky2009/goim/pkg/bus"
 )
 
 // Job is push job.
 type Job struct {
 	//c        *conf.Config
-	consumer *cluster.Consumer
+	consumer bus.Subscriber
 	comets   *Comets
 }
 
@@ -22,17 +21,17 @@ type Job struct {
 func New(c *conf.Config) *Job {
 	j := &Job{
 		//c:        c,
-		consumer: newKafkaSub(c.Kafka),
+		consumer: newSub(c.Bus),
 		comets:   InitComets(c.Comet),
 	}
 	return j
 }
 
-func newKafkaSub(c *conf.Kafka) *cluster.Consumer {
-	config := cluster.NewConfig()
-	config.Consumer.Return.Errors = true
-	config.Group.Return.Notifications = true
-	consumer, err := cluster.NewConsumer(c.Brokers, c.Group, []string{c.Topic}, config)
+func newSub(c *bus.Config) bus.Subscriber {
+	if c == nil {
+		panic("bus is not configured")
+	}
+	consumer, err := bus.NewSubscriber(c)
 	if err != nil {
 		panic(err)
 	}
@@ -55,13 +54,11 @@ func (j *Job) Consume() {
 		select {
 		case err := <-j.consumer.Errors():
 			g.Logger.Errorf("consumer error(%v)", err)
-		case n := <-j.consumer.Notifications():
-			g.Logger.Infof("consumer rebalanced(%v)", n)
 		case msg, ok := <-j.consumer.Messages():
 			if !ok {
 				return
 			}
-			j.consumer.MarkOffset(msg, "")
+			msg.Ack()
 			// process push message
 			pushMsg := new(pb.PushMsg)
 			if err := proto.Unmarshal(msg.Value, pushMsg); err != nil {
@@ -71,7 +68,7 @@ func (j *Job) Consume() {
 			if err := j.push(context.Background(), pushMsg); err != nil {
 				g.Logger.Errorf("j.push(%v) error(%v)", pushMsg, err)
 			}
-			g.Logger.Infof("consume: %s/%d/%d/%s/%v", msg.Topic, msg.Partition, msg.Offset, msg.Key, pushMsg)
+			g.Logger.Infof("consume: %s/%s/%s/%v", msg.Topic, msg.ID, msg.Key, pushMsg)
 		}
 	}
 }
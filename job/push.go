@@ -3,6 +3,7 @@ package job
 import (
 	"context"
 	"fmt"
+	"time"
 
 	pb_c "github.com/swanky2009/goim/grpc/comet"
 	pb_l "github.com/swanky2009/goim/grpc/logic"
@@ -10,26 +11,32 @@ import (
 )
 
 func (j *Job) push(ctx context.Context, m *pb_l.PushMsg) (err error) {
+	if m.Expire > 0 && m.Expire < time.Now().UnixNano()/int64(time.Millisecond) {
+		g.MetricsStat.IncrExpiredMsg()
+		g.Logger.Debugf("drop expired msg type:%s op:%d expire:%d", m.Type, m.Operation, m.Expire)
+		return
+	}
 	switch m.Type {
 	case pb_l.PushMsg_PUSH:
 
 		proto := &pb_c.Proto{Ver: 0, Op: m.Operation, Body: m.Msg}
 
-		j.comets.Push(m.Server, &pb_c.PushMsgReq{Keys: m.Keys, ProtoOp: m.Operation, Proto: proto})
+		j.comets.Push(m.Server, &pb_c.PushMsgReq{Keys: m.Keys, ProtoOp: m.Operation, Proto: proto, MsgID: m.MsgID}, m.Priority == pb_l.PushMsg_HIGH)
 
 		g.Logger.Debugf("push msg serverId: %s keys(%v)", m.Server, m.Keys)
 
 	case pb_l.PushMsg_ROOM:
 
-		proto := &pb_c.Proto{Ver: 0, Op: m.Operation, Body: m.Msg}
+		// the room seq lets clients detect the gaps
+		proto := &pb_c.Proto{Ver: 0, Op: m.Operation, Seq: m.Seq, Body: m.Msg}
 
-		j.comets.BroadcastRoom(m.Room, &pb_c.BroadcastRoomReq{RoomID: m.Room, Proto: proto})
+		j.comets.BroadcastRoom(m.Room, &pb_c.BroadcastRoomReq{RoomID: m.Room, Proto: proto, MsgID: m.MsgID})
 
 	case pb_l.PushMsg_BROADCAST:
 
 		proto := &pb_c.Proto{Ver: 0, Op: m.Operation, Body: m.Msg}
 
-		j.comets.Broadcast(&pb_c.BroadcastReq{ProtoOp: m.Operation, Proto: proto, Speed: m.Speed, Platform: m.Platform})
+		j.comets.Broadcast(&pb_c.BroadcastReq{ProtoOp: m.Operation, Proto: proto, Speed: m.Speed, Platform: m.Platform, MsgID: m.MsgID, App: m.App})
 
 	default:
 		err = fmt.Errorf("no match type: %s", m.Type)
@@ -0,0 +1,174 @@
+// Package apikey authenticates the callers of the push apis of logic by api
+// keys. A caller either sends its key id and secret as is, or signs the
+// request with:
+//
+//	hex(hmac-sha256(secret, method + "\n" + uri + "\n" + timestamp + "\n" + nonce + "\n" + hex(sha256(body))))
+//
+// the timestamp is unix seconds within the window, and a nonce is used once.
+// A grpc call is signed with the method "GRPC", its full method as the uri
+// and the request in protobuf as the body, the body of a stream is empty.
+package apikey
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/model"
+	xstr "github.com/swanky2009/goim/pkg/strings"
+)
+
+// MethodGRPC the method a grpc call is signed with.
+const MethodGRPC = "GRPC"
+
+// Key an api key with its parsed scope.
+type Key struct {
+	*conf.APIKey
+	types map[string]bool
+	ops   []xstr.Int32Range
+}
+
+// Allow reports whether the key may push the type, op and room.
+func (k *Key) Allow(typ string, op int32, room string) bool {
+	if len(k.types) > 0 && !k.types[typ] {
+		return false
+	}
+	if len(k.ops) > 0 && !xstr.InInt32Ranges(k.ops, op) {
+		return false
+	}
+	if typ == model.PushRoom {
+		return k.AllowRoom(room)
+	}
+	return true
+}
+
+// AllowRoom reports whether the room has a prefix of the key, any room if none.
+func (k *Key) AllowRoom(room string) bool {
+	if len(k.Rooms) == 0 {
+		return true
+	}
+	for _, prefix := range k.Rooms {
+		if strings.HasPrefix(room, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scoped reports whether the key is limited to some types, ops or rooms.
+func (k *Key) Scoped() bool {
+	return len(k.types) > 0 || len(k.ops) > 0 || len(k.Rooms) > 0
+}
+
+// Signed the parts of a signed request.
+type Signed struct {
+	Method    string
+	URI       string
+	Timestamp string
+	Nonce     string
+	Signature string
+	Body      []byte
+}
+
+// Keys the api keys, the callers are authenticated by.
+type Keys struct {
+	window   time.Duration
+	keys     map[string]*Key
+	useNonce func(c context.Context, id, nonce string, expire time.Duration) (bool, error)
+}
+
+// New new the api keys of the config, a nonce is used once by useNonce.
+func New(c *conf.APIAuth, useNonce func(c context.Context, id, nonce string, expire time.Duration) (bool, error)) (a *Keys, err error) {
+	a = &Keys{
+		window:   time.Duration(c.Window),
+		keys:     make(map[string]*Key, len(c.Keys)),
+		useNonce: useNonce,
+	}
+	for _, kc := range c.Keys {
+		if kc.ID == "" || kc.Secret == "" {
+			return nil, fmt.Errorf("api key %q needs id and secret", kc.ID)
+		}
+		if _, ok := a.keys[kc.ID]; ok {
+			return nil, fmt.Errorf("duplicate api key %q", kc.ID)
+		}
+		if !model.ValidID(kc.App) {
+			return nil, fmt.Errorf("api key %q invalid app %q", kc.ID, kc.App)
+		}
+		k := &Key{APIKey: kc, types: make(map[string]bool)}
+		for _, typ := range kc.Types {
+			switch typ {
+			case model.PushKeys, model.PushMids, model.PushRoom, model.PushAll:
+				k.types[typ] = true
+			default:
+				return nil, fmt.Errorf("api key %q unknown type %q", kc.ID, typ)
+			}
+		}
+		if k.ops, err = xstr.SplitInt32Ranges(kc.Ops, ","); err != nil {
+			return nil, fmt.Errorf("api key %q invalid ops %q", kc.ID, kc.Ops)
+		}
+		a.keys[kc.ID] = k
+	}
+	return
+}
+
+// Authenticate returns the key of the id sending its secret as is.
+func (a *Keys) Authenticate(id, secret string) (k *Key, err error) {
+	var ok bool
+	if k, ok = a.keys[id]; !ok {
+		return nil, fmt.Errorf("unknown key id")
+	}
+	if k.SignOnly {
+		return nil, fmt.Errorf("signature required")
+	}
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(k.Secret)) != 1 {
+		return nil, fmt.Errorf("invalid key")
+	}
+	return
+}
+
+// Verify returns the key of the id signing the request.
+func (a *Keys) Verify(c context.Context, id string, s *Signed) (k *Key, err error) {
+	var (
+		ok bool
+		ts int64
+	)
+	if k, ok = a.keys[id]; !ok {
+		return nil, fmt.Errorf("unknown key id")
+	}
+	if ts, err = strconv.ParseInt(s.Timestamp, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid timestamp")
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > a.window || skew < -a.window {
+		return nil, fmt.Errorf("timestamp out of window")
+	}
+	if s.Nonce == "" {
+		return nil, fmt.Errorf("nonce required")
+	}
+	want, _ := hex.DecodeString(s.Signature)
+	if !hmac.Equal(want, Sign(k.Secret, s.Method, s.URI, s.Timestamp, s.Nonce, s.Body)) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	// a nonce is kept as long as its timestamp is in the window
+	if ok, err = a.useNonce(c, k.ID, s.Nonce, a.window*2); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("nonce used")
+	}
+	return
+}
+
+// Sign signs the parts of a request with the secret.
+func Sign(secret, method, uri, ts, nonce string, body []byte) []byte {
+	bodySum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + uri + "\n" + ts + "\n" + nonce + "\n" + hex.EncodeToString(bodySum[:])))
+	return mac.Sum(nil)
+}
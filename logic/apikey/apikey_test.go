@@ -0,0 +1,93 @@
+package apikey
+
+import (
+	"context"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/model"
+	xtime "github.com/swanky2009/goim/pkg/time"
+)
+
+func testKeys(t *testing.T) *Keys {
+	nonces := make(map[string]bool)
+	a, err := New(&conf.APIAuth{
+		Window: xtime.Duration(time.Minute),
+		Keys: []*conf.APIKey{
+			{ID: "admin", Secret: "admin_secret"},
+			{ID: "chat", Secret: "chat_secret", SignOnly: true, Types: []string{model.PushRoom}, Ops: "1000-1099", Rooms: []string{"chat://"}},
+		},
+	}, func(c context.Context, id, nonce string, expire time.Duration) (bool, error) {
+		if nonces[id+nonce] {
+			return false, nil
+		}
+		nonces[id+nonce] = true
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func signed(secret, nonce string, body []byte, ts time.Time) *Signed {
+	tsStr := strconv.FormatInt(ts.Unix(), 10)
+	return &Signed{
+		Method:    MethodGRPC,
+		URI:       "/goim.logic.Logic/PushRoom",
+		Timestamp: tsStr,
+		Nonce:     nonce,
+		Signature: hex.EncodeToString(Sign(secret, MethodGRPC, "/goim.logic.Logic/PushRoom", tsStr, nonce, body)),
+		Body:      body,
+	}
+}
+
+func TestKeysAuthenticate(t *testing.T) {
+	a := testKeys(t)
+	k, err := a.Authenticate("admin", "admin_secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", k.ID)
+	_, err = a.Authenticate("admin", "wrong")
+	assert.NotNil(t, err)
+	_, err = a.Authenticate("nobody", "admin_secret")
+	assert.NotNil(t, err)
+	// chat signs only
+	_, err = a.Authenticate("chat", "chat_secret")
+	assert.NotNil(t, err)
+}
+
+func TestKeysVerify(t *testing.T) {
+	var (
+		a    = testKeys(t)
+		c    = context.Background()
+		body = []byte("hello")
+	)
+	k, err := a.Verify(c, "chat", signed("chat_secret", "n1", body, time.Now()))
+	assert.Nil(t, err)
+	assert.Equal(t, "chat", k.ID)
+	// replay
+	_, err = a.Verify(c, "chat", signed("chat_secret", "n1", body, time.Now()))
+	assert.NotNil(t, err)
+	// expired
+	_, err = a.Verify(c, "chat", signed("chat_secret", "n2", body, time.Now().Add(-time.Hour)))
+	assert.NotNil(t, err)
+	// tampered body
+	s := signed("chat_secret", "n3", body, time.Now())
+	s.Body = []byte("bye")
+	_, err = a.Verify(c, "chat", s)
+	assert.NotNil(t, err)
+}
+
+func TestKeyScope(t *testing.T) {
+	k := testKeys(t).keys["chat"]
+	assert.True(t, k.Allow(model.PushRoom, 1000, "chat://1"))
+	assert.False(t, k.Allow(model.PushRoom, 1000, "live://1"))
+	assert.False(t, k.Allow(model.PushRoom, 2000, "chat://1"))
+	assert.False(t, k.Allow(model.PushAll, 1000, ""))
+	assert.True(t, k.AllowRoom("chat://1"))
+	assert.False(t, k.AllowRoom("live://1"))
+}
@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"context"
+	"errors"
+
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// ErrAppQuota a connection or a push beyond the quota of its app.
+var ErrAppQuota = errors.New("beyond the quota of the app")
+
+// allowConn checks the app is within its connections, counted from the
+// online reports of the comets like the capacity of a room.
+func (l *Server) allowConn(c context.Context, app string) (err error) {
+	q, ok := l.c.Apps[app]
+	if !ok || q == nil || q.Conns <= 0 {
+		return
+	}
+	key := model.AppOnlineKey(app)
+	counts, err := l.dao.RoomCount(c, []string{key})
+	if err != nil {
+		g.Logger.Errorf("l.dao.RoomCount(%s) error(%v)", key, err)
+		return
+	}
+	if counts[key] >= q.Conns {
+		g.Logger.Warningf("app:%s connections %d beyond the quota %d", app, counts[key], q.Conns)
+		return ErrAppQuota
+	}
+	return
+}
+
+// appRoomCount merges the channels of the apps into the room counts of a
+// comet, under the online keys of the apps.
+func appRoomCount(roomCount, appCount map[string]int32) map[string]int32 {
+	if len(appCount) == 0 {
+		return roomCount
+	}
+	res := make(map[string]int32, len(roomCount)+len(appCount))
+	for room, n := range roomCount {
+		res[room] = n
+	}
+	for app, n := range appCount {
+		res[model.AppOnlineKey(app)] = n
+	}
+	return res
+}
+
+// OnlineApp gets the connections and the online of the rooms of the app.
+func (l *Server) OnlineApp(c context.Context, app string) (res *model.AppOnline, err error) {
+	all, err := l.dao.GetAllRoomCount(c)
+	if err != nil {
+		g.Logger.Errorf("GetAllRoomCount error(%v)", err)
+		return
+	}
+	res = &model.AppOnline{App: app, Conns: all[model.AppOnlineKey(app)], Rooms: make(map[string]int32)}
+	for key, n := range all {
+		if a, room := model.DecodeRoomKey(key); a == app && room != "" {
+			res.Rooms[room] = n
+		}
+	}
+	return
+}
+
+// unscopeKeys returns the keys of the app without it.
+func unscopeKeys(app string, keys []string) []string {
+	if app == "" || len(keys) == 0 {
+		return keys
+	}
+	res := make([]string, len(keys))
+	for i, key := range keys {
+		_, res[i] = model.DecodeKey(key)
+	}
+	return res
+}
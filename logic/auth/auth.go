@@ -0,0 +1,67 @@
+// Package auth authenticates the token a connection sends with OpAuth.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/swanky2009/goim/logic/g/conf"
+)
+
+const (
+	// ModeJWT verifies a signed jwt.
+	ModeJWT = "jwt"
+	// ModeHTTP asks the account service.
+	ModeHTTP = "http"
+	// ModeDev trusts a mid|key|roomid|platform|accepts[|app] token, never use it in production.
+	ModeDev = "dev"
+)
+
+var (
+	// ErrUnauthorized the token is rejected.
+	ErrUnauthorized = errors.New("auth: unauthorized")
+	// ErrTokenInvalid the token is malformed or the signature mismatch.
+	ErrTokenInvalid = errors.New("auth: invalid token")
+	// ErrTokenExpired the token is expired or not valid yet.
+	ErrTokenExpired = errors.New("auth: token expired")
+)
+
+// Identity the connection identity of an authenticated token.
+type Identity struct {
+	Mid      int64   `json:"mid"`
+	Key      string  `json:"key"` // empty means the key generated by comet
+	RoomID   string  `json:"room_id"`
+	Platform string  `json:"platform"`
+	Accepts  []int32 `json:"accepts"`
+	// App scopes the mid, key and room to a tenant, empty is the default app.
+	App string `json:"app,omitempty"`
+}
+
+// Authenticator authenticates the token of a connection.
+type Authenticator interface {
+	Auth(c context.Context, server, cookie string, token []byte) (*Identity, error)
+}
+
+// New returns the authenticator selected by the config mode.
+func New(c *conf.Auth) (Authenticator, error) {
+	if c == nil {
+		return nil, errors.New("auth: no auth config, set auth.mode to jwt, http or dev")
+	}
+	switch c.Mode {
+	case ModeJWT:
+		if c.JWT == nil {
+			return nil, errors.New("auth: no jwt config")
+		}
+		return newJWT(c.JWT)
+	case ModeHTTP:
+		if c.HTTP == nil || c.HTTP.URL == "" {
+			return nil, errors.New("auth: no http url")
+		}
+		return newHTTP(c.HTTP), nil
+	case ModeDev:
+		return dev{}, nil
+	default:
+		return nil, fmt.Errorf("auth: unknown mode %q, supports:[jwt,http,dev]", c.Mode)
+	}
+}
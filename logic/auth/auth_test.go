@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swanky2009/goim/logic/g/conf"
+	xtime "github.com/swanky2009/goim/pkg/time"
+)
+
+func signJWT(alg string, claims map[string]interface{}, sign func(signed []byte) []byte) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(secret string) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(crypto.SHA256.New, []byte(secret))
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func jwtConf(c *conf.JWT) *conf.Auth {
+	return &conf.Auth{Mode: ModeJWT, JWT: c}
+}
+
+func TestJWTHMAC(t *testing.T) {
+	a, err := New(jwtConf(&conf.JWT{Secret: "secret", Issuer: "account", Leeway: xtime.Duration(time.Second)}))
+	assert.Nil(t, err)
+	claims := map[string]interface{}{
+		"sub":      "123",
+		"iss":      "account",
+		"exp":      time.Now().Add(time.Minute).Unix(),
+		"room":     "live://1000",
+		"platform": "web",
+		"accepts":  []int{1000, 1001},
+	}
+	id, err := a.Auth(context.Background(), "server", "", []byte(signJWT("HS256", claims, hs256("secret"))))
+	assert.Nil(t, err)
+	assert.Equal(t, &Identity{Mid: 123, RoomID: "live://1000", Platform: "web", Accepts: []int32{1000, 1001}}, id)
+	// bad signature
+	_, err = a.Auth(context.Background(), "server", "", []byte(signJWT("HS256", claims, hs256("other"))))
+	assert.Equal(t, ErrTokenInvalid, err)
+	// algorithm is pinned
+	_, err = a.Auth(context.Background(), "server", "", []byte(signJWT("none", claims, func([]byte) []byte { return nil })))
+	assert.Equal(t, ErrTokenInvalid, err)
+	// issuer
+	claims["iss"] = "other"
+	_, err = a.Auth(context.Background(), "server", "", []byte(signJWT("HS256", claims, hs256("secret"))))
+	assert.Equal(t, ErrUnauthorized, err)
+	// expired
+	claims["iss"] = "account"
+	claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	_, err = a.Auth(context.Background(), "server", "", []byte(signJWT("HS256", claims, hs256("secret"))))
+	assert.Equal(t, ErrTokenExpired, err)
+	// exp is required
+	delete(claims, "exp")
+	_, err = a.Auth(context.Background(), "server", "", []byte(signJWT("HS256", claims, hs256("secret"))))
+	assert.Equal(t, ErrTokenInvalid, err)
+}
+
+func TestJWTRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+	f, err := ioutil.TempFile("", "goim_jwt_pub")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	pem.Encode(f, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	f.Close()
+
+	c := &conf.JWT{Algorithm: "RS256", PublicKey: f.Name(), Audience: "goim"}
+	c.Claims.Mid = "uid"
+	c.Claims.Accepts = "ops"
+	a, err := New(jwtConf(c))
+	assert.Nil(t, err)
+	claims := map[string]interface{}{
+		"uid": 7,
+		"aud": []string{"goim", "other"},
+		"exp": time.Now().Add(time.Minute).Unix(),
+		"ops": "1000,1002",
+	}
+	token := signJWT("RS256", claims, func(signed []byte) []byte {
+		h := crypto.SHA256.New()
+		h.Write(signed)
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h.Sum(nil))
+		assert.Nil(t, err)
+		return sig
+	})
+	id, err := a.Auth(context.Background(), "server", "", []byte(token))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), id.Mid)
+	assert.Equal(t, []int32{1000, 1002}, id.Accepts)
+	// a hmac token signed with the public key must not pass
+	_, err = a.Auth(context.Background(), "server", "", []byte(signJWT("HS256", claims, hs256(string(der)))))
+	assert.Equal(t, ErrTokenInvalid, err)
+}
+
+func TestHTTP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req httpAuthReq
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Token != "good" || req.Server != "server" || req.Cookie != "sid=1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(&Identity{Mid: 9, RoomID: "live://1", Platform: "ios", Accepts: []int32{1000}})
+	}))
+	defer ts.Close()
+	a, err := New(&conf.Auth{Mode: ModeHTTP, HTTP: &conf.AuthHTTP{URL: ts.URL, Timeout: xtime.Duration(time.Second)}})
+	assert.Nil(t, err)
+	id, err := a.Auth(context.Background(), "server", "sid=1", []byte("good"))
+	assert.Nil(t, err)
+	assert.Equal(t, &Identity{Mid: 9, RoomID: "live://1", Platform: "ios", Accepts: []int32{1000}}, id)
+	_, err = a.Auth(context.Background(), "server", "sid=1", []byte("bad"))
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestDev(t *testing.T) {
+	a, err := New(&conf.Auth{Mode: ModeDev})
+	assert.Nil(t, err)
+	id, err := a.Auth(context.Background(), "server", "", []byte("1|key|live://1000|web|1000,1001"))
+	assert.Nil(t, err)
+	assert.Equal(t, &Identity{Mid: 1, Key: "key", RoomID: "live://1000", Platform: "web", Accepts: []int32{1000, 1001}}, id)
+	id, err = a.Auth(context.Background(), "server", "", []byte("1||1000|web|1000|shop"))
+	assert.Nil(t, err)
+	assert.Equal(t, &Identity{Mid: 1, RoomID: "1000", Platform: "web", Accepts: []int32{1000}, App: "shop"}, id)
+	_, err = a.Auth(context.Background(), "server", "", []byte("1|key"))
+	assert.Equal(t, ErrTokenInvalid, err)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil)
+	assert.NotNil(t, err)
+	_, err = New(&conf.Auth{Mode: "none"})
+	assert.NotNil(t, err)
+	_, err = New(jwtConf(&conf.JWT{Algorithm: "ES256"}))
+	assert.NotNil(t, err)
+}
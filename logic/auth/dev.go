@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	xstr "github.com/swanky2009/goim/pkg/strings"
+)
+
+// dev trusts a mid|key|roomid|platform|accepts token, with an optional app.
+type dev struct{}
+
+func (dev) Auth(c context.Context, server, cookie string, token []byte) (id *Identity, err error) {
+	params := strings.Split(string(token), "|")
+	if len(params) != 5 && len(params) != 6 {
+		return nil, ErrTokenInvalid
+	}
+	id = &Identity{
+		Key:      params[1],
+		RoomID:   params[2],
+		Platform: params[3],
+	}
+	if id.Mid, err = strconv.ParseInt(params[0], 10, 64); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if id.Accepts, err = xstr.SplitInt32s(params[4], ","); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if len(params) == 6 {
+		id.App = params[5]
+	}
+	return
+}
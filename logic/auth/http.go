@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/swanky2009/goim/logic/g/conf"
+	xtime "github.com/swanky2009/goim/pkg/time"
+)
+
+// httpAuth asks the account service to authenticate the token.
+//
+// It posts {"server":"","cookie":"","token":""} to the url, a 200 response
+// carries the Identity as json, 401 and 403 reject the token.
+type httpAuth struct {
+	url    string
+	client *http.Client
+}
+
+type httpAuthReq struct {
+	Server string `json:"server"`
+	Cookie string `json:"cookie"`
+	Token  string `json:"token"`
+}
+
+func newHTTP(c *conf.AuthHTTP) *httpAuth {
+	if c.Timeout <= 0 {
+		c.Timeout = xtime.Duration(time.Second)
+	}
+	return &httpAuth{
+		url:    c.URL,
+		client: &http.Client{Timeout: time.Duration(c.Timeout)},
+	}
+}
+
+func (h *httpAuth) Auth(c context.Context, server, cookie string, token []byte) (id *Identity, err error) {
+	var (
+		body []byte
+		req  *http.Request
+		resp *http.Response
+	)
+	if body, err = json.Marshal(&httpAuthReq{Server: server, Cookie: cookie, Token: string(token)}); err != nil {
+		return
+	}
+	if req, err = http.NewRequest("POST", h.url, bytes.NewReader(body)); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if resp, err = h.client.Do(req.WithContext(c)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, ErrUnauthorized
+	default:
+		return nil, fmt.Errorf("auth: %s status %d", h.url, resp.StatusCode)
+	}
+	id = new(Identity)
+	if err = json.NewDecoder(resp.Body).Decode(id); err != nil {
+		return nil, err
+	}
+	if id.Mid == 0 {
+		return nil, ErrUnauthorized
+	}
+	return
+}
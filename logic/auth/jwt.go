@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	// hashes of the supported algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/swanky2009/goim/logic/g/conf"
+	xstr "github.com/swanky2009/goim/pkg/strings"
+)
+
+var jwtHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+}
+
+// jwtAuth verifies a HMAC or RSA signed jwt, the exp claim is required.
+type jwtAuth struct {
+	c      *conf.JWT
+	hash   crypto.Hash
+	secret []byte
+	pub    *rsa.PublicKey
+}
+
+func newJWT(c *conf.JWT) (j *jwtAuth, err error) {
+	if c.Algorithm == "" {
+		c.Algorithm = "HS256"
+	}
+	if c.Claims.Mid == "" {
+		c.Claims.Mid = "sub"
+	}
+	if c.Claims.Room == "" {
+		c.Claims.Room = "room"
+	}
+	if c.Claims.Platform == "" {
+		c.Claims.Platform = "platform"
+	}
+	if c.Claims.Accepts == "" {
+		c.Claims.Accepts = "accepts"
+	}
+	if c.Claims.App == "" {
+		c.Claims.App = "app"
+	}
+	hash, ok := jwtHashes[c.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("auth: unsupported jwt algorithm %q", c.Algorithm)
+	}
+	j = &jwtAuth{c: c, hash: hash}
+	if strings.HasPrefix(c.Algorithm, "HS") {
+		if c.Secret == "" {
+			return nil, errors.New("auth: no jwt secret")
+		}
+		j.secret = []byte(c.Secret)
+		return
+	}
+	var b []byte
+	if b, err = ioutil.ReadFile(c.PublicKey); err != nil {
+		return
+	}
+	if j.pub, err = parsePublicKey(b); err != nil {
+		return
+	}
+	return
+}
+
+func parsePublicKey(b []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("auth: jwt public key is not pem")
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if pub, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return pub, nil
+		}
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if pub, ok := key.(*rsa.PublicKey); ok {
+			return pub, nil
+		}
+	}
+	return nil, errors.New("auth: jwt public key is not rsa")
+}
+
+func (j *jwtAuth) Auth(c context.Context, server, cookie string, token []byte) (id *Identity, err error) {
+	var (
+		header struct {
+			Alg string `json:"alg"`
+		}
+		claims map[string]interface{}
+		sig    []byte
+		parts  = strings.Split(string(token), ".")
+	)
+	if len(parts) != 3 {
+		return nil, ErrTokenInvalid
+	}
+	// the algorithm is pinned by config, a token can not downgrade it
+	if err = decodeSegment(parts[0], &header); err != nil || header.Alg != j.c.Algorithm {
+		return nil, ErrTokenInvalid
+	}
+	if sig, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if err = j.verify(parts[0]+"."+parts[1], sig); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrTokenInvalid
+	}
+	if err = j.validate(claims); err != nil {
+		return
+	}
+	return j.identity(claims)
+}
+
+func (j *jwtAuth) verify(signed string, sig []byte) error {
+	h := j.hash.New()
+	if j.pub != nil {
+		h.Write([]byte(signed))
+		return rsa.VerifyPKCS1v15(j.pub, j.hash, h.Sum(nil), sig)
+	}
+	mac := hmac.New(j.hash.New, j.secret)
+	mac.Write([]byte(signed))
+	if !hmac.Equal(mac.Sum(nil), sig) {
+		return ErrTokenInvalid
+	}
+	return nil
+}
+
+// validate checks the registered claims exp, nbf, iss and aud.
+func (j *jwtAuth) validate(claims map[string]interface{}) error {
+	var (
+		now    = time.Now()
+		leeway = time.Duration(j.c.Leeway)
+	)
+	exp, ok := numberClaim(claims["exp"])
+	if !ok {
+		return ErrTokenInvalid
+	}
+	if now.After(time.Unix(exp, 0).Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok := numberClaim(claims["nbf"]); ok && now.Add(leeway).Before(time.Unix(nbf, 0)) {
+		return ErrTokenExpired
+	}
+	if j.c.Issuer != "" && claims["iss"] != j.c.Issuer {
+		return ErrUnauthorized
+	}
+	if j.c.Audience != "" {
+		switch aud := claims["aud"].(type) {
+		case string:
+			if aud != j.c.Audience {
+				return ErrUnauthorized
+			}
+		case []interface{}:
+			for _, a := range aud {
+				if a == j.c.Audience {
+					return nil
+				}
+			}
+			return ErrUnauthorized
+		default:
+			return ErrUnauthorized
+		}
+	}
+	return nil
+}
+
+// identity maps the configured claims to the connection.
+func (j *jwtAuth) identity(claims map[string]interface{}) (id *Identity, err error) {
+	var ok bool
+	id = new(Identity)
+	if id.Mid, ok = numberClaim(claims[j.c.Claims.Mid]); !ok || id.Mid == 0 {
+		return nil, ErrTokenInvalid
+	}
+	id.RoomID, _ = claims[j.c.Claims.Room].(string)
+	id.Platform, _ = claims[j.c.Claims.Platform].(string)
+	id.App, _ = claims[j.c.Claims.App].(string)
+	switch accepts := claims[j.c.Claims.Accepts].(type) {
+	case nil:
+	case string:
+		if id.Accepts, err = xstr.SplitInt32s(accepts, ","); err != nil {
+			return nil, ErrTokenInvalid
+		}
+	case []interface{}:
+		for _, a := range accepts {
+			op, ok := numberClaim(a)
+			if !ok {
+				return nil, ErrTokenInvalid
+			}
+			id.Accepts = append(id.Accepts, int32(op))
+		}
+	default:
+		return nil, ErrTokenInvalid
+	}
+	return
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// numberClaim reads an integer claim, numeric strings are accepted for mid.
+func numberClaim(v interface{}) (n int64, ok bool) {
+	var err error
+	switch v := v.(type) {
+	case json.Number:
+		if n, err = v.Int64(); err != nil {
+			var f float64
+			if f, err = v.Float64(); err != nil {
+				return
+			}
+			n = int64(f)
+		}
+	case string:
+		if n, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return
+		}
+	default:
+		return
+	}
+	return n, true
+}
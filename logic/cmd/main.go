@@ -25,20 +25,27 @@ func main() {
 		rpcSrv  *grpc.Server
 		httpSrv *http.Server
 	)
+	// load config, logger, discovery and zipkin
+	g.Init()
+
 	// Mechanical domain.
 	errc := make(chan error)
 
 	// new logic server
 	srv = logic.NewServer(g.Conf)
 
-	// new grpc server
-	rpcSrv = logicgrpc.New(g.Conf.RPCServer, srv)
+	// the middlewares of the grpc and http endpoints, reloaded on SIGHUP
+	chain := handlers.NewChain(g.Conf.Endpoints)
+	go handlers.ReloadHandler(chain)
+
+	// new grpc server, its pushes authenticated by the keys of the http api
+	rpcSrv = logicgrpc.New(g.Conf.RPCServer, g.Conf.HTTPServer.APIAuth, srv, chain)
 	wg.Wrap(func() {
 		logicgrpc.Start(g.Conf.RPCServer, rpcSrv, errc)
 	})
 
 	// new http server
-	httpSrv = logichttp.New(g.Conf.HTTPServer, srv)
+	httpSrv = logichttp.New(g.Conf.HTTPServer, srv, chain)
 	wg.Wrap(func() {
 		logichttp.Start(httpSrv, errc)
 	})
@@ -0,0 +1,204 @@
+package dao
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	pb "github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+	"github.com/swanky2009/goim/pkg/bus"
+	"github.com/swanky2009/goim/pkg/hash"
+)
+
+// NewPushMsg returns a message to the keys of the server.
+func NewPushMsg(op int32, server string, keys []string, msg []byte, opt *model.PushOptions) *pb.PushMsg {
+	pushMsg := &pb.PushMsg{
+		Type:      pb.PushMsg_PUSH,
+		Operation: op,
+		Server:    server,
+		Keys:      keys,
+		Msg:       msg,
+	}
+	setPushOptions(pushMsg, opt)
+	return pushMsg
+}
+
+// NewRoomMsg returns a message to the room with the room seq.
+func NewRoomMsg(op int32, room string, seq int64, msg []byte, opt *model.PushOptions) *pb.PushMsg {
+	pushMsg := &pb.PushMsg{
+		Type:      pb.PushMsg_ROOM,
+		Operation: op,
+		Room:      room,
+		Msg:       msg,
+		Seq:       seq,
+	}
+	setPushOptions(pushMsg, opt)
+	return pushMsg
+}
+
+// NewBroadcastMsg returns a message to all.
+func NewBroadcastMsg(op, speed int32, platform string, msg []byte, opt *model.PushOptions) *pb.PushMsg {
+	pushMsg := &pb.PushMsg{
+		Type:      pb.PushMsg_BROADCAST,
+		Operation: op,
+		Speed:     speed,
+		Msg:       msg,
+		Platform:  platform,
+		App:       opt.AppOf(),
+	}
+	setPushOptions(pushMsg, opt)
+	return pushMsg
+}
+
+func setPushOptions(m *pb.PushMsg, opt *model.PushOptions) {
+	if opt != nil {
+		m.Expire = opt.Expire()
+		if opt.Priority == model.PriorityHigh {
+			m.Priority = pb.PushMsg_HIGH
+		}
+		m.MsgID = opt.MsgID
+	}
+}
+
+// busHooks feeds the metrics with the results of the publishes, the
+// failures of an async publisher are only known here.
+func busHooks() *bus.Hooks {
+	return &bus.Hooks{
+		Published: func(m *bus.Message, took time.Duration) {
+			g.StatMetrics.BusPublished.With("topic", m.Topic).Add(1)
+			g.StatMetrics.BusLatency.With("topic", m.Topic).Observe(took.Seconds())
+		},
+		Failed: func(m *bus.Message, err error, spooled bool) {
+			g.StatMetrics.BusFailed.With("topic", m.Topic).Add(1)
+			if spooled {
+				g.StatMetrics.BusSpooled.With("topic", m.Topic).Add(1)
+			}
+			g.Logger.Errorf("bus.Publish(%s key:%s) spooled(%t) error(%v)", m.Topic, m.Key, spooled, err)
+		},
+	}
+}
+
+// ShardKeys splits the keys of a comet by their shard, the keys of a
+// message to a comet must be of one shard.
+func (d *Dao) ShardKeys(keys []string) [][]string {
+	n := d.c.PushShards
+	if n <= 1 {
+		return [][]string{keys}
+	}
+	shards := make([][]string, n)
+	for _, key := range keys {
+		i := hash.Index(key, n)
+		shards[i] = append(shards[i], key)
+	}
+	res := shards[:0]
+	for _, keys := range shards {
+		if len(keys) > 0 {
+			res = append(res, keys)
+		}
+	}
+	return res
+}
+
+// PushMsg push a message to databus, a message per shard of the keys.
+func (d *Dao) PushMsg(c context.Context, op int32, server string, keys []string, msg []byte, opt *model.PushOptions) (err error) {
+	shards := d.ShardKeys(keys)
+	if len(shards) == 1 {
+		return d.sendPushMsg(c, NewPushMsg(op, server, keys, msg, opt))
+	}
+	pushMsgs := make([]*pb.PushMsg, 0, len(shards))
+	for _, keys := range shards {
+		pushMsgs = append(pushMsgs, NewPushMsg(op, server, keys, msg, opt))
+	}
+	for _, err = range d.PushMsgs(c, pushMsgs) {
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// BroadcastRoomMsg push a message with the room seq to databus.
+func (d *Dao) BroadcastRoomMsg(c context.Context, op int32, room string, seq int64, msg []byte, opt *model.PushOptions) (err error) {
+	return d.sendPushMsg(c, NewRoomMsg(op, room, seq, msg, opt))
+}
+
+// BroadcastMsg push a message to databus.
+func (d *Dao) BroadcastMsg(c context.Context, op, speed int32, platform string, msg []byte, opt *model.PushOptions) (err error) {
+	return d.sendPushMsg(c, NewBroadcastMsg(op, speed, platform, msg, opt))
+}
+
+// PushMsgs push the messages to databus in one publish request, the error of
+// a message is at its index and nil if published.
+func (d *Dao) PushMsgs(c context.Context, pushMsgs []*pb.PushMsg) (errs []error) {
+	var (
+		err  error
+		msgs = make([]*bus.Message, 0, len(pushMsgs))
+		idx  = make([]int, 0, len(pushMsgs))
+	)
+	errs = make([]error, len(pushMsgs))
+	for i, pushMsg := range pushMsgs {
+		var m *bus.Message
+		if m, err = d.busMsg(pushMsg); err != nil {
+			errs[i] = err
+			continue
+		}
+		msgs = append(msgs, m)
+		idx = append(idx, i)
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	perrs := d.pub.Publish(c, msgs)
+	if perrs == nil {
+		return
+	}
+	g.Logger.Errorf("PushMsgs.send(%d msgs) error(%v)", len(msgs), perrs)
+	for i, perr := range perrs {
+		errs[idx[i]] = perr
+	}
+	return
+}
+
+// busMsg returns the bus message of the push message, keyed by the comet and
+// the shard of the keys, the room or the platform, so the messages of a key,
+// a room or a platform stay in order.
+func (d *Dao) busMsg(pushMsg *pb.PushMsg) (m *bus.Message, err error) {
+	var (
+		b   []byte
+		key string
+	)
+	if b, err = proto.Marshal(pushMsg); err != nil {
+		return
+	}
+	switch pushMsg.Type {
+	case pb.PushMsg_PUSH:
+		key = pushMsg.Server
+		if len(pushMsg.Keys) > 0 {
+			key += "/" + strconv.Itoa(hash.Index(pushMsg.Keys[0], d.c.PushShards))
+		}
+	case pb.PushMsg_ROOM:
+		key = pushMsg.Room
+	case pb.PushMsg_BROADCAST:
+		key = pushMsg.Platform
+	}
+	m = &bus.Message{
+		Topic: d.c.Bus.Topic,
+		Key:   key,
+		Value: b,
+	}
+	return
+}
+
+func (d *Dao) sendPushMsg(c context.Context, pushMsg *pb.PushMsg) (err error) {
+	m, err := d.busMsg(pushMsg)
+	if err != nil {
+		return
+	}
+	if err = bus.PublishOne(c, d.pub, m); err != nil {
+		g.Logger.Errorf("PushMsg.send(%s pushMsg:%v) error(%v)", pushMsg.Type, pushMsg, err)
+	}
+	return
+}
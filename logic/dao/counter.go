@@ -0,0 +1,99 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/swanky2009/goim/logic/g"
+)
+
+const (
+	_prefixCounter = "counter_%s" // key -> count in the window   string
+)
+
+// _incrWindow increases a counter, and expires it with the window started by
+// the first count.
+// KEYS: counter ARGV: window(ms)
+var _incrWindow = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// _incrLimit increases a counter like _incrWindow, and returns the count and
+// the milliseconds left of its window.
+// KEYS: counter ARGV: window(ms)
+var _incrLimit = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {n, ttl}
+`)
+
+func keyCounter(key string) string {
+	return fmt.Sprintf(_prefixCounter, key)
+}
+
+// IncrWindow increases the count of the key in the current fixed window of
+// the duration, and returns the count, shared by all logics.
+func (d *Dao) IncrWindow(c context.Context, key string, window time.Duration) (n int64, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var res interface{}
+	if res, err = _incrWindow.Run(d.redis, []string{keyCounter(key)}, int64(window/time.Millisecond)).Result(); err != nil {
+		g.Logger.Errorf("redis.Eval(incrWindow %s) error(%v)", key, err)
+		return
+	}
+	n, _ = res.(int64)
+	return
+}
+
+// IncrLimits increases the counts of the keys like IncrWindow in one
+// pipeline, and returns the time left of their windows too. A key counted
+// twice has both counts.
+func (d *Dao) IncrLimits(c context.Context, keys []string, windows []time.Duration) (ns []int64, lefts []time.Duration, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	cmds, err := d.incrLimits(keys, windows)
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		// a script flushed is loaded again
+		if err = _incrLimit.Load(d.redis).Err(); err == nil {
+			cmds, err = d.incrLimits(keys, windows)
+		}
+	}
+	if err != nil {
+		g.Logger.Errorf("redis.Pipelined(incrLimit %d keys) error(%v)", len(keys), err)
+		return
+	}
+	ns = make([]int64, len(keys))
+	lefts = make([]time.Duration, len(keys))
+	for i, cmd := range cmds {
+		if vals, ok := cmd.Val().([]interface{}); ok && len(vals) == 2 {
+			ns[i], _ = vals[0].(int64)
+			ms, _ := vals[1].(int64)
+			lefts[i] = time.Duration(ms) * time.Millisecond
+		}
+	}
+	return
+}
+
+func (d *Dao) incrLimits(keys []string, windows []time.Duration) (cmds []*redis.Cmd, err error) {
+	cmds = make([]*redis.Cmd, len(keys))
+	_, err = d.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = _incrLimit.EvalSha(pipe, []string{keyCounter(key)}, int64(windows[i]/time.Millisecond))
+		}
+		return nil
+	})
+	return
+}
@@ -2,52 +2,63 @@ package dao
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/go-redis/redis"
+	"github.com/swanky2009/goim/logic/dao/store"
 	"github.com/swanky2009/goim/logic/g/conf"
-	kafka "gopkg.in/Shopify/sarama.v1"
+	"github.com/swanky2009/goim/pkg/bus"
 )
 
-// Dao dao.
+// ErrNoRedis the data needs redis, which is not configured.
+var ErrNoRedis = errors.New("dao: redis is not configured")
+
+// Dao dao, the sessions and online counts are in the store, the other data in redis.
 type Dao struct {
-	c        *conf.Config
-	kafkaPub kafka.SyncProducer
-	redis    *redis.ClusterClient
-	// redis       *redis.Client
-	redisExpire time.Duration
+	store.Store
+	c     *conf.Config
+	pub   bus.Publisher
+	redis redis.UniversalClient
+	// idem the idempotency keys without redis
+	idem *idempotencyMemory
 }
 
-// New new a dao and return.
+// New new a dao and return. Redis is optional with a memory store.
 func New(c *conf.Config) *Dao {
+	if c.Bus == nil {
+		panic("bus is not configured")
+	}
+	pub, err := bus.NewPublisher(c.Bus, busHooks())
+	if err != nil {
+		panic(err)
+	}
 	d := &Dao{
-		c:           c,
-		kafkaPub:    newKafkaPub(c.Kafka),
-		redis:       newRedis(c.Redis),
-		redisExpire: time.Duration(c.Redis.Expire),
+		c:   c,
+		pub: pub,
 	}
-	return d
-}
-
-func newKafkaPub(c *conf.Kafka) kafka.SyncProducer {
-	var err error
-	kc := kafka.NewConfig()
-	kc.Producer.RequiredAcks = kafka.WaitForAll // Wait for all in-sync replicas to ack the message
-	kc.Producer.Retry.Max = 10                  // Retry up to 10 times to produce the message
-	kc.Producer.Return.Successes = true
-	pub, err := kafka.NewSyncProducer(c.Brokers, kc)
+	if c.Redis != nil {
+		d.redis = newRedis(c.Redis)
+	} else if c.Offline != nil {
+		panic("offline inbox needs redis")
+	} else {
+		d.idem = newIdempotencyMemory()
+	}
+	s, err := store.New(c.Store, d.redis)
 	if err != nil {
 		panic(err)
 	}
-	return pub
+	d.Store = s
+	return d
 }
 
-func newRedis(c *conf.Redis) *redis.ClusterClient {
-	redisdb := redis.NewClusterClient(
-		// redisdb := redis.NewClient(
-		&redis.ClusterOptions{
-			// &redis.Options{
+func newRedis(c *conf.Redis) (redisdb redis.UniversalClient) {
+	switch c.Mode {
+	case "cluster":
+		redisdb = redis.NewClusterClient(&redis.ClusterOptions{
 			Addrs:        c.Addrs,
+			Password:     c.Password,
 			DialTimeout:  time.Duration(c.DialTimeout),
 			ReadTimeout:  time.Duration(c.ReadTimeout),
 			WriteTimeout: time.Duration(c.WriteTimeout),
@@ -55,6 +66,37 @@ func newRedis(c *conf.Redis) *redis.ClusterClient {
 			PoolTimeout:  time.Duration(c.PoolTimeout),
 			IdleTimeout:  time.Duration(c.IdleTimeout),
 		})
+	case "single":
+		if len(c.Addrs) != 1 {
+			panic("redis single mode needs one addr")
+		}
+		redisdb = redis.NewClient(&redis.Options{
+			Addr:         c.Addrs[0],
+			Password:     c.Password,
+			DB:           c.DB,
+			DialTimeout:  time.Duration(c.DialTimeout),
+			ReadTimeout:  time.Duration(c.ReadTimeout),
+			WriteTimeout: time.Duration(c.WriteTimeout),
+			PoolSize:     c.PoolSize,
+			PoolTimeout:  time.Duration(c.PoolTimeout),
+			IdleTimeout:  time.Duration(c.IdleTimeout),
+		})
+	case "sentinel":
+		redisdb = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    c.MasterName,
+			SentinelAddrs: c.Addrs,
+			Password:      c.Password,
+			DB:            c.DB,
+			DialTimeout:   time.Duration(c.DialTimeout),
+			ReadTimeout:   time.Duration(c.ReadTimeout),
+			WriteTimeout:  time.Duration(c.WriteTimeout),
+			PoolSize:      c.PoolSize,
+			PoolTimeout:   time.Duration(c.PoolTimeout),
+			IdleTimeout:   time.Duration(c.IdleTimeout),
+		})
+	default:
+		panic(fmt.Sprintf("unknown redis mode %q", c.Mode))
+	}
 	_, err := redisdb.Ping().Result()
 	if err != nil {
 		panic(err)
@@ -62,12 +104,36 @@ func newRedis(c *conf.Redis) *redis.ClusterClient {
 	return redisdb
 }
 
+// needRedis returns ErrNoRedis if redis is not configured.
+func (d *Dao) needRedis() error {
+	if d.redis == nil {
+		return ErrNoRedis
+	}
+	return nil
+}
+
+// pingRedis check redis connection.
+func (d *Dao) pingRedis(c context.Context) (err error) {
+	if d.redis == nil {
+		return
+	}
+	_, err = d.redis.Ping().Result()
+	return
+}
+
 // Close close the resource.
 func (d *Dao) Close() {
-	d.redis.Close()
+	d.Store.Close()
+	d.pub.Close()
+	if d.redis != nil {
+		d.redis.Close()
+	}
 }
 
 // Ping dao ping.
-func (d *Dao) Ping(c context.Context) error {
+func (d *Dao) Ping(c context.Context) (err error) {
+	if err = d.Store.Ping(c); err != nil {
+		return
+	}
 	return d.pingRedis(c)
 }
@@ -1,22 +1,34 @@
 package dao
 
 import (
-	"flag"
+	"os"
 	"testing"
 
-	"github.com/swanky2009/goim/logic/g"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/pkg/bus"
 )
 
 var (
-	d *Dao
+	d  *Dao
+	mr *miniredis.Miniredis
 )
 
+// TestMain runs the tests against an in-process redis, and a channel bus.
 func TestMain(m *testing.M) {
-	flag.Set("conf", "../../../cmd/logic/logic-example.toml")
-	flag.Parse()
-	if err := conf.Init(); err != nil {
+	var err error
+	if mr, err = miniredis.Run(); err != nil {
 		panic(err)
 	}
-	d = New(conf.Conf)
-	m.Run()
+	c := &conf.Config{
+		Bus:     &bus.Config{Kind: bus.KindChannel, Topic: "goim-push-topic"},
+		Redis:   &conf.Redis{Mode: "single", Addrs: []string{mr.Addr()}},
+		Offline: &conf.Offline{},
+	}
+	c.Fix()
+	d = New(c)
+	code := m.Run()
+	d.Close()
+	mr.Close()
+	os.Exit(code)
 }
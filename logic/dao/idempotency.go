@@ -0,0 +1,148 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/pkg/hash"
+)
+
+const (
+	_prefixIdempotency = "idem_%s" // idempotency key -> result of the first push   string
+)
+
+// _idempotencySweepInterval the expired keys of the memory are removed at most
+// every interval, by a reservation.
+const _idempotencySweepInterval = time.Minute
+
+func keyIdempotency(key string) string {
+	return fmt.Sprintf(_prefixIdempotency, hash.Sha1s(key))
+}
+
+type idempotencyEntry struct {
+	res      []byte
+	deadline time.Time
+}
+
+// idempotencyMemory the idempotency keys without redis, kept in the process
+// so a push retried to another logic is pushed again.
+type idempotencyMemory struct {
+	mu    sync.Mutex
+	keys  map[string]*idempotencyEntry
+	swept time.Time
+}
+
+func newIdempotencyMemory() *idempotencyMemory {
+	return &idempotencyMemory{keys: make(map[string]*idempotencyEntry), swept: time.Now()}
+}
+
+// entry returns the entry of the key not expired, the lock is held.
+func (m *idempotencyMemory) entry(key string, now time.Time) *idempotencyEntry {
+	e, ok := m.keys[key]
+	if !ok {
+		return nil
+	}
+	if now.After(e.deadline) {
+		delete(m.keys, key)
+		return nil
+	}
+	return e
+}
+
+func (m *idempotencyMemory) add(key string, expire time.Duration) bool {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.Sub(m.swept) > _idempotencySweepInterval {
+		for k, e := range m.keys {
+			if now.After(e.deadline) {
+				delete(m.keys, k)
+			}
+		}
+		m.swept = now
+	}
+	if m.entry(key, now) != nil {
+		return false
+	}
+	m.keys[key] = &idempotencyEntry{deadline: now.Add(expire)}
+	return true
+}
+
+func (m *idempotencyMemory) set(key string, res []byte, expire time.Duration) {
+	now := time.Now()
+	m.mu.Lock()
+	if e := m.entry(key, now); e != nil {
+		e.res, e.deadline = res, now.Add(expire)
+	}
+	m.mu.Unlock()
+}
+
+func (m *idempotencyMemory) get(key string) (res []byte) {
+	m.mu.Lock()
+	if e := m.entry(key, time.Now()); e != nil {
+		res = e.res
+	}
+	m.mu.Unlock()
+	return
+}
+
+func (m *idempotencyMemory) del(key string) {
+	m.mu.Lock()
+	delete(m.keys, key)
+	m.mu.Unlock()
+}
+
+// AddIdempotencyKey reserves the idempotency key, it returns false if reserved
+// before. Without redis the keys are kept in the process.
+func (d *Dao) AddIdempotencyKey(c context.Context, key string, expire time.Duration) (ok bool, err error) {
+	if d.redis == nil {
+		return d.idem.add(key, expire), nil
+	}
+	if ok, err = d.redis.SetNX(keyIdempotency(key), "", expire).Result(); err != nil {
+		g.Logger.Errorf("redis.SetNX(idempotency %s) error(%v)", key, err)
+	}
+	return
+}
+
+// SetIdempotencyResult keeps the result of the push under the reserved key.
+func (d *Dao) SetIdempotencyResult(c context.Context, key string, res []byte, expire time.Duration) (err error) {
+	if d.redis == nil {
+		d.idem.set(key, res, expire)
+		return
+	}
+	if err = d.redis.SetXX(keyIdempotency(key), res, expire).Err(); err != nil {
+		g.Logger.Errorf("redis.SetXX(idempotency %s) error(%v)", key, err)
+	}
+	return
+}
+
+// IdempotencyResult gets the result of the first push, empty if in flight.
+func (d *Dao) IdempotencyResult(c context.Context, key string) (res []byte, err error) {
+	if d.redis == nil {
+		return d.idem.get(key), nil
+	}
+	if res, err = d.redis.Get(keyIdempotency(key)).Bytes(); err != nil {
+		if err == redis.Nil {
+			err = nil
+		} else {
+			g.Logger.Errorf("redis.Get(idempotency %s) error(%v)", key, err)
+		}
+	}
+	return
+}
+
+// DelIdempotencyKey frees the key of a failed push.
+func (d *Dao) DelIdempotencyKey(c context.Context, key string) (err error) {
+	if d.redis == nil {
+		d.idem.del(key)
+		return
+	}
+	if err = d.redis.Del(keyIdempotency(key)).Err(); err != nil {
+		g.Logger.Errorf("redis.Del(idempotency %s) error(%v)", key, err)
+	}
+	return
+}
@@ -1,81 +0,0 @@
-package dao
-
-import (
-	"context"
-
-	"github.com/gogo/protobuf/proto"
-	pb "github.com/swanky2009/goim/grpc/logic"
-	"github.com/swanky2009/goim/logic/g"
-	sarama "gopkg.in/Shopify/sarama.v1"
-)
-
-// PushMsg push a message to databus.
-func (d *Dao) PushMsg(c context.Context, op int32, server string, keys []string, msg []byte) (err error) {
-	pushMsg := &pb.PushMsg{
-		Type:      pb.PushMsg_PUSH,
-		Operation: op,
-		Server:    server,
-		Keys:      keys,
-		Msg:       msg,
-	}
-	b, err := proto.Marshal(pushMsg)
-	if err != nil {
-		return
-	}
-	m := &sarama.ProducerMessage{
-		Key:   sarama.StringEncoder(keys[0]),
-		Topic: d.c.Kafka.Topic,
-		Value: sarama.ByteEncoder(b),
-	}
-	if _, _, err = d.kafkaPub.SendMessage(m); err != nil {
-		g.Logger.Errorf("PushMsg.send(push pushMsg:%v) error(%v)", pushMsg, err)
-	}
-	return
-}
-
-// BroadcastRoomMsg push a message to databus.
-func (d *Dao) BroadcastRoomMsg(c context.Context, op int32, room string, msg []byte) (err error) {
-	pushMsg := &pb.PushMsg{
-		Type:      pb.PushMsg_ROOM,
-		Operation: op,
-		Room:      room,
-		Msg:       msg,
-	}
-	b, err := proto.Marshal(pushMsg)
-	if err != nil {
-		return
-	}
-	m := &sarama.ProducerMessage{
-		Key:   sarama.StringEncoder(room),
-		Topic: d.c.Kafka.Topic,
-		Value: sarama.ByteEncoder(b),
-	}
-	if _, _, err = d.kafkaPub.SendMessage(m); err != nil {
-		g.Logger.Errorf("PushMsg.send(broadcast_room pushMsg:%v) error(%v)", pushMsg, err)
-	}
-	return
-}
-
-// BroadcastMsg push a message to databus.
-func (d *Dao) BroadcastMsg(c context.Context, op, speed int32, platform string, msg []byte) (err error) {
-	pushMsg := &pb.PushMsg{
-		Type:      pb.PushMsg_BROADCAST,
-		Operation: op,
-		Speed:     speed,
-		Msg:       msg,
-		Platform:  platform,
-	}
-	b, err := proto.Marshal(pushMsg)
-	if err != nil {
-		return
-	}
-	m := &sarama.ProducerMessage{
-		Key:   sarama.StringEncoder(platform),
-		Topic: d.c.Kafka.Topic,
-		Value: sarama.ByteEncoder(b),
-	}
-	if _, _, err = d.kafkaPub.SendMessage(m); err != nil {
-		g.Logger.Errorf("PushMsg.send(broadcast pushMsg:%v) error(%v)", pushMsg, err)
-	}
-	return
-}
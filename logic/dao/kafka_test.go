@@ -12,10 +12,10 @@ func TestDaoPushMsg(t *testing.T) {
 		c      = context.Background()
 		op     = int32(0)
 		server = ""
-		msg    = ""
+		msg    = []byte("")
 		keys   = []string{"key"}
 	)
-	err := d.PushMsg(c, op, server, msg, keys)
+	err := d.PushMsg(c, op, server, keys, msg, nil)
 	assert.Nil(t, err)
 }
 
@@ -24,9 +24,10 @@ func TestDaoBroadcastRoomMsg(t *testing.T) {
 		c    = context.Background()
 		op   = int32(0)
 		room = ""
-		msg  = ""
+		seq  = int64(1)
+		msg  = []byte("")
 	)
-	err := d.BroadcastRoomMsg(c, op, room, msg)
+	err := d.BroadcastRoomMsg(c, op, room, seq, msg, nil)
 	assert.Nil(t, err)
 }
 
@@ -35,9 +36,9 @@ func TestDaoBroadcastMsg(t *testing.T) {
 		c        = context.Background()
 		op       = int32(0)
 		speed    = int32(0)
-		msg      = ""
+		msg      = []byte("")
 		platform = ""
 	)
-	err := d.BroadcastMsg(c, op, speed, msg, platform)
+	err := d.BroadcastMsg(c, op, speed, platform, msg, nil)
 	assert.Nil(t, err)
 }
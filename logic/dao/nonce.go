@@ -0,0 +1,28 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/swanky2009/goim/logic/g"
+)
+
+const (
+	_prefixNonce = "nonce_%s_%s" // key id, nonce -> 1   string
+)
+
+func keyNonce(id, nonce string) string {
+	return fmt.Sprintf(_prefixNonce, id, nonce)
+}
+
+// AddNonce records a nonce of the api key, it returns false if the nonce is used.
+func (d *Dao) AddNonce(c context.Context, id, nonce string, expire time.Duration) (ok bool, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	if ok, err = d.redis.SetNX(keyNonce(id, nonce), 1, expire).Result(); err != nil {
+		g.Logger.Errorf("redis.SetNX(%s,%s) error(%v)", id, nonce, err)
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+const (
+	_prefixOffline    = "offline_%d"    // mid -> offline msgs        list
+	_prefixAppOffline = "offline_%s_%d" // app, mid -> offline msgs   list
+)
+
+func keyOffline(app string, mid int64) string {
+	if app == "" {
+		return fmt.Sprintf(_prefixOffline, mid)
+	}
+	return fmt.Sprintf(_prefixAppOffline, app, mid)
+}
+
+// AddOfflineMsg appends a message to the inbox of the mid of the app, the oldest
+// messages are dropped beyond the inbox size.
+func (d *Dao) AddOfflineMsg(c context.Context, app string, mid int64, m *model.OfflineMsg) (err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var (
+		b   []byte
+		o   = d.c.Offline
+		key = keyOffline(app, mid)
+	)
+	if b, err = json.Marshal(m); err != nil {
+		return
+	}
+	if _, err = d.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.RPush(key, b)
+		pipe.LTrim(key, -o.Size, -1)
+		pipe.Expire(key, time.Duration(o.Expire))
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.TxPipelined(RPUSH %d,%d) error(%v)", mid, m.Op, err)
+	}
+	return
+}
+
+// OfflineMsgs gets the inbox of the mid, oldest first.
+func (d *Dao) OfflineMsgs(c context.Context, app string, mid int64) (msgs []*model.OfflineMsg, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var vals []string
+	if vals, err = d.redis.LRange(keyOffline(app, mid), 0, -1).Result(); err != nil {
+		g.Logger.Errorf("redis.LRange(%d) error(%v)", mid, err)
+		return
+	}
+	return parseOfflineMsgs(mid, vals), nil
+}
+
+// AckOfflineMsgs removes the messages delivered from the inbox of the mid,
+// the messages added meanwhile and the undelivered ones keep their place.
+func (d *Dao) AckOfflineMsgs(c context.Context, app string, mid int64, msgs []*model.OfflineMsg) (err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	key := keyOffline(app, mid)
+	if _, err = d.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		for _, m := range msgs {
+			b, err := json.Marshal(m)
+			if err != nil {
+				return err
+			}
+			pipe.LRem(key, 1, b)
+		}
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.TxPipelined(LREM %d) error(%v)", mid, err)
+	}
+	return
+}
+
+// DelOfflineMsgs clears the inbox of the mid.
+func (d *Dao) DelOfflineMsgs(c context.Context, app string, mid int64) (has bool, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var rows int64
+	if rows, err = d.redis.Del(keyOffline(app, mid)).Result(); err != nil {
+		g.Logger.Errorf("redis.Del(%d) error(%v)", mid, err)
+		return
+	}
+	has = rows > 0
+	return
+}
+
+func parseOfflineMsgs(mid int64, vals []string) (msgs []*model.OfflineMsg) {
+	msgs = make([]*model.OfflineMsg, 0, len(vals))
+	for _, v := range vals {
+		m := new(model.OfflineMsg)
+		if err := json.Unmarshal([]byte(v), m); err != nil {
+			g.Logger.Errorf("json.Unmarshal(offline %d %s) error(%v)", mid, v, err)
+			continue
+		}
+		msgs = append(msgs, m)
+	}
+	return
+}
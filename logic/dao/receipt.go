@@ -0,0 +1,194 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+	"github.com/swanky2009/goim/pkg/bus"
+)
+
+const (
+	_prefixReceipt = "receipt_%s" // app, msg id -> from, app, to:recipient -> 1, type:mid -> ts   hset
+	_fieldFrom     = "from"
+	_fieldApp      = "app"
+	_fieldTo       = "to:"
+)
+
+// ErrNotRecipient the receipt is sent by a mid the message is not pushed to.
+var ErrNotRecipient = errors.New("dao: receipt from a mid not pushed to")
+
+// _addReceipt records the first receipt of the type from the mid, messages not
+// pushed with an id are ignored, and the receipts of the mids they are not
+// pushed to are refused. It returns the sender, 1 if the receipt is new, 0 if
+// not, -1 if refused, and the app of the sender.
+// KEYS: receipt ARGV: type:mid, ts, recipient fields of the mid...
+var _addReceipt = redis.NewScript(`
+local from = redis.call('HGET', KEYS[1], 'from')
+if not from then
+	return {0, 0, ''}
+end
+local app = redis.call('HGET', KEYS[1], 'app') or ''
+for i = 3, #ARGV do
+	if redis.call('HEXISTS', KEYS[1], ARGV[i]) == 1 then
+		return {tonumber(from), redis.call('HSETNX', KEYS[1], ARGV[1], ARGV[2]), app}
+	end
+end
+return {tonumber(from), -1, app}
+`)
+
+// keyReceipt the receipts of a message of the app, the apps may reuse the
+// message ids.
+func keyReceipt(app, msgID string) string {
+	return fmt.Sprintf(_prefixReceipt, model.EncodeKey(app, msgID))
+}
+
+// ReceiptToKey the recipient field of a scoped key.
+func ReceiptToKey(key string) string {
+	return _fieldTo + "key:" + key
+}
+
+// ReceiptToMid the recipient field of a mid of the app.
+func ReceiptToMid(app string, mid int64) string {
+	return _fieldTo + "mid:" + app + ":" + strconv.FormatInt(mid, 10)
+}
+
+// ReceiptToRoom the recipient field of a scoped room.
+func ReceiptToRoom(room string) string {
+	return _fieldTo + "room:" + room
+}
+
+// ReceiptToAll the recipient field of all the mids of the app.
+func ReceiptToAll(app string) string {
+	return _fieldTo + "all:" + app
+}
+
+// AddReceiptMsg records the sender of the app and the recipients of a
+// message pushed with an id.
+func (d *Dao) AddReceiptMsg(c context.Context, app, msgID string, from int64, to []string) (err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var (
+		key    = keyReceipt(app, msgID)
+		fields = make(map[string]interface{}, len(to)+2)
+	)
+	fields[_fieldFrom] = from
+	fields[_fieldApp] = app
+	for _, f := range to {
+		fields[f] = 1
+	}
+	if _, err = d.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HMSet(key, fields)
+		pipe.Expire(key, time.Duration(d.c.Receipt.Expire))
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.TxPipelined(HMSET %s,%d) error(%v)", msgID, from, err)
+	}
+	return
+}
+
+// AddReceipt records a receipt of the mid connected by the scoped key in the
+// scoped room, from is 0 if the message of the app of the key is unknown or
+// without redis, app is the app of the sender. A receipt of a mid the message
+// is not pushed to is refused with ErrNotRecipient.
+func (d *Dao) AddReceipt(c context.Context, r *model.Receipt, key, room string) (from int64, app string, added bool, err error) {
+	if d.redis == nil {
+		return
+	}
+	var (
+		res       interface{}
+		keyApp, _ = model.DecodeKey(key)
+		args      = []interface{}{r.Type + ":" + strconv.FormatInt(r.Mid, 10), r.Ts, ReceiptToMid(keyApp, r.Mid), ReceiptToAll(keyApp)}
+	)
+	if key != "" {
+		args = append(args, ReceiptToKey(key))
+	}
+	if room != "" {
+		args = append(args, ReceiptToRoom(room))
+	}
+	if res, err = _addReceipt.Run(d.redis, []string{keyReceipt(keyApp, r.MsgID)}, args...).Result(); err != nil {
+		g.Logger.Errorf("redis.Eval(addReceipt %s,%d,%s) error(%v)", r.MsgID, r.Mid, r.Type, err)
+		return
+	}
+	if vals, ok := res.([]interface{}); ok && len(vals) == 3 {
+		from, _ = vals[0].(int64)
+		app, _ = vals[2].(string)
+		n, _ := vals[1].(int64)
+		if n < 0 {
+			return from, app, false, ErrNotRecipient
+		}
+		added = n == 1
+	}
+	return
+}
+
+// Receipt gets the receipts of a message of the app, nil if the message is
+// unknown.
+func (d *Dao) Receipt(c context.Context, app, msgID string) (state *model.ReceiptState, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var res map[string]string
+	if res, err = d.redis.HGetAll(keyReceipt(app, msgID)).Result(); err != nil {
+		g.Logger.Errorf("redis.HGetAll(%s) error(%v)", msgID, err)
+		return
+	}
+	if len(res) == 0 {
+		return
+	}
+	state = &model.ReceiptState{
+		MsgID:     msgID,
+		Delivered: make(map[int64]int64),
+		Read:      make(map[int64]int64),
+	}
+	for field, val := range res {
+		if field == _fieldFrom {
+			state.From, _ = strconv.ParseInt(val, 10, 64)
+			continue
+		}
+		if field == _fieldApp || strings.HasPrefix(field, _fieldTo) {
+			continue
+		}
+		typ, midStr := field, ""
+		if i := strings.IndexByte(field, ':'); i > 0 {
+			typ, midStr = field[:i], field[i+1:]
+		}
+		mid, err1 := strconv.ParseInt(midStr, 10, 64)
+		ts, err2 := strconv.ParseInt(val, 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		switch typ {
+		case model.ReceiptDelivered:
+			state.Delivered[mid] = ts
+		case model.ReceiptRead:
+			state.Read[mid] = ts
+		}
+	}
+	return
+}
+
+// PublishReceipt publishes a receipt to the receipt topic keyed by message id.
+func (d *Dao) PublishReceipt(c context.Context, r *model.Receipt) (err error) {
+	var b []byte
+	if b, err = json.Marshal(r); err != nil {
+		return
+	}
+	m := &bus.Message{
+		Topic: d.c.Receipt.Topic,
+		Key:   r.MsgID,
+		Value: b,
+	}
+	if err = bus.PublishOne(c, d.pub, m); err != nil {
+		g.Logger.Errorf("PublishReceipt.send(%+v) error(%v)", r, err)
+	}
+	return
+}
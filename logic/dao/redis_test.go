@@ -2,7 +2,9 @@ package dao
 
 import (
 	"context"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/swanky2009/goim/logic/model"
@@ -20,10 +22,10 @@ func TestDaoAddMapping(t *testing.T) {
 		key    = "test_key"
 		server = "test_server"
 	)
-	err := d.AddMapping(c, mid, key, server)
+	err := d.AddMapping(c, &model.Session{Mid: mid, Key: key, Server: server})
 	assert.Nil(t, err)
 
-	has, err := d.ExpireMapping(c, mid, key)
+	has, err := d.ExpireMapping(c, &model.Session{Mid: mid, Key: key})
 	assert.Nil(t, err)
 	assert.NotEqual(t, false, has)
 
@@ -31,7 +33,7 @@ func TestDaoAddMapping(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, server, res[0])
 
-	ress, mids, err := d.KeysByMids(c, []int64{mid})
+	ress, mids, err := d.KeysByMids(c, "", []int64{mid})
 	assert.Nil(t, err)
 	assert.Equal(t, server, ress[key])
 	assert.Equal(t, mid, mids[0])
@@ -45,16 +47,15 @@ func TestDaoAddServerInfo(t *testing.T) {
 	var (
 		c      = context.Background()
 		server = "test_server"
-		res    = &model.ServerInfo{}
 	)
-	err := d.AddServerInfo(c, server, res)
+	err := d.AddServerScore(c, server)
 	assert.Nil(t, err)
 
-	r, err := d.ServerInfos(c)
+	r, err := d.ServersRank(c, -1)
 	assert.Nil(t, err)
-	assert.Equal(t, r[0], res)
+	assert.Contains(t, r, server)
 
-	err = d.DelServerInfo(c, server)
+	err = d.DelServerScore(c, server)
 	assert.Nil(t, err)
 }
 
@@ -62,17 +63,275 @@ func TestDaoAddServerOnline(t *testing.T) {
 	var (
 		c      = context.Background()
 		server = "test_server"
-		online = &model.Online{
-			RoomCount: map[string]int32{"room": 10},
-		}
+		online = map[string]int32{"room": 10}
 	)
-	err := d.AddServerOnline(c, server, online)
+	err := d.AddServerScore(c, server)
+	assert.Nil(t, err)
+	err = d.UpdateRoomCount(c, server, online)
 	assert.Nil(t, err)
 
-	r, err := d.ServerOnline(c, server)
+	r, err := d.RoomCount(c, []string{"room"})
 	assert.Nil(t, err)
 	assert.Equal(t, online, r)
 
-	err = d.DelServerOnline(c, server)
+	err = d.DelServerScore(c, server)
+	assert.Nil(t, err)
+}
+
+func TestDaoRoomMembers(t *testing.T) {
+	var (
+		c    = context.Background()
+		room = "test://members"
+	)
+	ok, err := d.AddRoomMember(c, room, "key1", 2)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = d.AddRoomMember(c, room, "key2", 2)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	// full
+	ok, err = d.AddRoomMember(c, room, "key3", 2)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	// a member joining again
+	ok, err = d.AddRoomMember(c, room, "key1", 2)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, d.ExpireRoomMember(c, room, "key1"))
+	assert.Nil(t, d.ExpireRoomMember(c, room, "key3"))
+	members, err := mr.ZMembers(keyRoomMembers(room))
+	assert.Nil(t, err)
+	sort.Strings(members)
+	assert.Equal(t, []string{"key1", "key2"}, members)
+	assert.Nil(t, d.DelRoomMembers(c, room, []string{"key2", "key3"}))
+	ok, err = d.AddRoomMember(c, room, "key3", 2)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestDaoRoomHistory(t *testing.T) {
+	var (
+		c    = context.Background()
+		room = "test://history"
+	)
+	seq1, err := d.AddRoomMsg(c, room, 1000, []byte("msg1"))
+	assert.Nil(t, err)
+	seq2, err := d.AddRoomMsg(c, room, 1000, []byte("msg2"))
+	assert.Nil(t, err)
+	assert.Equal(t, seq1+1, seq2)
+	// the seq outlives the history
+	assert.True(t, mr.TTL(keyRoomHistory(room)) > 0)
+	assert.Equal(t, time.Duration(0), mr.TTL(keyRoomSeq(room)))
+
+	msgs, err := d.RoomHistory(c, room, seq1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, seq2, msgs[0].Seq)
+	assert.Equal(t, int32(1000), msgs[0].Op)
+	assert.Equal(t, []byte("msg2"), msgs[0].Msg)
+}
+
+func TestDaoOfflineMsgs(t *testing.T) {
+	var (
+		c   = context.Background()
+		mid = int64(100)
+	)
+	_, err := d.DelOfflineMsgs(c, "", mid)
+	assert.Nil(t, err)
+	assert.Nil(t, d.AddOfflineMsg(c, "", mid, &model.OfflineMsg{Op: 1000, Msg: []byte("msg1")}))
+	assert.Nil(t, d.AddOfflineMsg(c, "", mid, &model.OfflineMsg{Op: 1000, Msg: []byte("msg2")}))
+
+	msgs, err := d.OfflineMsgs(c, "", mid)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(msgs))
+
+	assert.Equal(t, []byte("msg1"), msgs[0].Msg)
+	assert.Equal(t, []byte("msg2"), msgs[1].Msg)
+
+	// the message added meanwhile stays
+	assert.Nil(t, d.AddOfflineMsg(c, "", mid, &model.OfflineMsg{Op: 1000, Msg: []byte("msg3")}))
+	assert.Nil(t, d.AckOfflineMsgs(c, "", mid, msgs[:1]))
+	msgs, err = d.OfflineMsgs(c, "", mid)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(msgs))
+	assert.Equal(t, []byte("msg2"), msgs[0].Msg)
+	assert.Equal(t, []byte("msg3"), msgs[1].Msg)
+}
+
+func TestDaoReceipt(t *testing.T) {
+	var (
+		c     = context.Background()
+		msgID = "test_msg"
+	)
+	from, app, added, err := d.AddReceipt(c, &model.Receipt{MsgID: "unknown_msg", Mid: 2, Type: model.ReceiptRead, Ts: 1}, "key2", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), from)
+	assert.Equal(t, false, added)
+
+	assert.Nil(t, d.AddReceiptMsg(c, "", msgID, 1, []string{ReceiptToMid("", 2), ReceiptToRoom("live://1")}))
+	from, app, added, err = d.AddReceipt(c, &model.Receipt{MsgID: msgID, Mid: 2, Type: model.ReceiptDelivered, Ts: 1}, "key2", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), from)
+	assert.Equal(t, "", app)
+	assert.Equal(t, true, added)
+	_, _, added, err = d.AddReceipt(c, &model.Receipt{MsgID: msgID, Mid: 2, Type: model.ReceiptDelivered, Ts: 2}, "key2", "")
+	assert.Nil(t, err)
+	assert.Equal(t, false, added)
+	// a member of the room pushed to
+	_, _, added, err = d.AddReceipt(c, &model.Receipt{MsgID: msgID, Mid: 3, Type: model.ReceiptDelivered, Ts: 3}, "key3", "live://1")
+	assert.Nil(t, err)
+	assert.Equal(t, true, added)
+	// a mid not pushed to
+	_, _, _, err = d.AddReceipt(c, &model.Receipt{MsgID: msgID, Mid: 4, Type: model.ReceiptRead, Ts: 4}, "key4", "live://2")
+	assert.Equal(t, ErrNotRecipient, err)
+	// the mid 2 of another app does not see the message
+	from, _, _, err = d.AddReceipt(c, &model.Receipt{MsgID: msgID, Mid: 2, Type: model.ReceiptRead, Ts: 4}, model.EncodeKey("app1", "key2"), "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), from)
+
+	// another app reusing the msg id
+	assert.Nil(t, d.AddReceiptMsg(c, "app1", msgID, 5, []string{ReceiptToMid("app1", 2)}))
+	from, app, added, err = d.AddReceipt(c, &model.Receipt{MsgID: msgID, Mid: 2, Type: model.ReceiptRead, Ts: 5}, model.EncodeKey("app1", "key2"), "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), from)
+	assert.Equal(t, "app1", app)
+	assert.Equal(t, true, added)
+
+	state, err := d.Receipt(c, "", msgID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), state.From)
+	assert.Equal(t, map[int64]int64{2: 1, 3: 3}, state.Delivered)
+	assert.Equal(t, 0, len(state.Read))
+	state, err = d.Receipt(c, "app1", msgID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), state.From)
+	assert.Equal(t, map[int64]int64{2: 5}, state.Read)
+	state, err = d.Receipt(c, "app2", msgID)
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+}
+
+func TestDaoScheduledPush(t *testing.T) {
+	var (
+		c  = context.Background()
+		sp = &model.ScheduledPush{ID: "test_schedule", DeliverAt: 1000, Caller: "key1", Item: &model.PushItem{Type: model.PushRoom, Op: 1000, Room: "test://1", Msg: []byte("msg")}}
+	)
+	assert.Nil(t, d.AddScheduledPush(c, sp))
+	assert.Nil(t, d.AddScheduledPush(c, &model.ScheduledPush{ID: "test_schedule_later", DeliverAt: 5000, Caller: "key1"}))
+	assert.Nil(t, d.AddScheduledPush(c, &model.ScheduledPush{ID: "test_schedule_anonymous", DeliverAt: 6000}))
+	sps, err := d.ScheduledPushes(c, "", 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(sps))
+	// the pages of a caller are full
+	sps, err = d.ScheduledPushes(c, "key1", 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(sps))
+	assert.Equal(t, "test_schedule_later", sps[0].ID)
+
+	sps, err = d.ClaimScheduledPushes(c, 2000, 1000, 10)
+	assert.Nil(t, err)
+	sp.Attempts = 1
+	assert.Equal(t, []*model.ScheduledPush{sp}, sps)
+	sps, err = d.ScheduledPushes(c, "key1", 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(sps))
+	// leased once
+	sps, err = d.ClaimScheduledPushes(c, 2500, 1000, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(sps))
+	// claimed again once the lease expires unacked
+	sps, err = d.ClaimScheduledPushes(c, 3000, 1000, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(sps))
+	assert.Equal(t, int64(2), sps[0].Attempts)
+	// delayed without using up an attempt
+	assert.Nil(t, d.DelayScheduledPush(c, sps[0], 3500))
+	sps, err = d.ClaimScheduledPushes(c, 3200, 1000, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(sps))
+	sps, err = d.ScheduledPushes(c, "key1", 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(sps))
+	sps, err = d.ClaimScheduledPushes(c, 3500, 1000, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(sps))
+	assert.Equal(t, int64(2), sps[0].Attempts)
+	assert.Nil(t, d.AckScheduledPush(c, sp.ID))
+	sps, err = d.ClaimScheduledPushes(c, 4500, 1000, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(sps))
+	sp, err = d.ScheduledPush(c, "test_schedule")
+	assert.Nil(t, err)
+	assert.Nil(t, sp)
+
+	has, err := d.CancelScheduledPush(c, "test_schedule_later", "key1")
+	assert.Nil(t, err)
+	assert.True(t, has)
+	sp, err = d.ScheduledPush(c, "test_schedule_later")
+	assert.Nil(t, err)
+	assert.Nil(t, sp)
+	sps, err = d.ScheduledPushes(c, "key1", 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(sps))
+	has, err = d.CancelScheduledPush(c, "test_schedule_anonymous", "")
+	assert.Nil(t, err)
+	assert.True(t, has)
+}
+
+func TestDaoIncrLimits(t *testing.T) {
+	var (
+		c    = context.Background()
+		keys = []string{"test_limit_a", "test_limit_b", "test_limit_a"}
+	)
+	ns, lefts, err := d.IncrLimits(c, keys, []time.Duration{time.Minute, time.Second, time.Minute})
+	assert.Nil(t, err)
+	first := ns[0]
+	assert.Equal(t, first+1, ns[2])
+	assert.True(t, ns[1] >= 1)
+	assert.True(t, lefts[0] > 0 && lefts[0] <= time.Minute)
+	assert.True(t, lefts[1] > 0 && lefts[1] <= time.Second)
+	ns, _, err = d.IncrLimits(c, keys[:1], []time.Duration{time.Minute})
+	assert.Nil(t, err)
+	assert.Equal(t, first+2, ns[0])
+}
+
+func TestDaoIdempotencyKey(t *testing.T) {
+	testIdempotencyKey(t, d)
+}
+
+func TestDaoIdempotencyKeyMemory(t *testing.T) {
+	var (
+		c  = context.Background()
+		md = &Dao{idem: newIdempotencyMemory()}
+	)
+	testIdempotencyKey(t, md)
+	// reserved again once expired
+	ok, err := md.AddIdempotencyKey(c, "test:expired", time.Millisecond)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	time.Sleep(time.Millisecond * 2)
+	ok, err = md.AddIdempotencyKey(c, "test:expired", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func testIdempotencyKey(t *testing.T, d *Dao) {
+	var (
+		c   = context.Background()
+		key = "test:idempotency"
+	)
+	assert.Nil(t, d.DelIdempotencyKey(c, key))
+	ok, err := d.AddIdempotencyKey(c, key, time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = d.AddIdempotencyKey(c, key, time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	res, err := d.IdempotencyResult(c, key)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+	assert.Nil(t, d.SetIdempotencyResult(c, key, []byte(`{"enqueued":1}`), time.Minute))
+	res, err = d.IdempotencyResult(c, key)
 	assert.Nil(t, err)
+	assert.Equal(t, `{"enqueued":1}`, string(res))
 }
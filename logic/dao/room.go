@@ -0,0 +1,247 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+	"github.com/swanky2009/goim/pkg/hash"
+)
+
+// the hash tag keeps the config and the bans of a room in the same cluster slot.
+const (
+	_prefixRoomConfig  = "room_conf_{%s}"    // room -> config                string
+	_prefixRoomBan     = "room_ban_{%s}"     // room -> banned mids           set
+	_prefixRoomSlow    = "room_slow_{%s}_%d" // room, mid -> the last message string
+	_prefixRoomMembers = "room_members_{%s}" // room -> key, expire at(ms)    zset
+)
+
+// _joinRoom adds a key to the members of a room unless it is full, the
+// members not renewed by a heartbeat expire first. A member joining again is
+// renewed.
+// KEYS: members ARGV: key, now(ms), expire at(ms), capacity, ttl(ms)
+// returns 1 if joined, 0 if full
+var _joinRoom = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[2])
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) and redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[4]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+func keyRoomConfig(room string) string {
+	return fmt.Sprintf(_prefixRoomConfig, hash.Sha1s(room))
+}
+
+func keyRoomBan(room string) string {
+	return fmt.Sprintf(_prefixRoomBan, hash.Sha1s(room))
+}
+
+func keyRoomMembers(room string) string {
+	return fmt.Sprintf(_prefixRoomMembers, hash.Sha1s(room))
+}
+
+func keyRoomSlow(room string, mid int64) string {
+	return fmt.Sprintf(_prefixRoomSlow, hash.Sha1s(room), mid)
+}
+
+// SetRoomConfig creates or replaces the config of the room, the bans are kept.
+func (d *Dao) SetRoomConfig(c context.Context, rc *model.RoomConfig) (err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var b []byte
+	conf := *rc
+	conf.Banned = nil
+	if b, err = json.Marshal(&conf); err != nil {
+		return
+	}
+	if err = d.redis.Set(keyRoomConfig(rc.Room), b, 0).Err(); err != nil {
+		g.Logger.Errorf("redis.Set(%s) error(%v)", rc.Room, err)
+	}
+	return
+}
+
+// RoomConfig gets the config and the bans of the room, nil if not found.
+func (d *Dao) RoomConfig(c context.Context, room string) (rc *model.RoomConfig, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var (
+		conf *redis.StringCmd
+		ban  *redis.StringSliceCmd
+	)
+	if _, err = d.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		conf = pipe.Get(keyRoomConfig(room))
+		ban = pipe.SMembers(keyRoomBan(room))
+		return nil
+	}); err != nil && err != redis.Nil {
+		g.Logger.Errorf("redis.Pipelined(GET SMEMBERS %s) error(%v)", room, err)
+		return
+	}
+	if rc, err = parseRoomConfig(conf); rc == nil || err != nil {
+		return
+	}
+	for _, v := range ban.Val() {
+		if mid, e := strconv.ParseInt(v, 10, 64); e == nil {
+			rc.Banned = append(rc.Banned, mid)
+		}
+	}
+	sort.Slice(rc.Banned, func(i, j int) bool { return rc.Banned[i] < rc.Banned[j] })
+	return
+}
+
+// RoomAccess gets the config of the room, nil if not found, and whether the
+// mid is banned from it. Every room is open without redis.
+func (d *Dao) RoomAccess(c context.Context, room string, mid int64) (rc *model.RoomConfig, banned bool, err error) {
+	if d.redis == nil {
+		return
+	}
+	var (
+		conf *redis.StringCmd
+		ban  *redis.BoolCmd
+	)
+	if _, err = d.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		conf = pipe.Get(keyRoomConfig(room))
+		ban = pipe.SIsMember(keyRoomBan(room), mid)
+		return nil
+	}); err != nil && err != redis.Nil {
+		g.Logger.Errorf("redis.Pipelined(GET SISMEMBER %s,%d) error(%v)", room, mid, err)
+		return
+	}
+	if rc, err = parseRoomConfig(conf); err != nil {
+		return
+	}
+	banned = ban.Val()
+	return
+}
+
+func parseRoomConfig(cmd *redis.StringCmd) (rc *model.RoomConfig, err error) {
+	b, err := cmd.Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	rc = new(model.RoomConfig)
+	if err = json.Unmarshal(b, rc); err != nil {
+		g.Logger.Errorf("json.Unmarshal(%s) error(%v)", b, err)
+	}
+	return
+}
+
+// DelRoomConfig deletes the config and the bans of the room, has is false if
+// it is not found.
+func (d *Dao) DelRoomConfig(c context.Context, room string) (has bool, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var n int64
+	if n, err = d.redis.Del(keyRoomConfig(room), keyRoomBan(room)).Result(); err != nil {
+		g.Logger.Errorf("redis.Del(%s) error(%v)", room, err)
+		return
+	}
+	has = n > 0
+	return
+}
+
+// BanRoomMids bans the mids from the room.
+func (d *Dao) BanRoomMids(c context.Context, room string, mids []int64) (err error) {
+	if err = d.needRedis(); err != nil || len(mids) == 0 {
+		return
+	}
+	if err = d.redis.SAdd(keyRoomBan(room), int64Args(mids)...).Err(); err != nil {
+		g.Logger.Errorf("redis.SAdd(%s,%v) error(%v)", room, mids, err)
+	}
+	return
+}
+
+// UnbanRoomMids lifts the bans of the mids from the room.
+func (d *Dao) UnbanRoomMids(c context.Context, room string, mids []int64) (err error) {
+	if err = d.needRedis(); err != nil || len(mids) == 0 {
+		return
+	}
+	if err = d.redis.SRem(keyRoomBan(room), int64Args(mids)...).Err(); err != nil {
+		g.Logger.Errorf("redis.SRem(%s,%v) error(%v)", room, mids, err)
+	}
+	return
+}
+
+func int64Args(is []int64) []interface{} {
+	args := make([]interface{}, len(is))
+	for i, v := range is {
+		args[i] = v
+	}
+	return args
+}
+
+// AllowRoomMsg reports whether the mid may send to the room in slow mode,
+// true for the first message in the interval. Every message is allowed
+// without redis.
+func (d *Dao) AllowRoomMsg(c context.Context, room string, mid int64, interval time.Duration) (ok bool, err error) {
+	if d.redis == nil {
+		return true, nil
+	}
+	if ok, err = d.redis.SetNX(keyRoomSlow(room, mid), 1, interval).Result(); err != nil {
+		g.Logger.Errorf("redis.SetNX(%s,%d) error(%v)", room, mid, err)
+	}
+	return
+}
+
+// AddRoomMember adds the key to the members of the room counted against its
+// capacity, ok is false if the room is full. A member expires like its
+// session unless renewed. Every room has room without redis.
+func (d *Dao) AddRoomMember(c context.Context, room, key string, capacity int32) (ok bool, err error) {
+	if d.redis == nil {
+		return true, nil
+	}
+	var (
+		res interface{}
+		ttl = time.Duration(d.c.Store.Expire)
+		now = time.Now()
+	)
+	if res, err = _joinRoom.Run(d.redis, []string{keyRoomMembers(room)}, key, now.UnixNano()/int64(time.Millisecond),
+		now.Add(ttl).UnixNano()/int64(time.Millisecond), capacity, int64(ttl/time.Millisecond)).Result(); err != nil {
+		g.Logger.Errorf("redis.Eval(joinRoom %s,%s) error(%v)", room, key, err)
+		return
+	}
+	n, _ := res.(int64)
+	ok = n == 1
+	return
+}
+
+// ExpireRoomMember renews the key if it is a member of the room.
+func (d *Dao) ExpireRoomMember(c context.Context, room, key string) (err error) {
+	if d.redis == nil {
+		return
+	}
+	at := time.Now().Add(time.Duration(d.c.Store.Expire)).UnixNano() / int64(time.Millisecond)
+	if err = d.redis.ZAddXX(keyRoomMembers(room), redis.Z{Score: float64(at), Member: key}).Err(); err != nil {
+		g.Logger.Errorf("redis.ZAddXX(%s,%s) error(%v)", room, key, err)
+	}
+	return
+}
+
+// DelRoomMembers removes the keys from the members of the room.
+func (d *Dao) DelRoomMembers(c context.Context, room string, keys []string) (err error) {
+	if d.redis == nil || len(keys) == 0 {
+		return
+	}
+	members := make([]interface{}, len(keys))
+	for i, key := range keys {
+		members[i] = key
+	}
+	if err = d.redis.ZRem(keyRoomMembers(room), members...).Err(); err != nil {
+		g.Logger.Errorf("redis.ZRem(%s,%v) error(%v)", room, keys, err)
+	}
+	return
+}
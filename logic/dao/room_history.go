@@ -0,0 +1,99 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+	"github.com/swanky2009/goim/pkg/hash"
+)
+
+// the hash tag keeps the seq and the history of a room in the same cluster slot.
+const (
+	_prefixRoomSeq     = "room_seq_{%s}"     // room -> last seq             string
+	_prefixRoomHistory = "room_history_{%s}" // room -> seq-0: op, msg, ts   stream
+)
+
+// _addRoomMsg increases the room seq and appends the message with it as the stream id.
+// The seq does not expire with the history, a room restarting at 1 would look
+// older than the messages the clients have seen.
+// KEYS: seq, history ARGV: size, op, msg, ts, expire(ms)
+var _addRoomMsg = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[1])
+redis.call('XADD', KEYS[2], 'MAXLEN', '~', ARGV[1], seq .. '-0', 'op', ARGV[2], 'msg', ARGV[3], 'ts', ARGV[4])
+redis.call('PEXPIRE', KEYS[2], ARGV[5])
+return seq
+`)
+
+func keyRoomSeq(room string) string {
+	return fmt.Sprintf(_prefixRoomSeq, hash.Sha1s(room))
+}
+
+func keyRoomHistory(room string) string {
+	return fmt.Sprintf(_prefixRoomHistory, hash.Sha1s(room))
+}
+
+// AddRoomMsg appends a message to the room history and returns its seq, no
+// history is kept and the seq is 0 without redis.
+func (d *Dao) AddRoomMsg(c context.Context, room string, op int32, msg []byte) (seq int64, err error) {
+	if d.redis == nil {
+		return
+	}
+	var (
+		res    interface{}
+		h      = d.c.RoomHistory
+		ts     = time.Now().UnixNano() / int64(time.Millisecond)
+		expire = time.Duration(h.Expire) / time.Millisecond
+	)
+	if res, err = _addRoomMsg.Run(d.redis, []string{keyRoomSeq(room), keyRoomHistory(room)}, h.Size, op, msg, ts, int64(expire)).Result(); err != nil {
+		g.Logger.Errorf("redis.Eval(addRoomMsg %s,%d) error(%v)", room, op, err)
+		return
+	}
+	seq, _ = res.(int64)
+	return
+}
+
+// RoomHistory gets at most count messages of the room after the seq.
+func (d *Dao) RoomHistory(c context.Context, room string, sinceSeq int64, count int64) (msgs []*model.RoomMsg, err error) {
+	if d.redis == nil {
+		return
+	}
+	var xmsgs []*redis.XMessage
+	if xmsgs, err = d.redis.XRangeN(keyRoomHistory(room), fmt.Sprintf("%d-0", sinceSeq+1), "+", count).Result(); err != nil {
+		g.Logger.Errorf("redis.XRange(%s,%d) error(%v)", room, sinceSeq, err)
+		return
+	}
+	for _, xm := range xmsgs {
+		var m *model.RoomMsg
+		if m, err = parseRoomMsg(xm); err != nil {
+			g.Logger.Errorf("parseRoomMsg(%s,%s) error(%v)", room, xm.ID, err)
+			return
+		}
+		msgs = append(msgs, m)
+	}
+	return
+}
+
+func parseRoomMsg(xm *redis.XMessage) (m *model.RoomMsg, err error) {
+	var op int64
+	m = new(model.RoomMsg)
+	if m.Seq, err = strconv.ParseInt(strings.TrimSuffix(xm.ID, "-0"), 10, 64); err != nil {
+		return
+	}
+	if op, err = strconv.ParseInt(fmt.Sprint(xm.Values["op"]), 10, 32); err != nil {
+		return
+	}
+	m.Op = int32(op)
+	if m.Ts, err = strconv.ParseInt(fmt.Sprint(xm.Values["ts"]), 10, 64); err != nil {
+		return
+	}
+	if msg, ok := xm.Values["msg"].(string); ok {
+		m.Msg = []byte(msg)
+	}
+	return
+}
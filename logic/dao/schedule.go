@@ -0,0 +1,246 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// the hash tag keeps the queue and the pushes in the same cluster slot.
+const (
+	_keySchedule          = "schedule_{push}"          // id -> deliver at(ms)     zset
+	_keyScheduleJobs      = "schedule_{push}_jobs"     // id -> scheduled push     hash
+	_keyScheduleLeases    = "schedule_{push}_leases"   // id -> lease deadline(ms) zset
+	_keyScheduleAttempts  = "schedule_{push}_attempts" // id -> times claimed      hash
+	_prefixScheduleCaller = "schedule_{push}_caller_"  // id -> deliver at(ms)     zset
+)
+
+// _claimSchedule leases the pushes due and the pushes whose lease expired
+// until the deadline, so a push is fired by one logic at a time. A push stays
+// until acked, a logic dying before is replaced by the next claim. The pushes
+// due leave the queue and the queue of their caller.
+// KEYS: schedule, jobs, leases, attempts ARGV: now(ms), count, deadline(ms), caller prefix
+// returns job, attempts, ...
+var _claimSchedule = redis.NewScript(`
+local count = tonumber(ARGV[2])
+local ids = redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', ARGV[1], 'LIMIT', 0, count)
+if #ids < count then
+	for _, id in ipairs(redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, count - #ids)) do
+		redis.call('ZREM', KEYS[1], id)
+		ids[#ids + 1] = id
+	end
+end
+local res = {}
+for _, id in ipairs(ids) do
+	local job = redis.call('HGET', KEYS[2], id)
+	if job then
+		local ok, sp = pcall(cjson.decode, job)
+		if ok and type(sp.caller) == 'string' then
+			redis.call('ZREM', ARGV[4] .. sp.caller, id)
+		end
+		redis.call('ZADD', KEYS[3], ARGV[3], id)
+		res[#res + 1] = job
+		res[#res + 1] = redis.call('HINCRBY', KEYS[4], id, 1)
+	else
+		redis.call('ZREM', KEYS[3], id)
+		redis.call('HDEL', KEYS[4], id)
+	end
+end
+return res
+`)
+
+// _cancelSchedule removes a push not fired yet.
+// KEYS: schedule, jobs, caller ARGV: id
+var _cancelSchedule = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 1 then
+	redis.call('HDEL', KEYS[2], ARGV[1])
+	redis.call('ZREM', KEYS[3], ARGV[1])
+	return 1
+end
+return 0
+`)
+
+// _delaySchedule puts a claimed push back in the queue at a later time, the
+// claim is not counted in its attempts.
+// KEYS: schedule, leases, attempts, caller(optional) ARGV: id, at(ms)
+var _delaySchedule = redis.NewScript(`
+if redis.call('ZREM', KEYS[2], ARGV[1]) == 1 then
+	redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+	if KEYS[4] then
+		redis.call('ZADD', KEYS[4], ARGV[2], ARGV[1])
+	end
+	redis.call('HINCRBY', KEYS[3], ARGV[1], -1)
+	return 1
+end
+return 0
+`)
+
+// keyScheduleCaller the pushes scheduled by the caller, so the caller lists
+// its own pushes by pages.
+func keyScheduleCaller(caller string) string {
+	return fmt.Sprintf("%s%s", _prefixScheduleCaller, caller)
+}
+
+// AddScheduledPush keeps the push until its deliver time.
+func (d *Dao) AddScheduledPush(c context.Context, sp *model.ScheduledPush) (err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var b []byte
+	if b, err = json.Marshal(sp); err != nil {
+		return
+	}
+	if _, err = d.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(_keyScheduleJobs, sp.ID, b)
+		pipe.ZAdd(_keySchedule, redis.Z{Score: float64(sp.DeliverAt), Member: sp.ID})
+		if sp.Caller != "" {
+			pipe.ZAdd(keyScheduleCaller(sp.Caller), redis.Z{Score: float64(sp.DeliverAt), Member: sp.ID})
+		}
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.TxPipelined(HSET ZADD schedule %s) error(%v)", sp.ID, err)
+	}
+	return
+}
+
+// ScheduledPush gets a push not fired yet, nil if not found.
+func (d *Dao) ScheduledPush(c context.Context, id string) (sp *model.ScheduledPush, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var b []byte
+	if b, err = d.redis.HGet(_keyScheduleJobs, id).Bytes(); err != nil {
+		if err == redis.Nil {
+			err = nil
+		} else {
+			g.Logger.Errorf("redis.HGet(schedule %s) error(%v)", id, err)
+		}
+		return
+	}
+	sp = new(model.ScheduledPush)
+	if err = json.Unmarshal(b, sp); err != nil {
+		g.Logger.Errorf("json.Unmarshal(schedule %s) error(%v)", id, err)
+	}
+	return
+}
+
+// ScheduledPushes gets the pushes not fired yet of the caller, or of all if
+// empty, in the order of deliver time.
+func (d *Dao) ScheduledPushes(c context.Context, caller string, offset, count int64) (sps []*model.ScheduledPush, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var (
+		ids  []string
+		vals []interface{}
+		key  = _keySchedule
+	)
+	if caller != "" {
+		key = keyScheduleCaller(caller)
+	}
+	if ids, err = d.redis.ZRange(key, offset, offset+count-1).Result(); err != nil {
+		g.Logger.Errorf("redis.ZRange(%s %d,%d) error(%v)", key, offset, count, err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if vals, err = d.redis.HMGet(_keyScheduleJobs, ids...).Result(); err != nil {
+		g.Logger.Errorf("redis.HMGet(schedule %v) error(%v)", ids, err)
+		return
+	}
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if sp := parseScheduledPush(ids[i], s); sp != nil {
+			sps = append(sps, sp)
+		}
+	}
+	return
+}
+
+// ClaimScheduledPushes leases at most count pushes due at now(unix ms) until
+// the lease expires, none without redis. The pushes leased before and not
+// acked by the deadline are claimed again first.
+func (d *Dao) ClaimScheduledPushes(c context.Context, now, lease, count int64) (sps []*model.ScheduledPush, err error) {
+	if d.redis == nil {
+		return
+	}
+	var res interface{}
+	if res, err = _claimSchedule.Run(d.redis, []string{_keySchedule, _keyScheduleJobs, _keyScheduleLeases, _keyScheduleAttempts},
+		now, count, now+lease, _prefixScheduleCaller).Result(); err != nil {
+		g.Logger.Errorf("redis.Eval(claimSchedule %d) error(%v)", now, err)
+		return
+	}
+	vals, _ := res.([]interface{})
+	for i := 0; i+1 < len(vals); i += 2 {
+		s, _ := vals[i].(string)
+		if sp := parseScheduledPush("", s); sp != nil {
+			sp.Attempts, _ = vals[i+1].(int64)
+			sps = append(sps, sp)
+		}
+	}
+	return
+}
+
+// AckScheduledPush removes a claimed push once fired, or given up.
+func (d *Dao) AckScheduledPush(c context.Context, id string) (err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	if _, err = d.redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.ZRem(_keyScheduleLeases, id)
+		pipe.HDel(_keyScheduleJobs, id)
+		pipe.HDel(_keyScheduleAttempts, id)
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.TxPipelined(ZREM HDEL schedule %s) error(%v)", id, err)
+	}
+	return
+}
+
+// DelayScheduledPush puts a claimed push back to be fired at at(unix ms),
+// without using up an attempt.
+func (d *Dao) DelayScheduledPush(c context.Context, sp *model.ScheduledPush, at int64) (err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	keys := []string{_keySchedule, _keyScheduleLeases, _keyScheduleAttempts}
+	if sp.Caller != "" {
+		keys = append(keys, keyScheduleCaller(sp.Caller))
+	}
+	if err = _delaySchedule.Run(d.redis, keys, sp.ID, at).Err(); err != nil {
+		g.Logger.Errorf("redis.Eval(delaySchedule %s %d) error(%v)", sp.ID, at, err)
+	}
+	return
+}
+
+// CancelScheduledPush removes a push of the caller not fired yet.
+func (d *Dao) CancelScheduledPush(c context.Context, id, caller string) (has bool, err error) {
+	if err = d.needRedis(); err != nil {
+		return
+	}
+	var res interface{}
+	if res, err = _cancelSchedule.Run(d.redis, []string{_keySchedule, _keyScheduleJobs, keyScheduleCaller(caller)}, id).Result(); err != nil {
+		g.Logger.Errorf("redis.Eval(cancelSchedule %s) error(%v)", id, err)
+		return
+	}
+	n, _ := res.(int64)
+	has = n == 1
+	return
+}
+
+func parseScheduledPush(id, v string) *model.ScheduledPush {
+	sp := new(model.ScheduledPush)
+	if err := json.Unmarshal([]byte(v), sp); err != nil {
+		g.Logger.Errorf("json.Unmarshal(schedule %s %s) error(%v)", id, v, err)
+		return nil
+	}
+	return sp
+}
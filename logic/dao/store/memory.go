@@ -0,0 +1,353 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// _sweepInterval the expired sessions of a memory store are removed at.
+const _sweepInterval = time.Minute
+
+// appMid a mid in its app.
+type appMid struct {
+	app string
+	mid int64
+}
+
+// sessionMid returns the mid of a session in the app of its key.
+func sessionMid(mid int64, key string) appMid {
+	app, _ := model.DecodeKey(key)
+	return appMid{app: app, mid: mid}
+}
+
+type midSessions struct {
+	keys     map[string]string // key -> server
+	deadline time.Time
+}
+
+type keySession struct {
+	model.Session
+	deadline time.Time
+}
+
+type serverOnline struct {
+	rooms    map[string]int32
+	deadline time.Time
+}
+
+// memoryStore keeps everything in the process, for a single logic run
+// locally or in tests.
+type memoryStore struct {
+	mu           sync.Mutex
+	expire       time.Duration
+	onlineExpire time.Duration
+	mids         map[appMid]*midSessions
+	keys         map[string]*keySession
+	servers      map[string]float64
+	online       map[string]*serverOnline // server -> snapshot
+	rooms        map[string]int32         // room -> count of all servers
+	done         chan struct{}
+}
+
+// NewMemory returns a store in memory, a session expires after no heartbeat
+// in expire, and the room counts of a server after no renewal in onlineExpire.
+func NewMemory(expire, onlineExpire time.Duration) Store {
+	s := &memoryStore{
+		expire:       expire,
+		onlineExpire: onlineExpire,
+		mids:         make(map[appMid]*midSessions),
+		keys:         make(map[string]*keySession),
+		servers:      make(map[string]float64),
+		online:       make(map[string]*serverOnline),
+		rooms:        make(map[string]int32),
+		done:         make(chan struct{}),
+	}
+	go s.sweepproc()
+	return s
+}
+
+func (s *memoryStore) sweepproc() {
+	ticker := time.NewTicker(_sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for mid, ms := range s.mids {
+				if now.After(ms.deadline) {
+					delete(s.mids, mid)
+				}
+			}
+			for key, ks := range s.keys {
+				if now.After(ks.deadline) {
+					delete(s.keys, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *memoryStore) Ping(c context.Context) error {
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	close(s.done)
+	return nil
+}
+
+// mid returns the live sessions of the mid, nil if none.
+func (s *memoryStore) mid(mid appMid, now time.Time) *midSessions {
+	ms, ok := s.mids[mid]
+	if !ok {
+		return nil
+	}
+	if now.After(ms.deadline) {
+		delete(s.mids, mid)
+		return nil
+	}
+	return ms
+}
+
+// key returns the live session of the key, nil if none.
+func (s *memoryStore) key(key string, now time.Time) *keySession {
+	ks, ok := s.keys[key]
+	if !ok {
+		return nil
+	}
+	if now.After(ks.deadline) {
+		delete(s.keys, key)
+		return nil
+	}
+	return ks
+}
+
+func (s *memoryStore) AddMapping(c context.Context, sess *model.Session) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess.Mid > 0 {
+		mid := sessionMid(sess.Mid, sess.Key)
+		ms := s.mid(mid, now)
+		if ms == nil {
+			ms = &midSessions{keys: make(map[string]string)}
+			s.mids[mid] = ms
+		}
+		ms.keys[sess.Key] = sess.Server
+		ms.deadline = now.Add(s.expire)
+	}
+	s.keys[sess.Key] = &keySession{Session: *sess, deadline: now.Add(s.expire)}
+	return nil
+}
+
+func (s *memoryStore) ExpireMapping(c context.Context, sess *model.Session) (has bool, err error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess.Mid > 0 {
+		if ms := s.mid(sessionMid(sess.Mid, sess.Key), now); ms != nil {
+			ms.deadline = now.Add(s.expire)
+		}
+	}
+	if ks := s.key(sess.Key, now); ks != nil {
+		ks.Room, ks.Platform, ks.HeartbeatTime = sess.Room, sess.Platform, sess.HeartbeatTime
+		ks.deadline = now.Add(s.expire)
+		has = true
+	}
+	return
+}
+
+func (s *memoryStore) DelMapping(c context.Context, mid int64, key, server string) (has bool, err error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if mid > 0 {
+		am := sessionMid(mid, key)
+		if ms := s.mid(am, now); ms != nil {
+			delete(ms.keys, key)
+			if len(ms.keys) == 0 {
+				delete(s.mids, am)
+			}
+		}
+	}
+	has = s.key(key, now) != nil
+	delete(s.keys, key)
+	return
+}
+
+func (s *memoryStore) ServersByKeys(c context.Context, keys []string) (res []string, err error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res = make([]string, len(keys))
+	for i, key := range keys {
+		if ks := s.key(key, now); ks != nil {
+			res[i] = ks.Server
+		}
+	}
+	return
+}
+
+func (s *memoryStore) KeysByMids(c context.Context, app string, mids []int64) (ress map[string]string, olMids []int64, err error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ress = make(map[string]string)
+	for _, mid := range mids {
+		ms := s.mid(appMid{app: app, mid: mid}, now)
+		if ms == nil || len(ms.keys) == 0 {
+			continue
+		}
+		olMids = append(olMids, mid)
+		for k, v := range ms.keys {
+			ress[k] = v
+		}
+	}
+	return
+}
+
+func (s *memoryStore) SessionsByMids(c context.Context, app string, mids []int64) (sessions []*model.Session, err error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, mid := range mids {
+		ms := s.mid(appMid{app: app, mid: mid}, now)
+		if ms == nil {
+			continue
+		}
+		for key := range ms.keys {
+			ks := s.key(key, now)
+			if ks == nil {
+				// a key without a session is stale
+				delete(ms.keys, key)
+				continue
+			}
+			sess := ks.Session
+			sessions = append(sessions, &sess)
+		}
+	}
+	return
+}
+
+func (s *memoryStore) AddServerScore(c context.Context, server string) error {
+	s.mu.Lock()
+	if _, ok := s.servers[server]; !ok {
+		s.servers[server] = 0
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStore) DelServerScore(c context.Context, server string) error {
+	s.mu.Lock()
+	delete(s.servers, server)
+	s.delOnline(server)
+	s.mu.Unlock()
+	return nil
+}
+
+// delOnline subtracts the snapshot of the server from the room totals.
+func (s *memoryStore) delOnline(server string) {
+	so, ok := s.online[server]
+	if !ok {
+		return
+	}
+	for room, count := range so.rooms {
+		s.addRoom(room, -count)
+	}
+	delete(s.online, server)
+}
+
+func (s *memoryStore) addRoom(room string, n int32) {
+	if s.rooms[room] += n; s.rooms[room] <= 0 {
+		delete(s.rooms, room)
+	}
+}
+
+func (s *memoryStore) IncrServerScore(c context.Context, server string) error {
+	s.mu.Lock()
+	s.servers[server]++
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStore) DecrServerScore(c context.Context, server string) error {
+	s.mu.Lock()
+	s.servers[server]--
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStore) ServersRank(c context.Context, num int64) (list []string, err error) {
+	s.mu.Lock()
+	for server := range s.servers {
+		list = append(list, server)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if si, sj := s.servers[list[i]], s.servers[list[j]]; si != sj {
+			return si < sj
+		}
+		return list[i] < list[j]
+	})
+	s.mu.Unlock()
+	// the stop index of ZRANGE
+	if num < 0 {
+		num += int64(len(list))
+	}
+	if num < 0 {
+		return nil, nil
+	}
+	if num < int64(len(list)-1) {
+		list = list[:num+1]
+	}
+	return
+}
+
+func (s *memoryStore) UpdateRoomCount(c context.Context, server string, roomCount map[string]int32) error {
+	rooms := make(map[string]int32, len(roomCount))
+	for room, count := range roomCount {
+		rooms[room] = count
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delOnline(server)
+	for room, count := range rooms {
+		s.addRoom(room, count)
+	}
+	s.online[server] = &serverOnline{rooms: rooms, deadline: time.Now().Add(s.onlineExpire)}
+	return nil
+}
+
+func (s *memoryStore) GetAllRoomCount(c context.Context) (allRoomCount map[string]int32, err error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for server, so := range s.online {
+		if now.After(so.deadline) {
+			s.delOnline(server)
+			delete(s.servers, server)
+		}
+	}
+	allRoomCount = make(map[string]int32, len(s.rooms))
+	for room, count := range s.rooms {
+		allRoomCount[room] = count
+	}
+	return
+}
+
+func (s *memoryStore) RoomCount(c context.Context, rooms []string) (roomCount map[string]int32, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	roomCount = make(map[string]int32, len(rooms))
+	for _, room := range rooms {
+		roomCount[room] = s.rooms[room]
+	}
+	return
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+func TestMemoryMapping(t *testing.T) {
+	var (
+		c = context.Background()
+		s = NewMemory(time.Minute, time.Minute)
+	)
+	defer s.Close()
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: 1, Key: "key1", Server: "server1"}))
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: 1, Key: "key2", Server: "server2"}))
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: 0, Key: "guest", Server: "server1"}))
+
+	servers, err := s.ServersByKeys(c, []string{"key1", "none", "guest"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"server1", "", "server1"}, servers)
+	keys, olMids, err := s.KeysByMids(c, "", []int64{1, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"key1": "server1", "key2": "server2"}, keys)
+	assert.Equal(t, []int64{1}, olMids)
+
+	has, err := s.ExpireMapping(c, &model.Session{Mid: 1, Key: "key1"})
+	assert.Nil(t, err)
+	assert.True(t, has)
+	has, err = s.DelMapping(c, 1, "key1", "server1")
+	assert.Nil(t, err)
+	assert.True(t, has)
+	has, err = s.ExpireMapping(c, &model.Session{Mid: 1, Key: "key1"})
+	assert.Nil(t, err)
+	assert.False(t, has)
+	keys, _, err = s.KeysByMids(c, "", []int64{1})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"key2": "server2"}, keys)
+}
+
+func TestMemoryMappingApp(t *testing.T) {
+	var (
+		c = context.Background()
+		s = NewMemory(time.Minute, time.Minute)
+	)
+	defer s.Close()
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: 1, Key: "key1", Server: "server1"}))
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: 1, Key: model.EncodeKey("app1", "key2"), Server: "server2"}))
+	// a key of the default app looking like a scoped one stays in it
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: 1, Key: "app1://key3", Server: "server1"}))
+
+	keys, olMids, err := s.KeysByMids(c, "", []int64{1})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"key1": "server1", "app1://key3": "server1"}, keys)
+	keys, olMids, err = s.KeysByMids(c, "app1", []int64{1})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{model.EncodeKey("app1", "key2"): "server2"}, keys)
+	assert.Equal(t, []int64{1}, olMids)
+	_, olMids, err = s.KeysByMids(c, "app2", []int64{1})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(olMids))
+
+	has, err := s.DelMapping(c, 1, model.EncodeKey("app1", "key2"), "server2")
+	assert.Nil(t, err)
+	assert.True(t, has)
+	_, olMids, err = s.KeysByMids(c, "app1", []int64{1})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(olMids))
+	_, olMids, err = s.KeysByMids(c, "", []int64{1})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1}, olMids)
+}
+
+func TestMemoryMappingExpire(t *testing.T) {
+	var (
+		c = context.Background()
+		s = NewMemory(time.Millisecond*50, time.Minute)
+	)
+	defer s.Close()
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: 1, Key: "key1", Server: "server1"}))
+	time.Sleep(time.Millisecond * 100)
+	servers, err := s.ServersByKeys(c, []string{"key1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{""}, servers)
+	_, olMids, err := s.KeysByMids(c, "", []int64{1})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(olMids))
+}
+
+func TestMemoryServers(t *testing.T) {
+	var (
+		c = context.Background()
+		s = NewMemory(time.Minute, time.Minute)
+	)
+	defer s.Close()
+	assert.Nil(t, s.AddServerScore(c, "server1"))
+	assert.Nil(t, s.AddServerScore(c, "server2"))
+	assert.Nil(t, s.IncrServerScore(c, "server1"))
+	assert.Nil(t, s.IncrServerScore(c, "server1"))
+	assert.Nil(t, s.IncrServerScore(c, "server2"))
+	// adding keeps the score
+	assert.Nil(t, s.AddServerScore(c, "server1"))
+	list, err := s.ServersRank(c, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"server2", "server1"}, list)
+	list, err = s.ServersRank(c, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"server2"}, list)
+
+	assert.Nil(t, s.UpdateRoomCount(c, "server1", map[string]int32{"room1": 2, "room2": 1}))
+	assert.Nil(t, s.UpdateRoomCount(c, "server2", map[string]int32{"room1": 3}))
+	counts, err := s.GetAllRoomCount(c)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int32{"room1": 5, "room2": 1}, counts)
+	counts, err = s.RoomCount(c, []string{"room2", "room3"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int32{"room2": 1, "room3": 0}, counts)
+	// a room left by the server is not counted
+	assert.Nil(t, s.UpdateRoomCount(c, "server1", map[string]int32{"room1": 1}))
+	counts, err = s.GetAllRoomCount(c)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int32{"room1": 4}, counts)
+
+	assert.Nil(t, s.DelServerScore(c, "server2"))
+	counts, err = s.GetAllRoomCount(c)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int32{"room1": 1}, counts)
+	list, err = s.ServersRank(c, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"server1"}, list)
+}
+
+func TestMemoryOnlineExpire(t *testing.T) {
+	var (
+		c = context.Background()
+		s = NewMemory(time.Minute, time.Millisecond*50)
+	)
+	defer s.Close()
+	assert.Nil(t, s.AddServerScore(c, "server1"))
+	assert.Nil(t, s.UpdateRoomCount(c, "server1", map[string]int32{"room1": 2, "room2": 1}))
+	assert.Nil(t, s.UpdateRoomCount(c, "server2", map[string]int32{"room1": 3}))
+	time.Sleep(time.Millisecond * 30)
+	// server2 renews, server1 crashed
+	assert.Nil(t, s.UpdateRoomCount(c, "server2", map[string]int32{"room1": 4}))
+	time.Sleep(time.Millisecond * 30)
+	counts, err := s.GetAllRoomCount(c)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int32{"room1": 4}, counts)
+	list, err := s.ServersRank(c, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(list))
+	// a server back reports all its rooms again
+	assert.Nil(t, s.UpdateRoomCount(c, "server1", map[string]int32{"room2": 1}))
+	counts, err = s.GetAllRoomCount(c)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int32{"room1": 4, "room2": 1}, counts)
+}
+
+func TestMemorySessions(t *testing.T) {
+	var (
+		c = context.Background()
+		s = NewMemory(time.Minute, time.Minute)
+	)
+	defer s.Close()
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: 1, Key: "key1", Server: "server1", Platform: "web", Room: "live://1", ConnectTime: 1, HeartbeatTime: 1}))
+	has, err := s.ExpireMapping(c, &model.Session{Mid: 1, Key: "key1", Server: "server1", Platform: "web", Room: "live://2", HeartbeatTime: 2})
+	assert.Nil(t, err)
+	assert.True(t, has)
+	sessions, err := s.SessionsByMids(c, "", []int64{1, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []*model.Session{{Mid: 1, Key: "key1", Server: "server1", Platform: "web", Room: "live://2", ConnectTime: 1, HeartbeatTime: 2}}, sessions)
+	// a key whose session is gone is skipped and forgotten by its mid
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: 1, Key: "key2", Server: "server1"}))
+	ms := s.(*memoryStore)
+	delete(ms.keys, "key2")
+	sessions, err = s.SessionsByMids(c, "", []int64{1})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(sessions))
+	keys, _, err := s.KeysByMids(c, "", []int64{1})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"key1": "server1"}, keys)
+	s.DelMapping(c, 1, "key1", "server1")
+	sessions, err = s.SessionsByMids(c, "", []int64{1})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(sessions))
+}
@@ -0,0 +1,482 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+const (
+	_prefixMidServer = "mid_%d"     // mid -> key:server     hset
+	_prefixAppMid    = "mid_%s_%d"  // app, mid -> key:server hset
+	_prefixKeyServer = "key_%s"     // key -> server         string
+	_prefixSession   = "session_%s" // key -> session fields hset
+	_keyServers      = "servers"    // key -> server list    sortedset
+)
+
+// The hash tag keeps the online keys in one cluster slot, so a script updates
+// the snapshot of a server and the room totals together.
+const (
+	_prefixServerOnline = "online_{online}_%s"      // server -> room:count     hset
+	_keyRoomOnline      = "online_{online}_rooms"   // room -> count of all     hset
+	_keyOnlineServers   = "online_{online}_servers" // server -> deadline(ms)   sortedset
+)
+
+// keyMidServer the mids of an app are scoped, the default app "" is not.
+func keyMidServer(app string, mid int64) string {
+	if app == "" {
+		return fmt.Sprintf(_prefixMidServer, mid)
+	}
+	return fmt.Sprintf(_prefixAppMid, app, mid)
+}
+
+// keySessionMid returns the mid key of a session in the app of its key.
+func keySessionMid(mid int64, key string) string {
+	app, _ := model.DecodeKey(key)
+	return keyMidServer(app, mid)
+}
+
+func keyKeyServer(key string) string {
+	return fmt.Sprintf(_prefixKeyServer, key)
+}
+
+func keyKeySession(key string) string {
+	return fmt.Sprintf(_prefixSession, key)
+}
+
+func keyServerOnline(server string) string {
+	return fmt.Sprintf(_prefixServerOnline, server)
+}
+
+// _renewOnline replaces the snapshot of a server, and adds the differences
+// to the room totals, so the cost is the rooms of the server instead of all
+// rooms. A room total down to 0 is deleted.
+// KEYS: snapshot, totals, deadlines ARGV: server, deadline, room, count...
+var _renewOnline = redis.NewScript(`
+local delta = {}
+local old = redis.call('HGETALL', KEYS[1])
+for i = 1, #old, 2 do
+	delta[old[i]] = -tonumber(old[i+1])
+end
+redis.call('DEL', KEYS[1])
+for i = 3, #ARGV, 2 do
+	delta[ARGV[i]] = (delta[ARGV[i]] or 0) + tonumber(ARGV[i+1])
+	redis.call('HSET', KEYS[1], ARGV[i], ARGV[i+1])
+end
+for room, n in pairs(delta) do
+	if n ~= 0 and redis.call('HINCRBY', KEYS[2], room, n) <= 0 then
+		redis.call('HDEL', KEYS[2], room)
+	end
+end
+redis.call('ZADD', KEYS[3], ARGV[2], ARGV[1])
+return 0
+`)
+
+// _delOnline subtracts the snapshot of a server from the room totals and
+// deletes it, unless the server renewed it after the deadline, 0 for always.
+// KEYS: snapshot, totals, deadlines ARGV: server, deadline
+var _delOnline = redis.NewScript(`
+local deadline = redis.call('ZSCORE', KEYS[3], ARGV[1])
+if ARGV[2] ~= '0' and deadline and tonumber(deadline) > tonumber(ARGV[2]) then
+	return 0
+end
+local old = redis.call('HGETALL', KEYS[1])
+for i = 1, #old, 2 do
+	if redis.call('HINCRBY', KEYS[2], old[i], -tonumber(old[i+1])) <= 0 then
+		redis.call('HDEL', KEYS[2], old[i])
+	end
+end
+redis.call('DEL', KEYS[1])
+redis.call('ZREM', KEYS[3], ARGV[1])
+return 1
+`)
+
+// redisStore keeps the sessions and online counts in redis, a cluster, a
+// single node or a sentinel backed master. The keys of a mid, a key and a
+// room are in different cluster slots, so the commands on many of them are
+// pipelined instead of scripted, a cluster pipeline is split by node.
+//
+// The online counts are a snapshot per server and the room totals, the
+// snapshot of a server not renewed in the online expire is subtracted from
+// the totals by the next read. Nothing scans the rooms ever seen.
+type redisStore struct {
+	redis        redis.UniversalClient
+	cluster      bool
+	expire       time.Duration
+	onlineExpire time.Duration
+}
+
+// NewRedis returns a store on the redis client, a session expires after no
+// heartbeat in expire, and the room counts of a server after no renewal in onlineExpire.
+func NewRedis(client redis.UniversalClient, expire, onlineExpire time.Duration) Store {
+	_, cluster := client.(*redis.ClusterClient)
+	return &redisStore{redis: client, cluster: cluster, expire: expire, onlineExpire: onlineExpire}
+}
+
+// Ping check redis connection.
+func (s *redisStore) Ping(c context.Context) (err error) {
+	_, err = s.redis.Ping().Result()
+	return
+}
+
+// Close the client is closed by its owner.
+func (s *redisStore) Close() error {
+	return nil
+}
+
+// AddMapping add a mapping.
+// Mapping:
+// mid:用户ID key:设备ID
+// mid -> key_server  一个用户可同时登录多个设备
+// key -> server 一个用户设备对应一个server
+// key -> session 设备的平台、房间、连接和心跳时间
+func (s *redisStore) AddMapping(c context.Context, sess *model.Session) (err error) {
+	// the session is written before the key is indexed by the mid, so that
+	// a key indexed without a session is stale
+	if _, err = s.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(keyKeyServer(sess.Key), sess.Server, s.expire)
+		pipe.HMSet(keyKeySession(sess.Key), map[string]interface{}{
+			"mid":      sess.Mid,
+			"server":   sess.Server,
+			"platform": sess.Platform,
+			"room":     sess.Room,
+			"ctime":    sess.ConnectTime,
+			"htime":    sess.HeartbeatTime,
+		})
+		pipe.Expire(keyKeySession(sess.Key), s.expire)
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.Pipelined(SET HMSET EXPIRE %d,%s,%s) error(%v)", sess.Mid, sess.Key, sess.Server, err)
+		return
+	}
+	if sess.Mid <= 0 {
+		return
+	}
+	if _, err = s.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(keySessionMid(sess.Mid, sess.Key), sess.Key, sess.Server)
+		pipe.Expire(keySessionMid(sess.Mid, sess.Key), s.expire)
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.Pipelined(HSET EXPIRE %d,%s,%s) error(%v)", sess.Mid, sess.Key, sess.Server, err)
+	}
+	return
+}
+
+// ExpireMapping expire a mapping.
+func (s *redisStore) ExpireMapping(c context.Context, sess *model.Session) (has bool, err error) {
+	var expire *redis.BoolCmd
+	if _, err = s.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		if sess.Mid > 0 {
+			pipe.Expire(keySessionMid(sess.Mid, sess.Key), s.expire)
+		}
+		expire = pipe.Expire(keyKeyServer(sess.Key), s.expire)
+		pipe.HMSet(keyKeySession(sess.Key), map[string]interface{}{
+			"mid":      sess.Mid,
+			"server":   sess.Server,
+			"platform": sess.Platform,
+			"room":     sess.Room,
+			"htime":    sess.HeartbeatTime,
+		})
+		pipe.Expire(keyKeySession(sess.Key), s.expire)
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.Pipelined(EXPIRE %d,%s) error(%v)", sess.Mid, sess.Key, err)
+		return
+	}
+	has = expire.Val()
+	return
+}
+
+// DelMapping del a mapping.
+func (s *redisStore) DelMapping(c context.Context, mid int64, key, server string) (has bool, err error) {
+	var del *redis.IntCmd
+	if _, err = s.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		if mid > 0 {
+			pipe.HDel(keySessionMid(mid, key), key)
+		}
+		del = pipe.Del(keyKeyServer(key))
+		pipe.Del(keyKeySession(key))
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.Pipelined(HDEL DEL %d,%s,%s) error(%v)", mid, key, server, err)
+		return
+	}
+	has = del.Val() > 0
+	return
+}
+
+// ServersByKeys get the servers of the keys in one round trip, by MGET on a
+// single node and pipelined GETs on a cluster.
+func (s *redisStore) ServersByKeys(c context.Context, keys []string) (res []string, err error) {
+	if len(keys) == 0 {
+		return
+	}
+	args := make([]string, len(keys))
+	for i, key := range keys {
+		args[i] = keyKeyServer(key)
+	}
+	res = make([]string, len(keys))
+	if !s.cluster {
+		var vals []interface{}
+		if vals, err = s.redis.MGet(args...).Result(); err != nil {
+			g.Logger.Errorf("redis.MGet(%d keys) error(%v)", len(keys), err)
+			return
+		}
+		for i, v := range vals {
+			res[i], _ = v.(string)
+		}
+		return
+	}
+	cmds := make([]*redis.StringCmd, len(args))
+	// a key not found fails its GET with redis.Nil
+	s.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, arg := range args {
+			cmds[i] = pipe.Get(arg)
+		}
+		return nil
+	})
+	for i, cmd := range cmds {
+		if err = cmd.Err(); err != nil && err != redis.Nil {
+			g.Logger.Errorf("redis.Pipelined(GET %d keys) error(%v)", len(keys), err)
+			return
+		}
+		res[i] = cmd.Val()
+	}
+	err = nil
+	return
+}
+
+// KeysByMids get the key servers of the mids in one pipeline.
+func (s *redisStore) KeysByMids(c context.Context, app string, mids []int64) (ress map[string]string, olMids []int64, err error) {
+	ress = make(map[string]string)
+	if len(mids) == 0 {
+		return
+	}
+	cmds := make([]*redis.StringStringMapCmd, len(mids))
+	if _, err = s.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, mid := range mids {
+			cmds[i] = pipe.HGetAll(keyMidServer(app, mid))
+		}
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.Pipelined(HGETALL %d mids) error(%v)", len(mids), err)
+		return
+	}
+	for i, cmd := range cmds {
+		res := cmd.Val()
+		if len(res) > 0 {
+			olMids = append(olMids, mids[i])
+		}
+		for k, v := range res {
+			ress[k] = v
+		}
+	}
+	return
+}
+
+// SessionsByMids get the keys of the mids in one pipeline, and their
+// sessions in another. A key without a session is stale, it is skipped and
+// removed from the keys of its mid.
+func (s *redisStore) SessionsByMids(c context.Context, app string, mids []int64) (sessions []*model.Session, err error) {
+	if len(mids) == 0 {
+		return
+	}
+	keys := make([]*redis.StringStringMapCmd, len(mids))
+	if _, err = s.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, mid := range mids {
+			keys[i] = pipe.HGetAll(keyMidServer(app, mid))
+		}
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.Pipelined(HGETALL %d mids) error(%v)", len(mids), err)
+		return
+	}
+	for i, cmd := range keys {
+		for key, server := range cmd.Val() {
+			sessions = append(sessions, &model.Session{Mid: mids[i], Key: key, Server: server})
+		}
+	}
+	if len(sessions) == 0 {
+		return
+	}
+	cmds := make([]*redis.StringStringMapCmd, len(sessions))
+	if _, err = s.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, sess := range sessions {
+			cmds[i] = pipe.HGetAll(keyKeySession(sess.Key))
+		}
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.Pipelined(HGETALL %d sessions) error(%v)", len(sessions), err)
+		return
+	}
+	var stale []*model.Session
+	online := sessions[:0]
+	for i, cmd := range cmds {
+		sess := sessions[i]
+		res := cmd.Val()
+		if len(res) == 0 {
+			stale = append(stale, sess)
+			continue
+		}
+		if server := res["server"]; server != "" {
+			sess.Server = server
+		}
+		sess.Platform = res["platform"]
+		sess.Room = res["room"]
+		sess.ConnectTime, _ = strconv.ParseInt(res["ctime"], 10, 64)
+		sess.HeartbeatTime, _ = strconv.ParseInt(res["htime"], 10, 64)
+		online = append(online, sess)
+	}
+	if len(stale) > 0 {
+		s.delStaleKeys(app, stale)
+	}
+	return online, nil
+}
+
+// delStaleKeys removes the keys without a session from the keys of their
+// mids, the keys are left on an error.
+func (s *redisStore) delStaleKeys(app string, stale []*model.Session) {
+	if _, err := s.redis.Pipelined(func(pipe redis.Pipeliner) error {
+		for _, sess := range stale {
+			pipe.HDel(keyMidServer(app, sess.Mid), sess.Key)
+		}
+		return nil
+	}); err != nil {
+		g.Logger.Errorf("redis.Pipelined(HDEL %d stale keys) error(%v)", len(stale), err)
+	}
+}
+
+//add server info
+func (s *redisStore) AddServerScore(c context.Context, server string) (err error) {
+	if err = s.redis.ZAddNX(_keyServers, redis.Z{Member: server, Score: 0}).Err(); err != nil {
+		g.Logger.Errorf("redis.Do(ZADD NX %s,%s) error(%v)", _keyServers, server, err)
+	}
+	return
+}
+
+//del server info
+func (s *redisStore) DelServerScore(c context.Context, server string) (err error) {
+	if err = s.redis.ZRem(_keyServers, server).Err(); err != nil {
+		g.Logger.Errorf("redis.Do(ZREM %s,%s) error(%v)", _keyServers, server, err)
+		return
+	}
+	_, err = s.delOnline(server, 0)
+	return
+}
+
+// delOnline deletes the room counts of the server, unless it renewed them
+// after the deadline in ms, 0 for always.
+func (s *redisStore) delOnline(server string, deadline int64) (ok bool, err error) {
+	var res interface{}
+	if res, err = _delOnline.Run(s.redis, []string{keyServerOnline(server), _keyRoomOnline, _keyOnlineServers}, server, deadline).Result(); err != nil {
+		g.Logger.Errorf("redis.Eval(delOnline %s,%d) error(%v)", server, deadline, err)
+		return
+	}
+	n, _ := res.(int64)
+	ok = n == 1
+	return
+}
+
+//incr server score
+func (s *redisStore) IncrServerScore(c context.Context, server string) (err error) {
+	if err = s.redis.ZIncrBy(_keyServers, 1, server).Err(); err != nil {
+		g.Logger.Errorf("redis.Do(ZIncrBy %s,%s) error(%v)", _keyServers, server, err)
+	}
+	return
+}
+
+//decr server score
+func (s *redisStore) DecrServerScore(c context.Context, server string) (err error) {
+	if err = s.redis.ZIncrBy(_keyServers, -1, server).Err(); err != nil {
+		g.Logger.Errorf("redis.Do(ZIncrBy %s,%s) error(%v)", _keyServers, server, err)
+	}
+	return
+}
+
+//get server list top
+func (s *redisStore) ServersRank(c context.Context, num int64) (list []string, err error) {
+	if list, err = s.redis.ZRange(_keyServers, 0, num).Result(); err != nil {
+		g.Logger.Errorf("redis.Do(ZRange %s) error(%v)", _keyServers, err)
+	}
+	return
+}
+
+// UpdateRoomCount replaces the snapshot of the server and renews its deadline.
+func (s *redisStore) UpdateRoomCount(c context.Context, server string, roomCount map[string]int32) (err error) {
+	deadline := time.Now().Add(s.onlineExpire).UnixNano() / int64(time.Millisecond)
+	args := make([]interface{}, 0, 2+len(roomCount)*2)
+	args = append(args, server, deadline)
+	for room, count := range roomCount {
+		args = append(args, room, count)
+	}
+	if err = _renewOnline.Run(s.redis, []string{keyServerOnline(server), _keyRoomOnline, _keyOnlineServers}, args...).Err(); err != nil {
+		g.Logger.Errorf("redis.Eval(renewOnline %s,%d rooms) error(%v)", server, len(roomCount), err)
+	}
+	return
+}
+
+// GetAllRoomCount deletes the servers expired, and gets the room totals.
+func (s *redisStore) GetAllRoomCount(c context.Context) (allRoomCount map[string]int32, err error) {
+	if err = s.expireOnline(time.Now().UnixNano() / int64(time.Millisecond)); err != nil {
+		return
+	}
+	var totals map[string]string
+	if totals, err = s.redis.HGetAll(_keyRoomOnline).Result(); err != nil {
+		g.Logger.Errorf("redis.Do(HGETALL %s) error(%v)", _keyRoomOnline, err)
+		return
+	}
+	allRoomCount = make(map[string]int32, len(totals))
+	for room, v := range totals {
+		count, _ := strconv.ParseInt(v, 10, 32)
+		allRoomCount[room] = int32(count)
+	}
+	return
+}
+
+// RoomCount gets the totals of the rooms.
+func (s *redisStore) RoomCount(c context.Context, rooms []string) (roomCount map[string]int32, err error) {
+	roomCount = make(map[string]int32, len(rooms))
+	if len(rooms) == 0 {
+		return
+	}
+	var vals []interface{}
+	if vals, err = s.redis.HMGet(_keyRoomOnline, rooms...).Result(); err != nil {
+		g.Logger.Errorf("redis.Do(HMGET %s %d rooms) error(%v)", _keyRoomOnline, len(rooms), err)
+		return
+	}
+	for i, v := range vals {
+		str, _ := v.(string)
+		count, _ := strconv.ParseInt(str, 10, 32)
+		roomCount[rooms[i]] = int32(count)
+	}
+	return
+}
+
+// expireOnline deletes the room counts and the score of the servers not
+// renewed before now in ms, a comet crashed or cut off.
+func (s *redisStore) expireOnline(now int64) (err error) {
+	var servers []string
+	if servers, err = s.redis.ZRangeByScore(_keyOnlineServers, redis.ZRangeBy{Min: "-inf", Max: strconv.FormatInt(now, 10)}).Result(); err != nil {
+		g.Logger.Errorf("redis.Do(ZRANGEBYSCORE %s) error(%v)", _keyOnlineServers, err)
+		return
+	}
+	for _, server := range servers {
+		var ok bool
+		if ok, err = s.delOnline(server, now); err != nil {
+			return
+		}
+		if ok {
+			g.Logger.Warnf("server:%s online expired", server)
+			if err = s.redis.ZRem(_keyServers, server).Err(); err != nil {
+				g.Logger.Errorf("redis.Do(ZREM %s,%s) error(%v)", _keyServers, server, err)
+				return
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// testRedis connects to GOIM_TEST_REDIS, a node or the comma separated nodes
+// of a cluster, or to an in-process redis if it is not set.
+func testRedis(tb testing.TB) redis.UniversalClient {
+	addrs := os.Getenv("GOIM_TEST_REDIS")
+	if addrs == "" {
+		mr, err := miniredis.Run()
+		if err != nil {
+			tb.Fatal(err)
+		}
+		tb.Cleanup(mr.Close)
+		addrs = mr.Addr()
+	}
+	client := redis.NewUniversalClient(&redis.UniversalOptions{Addrs: strings.Split(addrs, ",")})
+	if err := client.Ping().Err(); err != nil {
+		tb.Fatal(err)
+	}
+	return client
+}
+
+func TestRedisMapping(t *testing.T) {
+	var (
+		c      = context.Background()
+		client = testRedis(t)
+		s      = NewRedis(client, time.Minute, time.Minute)
+		mid    = time.Now().UnixNano()
+		key    = fmt.Sprintf("test_key_%d", mid)
+	)
+	defer client.Close()
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: mid, Key: key + "1", Server: "server1"}))
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: mid, Key: key + "2", Server: "server2"}))
+
+	servers, err := s.ServersByKeys(c, []string{key + "1", key + "none", key + "2"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"server1", "", "server2"}, servers)
+	keys, olMids, err := s.KeysByMids(c, "", []int64{mid, mid + 1})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{key + "1": "server1", key + "2": "server2"}, keys)
+	assert.Equal(t, []int64{mid}, olMids)
+
+	has, err := s.ExpireMapping(c, &model.Session{Mid: mid, Key: key + "1", Server: "server1", Platform: "web", Room: "live://1", HeartbeatTime: 2})
+	assert.Nil(t, err)
+	assert.True(t, has)
+	sessions, err := s.SessionsByMids(c, "", []int64{mid})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(sessions))
+	for _, sess := range sessions {
+		if sess.Key == key+"1" {
+			assert.Equal(t, &model.Session{Mid: mid, Key: key + "1", Server: "server1", Platform: "web", Room: "live://1", HeartbeatTime: 2}, sess)
+		}
+	}
+
+	has, err = s.DelMapping(c, mid, key+"1", "server1")
+	assert.Nil(t, err)
+	assert.True(t, has)
+	has, err = s.ExpireMapping(c, &model.Session{Mid: mid, Key: key + "1"})
+	assert.Nil(t, err)
+	assert.False(t, has)
+	keys, _, err = s.KeysByMids(c, "", []int64{mid})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{key + "2": "server2"}, keys)
+
+	// a key whose session is gone is skipped and removed from its mid
+	assert.Nil(t, s.AddMapping(c, &model.Session{Mid: mid, Key: key + "3", Server: "server3"}))
+	assert.Nil(t, client.Del(keyKeySession(key+"3")).Err())
+	sessions, err = s.SessionsByMids(c, "", []int64{mid})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(sessions))
+	assert.Equal(t, key+"2", sessions[0].Key)
+	keys, _, err = s.KeysByMids(c, "", []int64{mid})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{key + "2": "server2"}, keys)
+	s.DelMapping(c, mid, key+"2", "server2")
+}
+
+func TestRedisRoomCount(t *testing.T) {
+	var (
+		c      = context.Background()
+		client = testRedis(t)
+		s      = NewRedis(client, time.Minute, time.Minute)
+		room   = fmt.Sprintf("test://%d", time.Now().UnixNano())
+	)
+	defer client.Close()
+	// a flushed script is loaded again
+	client.ScriptFlush()
+	assert.Nil(t, s.UpdateRoomCount(c, "test_server1", map[string]int32{room + "1": 2, room + "2": 1}))
+	assert.Nil(t, s.UpdateRoomCount(c, "test_server2", map[string]int32{room + "1": 3}))
+	counts, err := s.GetAllRoomCount(c)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(5), counts[room+"1"])
+	assert.Equal(t, int32(1), counts[room+"2"])
+	counts, err = s.RoomCount(c, []string{room + "2", room + "3"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int32{room + "2": 1, room + "3": 0}, counts)
+
+	assert.Nil(t, s.UpdateRoomCount(c, "test_server1", map[string]int32{room + "1": 1}))
+	assert.Nil(t, s.DelServerScore(c, "test_server2"))
+	counts, err = s.GetAllRoomCount(c)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), counts[room+"1"])
+	assert.Equal(t, int32(0), counts[room+"2"])
+	s.DelServerScore(c, "test_server1")
+}
+
+func TestRedisOnlineExpire(t *testing.T) {
+	var (
+		c      = context.Background()
+		client = testRedis(t)
+		s      = NewRedis(client, time.Minute, time.Millisecond*100)
+		room   = fmt.Sprintf("test://%d", time.Now().UnixNano())
+	)
+	defer client.Close()
+	assert.Nil(t, s.AddServerScore(c, "test_server3"))
+	assert.Nil(t, s.UpdateRoomCount(c, "test_server3", map[string]int32{room: 2}))
+	assert.Nil(t, s.UpdateRoomCount(c, "test_server4", map[string]int32{room: 3}))
+	time.Sleep(time.Millisecond * 60)
+	assert.Nil(t, s.UpdateRoomCount(c, "test_server4", map[string]int32{room: 4}))
+	time.Sleep(time.Millisecond * 60)
+	counts, err := s.GetAllRoomCount(c)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(4), counts[room])
+	score, err := client.ZScore(_keyServers, "test_server3").Result()
+	assert.Equal(t, redis.Nil, err, "score %v", score)
+	s.DelServerScore(c, "test_server4")
+}
+
+// benchMids maps n mids to a key each, the mids are pushed to by the benchmarks.
+func benchMids(b *testing.B, s Store, n int) (mids []int64, keys []string) {
+	c := context.Background()
+	for i := 0; i < n; i++ {
+		mid := int64(1000000000 + i)
+		key := fmt.Sprintf("bench_key_%d", i)
+		if err := s.AddMapping(c, &model.Session{Mid: mid, Key: key, Server: "bench_server"}); err != nil {
+			b.Fatal(err)
+		}
+		mids = append(mids, mid)
+		keys = append(keys, key)
+	}
+	return
+}
+
+// keysByMidsSerial is KeysByMids before pipelining, one HGETALL per mid.
+func keysByMidsSerial(client redis.UniversalClient, mids []int64) (ress map[string]string, olMids []int64, err error) {
+	ress = make(map[string]string)
+	for _, mid := range mids {
+		var res map[string]string
+		if res, err = client.HGetAll(keyMidServer("", mid)).Result(); err != nil {
+			return
+		}
+		if len(res) > 0 {
+			olMids = append(olMids, mid)
+		}
+		for k, v := range res {
+			ress[k] = v
+		}
+	}
+	return
+}
+
+// serversByKeysSerial is ServersByKeys before batching, one GET per key.
+func serversByKeysSerial(client redis.UniversalClient, keys []string) (res []string, err error) {
+	for _, key := range keys {
+		val, err := client.Get(keyKeyServer(key)).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		res = append(res, val)
+	}
+	return
+}
+
+// The lookups of a push to 1000 mids, then to their 1000 keys:
+//
+//	GOIM_TEST_REDIS=127.0.0.1:6379 go test -run NONE -bench 1000 ./logic/dao/store/
+func BenchmarkPushMids1000Serial(b *testing.B) {
+	client := testRedis(b)
+	defer client.Close()
+	mids, _ := benchMids(b, NewRedis(client, time.Minute, time.Minute), 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := keysByMidsSerial(client, mids); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPushMids1000(b *testing.B) {
+	client := testRedis(b)
+	defer client.Close()
+	s := NewRedis(client, time.Minute, time.Minute)
+	mids, _ := benchMids(b, s, 1000)
+	c := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := s.KeysByMids(c, "", mids); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPushKeys1000Serial(b *testing.B) {
+	client := testRedis(b)
+	defer client.Close()
+	_, keys := benchMids(b, NewRedis(client, time.Minute, time.Minute), 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := serversByKeysSerial(client, keys); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPushKeys1000(b *testing.B) {
+	client := testRedis(b)
+	defer client.Close()
+	s := NewRedis(client, time.Minute, time.Minute)
+	_, keys := benchMids(b, s, 1000)
+	c := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.ServersByKeys(c, keys); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// The kinds of store.
+const (
+	KindRedis  = "redis"
+	KindMemory = "memory"
+)
+
+// Store keeps the sessions mapping mids and keys to comet servers, the
+// online scores of the servers and the online counts of the rooms.
+type Store interface {
+	Ping(c context.Context) error
+	Close() error
+
+	// AddMapping maps the mid and the key of the session to its server, and
+	// keeps the session. The mid of a key scoped to an app is in the app.
+	AddMapping(c context.Context, s *model.Session) error
+	// ExpireMapping renews a mapping, and updates the room, platform and
+	// heartbeat time of the session, has is false if it is expired.
+	ExpireMapping(c context.Context, s *model.Session) (has bool, err error)
+	// DelMapping deletes a mapping, has is false if it is not found.
+	DelMapping(c context.Context, mid int64, key, server string) (has bool, err error)
+	// ServersByKeys gets the servers of the keys, empty if offline.
+	ServersByKeys(c context.Context, keys []string) ([]string, error)
+	// KeysByMids gets the key servers of the mids of the app, and the mids online.
+	KeysByMids(c context.Context, app string, mids []int64) (keyServers map[string]string, olMids []int64, err error)
+	// SessionsByMids gets the sessions of the mids of the app online.
+	SessionsByMids(c context.Context, app string, mids []int64) ([]*model.Session, error)
+
+	// AddServerScore adds the server if not found.
+	AddServerScore(c context.Context, server string) error
+	// DelServerScore deletes the server and its room counts.
+	DelServerScore(c context.Context, server string) error
+	IncrServerScore(c context.Context, server string) error
+	DecrServerScore(c context.Context, server string) error
+	// ServersRank gets the servers ordered by score, num is the last index or -1 for all.
+	ServersRank(c context.Context, num int64) ([]string, error)
+
+	// UpdateRoomCount replaces the room counts of the server, they are kept
+	// until the server does not renew them in the online expire.
+	UpdateRoomCount(c context.Context, server string, roomCount map[string]int32) error
+	// GetAllRoomCount gets the counts of all rooms, the servers expired are
+	// deleted first.
+	GetAllRoomCount(c context.Context) (map[string]int32, error)
+	// RoomCount gets the counts of the rooms, 0 if empty.
+	RoomCount(c context.Context, rooms []string) (map[string]int32, error)
+}
+
+// New returns the store of the config, a redis store uses the client.
+func New(c *conf.Store, client redis.UniversalClient) (Store, error) {
+	switch c.Kind {
+	case KindRedis:
+		if client == nil {
+			return nil, fmt.Errorf("store: redis store needs redis")
+		}
+		return NewRedis(client, time.Duration(c.Expire), time.Duration(c.OnlineExpire)), nil
+	case KindMemory:
+		return NewMemory(time.Duration(c.Expire), time.Duration(c.OnlineExpire)), nil
+	default:
+		return nil, fmt.Errorf("store: unknown kind %q", c.Kind)
+	}
+}
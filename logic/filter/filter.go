@@ -0,0 +1,142 @@
+// Package filter passes, modifies or refuses the messages sent by clients.
+package filter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/swanky2009/goim/logic/g/conf"
+	xstr "github.com/swanky2009/goim/pkg/strings"
+)
+
+const (
+	// KindWords replaces or refuses sensitive words.
+	KindWords = "words"
+	// KindLength refuses long messages.
+	KindLength = "length"
+	// KindRate refuses the messages of a mid over a rate.
+	KindRate = "rate"
+	// KindHTTP asks a moderation webhook.
+	KindHTTP = "http"
+)
+
+// Message a message sent by a client, a filter may change Msg.
+type Message struct {
+	Mid      int64
+	Key      string
+	Room     string
+	Platform string
+	Op       int32
+	Msg      []byte
+}
+
+// Filter passes a message, changes its Msg or refuses it with a
+// *model.Reject. A filter failing handles it by itself, so any error
+// refuses the message.
+type Filter interface {
+	Filter(c context.Context, m *Message) error
+}
+
+type stage struct {
+	ops []xstr.Int32Range
+	f   Filter
+}
+
+// Chain runs the filters matching the operation of a message in order, until
+// one refuses it. A nil chain passes every message.
+type Chain struct {
+	stages []*stage
+}
+
+// New returns the chain of the config, the rate filters count by incr, or in
+// process if nil.
+func New(c *conf.Filter, incr Counter) (ch *Chain, err error) {
+	ch = new(Chain)
+	if c == nil {
+		return
+	}
+	if incr == nil {
+		incr = newLocalCounter().Incr
+	}
+	for _, fc := range c.Chain {
+		var f Filter
+		switch fc.Kind {
+		case KindWords:
+			var words []string
+			if words, err = loadWords(fc.Words, fc.WordsFile); err == nil {
+				f = NewWords(words, fc.Replace, fc.Reject)
+			}
+		case KindLength:
+			if fc.MaxLength <= 0 {
+				err = fmt.Errorf("filter: length needs max_length")
+			}
+			f = NewLength(fc.MaxLength)
+		case KindRate:
+			if fc.Limit <= 0 {
+				err = fmt.Errorf("filter: rate needs limit")
+			}
+			f = NewRate(fc.Limit, time.Duration(fc.Interval), incr)
+		case KindHTTP:
+			f, err = NewHTTP(fc.URL, time.Duration(fc.Timeout), fc.FailClosed)
+		default:
+			err = fmt.Errorf("filter: unknown kind %q, supports:[words,length,rate,http]", fc.Kind)
+		}
+		if err == nil {
+			err = ch.Add(fc.Ops, f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// Add appends a filter of the operations and ranges, eg. "4,100-1000".
+func (ch *Chain) Add(ops string, f Filter) (err error) {
+	rs, err := xstr.SplitInt32Ranges(ops, ",")
+	if err != nil || len(rs) == 0 {
+		return fmt.Errorf("filter: invalid ops %q", ops)
+	}
+	ch.stages = append(ch.stages, &stage{ops: rs, f: f})
+	return
+}
+
+// Filter runs the filters on the message, it is refused by the first error.
+func (ch *Chain) Filter(c context.Context, m *Message) (err error) {
+	if ch == nil {
+		return
+	}
+	for _, st := range ch.stages {
+		if !xstr.InInt32Ranges(st.ops, m.Op) {
+			continue
+		}
+		if err = st.f.Filter(c, m); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// loadWords returns the words and the words of the file, one per line, the
+// empty lines and the lines starting with # are skipped.
+func loadWords(words []string, file string) ([]string, error) {
+	if file == "" {
+		return words, nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if w := strings.TrimSpace(scanner.Text()); w != "" && !strings.HasPrefix(w, "#") {
+			words = append(words, w)
+		}
+	}
+	return words, scanner.Err()
+}
@@ -0,0 +1,120 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/model"
+	xtime "github.com/swanky2009/goim/pkg/time"
+)
+
+func rejectCode(err error) int32 {
+	if r := model.AsReject(err); r != nil {
+		return r.Code
+	}
+	return 0
+}
+
+func TestWords(t *testing.T) {
+	var (
+		c = context.Background()
+		w = NewWords([]string{"bad", "badword", "坏蛋"}, "*", false)
+	)
+	m := &Message{Msg: []byte("a BadWord, bad and 坏蛋!")}
+	assert.Nil(t, w.Filter(c, m))
+	assert.Equal(t, "a *******, *** and **!", string(m.Msg))
+	m = &Message{Msg: []byte("good")}
+	assert.Nil(t, w.Filter(c, m))
+	assert.Equal(t, "good", string(m.Msg))
+	// binary passes
+	m = &Message{Msg: []byte{'b', 'a', 'd', 0xff}}
+	assert.Nil(t, w.Filter(c, m))
+	assert.Equal(t, []byte{'b', 'a', 'd', 0xff}, m.Msg)
+
+	w = NewWords([]string{"bad"}, "*", true)
+	assert.Equal(t, model.RejectFiltered, rejectCode(w.Filter(c, &Message{Msg: []byte("so bad")})))
+}
+
+func TestLengthAndRate(t *testing.T) {
+	c := context.Background()
+	assert.Nil(t, NewLength(3).Filter(c, &Message{Msg: []byte("abc")}))
+	assert.Equal(t, model.RejectTooLong, rejectCode(NewLength(3).Filter(c, &Message{Msg: []byte("abcd")})))
+
+	r := NewRate(2, time.Millisecond*50, newLocalCounter().Incr)
+	assert.Nil(t, r.Filter(c, &Message{Mid: 1}))
+	assert.Nil(t, r.Filter(c, &Message{Mid: 1}))
+	assert.Equal(t, model.RejectRateLimited, rejectCode(r.Filter(c, &Message{Mid: 1})))
+	assert.Nil(t, r.Filter(c, &Message{Mid: 2}))
+	// guests are counted by key
+	assert.Nil(t, r.Filter(c, &Message{Key: "guest1"}))
+	assert.Nil(t, r.Filter(c, &Message{Key: "guest2"}))
+	time.Sleep(time.Millisecond * 60)
+	assert.Nil(t, r.Filter(c, &Message{Mid: 1}))
+}
+
+func TestHTTP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := new(hookReq)
+		json.NewDecoder(r.Body).Decode(req)
+		switch string(req.Msg) {
+		case "spam":
+			json.NewEncoder(w).Encode(&hookReply{Action: ActionReject, Message: "spam"})
+		case "shout":
+			json.NewEncoder(w).Encode(&hookReply{Action: ActionModify, Msg: []byte("SHOUT")})
+		case "slow":
+			time.Sleep(time.Millisecond * 100)
+			fallthrough
+		default:
+			json.NewEncoder(w).Encode(&hookReply{Action: ActionPass})
+		}
+	}))
+	defer ts.Close()
+	c := context.Background()
+	h, err := NewHTTP(ts.URL, time.Millisecond*50, false)
+	assert.Nil(t, err)
+	m := &Message{Msg: []byte("hi")}
+	assert.Nil(t, h.Filter(c, m))
+	assert.Equal(t, "hi", string(m.Msg))
+	m = &Message{Msg: []byte("shout")}
+	assert.Nil(t, h.Filter(c, m))
+	assert.Equal(t, "SHOUT", string(m.Msg))
+	err = h.Filter(c, &Message{Msg: []byte("spam")})
+	assert.Equal(t, &model.Reject{Code: model.RejectFiltered, Message: "spam"}, err)
+	// a timeout passes, unless fail closed
+	assert.Nil(t, h.Filter(c, &Message{Msg: []byte("slow")}))
+	h, _ = NewHTTP(ts.URL, time.Millisecond*50, true)
+	assert.Equal(t, model.RejectFiltered, rejectCode(h.Filter(c, &Message{Msg: []byte("slow")})))
+}
+
+func TestChain(t *testing.T) {
+	c := context.Background()
+	ch, err := New(&conf.Filter{Chain: []*conf.FilterStage{
+		{Kind: KindWords, Ops: "4", Words: []string{"bad"}, Replace: "*"},
+		{Kind: KindLength, Ops: "4,1000", MaxLength: 5},
+		{Kind: KindRate, Ops: "4", Limit: 100, Interval: xtime.Duration(time.Second)},
+	}}, nil)
+	assert.Nil(t, err)
+	m := &Message{Op: 4, Msg: []byte("bad")}
+	assert.Nil(t, ch.Filter(c, m))
+	assert.Equal(t, "***", string(m.Msg))
+	// words are not filtered for 1000, but length is
+	m = &Message{Op: 1000, Msg: []byte("bad")}
+	assert.Nil(t, ch.Filter(c, m))
+	assert.Equal(t, "bad", string(m.Msg))
+	assert.Equal(t, model.RejectTooLong, rejectCode(ch.Filter(c, &Message{Op: 1000, Msg: []byte("badbad")})))
+	// receipts are not filtered
+	assert.Nil(t, ch.Filter(c, &Message{Op: 20, Msg: []byte("badbad")}))
+	// a nil chain passes
+	assert.Nil(t, (*Chain)(nil).Filter(c, &Message{Op: 4}))
+
+	_, err = New(&conf.Filter{Chain: []*conf.FilterStage{{Kind: "regexp", Ops: "4"}}}, nil)
+	assert.NotNil(t, err)
+	_, err = New(&conf.Filter{Chain: []*conf.FilterStage{{Kind: KindLength, Ops: "4"}}}, nil)
+	assert.NotNil(t, err)
+}
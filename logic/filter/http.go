@@ -0,0 +1,123 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// The actions replied by a moderation hook.
+const (
+	ActionPass   = "pass"
+	ActionModify = "modify"
+	ActionReject = "reject"
+)
+
+// hookReq the json body posted to a moderation hook, msg is base64.
+type hookReq struct {
+	Mid      int64  `json:"mid"`
+	Key      string `json:"key"`
+	Room     string `json:"room"`
+	Platform string `json:"platform"`
+	Op       int32  `json:"op"`
+	Msg      []byte `json:"msg"`
+}
+
+// hookReply the json replied by a moderation hook, msg is the base64 of the
+// message modified, message the reason of a reject.
+type hookReply struct {
+	Action  string `json:"action"`
+	Msg     []byte `json:"msg"`
+	Message string `json:"message"`
+}
+
+// HTTP posts the messages to a moderation hook, which passes, modifies or
+// refuses them. A message passes when the hook fails, unless failClosed.
+type HTTP struct {
+	url        string
+	timeout    time.Duration
+	failClosed bool
+	client     *http.Client
+}
+
+// NewHTTP returns a filter of the hook url.
+func NewHTTP(url string, timeout time.Duration, failClosed bool) (*HTTP, error) {
+	if url == "" {
+		return nil, errors.New("filter: http needs url")
+	}
+	return &HTTP{url: url, timeout: timeout, failClosed: failClosed, client: &http.Client{}}, nil
+}
+
+func (h *HTTP) Filter(c context.Context, m *Message) error {
+	reply, err := h.moderate(c, m)
+	if err != nil {
+		g.Logger.Errorf("filter http moderate(%s,%d) error(%v)", h.url, m.Mid, err)
+		if h.failClosed {
+			return &model.Reject{Code: model.RejectFiltered, Message: "moderation unavailable"}
+		}
+		return nil
+	}
+	switch reply.Action {
+	case ActionModify:
+		m.Msg = reply.Msg
+	case ActionReject:
+		msg := reply.Message
+		if msg == "" {
+			msg = "refused by moderation"
+		}
+		return &model.Reject{Code: model.RejectFiltered, Message: msg}
+	}
+	return nil
+}
+
+func (h *HTTP) moderate(c context.Context, m *Message) (reply *hookReply, err error) {
+	var (
+		b    []byte
+		req  *http.Request
+		resp *http.Response
+	)
+	if b, err = json.Marshal(&hookReq{
+		Mid:      m.Mid,
+		Key:      m.Key,
+		Room:     m.Room,
+		Platform: m.Platform,
+		Op:       m.Op,
+		Msg:      m.Msg,
+	}); err != nil {
+		return
+	}
+	if req, err = http.NewRequest("POST", h.url, bytes.NewReader(b)); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c, cancel := context.WithTimeout(c, h.timeout)
+	defer cancel()
+	if resp, err = h.client.Do(req.WithContext(c)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	reply = new(hookReply)
+	if err = json.NewDecoder(resp.Body).Decode(reply); err != nil {
+		return
+	}
+	switch reply.Action {
+	case ActionPass, ActionReject:
+	case ActionModify:
+		if len(reply.Msg) == 0 {
+			err = errors.New("modify without msg")
+		}
+	default:
+		err = fmt.Errorf("unknown action %q", reply.Action)
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// Length refuses the messages longer than a max bytes.
+type Length struct {
+	max int
+}
+
+// NewLength returns a filter of the max bytes.
+func NewLength(max int) *Length {
+	return &Length{max: max}
+}
+
+func (l *Length) Filter(c context.Context, m *Message) error {
+	if len(m.Msg) > l.max {
+		return &model.Reject{Code: model.RejectTooLong, Message: fmt.Sprintf("%d bytes, max %d", len(m.Msg), l.max)}
+	}
+	return nil
+}
+
+// Counter increases the count of the key in the current window of the
+// duration, and returns the count.
+type Counter func(c context.Context, key string, window time.Duration) (int64, error)
+
+// Rate refuses the messages of a mid over a limit per interval, a guest is
+// counted by its key. The messages pass if the counter fails.
+type Rate struct {
+	limit    int64
+	interval time.Duration
+	incr     Counter
+}
+
+// NewRate returns a filter of limit messages per interval counted by incr.
+func NewRate(limit int64, interval time.Duration, incr Counter) *Rate {
+	return &Rate{limit: limit, interval: interval, incr: incr}
+}
+
+func (r *Rate) Filter(c context.Context, m *Message) error {
+	key := "filter_mid_" + strconv.FormatInt(m.Mid, 10)
+	if m.Mid == 0 {
+		key = "filter_key_" + m.Key
+	}
+	n, err := r.incr(c, key, r.interval)
+	if err != nil {
+		g.Logger.Errorf("filter rate incr(%s) error(%v)", key, err)
+		return nil
+	}
+	if n > r.limit {
+		return &model.Reject{Code: model.RejectRateLimited, Message: fmt.Sprintf("max %d messages per %s", r.limit, r.interval)}
+	}
+	return nil
+}
+
+type window struct {
+	start time.Time
+	n     int64
+}
+
+// localCounter counts in fixed windows in process, the windows ended are
+// swept once a window.
+type localCounter struct {
+	mu      sync.Mutex
+	windows map[string]*window
+	swept   time.Time
+}
+
+func newLocalCounter() *localCounter {
+	return &localCounter{windows: make(map[string]*window), swept: time.Now()}
+}
+
+func (lc *localCounter) Incr(c context.Context, key string, d time.Duration) (int64, error) {
+	now := time.Now()
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if now.Sub(lc.swept) > d {
+		for k, w := range lc.windows {
+			if now.Sub(w.start) >= d {
+				delete(lc.windows, k)
+			}
+		}
+		lc.swept = now
+	}
+	w, ok := lc.windows[key]
+	if !ok || now.Sub(w.start) >= d {
+		w = &window{start: now}
+		lc.windows[key] = w
+	}
+	w.n++
+	return w.n, nil
+}
@@ -0,0 +1,97 @@
+package filter
+
+import (
+	"context"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/swanky2009/goim/logic/model"
+)
+
+type trieNode struct {
+	next map[rune]*trieNode
+	end  bool
+}
+
+// Words replaces each rune of the sensitive words in a message, or refuses
+// it. Words are matched case insensitively by a trie, the longest first.
+type Words struct {
+	root    *trieNode
+	replace string
+	reject  bool
+}
+
+// NewWords returns a filter of the words.
+func NewWords(words []string, replace string, reject bool) *Words {
+	w := &Words{root: new(trieNode), replace: replace, reject: reject}
+	for _, word := range words {
+		w.add(word)
+	}
+	return w
+}
+
+func (w *Words) add(word string) {
+	if word == "" {
+		return
+	}
+	node := w.root
+	for _, r := range word {
+		r = unicode.ToLower(r)
+		if node.next == nil {
+			node.next = make(map[rune]*trieNode)
+		}
+		next, ok := node.next[r]
+		if !ok {
+			next = new(trieNode)
+			node.next[r] = next
+		}
+		node = next
+	}
+	node.end = true
+}
+
+// match returns the runes of the longest word at rs[i:], 0 if none.
+func (w *Words) match(rs []rune, i int) (n int) {
+	node := w.root
+	for j := i; j < len(rs) && node.next != nil; j++ {
+		if node = node.next[unicode.ToLower(rs[j])]; node == nil {
+			break
+		}
+		if node.end {
+			n = j - i + 1
+		}
+	}
+	return
+}
+
+// Filter skips the messages which are not utf-8 text.
+func (w *Words) Filter(c context.Context, m *Message) error {
+	if !utf8.Valid(m.Msg) {
+		return nil
+	}
+	var (
+		found bool
+		rs    = []rune(string(m.Msg))
+		out   = make([]rune, 0, len(rs))
+	)
+	for i := 0; i < len(rs); {
+		n := w.match(rs, i)
+		if n == 0 {
+			out = append(out, rs[i])
+			i++
+			continue
+		}
+		if w.reject {
+			return &model.Reject{Code: model.RejectFiltered, Message: "the message has sensitive words"}
+		}
+		found = true
+		for k := 0; k < n; k++ {
+			out = append(out, []rune(w.replace)...)
+		}
+		i += n
+	}
+	if found {
+		m.Msg = []byte(string(out))
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/swanky2009/goim/pkg/bus"
 	xtime "github.com/swanky2009/goim/pkg/time"
 	"gopkg.in/yaml.v2"
 )
@@ -32,8 +33,22 @@ type Config struct {
 	RPCServer     *RPCServer
 	HTTPServer    *HTTPServer
 	Kafka         *Kafka
+	Bus           *bus.Config
+	PushShards    int `yaml:"push_shards"` // the keys of a comet are split into, default 1
 	Redis         *Redis
+	Store         *Store
 	Regions       map[string][]string
+	Auth          *Auth
+	Apps          map[string]*App        // app -> quotas, an app not found is unlimited
+	RateLimit     *RateLimit             `yaml:"rate_limit"`
+	Endpoints     map[string]*Middleware // grpc method or http path -> middlewares, "default" for the others
+	Upstream      *Upstream
+	Filter        *Filter
+	RoomHistory   *RoomHistory `yaml:"room_history"`
+	Offline       *Offline
+	Receipt       *Receipt
+	Schedule      *Schedule
+	Idempotency   *Idempotency
 	Zipkin        *zipkinConf
 	MetricsServer struct {
 		Addr string
@@ -75,7 +90,15 @@ type zipkinConf struct {
 
 // Redis .
 type Redis struct {
-	Addrs        []string
+	// Mode cluster, single or sentinel, default cluster.
+	Mode string
+	// Addrs the cluster nodes, the node of single, or the sentinels.
+	Addrs []string
+	// MasterName the master of sentinel.
+	MasterName string `yaml:"master_name"`
+	Password   string
+	// DB of single and sentinel.
+	DB           int
 	DialTimeout  xtime.Duration
 	ReadTimeout  xtime.Duration
 	WriteTimeout xtime.Duration
@@ -85,12 +108,216 @@ type Redis struct {
 	Expire       xtime.Duration
 }
 
-// Kafka .
+// Store keeps the sessions and the online counts.
+type Store struct {
+	// Kind redis or memory, default redis. A memory store is local to one
+	// logic, so it is for running locally and tests.
+	Kind string
+	// Expire a session expires after no heartbeat in, default redis.expire or 30m.
+	Expire xtime.Duration
+	// OnlineExpire the room counts of a comet are dropped after it has not
+	// renewed them in, default three online ticks.
+	OnlineExpire xtime.Duration `yaml:"online_expire"`
+}
+
+// Kafka kafka of the upstream destinations, and of the bus if it is not
+// configured.
 type Kafka struct {
 	Topic   string
 	Brokers []string
 }
 
+// RoomHistory keeps the latest messages pushed to every room.
+type RoomHistory struct {
+	// Size max messages kept per room, default 100.
+	Size int64
+	// Expire a room history expires after no push in, default 24h.
+	Expire xtime.Duration
+}
+
+// Offline keeps the messages pushed to offline mids, and delivers them on
+// the next connect. Disabled if not configured.
+type Offline struct {
+	// Size max messages kept per mid, default 100.
+	Size int64
+	// Expire an inbox expires after no message in, default 7 days.
+	Expire xtime.Duration
+}
+
+// Receipt aggregates the delivered and read receipts of the messages pushed with an id.
+type Receipt struct {
+	// Expire the receipts of a message expire after pushed, default 7 days.
+	Expire xtime.Duration
+	// Topic the receipts are published to on the bus, no publishing if empty.
+	Topic string
+}
+
+// Schedule fires the pushes scheduled at a time.
+type Schedule struct {
+	// Interval the due pushes are polled at, default 1s.
+	Interval xtime.Duration
+	// Batch max pushes fired per poll, default 100.
+	Batch int64
+	// MaxDelay the latest a push is scheduled at from now, default 30 days.
+	MaxDelay xtime.Duration `yaml:"max_delay"`
+	// Lease a claimed push is fired in, or claimed again, default 30s.
+	Lease xtime.Duration
+	// Attempts a push failing is claimed at most, default 3.
+	Attempts int64
+}
+
+// Idempotency deduplicates the pushes with an idempotency key, in redis, or
+// in the process without redis.
+type Idempotency struct {
+	// Window a key is kept for after the first push, default 24h.
+	Window xtime.Duration
+}
+
+// Auth is the authenticator config of Connect.
+type Auth struct {
+	// Mode jwt, http or dev, dev trusts a mid|key|roomid|platform|accepts token.
+	Mode string
+	JWT  *JWT
+	HTTP *AuthHTTP
+}
+
+// JWT is the jwt authenticator config.
+type JWT struct {
+	// Algorithm HS256 HS384 HS512 RS256 RS384 RS512, default HS256.
+	Algorithm string
+	// Secret hmac secret.
+	Secret string
+	// PublicKey rsa public key pem file.
+	PublicKey string `yaml:"public_key"`
+	Issuer    string
+	Audience  string
+	Leeway    xtime.Duration
+	// Claims names of the claims mapped to the connection,
+	// default sub, room, platform, accepts and app.
+	Claims struct {
+		Mid      string
+		Room     string
+		Platform string
+		Accepts  string
+		App      string
+	}
+}
+
+// App the quotas of an app, 0 is unlimited.
+type App struct {
+	// Conns the most connections of the app, counted from the online
+	// reports of the comets, so a burst may pass it within an online tick.
+	Conns int32
+	// PushRate the most pushes of the app per second across the logics.
+	PushRate int64 `yaml:"push_rate"`
+}
+
+// RateLimit limits the pushes across the logics, counted in fixed windows
+// through redis, 0 is unlimited.
+type RateLimit struct {
+	// Window the counting window, default 1s.
+	Window xtime.Duration
+	// Caller the most pushes of a caller in a window.
+	Caller int64
+	// Types push type (keys, mids, room, all) -> the most pushes of the
+	// type by a caller in a window.
+	Types map[string]int64
+	// Room the most pushes to a room in a window.
+	Room int64
+}
+
+// Middleware the middlewares wrapped around a logic endpoint, from the
+// outermost: tracing, logging, metrics, rate limit, circuit breaker and
+// timeout. A zero field leaves its middleware out.
+type Middleware struct {
+	Tracing   bool
+	Logging   bool
+	Metrics   bool
+	RateLimit *EndpointLimit `yaml:"rate_limit"`
+	Breaker   *Breaker
+	Timeout   xtime.Duration
+}
+
+// EndpointLimit a token bucket of the calls of an endpoint on a logic.
+type EndpointLimit struct {
+	Rate  float64 // calls per second
+	Burst int     // default the rate
+}
+
+// Breaker a circuit breaker of an endpoint on a logic, it opens after
+// failures calls fail in a row, and lets max requests through when half
+// open after the timeout.
+type Breaker struct {
+	MaxRequests uint32         `yaml:"max_requests"`
+	Interval    xtime.Duration // the failures are cleared in the interval when closed, 0 never
+	Timeout     xtime.Duration // open for, default 60s
+	Failures    uint32         // default 5
+}
+
+// AuthHTTP is the http callback authenticator config.
+type AuthHTTP struct {
+	URL     string
+	Timeout xtime.Duration // default 1s
+}
+
+// Upstream routes the messages sent by clients to business systems.
+type Upstream struct {
+	Routes []*UpstreamRoute
+}
+
+// UpstreamRoute routes some operations to a destination.
+type UpstreamRoute struct {
+	// Ops operations and ranges, eg. "4,1000-1099".
+	Ops string
+	// Kind kafka, http or grpc.
+	Kind string
+	// Topic and Brokers of kafka, default brokers are the logic kafka brokers.
+	Topic   string
+	Brokers []string
+	// URL http webhook.
+	URL string
+	// Addr grpc address of an Upstream service.
+	Addr    string
+	Timeout xtime.Duration
+	// Retry max retries, the delay starts at Backoff and doubles.
+	Retry   int
+	Backoff xtime.Duration
+	// Queue max pending messages, newer messages are dropped when full.
+	Queue int
+}
+
+// Filter the chain of filters the messages sent by clients pass in order,
+// before they are routed upstream and pushed to rooms.
+type Filter struct {
+	Chain []*FilterStage
+}
+
+// FilterStage a filter of the chain.
+type FilterStage struct {
+	// Kind words, length, rate or http.
+	Kind string
+	// Ops operations and ranges filtered, default "4,100-1000", the messages
+	// sent to rooms and the business messages.
+	Ops string
+	// Words the sensitive words, and WordsFile more of them one per line.
+	// Each rune of a word is replaced by Replace, default "*", a message
+	// having words is refused instead if Reject.
+	Words     []string
+	WordsFile string `yaml:"words_file"`
+	Replace   string
+	Reject    bool
+	// MaxLength bytes of a message.
+	MaxLength int `yaml:"max_length"`
+	// Limit messages per Interval of a mid, default 1s.
+	Limit    int64
+	Interval xtime.Duration
+	// URL of the http moderation hook, a message is refused when the hook
+	// fails or is over Timeout, default 1s, if FailClosed.
+	URL        string
+	Timeout    xtime.Duration
+	FailClosed bool `yaml:"fail_closed"`
+}
+
 // RPCServer is RPC server config.
 type RPCServer struct {
 	Network           string
@@ -109,6 +336,34 @@ type HTTPServer struct {
 	Addr         string
 	ReadTimeout  xtime.Duration
 	WriteTimeout xtime.Duration
+	APIAuth      *APIAuth `yaml:"api_auth"`
+}
+
+// APIAuth authenticates the callers of the http api and of the grpc pushes,
+// every caller needs a key if any is configured.
+type APIAuth struct {
+	Keys []*APIKey
+	// Window max clock skew of a signed request, default 5m.
+	Window xtime.Duration
+}
+
+// APIKey a caller of the http api or the grpc pushes, it sends the secret as
+// is or signs the requests with it.
+type APIKey struct {
+	// ID names the caller in the requests and the audit log.
+	ID     string
+	Secret string
+	// SignOnly rejects the secret sent as is.
+	SignOnly bool `yaml:"sign_only"`
+	// Types the push types allowed: keys, mids, room and all, empty allows all.
+	Types []string
+	// Ops the operations allowed, eg. "1000-1099", empty allows all.
+	Ops string
+	// Rooms the room prefixes allowed, empty allows all.
+	Rooms []string
+	// App the keys, mids and rooms pushed by the key are scoped to, and its
+	// pushes to all are limited to, empty pushes to the ids as is.
+	App string
 }
 
 func LoadConf(curPath string) (*Config, error) {
@@ -142,12 +397,13 @@ func LoadConf(curPath string) (*Config, error) {
 		conf.LogPath = curPath + "/logs/"
 	}
 
-	conf.fix()
+	conf.Fix()
 
 	return conf, nil
 }
 
-func (c *Config) fix() {
+// Fix sets the defaults, it is called by LoadConf.
+func (c *Config) Fix() {
 	if c.Env == nil {
 		c.Env = new(Env)
 	}
@@ -165,9 +421,192 @@ func (c *Config) fix() {
 	if c.RPCServer != nil {
 		c.RPCServer.fix()
 	}
+	if c.HTTPServer != nil && c.HTTPServer.APIAuth != nil {
+		c.HTTPServer.APIAuth.fix()
+	}
+	if c.Bus == nil && c.Kafka != nil {
+		c.Bus = &bus.Config{Kind: bus.KindKafka, Topic: c.Kafka.Topic, Addrs: c.Kafka.Brokers}
+	}
+	if c.Bus != nil {
+		c.Bus.Fix()
+	}
+	if c.PushShards <= 0 {
+		c.PushShards = 1
+	}
 	if c.Redis != nil {
 		c.Redis.fix()
 	}
+	if c.Store == nil {
+		c.Store = new(Store)
+	}
+	if c.Store.Expire <= 0 && c.Redis != nil {
+		c.Store.Expire = c.Redis.Expire
+	}
+	if c.Store.OnlineExpire <= 0 {
+		c.Store.OnlineExpire = c.OnlineTick * 3
+	}
+	c.Store.fix()
+	if c.Upstream != nil {
+		for _, r := range c.Upstream.Routes {
+			r.fix()
+		}
+	}
+	if c.Filter != nil {
+		for _, f := range c.Filter.Chain {
+			f.fix()
+		}
+	}
+	if c.RoomHistory == nil {
+		c.RoomHistory = new(RoomHistory)
+	}
+	c.RoomHistory.fix()
+	if c.Offline != nil {
+		c.Offline.fix()
+	}
+	if c.Receipt == nil {
+		c.Receipt = new(Receipt)
+	}
+	c.Receipt.fix()
+	if c.Schedule == nil {
+		c.Schedule = new(Schedule)
+	}
+	c.Schedule.fix()
+	if c.Idempotency == nil {
+		c.Idempotency = new(Idempotency)
+	}
+	c.Idempotency.fix()
+	if c.RateLimit != nil {
+		c.RateLimit.fix()
+	}
+	c.Endpoints = fixEndpoints(c.Endpoints)
+}
+
+// fixEndpoints defaults the middlewares of the endpoints, without any the
+// endpoints are traced and measured, and Connect is limited as before.
+func fixEndpoints(eps map[string]*Middleware) map[string]*Middleware {
+	if eps == nil {
+		eps = map[string]*Middleware{
+			"Connect": {
+				Tracing:   true,
+				Metrics:   true,
+				RateLimit: &EndpointLimit{Rate: 1000, Burst: 10000},
+				Breaker:   &Breaker{MaxRequests: 5000, Interval: xtime.Duration(time.Second)},
+			},
+		}
+	}
+	if eps["default"] == nil {
+		eps["default"] = &Middleware{Tracing: true, Metrics: true}
+	}
+	for _, m := range eps {
+		if m != nil {
+			m.fix()
+		}
+	}
+	return eps
+}
+
+func (m *Middleware) fix() {
+	if m.RateLimit != nil && m.RateLimit.Burst <= 0 {
+		m.RateLimit.Burst = int(m.RateLimit.Rate)
+		if m.RateLimit.Burst <= 0 {
+			m.RateLimit.Burst = 1
+		}
+	}
+	if b := m.Breaker; b != nil {
+		if b.Timeout <= 0 {
+			b.Timeout = xtime.Duration(time.Minute)
+		}
+		if b.Failures <= 0 {
+			b.Failures = 5
+		}
+	}
+}
+
+func (r *RateLimit) fix() {
+	if r.Window <= 0 {
+		r.Window = xtime.Duration(time.Second)
+	}
+}
+
+func (i *Idempotency) fix() {
+	if i.Window <= 0 {
+		i.Window = xtime.Duration(time.Hour * 24)
+	}
+}
+
+func (s *Schedule) fix() {
+	if s.Interval <= 0 {
+		s.Interval = xtime.Duration(time.Second)
+	}
+	if s.Batch <= 0 {
+		s.Batch = 100
+	}
+	if s.MaxDelay <= 0 {
+		s.MaxDelay = xtime.Duration(time.Hour * 24 * 30)
+	}
+	if s.Lease <= 0 {
+		s.Lease = xtime.Duration(30 * time.Second)
+	}
+	if s.Attempts <= 0 {
+		s.Attempts = 3
+	}
+}
+
+func (r *Receipt) fix() {
+	if r.Expire <= 0 {
+		r.Expire = xtime.Duration(time.Hour * 24 * 7)
+	}
+}
+
+func (a *APIAuth) fix() {
+	if a.Window <= 0 {
+		a.Window = xtime.Duration(time.Minute * 5)
+	}
+}
+
+func (o *Offline) fix() {
+	if o.Size <= 0 {
+		o.Size = 100
+	}
+	if o.Expire <= 0 {
+		o.Expire = xtime.Duration(time.Hour * 24 * 7)
+	}
+}
+
+func (r *RoomHistory) fix() {
+	if r.Size <= 0 {
+		r.Size = 100
+	}
+	if r.Expire <= 0 {
+		r.Expire = xtime.Duration(time.Hour * 24)
+	}
+}
+
+func (r *UpstreamRoute) fix() {
+	if r.Timeout <= 0 {
+		r.Timeout = xtime.Duration(time.Second)
+	}
+	if r.Backoff <= 0 {
+		r.Backoff = xtime.Duration(time.Millisecond * 100)
+	}
+	if r.Queue <= 0 {
+		r.Queue = 1024
+	}
+}
+
+func (f *FilterStage) fix() {
+	if f.Ops == "" {
+		f.Ops = "4,100-1000"
+	}
+	if f.Replace == "" {
+		f.Replace = "*"
+	}
+	if f.Interval <= 0 {
+		f.Interval = xtime.Duration(time.Second)
+	}
+	if f.Timeout <= 0 {
+		f.Timeout = xtime.Duration(time.Second)
+	}
 }
 
 func (e *Env) fix() {
@@ -209,7 +648,19 @@ func (r *RPCServer) fix() {
 	}
 }
 
+func (s *Store) fix() {
+	if s.Kind == "" {
+		s.Kind = "redis"
+	}
+	if s.Expire <= 0 {
+		s.Expire = xtime.Duration(time.Minute * 30)
+	}
+}
+
 func (r *Redis) fix() {
+	if r.Mode == "" {
+		r.Mode = "cluster"
+	}
 	if r.DialTimeout <= 0 {
 		r.DialTimeout = xtime.Duration(time.Millisecond * 100)
 	}
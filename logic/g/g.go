@@ -12,6 +12,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/openzipkin/zipkin-go/reporter"
+	"github.com/sirupsen/logrus"
 	"github.com/swanky2009/goim/logic/g/conf"
 )
 
@@ -23,14 +24,17 @@ const (
 var (
 	Conf *conf.Config
 
-	Logger logger
-
-	//StatMetrics *Metrics
+	// Logger and StatMetrics default to stderr logging and discarded metrics,
+	// so the logic packages can be used without calling Init.
+	Logger      = logger{logrus.New()}
+	StatMetrics = discardMetrics()
 
 	zipkinReporter reporter.Reporter
 )
 
-func init() {
+// Init loads the logic config next to the binary, then sets up the logger,
+// discovery and zipkin of a logic process.
+func Init() {
 	curPath := GetCurrentDir()
 
 	SetPid(curPath)
@@ -54,11 +58,17 @@ func init() {
 	Logger.Infof("goim-logic [version: %s env: %+v] start", ver, Conf.Env)
 
 	//Metrics
-	//StatMetrics = MetricsInstrumenting()
+	StatMetrics = MetricsInstrumenting()
 
 	zipkinReporter = NewZipkinReporter()
 }
 
+// ReloadConf loads the config next to the binary again, for the settings
+// which are reloadable.
+func ReloadConf() (*conf.Config, error) {
+	return conf.LoadConf(GetCurrentDir())
+}
+
 func GetCurrentDir() string {
 	dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 	if err != nil {
@@ -0,0 +1,28 @@
+package g
+
+import (
+	"github.com/go-kit/kit/metrics"
+)
+
+type discardCounter struct{}
+
+func (c discardCounter) With(...string) metrics.Counter { return c }
+func (c discardCounter) Add(float64)                    {}
+
+type discardHistogram struct{}
+
+func (h discardHistogram) With(...string) metrics.Histogram { return h }
+func (h discardHistogram) Observe(float64)                  {}
+
+// discardMetrics metrics which drop every observation, used until Init registers the prometheus ones.
+func discardMetrics() *Metrics {
+	return &Metrics{
+		BusPublished:     discardCounter{},
+		BusFailed:        discardCounter{},
+		BusSpooled:       discardCounter{},
+		BusLatency:       discardHistogram{},
+		PushThrottled:    discardCounter{},
+		EndpointRequests: discardCounter{},
+		EndpointLatency:  discardHistogram{},
+	}
+}
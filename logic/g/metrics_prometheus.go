@@ -0,0 +1,80 @@
+package g
+
+import (
+	"github.com/go-kit/kit/metrics"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+type Metrics struct {
+	// bus
+	BusPublished metrics.Counter
+	BusFailed    metrics.Counter
+	BusSpooled   metrics.Counter
+	BusLatency   metrics.Histogram
+	// push
+	PushThrottled metrics.Counter
+	// endpoint
+	EndpointRequests metrics.Counter
+	EndpointLatency  metrics.Histogram
+}
+
+func MetricsInstrumenting() *Metrics {
+	namespace, subsystem := "goim", "logic"
+	fieldKeys := []string{"topic"}
+
+	BusPublished := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "bus_published",
+		Help:      "Number of messages published to the bus.",
+	}, fieldKeys)
+	BusFailed := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "bus_failed",
+		Help:      "Number of messages failed to publish to the bus.",
+	}, fieldKeys)
+	BusSpooled := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "bus_spooled",
+		Help:      "Number of messages failed and spooled to republish.",
+	}, fieldKeys)
+	BusLatency := kitprometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "bus_latency_seconds",
+		Help:      "Seconds a message took to be published to the bus.",
+		Buckets:   stdprometheus.DefBuckets,
+	}, fieldKeys)
+	PushThrottled := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "push_throttled",
+		Help:      "Number of pushes refused beyond a rate limit.",
+	}, []string{"scope", "type"})
+	EndpointRequests := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "endpoint_requests",
+		Help:      "Number of calls of the grpc and http endpoints.",
+	}, []string{"endpoint", "error"})
+	EndpointLatency := kitprometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "endpoint_latency_seconds",
+		Help:      "Seconds a call of an endpoint took.",
+		Buckets:   stdprometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	return &Metrics{
+		BusPublished,
+		BusFailed,
+		BusSpooled,
+		BusLatency,
+		PushThrottled,
+		EndpointRequests,
+		EndpointLatency,
+	}
+}
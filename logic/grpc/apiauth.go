@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"github.com/swanky2009/goim/logic/apikey"
+	"github.com/swanky2009/goim/logic/g"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// The metadata of an authenticated push, the same as the headers of the
+// http api. A call is signed with its full method and the request in
+// protobuf, see apikey.
+const (
+	mdKeyID     = "x-goim-key-id"
+	mdKey       = "x-goim-key"
+	mdTimestamp = "x-goim-timestamp"
+	mdNonce     = "x-goim-nonce"
+	mdSignature = "x-goim-signature"
+)
+
+// _service the prefix of the full methods of logic.
+const _service = "/goim.logic.Logic/"
+
+type callerKey struct{}
+
+// authPush authenticates the caller of a push rpc, the req signed is nil for
+// a stream. The pushes are not authenticated if no api key is configured,
+// the rpcs of comet are trusted.
+func (s *server) authPush(ctx context.Context, method string, req proto.Message) (context.Context, error) {
+	if s.auth == nil {
+		return ctx, nil
+	}
+	k, err := s.authenticate(ctx, method, req)
+	if err != nil {
+		g.Logger.Warningf("api auth %s remote:%s key:%s error(%v)", method, peerAddr(ctx), mdGet(ctx, mdKeyID), err)
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return context.WithValue(ctx, callerKey{}, k), nil
+}
+
+func (s *server) authenticate(ctx context.Context, method string, req proto.Message) (k *apikey.Key, err error) {
+	id := mdGet(ctx, mdKeyID)
+	sig := mdGet(ctx, mdSignature)
+	if sig == "" {
+		return s.auth.Authenticate(id, mdGet(ctx, mdKey))
+	}
+	var body []byte
+	if req != nil {
+		if body, err = proto.Marshal(req); err != nil {
+			return
+		}
+	}
+	return s.auth.Verify(ctx, id, &apikey.Signed{
+		Method:    apikey.MethodGRPC,
+		URI:       method,
+		Timestamp: mdGet(ctx, mdTimestamp),
+		Nonce:     mdGet(ctx, mdNonce),
+		Signature: sig,
+		Body:      body,
+	})
+}
+
+// mdGet returns the first value of the incoming metadata key.
+func mdGet(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if vs := md.Get(key); len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+// caller returns the key of the call, nil if the pushes are not authenticated.
+func caller(ctx context.Context) *apikey.Key {
+	k, _ := ctx.Value(callerKey{}).(*apikey.Key)
+	return k
+}
+
+// pushCaller returns the caller a push is rate limited as, the id of its
+// api key or the host of an anonymous peer.
+func pushCaller(ctx context.Context) string {
+	if k := caller(ctx); k != nil {
+		return k.ID
+	}
+	return peerHost(ctx)
+}
+
+// allow returns a permission denied error if the caller may not push the
+// type, op and room.
+func allow(ctx context.Context, typ string, op int32, room string) error {
+	if k := caller(ctx); k != nil && !k.Allow(typ, op, room) {
+		return status.Error(codes.PermissionDenied, "push out of the scope of the key")
+	}
+	return nil
+}
+
+// allowRoom returns a permission denied error if the room is out of the
+// rooms of the caller.
+func allowRoom(ctx context.Context, room string) error {
+	if k := caller(ctx); k != nil && !k.AllowRoom(room) {
+		return status.Error(codes.PermissionDenied, "room out of the scope of the key")
+	}
+	return nil
+}
+
+// allowRead returns a permission denied error if the caller is limited to
+// some types, ops or rooms, which do not limit the reads of the connections.
+func allowRead(ctx context.Context) error {
+	if k := caller(ctx); k != nil && k.Scoped() {
+		return status.Error(codes.PermissionDenied, "read out of the scope of the key")
+	}
+	return nil
+}
+
+// audit logs a push naming the caller.
+func audit(ctx context.Context, typ string, op int32, target string, err error) {
+	name := "anonymous"
+	if k := caller(ctx); k != nil {
+		name = k.ID
+	}
+	g.Logger.WithFields(logrus.Fields{
+		"caller": name,
+		"remote": peerAddr(ctx),
+		"type":   typ,
+		"op":     op,
+		"target": target,
+		"error":  err,
+	}).Info("audit push")
+}
+
+// peerAddr returns the address of the peer of the call, "" if unknown.
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
@@ -0,0 +1,179 @@
+package grpc
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	pb "github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/logic/apikey"
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/model"
+	xtime "github.com/swanky2009/goim/pkg/time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func testServer(t *testing.T) *server {
+	nonces := make(map[string]bool)
+	auth, err := apikey.New(&conf.APIAuth{
+		Window: xtime.Duration(time.Minute),
+		Keys: []*conf.APIKey{
+			{ID: "admin", Secret: "admin_secret"},
+			{ID: "chat", Secret: "chat_secret", SignOnly: true, Types: []string{model.PushRoom}, Ops: "1000-1099", Rooms: []string{"chat://"}},
+			{ID: "shop", Secret: "shop_secret", App: "shop"},
+		},
+	}, func(c context.Context, id, nonce string, expire time.Duration) (bool, error) {
+		if nonces[id+nonce] {
+			return false, nil
+		}
+		nonces[id+nonce] = true
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &server{auth: auth}
+}
+
+func keyContext(id, secret string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(mdKeyID, id, mdKey, secret))
+}
+
+func signedContext(id, secret, method, nonce string, req proto.Message) context.Context {
+	var body []byte
+	if req != nil {
+		body, _ = proto.Marshal(req)
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	sig := hex.EncodeToString(apikey.Sign(secret, apikey.MethodGRPC, method, ts, nonce, body))
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(mdKeyID, id, mdTimestamp, ts, mdNonce, nonce, mdSignature, sig))
+}
+
+func TestAuthPush(t *testing.T) {
+	var (
+		s      = testServer(t)
+		method = _service + "PushRoom"
+		req    = &pb.PushRoomReq{Op: 1000, Room: "chat://1", Msg: []byte("hello")}
+	)
+	ctx, err := s.authPush(keyContext("admin", "admin_secret"), method, req)
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", caller(ctx).ID)
+	_, err = s.authPush(keyContext("admin", "wrong"), method, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = s.authPush(context.Background(), method, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	// chat signs only
+	_, err = s.authPush(keyContext("chat", "chat_secret"), method, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx, err = s.authPush(signedContext("chat", "chat_secret", method, "n1", req), method, req)
+	assert.Nil(t, err)
+	assert.Equal(t, "chat", caller(ctx).ID)
+	// replay
+	_, err = s.authPush(signedContext("chat", "chat_secret", method, "n1", req), method, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	// another request
+	_, err = s.authPush(signedContext("chat", "chat_secret", method, "n2", req), method, &pb.PushRoomReq{Op: 1000, Room: "chat://2", Msg: []byte("hello")})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	// another method
+	_, err = s.authPush(signedContext("chat", "chat_secret", method, "n3", req), _service+"PushAll", req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// not authenticated without keys
+	ctx, err = (&server{}).authPush(context.Background(), method, req)
+	assert.Nil(t, err)
+	assert.Nil(t, caller(ctx))
+}
+
+func TestAllowPush(t *testing.T) {
+	s := testServer(t)
+	ctx, _ := s.authPush(signedContext("chat", "chat_secret", _service+"PushStream", "n1", nil), _service+"PushStream", nil)
+	assert.Nil(t, allow(ctx, model.PushRoom, 1000, "chat://1"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(allow(ctx, model.PushRoom, 1000, "live://1")))
+	assert.Equal(t, codes.PermissionDenied, status.Code(allow(ctx, model.PushRoom, 2000, "chat://1")))
+	assert.Equal(t, codes.PermissionDenied, status.Code(allow(ctx, model.PushAll, 1000, "")))
+	assert.Nil(t, allow(context.Background(), model.PushAll, 1000, ""))
+	// the reads of the chat key
+	assert.Nil(t, allowRoom(ctx, "chat://1"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(allowRoom(ctx, "live://1")))
+	assert.Equal(t, codes.PermissionDenied, status.Code(allowRead(ctx)))
+	assert.Nil(t, allowRead(context.Background()))
+}
+
+func TestPushOptionsApp(t *testing.T) {
+	s := testServer(t)
+	for _, c := range []struct {
+		id, secret, asked, app string
+	}{
+		{"admin", "admin_secret", "", ""},
+		{"admin", "admin_secret", "game", "game"},
+		{"shop", "shop_secret", "", "shop"},
+		// a key of an app may not ask another
+		{"shop", "shop_secret", "game", "shop"},
+	} {
+		ctx, err := s.authPush(keyContext(c.id, c.secret), _service+"PushAll", nil)
+		assert.Nil(t, err)
+		opt := pushOptions(ctx, &pb.PushOptions{App: c.asked})
+		assert.Equal(t, c.app, opt.App, c.id+" "+c.asked)
+		assert.Equal(t, c.id, opt.Caller)
+	}
+}
+
+type testPushStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	reqs    []*pb.PushStreamReq
+	replies []*pb.PushStreamReply
+}
+
+func (s *testPushStream) Context() context.Context { return s.ctx }
+
+func (s *testPushStream) Recv() (*pb.PushStreamReq, error) {
+	if len(s.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+
+func (s *testPushStream) Send(r *pb.PushStreamReply) error {
+	s.replies = append(s.replies, r)
+	return nil
+}
+
+func TestPushStream(t *testing.T) {
+	var (
+		s   = testServer(t)
+		msg = []byte("hello")
+	)
+	stream := &testPushStream{ctx: keyContext("admin", "wrong")}
+	assert.Equal(t, codes.Unauthenticated, status.Code(s.PushStream(stream)))
+
+	stream = &testPushStream{
+		ctx: signedContext("chat", "chat_secret", _service+"PushStream", "n1", nil),
+		reqs: []*pb.PushStreamReq{
+			{Id: 1},
+			{Id: 2, Room: &pb.PushRoomReq{Op: 1000, Room: "chat://1", Msg: msg}, All: &pb.PushAllReq{Op: 1000, Msg: msg}},
+			{Id: 3, Room: &pb.PushRoomReq{Op: 1000, Room: "chat://1"}},
+			{Id: 4, Room: &pb.PushRoomReq{Op: 1000, Room: "live://1", Msg: msg}},
+			{Id: 5, All: &pb.PushAllReq{Op: 1000, Msg: msg}},
+		},
+	}
+	assert.Nil(t, s.PushStream(stream))
+	assert.Equal(t, 5, len(stream.replies))
+	for i, code := range []codes.Code{codes.InvalidArgument, codes.InvalidArgument, codes.InvalidArgument, codes.PermissionDenied, codes.PermissionDenied} {
+		assert.Equal(t, int64(i+1), stream.replies[i].Id)
+		assert.Equal(t, int32(code), stream.replies[i].Code)
+		assert.Nil(t, stream.replies[i].Reply)
+	}
+}
@@ -2,42 +2,285 @@ package grpc
 
 import (
 	"context"
-	//"fmt"
 
 	"github.com/go-kit/kit/endpoint"
 
 	pb "github.com/swanky2009/goim/grpc/logic"
 	"github.com/swanky2009/goim/logic"
 	"github.com/swanky2009/goim/logic/handlers"
+	"github.com/swanky2009/goim/logic/model"
 )
 
 var (
 	endpoints Endpoints
 )
 
+// Endpoints the go-kit endpoints of the logic rpcs, named by their methods
+// in the middleware chain.
 type Endpoints struct {
-	ConnectEndpoint endpoint.Endpoint
+	PingEndpoint        endpoint.Endpoint
+	CloseEndpoint       endpoint.Endpoint
+	ConnectEndpoint     endpoint.Endpoint
+	DisconnectEndpoint  endpoint.Endpoint
+	HeartbeatEndpoint   endpoint.Endpoint
+	RenewOnlineEndpoint endpoint.Endpoint
+	ReceiveEndpoint     endpoint.Endpoint
+	JoinRoomEndpoint    endpoint.Endpoint
+	RoomHistoryEndpoint endpoint.Endpoint
+	PresenceEndpoint    endpoint.Endpoint
+	OnlineMidsEndpoint  endpoint.Endpoint
+	PushKeysEndpoint    endpoint.Endpoint
+	PushMidsEndpoint    endpoint.Endpoint
+	PushRoomEndpoint    endpoint.Endpoint
+	PushAllEndpoint     endpoint.Endpoint
+	OnlineTopEndpoint   endpoint.Endpoint
+	OnlineRoomEndpoint  endpoint.Endpoint
 }
 
-func NewEndpoints(s *logic.Server) Endpoints {
-
-	connectEndpoint := MakeConnectEndpoint(s)
-
-	connectEndpoint = handlers.WrapEndpoints(connectEndpoint)
-
+// NewEndpoints makes the endpoints wrapped in the middlewares of the chain.
+func NewEndpoints(s *logic.Server, chain *handlers.Chain) Endpoints {
 	return Endpoints{
-		ConnectEndpoint: connectEndpoint,
+		PingEndpoint:        chain.Wrap("Ping", MakePingEndpoint(s)),
+		CloseEndpoint:       chain.Wrap("Close", MakeCloseEndpoint(s)),
+		ConnectEndpoint:     chain.Wrap("Connect", MakeConnectEndpoint(s)),
+		DisconnectEndpoint:  chain.Wrap("Disconnect", MakeDisconnectEndpoint(s)),
+		HeartbeatEndpoint:   chain.Wrap("Heartbeat", MakeHeartbeatEndpoint(s)),
+		RenewOnlineEndpoint: chain.Wrap("RenewOnline", MakeRenewOnlineEndpoint(s)),
+		ReceiveEndpoint:     chain.Wrap("Receive", MakeReceiveEndpoint(s)),
+		JoinRoomEndpoint:    chain.Wrap("JoinRoom", MakeJoinRoomEndpoint(s)),
+		RoomHistoryEndpoint: chain.Wrap("RoomHistory", MakeRoomHistoryEndpoint(s)),
+		PresenceEndpoint:    chain.Wrap("Presence", MakePresenceEndpoint(s)),
+		OnlineMidsEndpoint:  chain.Wrap("OnlineMids", MakeOnlineMidsEndpoint(s)),
+		PushKeysEndpoint:    chain.Wrap("PushKeys", MakePushKeysEndpoint(s)),
+		PushMidsEndpoint:    chain.Wrap("PushMids", MakePushMidsEndpoint(s)),
+		PushRoomEndpoint:    chain.Wrap("PushRoom", MakePushRoomEndpoint(s)),
+		PushAllEndpoint:     chain.Wrap("PushAll", MakePushAllEndpoint(s)),
+		OnlineTopEndpoint:   chain.Wrap("OnlineTop", MakeOnlineTopEndpoint(s)),
+		OnlineRoomEndpoint:  chain.Wrap("OnlineRoom", MakeOnlineRoomEndpoint(s)),
 	}
 }
 
 // Make Endpoints
+func MakePingEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		return &pb.PingReply{}, nil
+	}
+}
+
+func MakeCloseEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		return &pb.CloseReply{}, nil
+	}
+}
+
 func MakeConnectEndpoint(s *logic.Server) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		req := request.(*pb.ConnectReq)
-		mid, key, room, platform, accepts, err := s.Connect(ctx, req.Server, req.ServerKey, req.Cookie, req.Token)
+		mid, key, room, platform, accepts, app, err := s.Connect(ctx, req.Server, req.ServerKey, req.Cookie, req.Token)
 		if err != nil {
 			return &pb.ConnectReply{}, err
 		}
-		return &pb.ConnectReply{Mid: mid, Key: key, RoomID: room, Accepts: accepts, Platform: platform}, nil
+		return &pb.ConnectReply{Mid: mid, Key: key, RoomID: room, Accepts: accepts, Platform: platform, App: app}, nil
+	}
+}
+
+func MakeDisconnectEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.DisconnectReq)
+		has, err := s.Disconnect(ctx, req.Mid, req.Key, req.Server, req.Room)
+		if err != nil {
+			return &pb.DisconnectReply{}, err
+		}
+		return &pb.DisconnectReply{Has: has}, nil
+	}
+}
+
+func MakeHeartbeatEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.HeartbeatReq)
+		if err = s.Heartbeat(ctx, req.Mid, req.Key, req.Server, req.Room, req.Platform, req.Connected); err != nil {
+			return &pb.HeartbeatReply{}, err
+		}
+		return &pb.HeartbeatReply{}, nil
+	}
+}
+
+func MakeRenewOnlineEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.OnlineReq)
+		allRoomCount, err := s.RenewOnline(ctx, req.Server, req.RoomCount, req.AppCount)
+		if err != nil {
+			return &pb.OnlineReply{}, err
+		}
+		return &pb.OnlineReply{AllRoomCount: allRoomCount}, nil
+	}
+}
+
+// MakeReceiveEndpoint replies a message refused with the reason.
+func MakeReceiveEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.ReceiveReq)
+		if err = s.Receive(ctx, req.Mid, req.Key, req.Server, req.Platform, req.Room, req.Op, req.Msg); err != nil {
+			if r := model.AsReject(err); r != nil {
+				return &pb.ReceiveReply{Code: r.Code, Message: r.Message}, nil
+			}
+			return &pb.ReceiveReply{}, err
+		}
+		return &pb.ReceiveReply{}, nil
+	}
+}
+
+// MakeJoinRoomEndpoint replies a join refused with the reason, the room is
+// scoped to the app of the key, the room left is scoped already.
+func MakeJoinRoomEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.JoinRoomReq)
+		app, _ := model.DecodeKey(req.Key)
+		room, err := model.ScopeRoom(app, req.Room)
+		if err != nil {
+			return &pb.JoinRoomReply{}, err
+		}
+		if err = s.JoinRoom(ctx, req.Mid, req.Key, req.From, room); err != nil {
+			if r := model.AsReject(err); r != nil {
+				return &pb.JoinRoomReply{Code: r.Code, Message: r.Message}, nil
+			}
+			return &pb.JoinRoomReply{}, err
+		}
+		return &pb.JoinRoomReply{Room: room}, nil
+	}
+}
+
+func MakeRoomHistoryEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.RoomHistoryReq)
+		msgs, err := s.RoomHistory(ctx, req.Room, req.SinceSeq, int64(req.Count))
+		if err != nil {
+			return &pb.RoomHistoryReply{}, err
+		}
+		reply := &pb.RoomHistoryReply{Msgs: make([]*pb.RoomMsg, 0, len(msgs))}
+		for _, m := range msgs {
+			reply.Msgs = append(reply.Msgs, &pb.RoomMsg{Seq: m.Seq, Op: m.Op, Msg: m.Msg, Ts: m.Ts})
+		}
+		return reply, nil
+	}
+}
+
+func MakePresenceEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.PresenceReq)
+		res, err := s.Presence(ctx, req.App, req.Mids)
+		if err != nil {
+			return &pb.PresenceReply{}, err
+		}
+		reply := &pb.PresenceReply{}
+		for _, mid := range req.Mids {
+			for _, sess := range res[mid] {
+				reply.Sessions = append(reply.Sessions, &pb.Session{
+					Mid:           sess.Mid,
+					Key:           sess.Key,
+					Server:        sess.Server,
+					Platform:      sess.Platform,
+					Room:          sess.Room,
+					ConnectTime:   sess.ConnectTime,
+					HeartbeatTime: sess.HeartbeatTime,
+				})
+			}
+			// a mid asked twice is replied once
+			delete(res, mid)
+		}
+		return reply, nil
+	}
+}
+
+func MakeOnlineMidsEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.OnlineMidsReq)
+		mids, err := s.OnlineMids(ctx, req.App, req.Mids)
+		if err != nil {
+			return &pb.OnlineMidsReply{}, err
+		}
+		return &pb.OnlineMidsReply{Mids: mids}, nil
+	}
+}
+
+func MakePushKeysEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.PushKeysReq)
+		opt := pushOptions(ctx, req.Options)
+		if err = beforePush(ctx, s, req.Options, &model.PushItem{Type: model.PushKeys, Keys: req.Keys, Options: opt}); err != nil {
+			return &pb.PushReply{}, err
+		}
+		res, err := s.PushKeysOpt(ctx, req.Op, req.Keys, req.Msg, opt)
+		if err != nil {
+			return &pb.PushReply{}, err
+		}
+		return pushReply(res), nil
+	}
+}
+
+func MakePushMidsEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.PushMidsReq)
+		opt := pushOptions(ctx, req.Options)
+		if err = beforePush(ctx, s, req.Options, &model.PushItem{Type: model.PushMids, Mids: req.Mids, Options: opt}); err != nil {
+			return &pb.PushReply{}, err
+		}
+		res, err := s.PushMidsOpt(ctx, req.Op, req.Mids, req.Msg, opt)
+		if err != nil {
+			return &pb.PushReply{}, err
+		}
+		return pushReply(res), nil
+	}
+}
+
+func MakePushRoomEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.PushRoomReq)
+		opt := pushOptions(ctx, req.Options)
+		if err = beforePush(ctx, s, req.Options, &model.PushItem{Type: model.PushRoom, Room: req.Room, Options: opt}); err != nil {
+			return &pb.PushReply{}, err
+		}
+		res, err := s.PushRoomOpt(ctx, req.Op, req.Room, req.Msg, opt)
+		if err != nil {
+			return &pb.PushReply{}, err
+		}
+		return pushReply(res), nil
+	}
+}
+
+func MakePushAllEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.PushAllReq)
+		opt := pushOptions(ctx, req.Options)
+		if err = beforePush(ctx, s, req.Options, &model.PushItem{Type: model.PushAll, Speed: req.Speed, Platform: req.Platform, Options: opt}); err != nil {
+			return &pb.PushReply{}, err
+		}
+		res, err := s.PushAllOpt(ctx, req.Op, req.Speed, req.Platform, req.Msg, opt)
+		if err != nil {
+			return &pb.PushReply{}, err
+		}
+		return pushReply(res), nil
+	}
+}
+
+func MakeOnlineTopEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.OnlineTopReq)
+		addrs, err := s.OnlineTop(ctx, req.Type, req.Limit)
+		if err != nil {
+			return &pb.OnlineTopReply{}, err
+		}
+		return &pb.OnlineTopReply{Addrs: addrs}, nil
+	}
+}
+
+func MakeOnlineRoomEndpoint(s *logic.Server) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(*pb.OnlineRoomReq)
+		res, err := s.OnlineRoom(ctx, req.App, req.Rooms)
+		if err != nil {
+			return &pb.OnlineRoomReply{}, err
+		}
+		return &pb.OnlineRoomReply{RoomCount: res}, nil
 	}
 }
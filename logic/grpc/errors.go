@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/ratelimit"
+	"github.com/sony/gobreaker"
+	"github.com/swanky2009/goim/logic"
+	"github.com/swanky2009/goim/logic/dao"
+	"github.com/swanky2009/goim/logic/model"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcError returns the status error of an endpoint error, so that callers
+// tell a request to retry later from a broken one.
+func grpcError(err error) error {
+	if model.AsRateLimited(err) != nil {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	switch err {
+	case nil:
+		return nil
+	case ratelimit.ErrLimited, logic.ErrAppQuota:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case gobreaker.ErrOpenState, gobreaker.ErrTooManyRequests:
+		return status.Error(codes.Unavailable, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	case dao.ErrNoRedis:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case model.ErrInvalidID:
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,315 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+
+	comet "github.com/swanky2009/goim/grpc/comet"
+	pb "github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/logic"
+	"github.com/swanky2009/goim/logic/model"
+	xstr "github.com/swanky2009/goim/pkg/strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// _defaultOnlineTop the number of addresses of an online top without a limit.
+const _defaultOnlineTop = 2
+
+// PushKeys push a message to the keys.
+func (s *server) PushKeys(ctx context.Context, req *pb.PushKeysReq) (*pb.PushReply, error) {
+	ctx, err := s.authPush(ctx, _service+"PushKeys", req)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := pushKeys(ctx, req)
+	if err != nil {
+		return nil, pushError(ctx, err)
+	}
+	return reply, nil
+}
+
+// PushMids push a message to the mids.
+func (s *server) PushMids(ctx context.Context, req *pb.PushMidsReq) (*pb.PushReply, error) {
+	ctx, err := s.authPush(ctx, _service+"PushMids", req)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := pushMids(ctx, req)
+	if err != nil {
+		return nil, pushError(ctx, err)
+	}
+	return reply, nil
+}
+
+// PushRoom push a message to a room.
+func (s *server) PushRoom(ctx context.Context, req *pb.PushRoomReq) (*pb.PushReply, error) {
+	ctx, err := s.authPush(ctx, _service+"PushRoom", req)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := pushRoom(ctx, req)
+	if err != nil {
+		return nil, pushError(ctx, err)
+	}
+	return reply, nil
+}
+
+// PushAll push a message to all, or all of a platform.
+func (s *server) PushAll(ctx context.Context, req *pb.PushAllReq) (*pb.PushReply, error) {
+	ctx, err := s.authPush(ctx, _service+"PushAll", req)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := pushAll(ctx, req)
+	if err != nil {
+		return nil, pushError(ctx, err)
+	}
+	return reply, nil
+}
+
+// PushStream push the messages of a stream, the caller is authenticated
+// once when the stream opens, and every push is checked, scoped and audited
+// the same as its unary rpc and replied in order.
+func (s *server) PushStream(stream pb.Logic_PushStreamServer) error {
+	ctx, err := s.authPush(stream.Context(), _service+"PushStream", nil)
+	if err != nil {
+		return err
+	}
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = stream.Send(pushStream(ctx, req)); err != nil {
+			return err
+		}
+	}
+}
+
+// pushStream pushes a push of a stream and returns its reply.
+func pushStream(ctx context.Context, req *pb.PushStreamReq) *pb.PushStreamReply {
+	var (
+		reply *pb.PushReply
+		err   error
+	)
+	switch {
+	case req.Keys != nil && req.Mids == nil && req.Room == nil && req.All == nil:
+		reply, err = pushKeys(ctx, req.Keys)
+	case req.Keys == nil && req.Mids != nil && req.Room == nil && req.All == nil:
+		reply, err = pushMids(ctx, req.Mids)
+	case req.Keys == nil && req.Mids == nil && req.Room != nil && req.All == nil:
+		reply, err = pushRoom(ctx, req.Room)
+	case req.Keys == nil && req.Mids == nil && req.Room == nil && req.All != nil:
+		reply, err = pushAll(ctx, req.All)
+	default:
+		err = status.Error(codes.InvalidArgument, "exactly one push of keys, mids, room or all")
+	}
+	res := &pb.PushStreamReply{Id: req.Id, Reply: reply}
+	if err != nil {
+		st, _ := status.FromError(grpcError(err))
+		res.Code, res.Message = int32(st.Code()), st.Message()
+		if rl := model.AsRateLimited(err); rl != nil {
+			res.RetryAfter = retryAfter(rl)
+		}
+	}
+	return res
+}
+
+func pushKeys(ctx context.Context, req *pb.PushKeysReq) (*pb.PushReply, error) {
+	if err := validatePush(req.Op, len(req.Keys), req.Msg, req.Options); err != nil {
+		return nil, err
+	}
+	return push(ctx, endpoints.PushKeysEndpoint, model.PushKeys, req.Op, "", strings.Join(req.Keys, ","), req)
+}
+
+func pushMids(ctx context.Context, req *pb.PushMidsReq) (*pb.PushReply, error) {
+	if err := validatePush(req.Op, len(req.Mids), req.Msg, req.Options); err != nil {
+		return nil, err
+	}
+	return push(ctx, endpoints.PushMidsEndpoint, model.PushMids, req.Op, "", xstr.JoinInt64s(req.Mids, ","), req)
+}
+
+func pushRoom(ctx context.Context, req *pb.PushRoomReq) (*pb.PushReply, error) {
+	targets := 0
+	if req.Room != "" {
+		targets = 1
+	}
+	if err := validatePush(req.Op, targets, req.Msg, req.Options); err != nil {
+		return nil, err
+	}
+	return push(ctx, endpoints.PushRoomEndpoint, model.PushRoom, req.Op, req.Room, req.Room, req)
+}
+
+func pushAll(ctx context.Context, req *pb.PushAllReq) (*pb.PushReply, error) {
+	if err := validatePush(req.Op, 1, req.Msg, req.Options); err != nil {
+		return nil, err
+	}
+	if req.Speed < 0 {
+		return nil, status.Error(codes.InvalidArgument, "negative speed")
+	}
+	return push(ctx, endpoints.PushAllEndpoint, model.PushAll, req.Op, "", req.Platform, req)
+}
+
+// push checks the scope of the caller, pushes the request on the endpoint
+// and audits it, the target names the keys, mids, room or platform.
+func push(ctx context.Context, ep endpoint.Endpoint, typ string, op int32, room, target string, req interface{}) (*pb.PushReply, error) {
+	if err := allow(ctx, typ, op, room); err != nil {
+		return nil, err
+	}
+	resp, err := ep(ctx, req)
+	audit(ctx, typ, op, target, err)
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.PushReply), nil
+}
+
+// OnlineTop get the top online addresses of comets.
+func (s *server) OnlineTop(ctx context.Context, req *pb.OnlineTopReq) (*pb.OnlineTopReply, error) {
+	if req.Limit <= 0 {
+		req.Limit = _defaultOnlineTop
+	}
+	if err := allowRead(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := endpoints.OnlineTopEndpoint(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.OnlineTopReply), nil
+}
+
+// OnlineRoom get the online count of the rooms.
+func (s *server) OnlineRoom(ctx context.Context, req *pb.OnlineRoomReq) (*pb.OnlineRoomReply, error) {
+	if len(req.Rooms) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no rooms")
+	}
+	for _, room := range req.Rooms {
+		if err := allowRoom(ctx, room); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := endpoints.OnlineRoomEndpoint(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.OnlineRoomReply), nil
+}
+
+// validatePush checks a push the same as /v2/push, the targets is the number
+// of keys, mids or rooms pushed to.
+func validatePush(op int32, targets int, msg []byte, o *pb.PushOptions) error {
+	if op <= 0 {
+		return status.Error(codes.InvalidArgument, "invalid op")
+	}
+	if targets == 0 {
+		return status.Error(codes.InvalidArgument, "no target")
+	}
+	if len(msg) == 0 {
+		return status.Error(codes.InvalidArgument, "empty msg")
+	}
+	if len(msg) > int(comet.MaxBodySize) {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("msg %d bytes, max %d", len(msg), comet.MaxBodySize))
+	}
+	if o == nil {
+		return nil
+	}
+	if o.Ttl < 0 {
+		return status.Error(codes.InvalidArgument, "negative ttl")
+	}
+	switch o.Priority {
+	case "", model.PriorityNormal, model.PriorityHigh:
+	default:
+		return status.Error(codes.InvalidArgument, "priority is normal or high")
+	}
+	if o.MsgID != "" && o.From <= 0 {
+		return status.Error(codes.InvalidArgument, "msgID needs from")
+	}
+	return nil
+}
+
+// beforePush gives up a push the caller no longer waits for, and keeps the
+// receipts of a message having a msg id for the recipients of the item.
+func beforePush(ctx context.Context, s *logic.Server, o *pb.PushOptions, item *model.PushItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if o != nil && o.MsgID != "" {
+		return s.AddReceiptMsg(ctx, o.MsgID, o.From, item)
+	}
+	return nil
+}
+
+// pushOptions returns the options of a push, rate limited as the caller.
+// The targets are scoped to the app of the key, or the app asked by a
+// caller of the default app.
+func pushOptions(ctx context.Context, o *pb.PushOptions) *model.PushOptions {
+	opt := &model.PushOptions{Caller: pushCaller(ctx)}
+	if o != nil {
+		opt.TTL, opt.Priority, opt.MsgID, opt.App = time.Duration(o.Ttl)*time.Second, o.Priority, o.MsgID, o.App
+	}
+	if k := caller(ctx); k != nil && k.App != "" {
+		opt.App = k.App
+	}
+	return opt
+}
+
+// peerHost returns the host of the peer of the call, "" if unknown.
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// pushError returns the status error of a push, a push beyond a rate limit
+// tells the seconds to retry after in the retry-after header.
+func pushError(ctx context.Context, err error) error {
+	if rl := model.AsRateLimited(err); rl != nil {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.FormatInt(retryAfter(rl), 10)))
+	}
+	return grpcError(err)
+}
+
+// retryAfter returns the seconds to retry a push beyond a rate limit after.
+func retryAfter(rl *model.RateLimited) int64 {
+	if secs := int64((rl.RetryAfter + time.Second - 1) / time.Second); secs > 0 {
+		return secs
+	}
+	return 1
+}
+
+func pushReply(res *model.PushResult) *pb.PushReply {
+	if res == nil {
+		return &pb.PushReply{}
+	}
+	return &pb.PushReply{
+		OnlineKeys:  res.OnlineKeys,
+		OfflineKeys: res.OfflineKeys,
+		OnlineMids:  res.OnlineMids,
+		OfflineMids: res.OfflineMids,
+		StoredMids:  res.StoredMids,
+		Enqueued:    int32(res.Enqueued),
+		Seq:         res.Seq,
+	}
+}
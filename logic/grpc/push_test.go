@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/ratelimit"
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+	pb "github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/logic"
+	"github.com/swanky2009/goim/logic/dao"
+	"github.com/swanky2009/goim/logic/model"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidatePush(t *testing.T) {
+	msg := []byte("hello")
+	assert.Nil(t, validatePush(1, 1, msg, nil))
+	assert.Nil(t, validatePush(1, 2, msg, &pb.PushOptions{Ttl: 10, Priority: "high", MsgID: "m1", From: 1}))
+	for _, err := range []error{
+		validatePush(0, 1, msg, nil),
+		validatePush(1, 0, msg, nil),
+		validatePush(1, 1, nil, nil),
+		validatePush(1, 1, make([]byte, 1<<13), nil),
+		validatePush(1, 1, msg, &pb.PushOptions{Ttl: -1}),
+		validatePush(1, 1, msg, &pb.PushOptions{Priority: "urgent"}),
+		validatePush(1, 1, msg, &pb.PushOptions{MsgID: "m1"}),
+	} {
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestGRPCError(t *testing.T) {
+	assert.Nil(t, grpcError(nil))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(grpcError(ratelimit.ErrLimited)))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(grpcError(logic.ErrAppQuota)))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(grpcError(&model.RateLimited{Scope: "room", RetryAfter: time.Second})))
+	assert.Equal(t, codes.Unavailable, status.Code(grpcError(gobreaker.ErrOpenState)))
+	assert.Equal(t, codes.Unavailable, status.Code(grpcError(gobreaker.ErrTooManyRequests)))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(grpcError(context.DeadlineExceeded)))
+	assert.Equal(t, codes.Canceled, status.Code(grpcError(context.Canceled)))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(grpcError(dao.ErrNoRedis)))
+	assert.Equal(t, codes.Internal, status.Code(grpcError(errors.New("kafka down"))))
+	assert.Equal(t, codes.NotFound, status.Code(grpcError(status.Error(codes.NotFound, "x"))))
+}
+
+func TestBeforePushDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := beforePush(ctx, nil, &pb.PushOptions{MsgID: "m1", From: 1}, &model.PushItem{Type: model.PushMids, Mids: []int64{2}})
+	assert.Equal(t, codes.Canceled, status.Code(grpcError(err)))
+}
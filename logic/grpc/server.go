@@ -7,8 +7,10 @@ import (
 
 	pb "github.com/swanky2009/goim/grpc/logic"
 	"github.com/swanky2009/goim/logic"
+	"github.com/swanky2009/goim/logic/apikey"
 	"github.com/swanky2009/goim/logic/g"
 	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/handlers"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
@@ -16,8 +18,18 @@ import (
 	"google.golang.org/grpc/keepalive"
 )
 
-// New logic grpc server
-func New(c *conf.RPCServer, s *logic.Server) *grpc.Server {
+// New logic grpc server, the callers of the pushes are authenticated if api
+// keys are configured, and the rpcs are wrapped in the middlewares of the chain.
+func New(c *conf.RPCServer, a *conf.APIAuth, s *logic.Server, chain *handlers.Chain) *grpc.Server {
+	var auth *apikey.Keys
+	if a != nil && len(a.Keys) > 0 {
+		var err error
+		if auth, err = apikey.New(a, s.UseNonce); err != nil {
+			panic(err)
+		}
+	} else {
+		g.Logger.Warningf("grpc push is not authenticated, configure httpserver.api_auth")
+	}
 	keepParams := grpc.KeepaliveParams(keepalive.ServerParameters{
 		MaxConnectionIdle:     time.Duration(c.IdleTimeout),
 		MaxConnectionAgeGrace: time.Duration(c.ForceCloseWait),
@@ -30,9 +42,9 @@ func New(c *conf.RPCServer, s *logic.Server) *grpc.Server {
 	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
 
 	//初始化注入点
-	endpoints = NewEndpoints(s)
+	endpoints = NewEndpoints(s, chain)
 
-	pb.RegisterLogicServer(srv, &server{s})
+	pb.RegisterLogicServer(srv, &server{srv: s, auth: auth})
 
 	return srv
 }
@@ -54,68 +66,108 @@ func Start(c *conf.RPCServer, s *grpc.Server, errc chan error) {
 }
 
 type server struct {
-	srv *logic.Server
+	srv  *logic.Server
+	auth *apikey.Keys
 }
 
 var _ pb.LogicServer = &server{}
 
 // Ping Service
 func (s *server) Ping(ctx context.Context, req *pb.PingReq) (*pb.PingReply, error) {
-	return &pb.PingReply{}, nil
+	resp, err := endpoints.PingEndpoint(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.PingReply), nil
 }
 
 // Close Service
 func (s *server) Close(ctx context.Context, req *pb.CloseReq) (*pb.CloseReply, error) {
-	return &pb.CloseReply{}, nil
+	resp, err := endpoints.CloseEndpoint(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.CloseReply), nil
 }
 
 // Connect connect a conn.
 func (s *server) Connect(ctx context.Context, req *pb.ConnectReq) (*pb.ConnectReply, error) {
-
-	//call middleware func add Zipkin,ratelimit,circuitbreaker
 	resp, err := endpoints.ConnectEndpoint(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, grpcError(err)
 	}
 	return resp.(*pb.ConnectReply), nil
-
-	// mid, key, room, platform, accepts, err := s.srv.Connect(ctx, req.Server, req.ServerKey, req.Cookie, req.Token)
-	// if err != nil {
-	// 	return &pb.ConnectReply{}, err
-	// }
-	// return &pb.ConnectReply{Mid: mid, Key: key, RoomID: room, Accepts: accepts, Platform: platform}, nil
 }
 
 // Disconnect disconnect a conn.
 func (s *server) Disconnect(ctx context.Context, req *pb.DisconnectReq) (*pb.DisconnectReply, error) {
-	has, err := s.srv.Disconnect(ctx, req.Mid, req.Key, req.Server)
+	resp, err := endpoints.DisconnectEndpoint(ctx, req)
 	if err != nil {
-		return &pb.DisconnectReply{}, err
+		return nil, grpcError(err)
 	}
-	return &pb.DisconnectReply{Has: has}, nil
+	return resp.(*pb.DisconnectReply), nil
 }
 
 // Heartbeat beartbeat a conn.
 func (s *server) Heartbeat(ctx context.Context, req *pb.HeartbeatReq) (*pb.HeartbeatReply, error) {
-	if err := s.srv.Heartbeat(ctx, req.Mid, req.Key, req.Server); err != nil {
-		return &pb.HeartbeatReply{}, err
+	resp, err := endpoints.HeartbeatEndpoint(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
 	}
-	return &pb.HeartbeatReply{}, nil
+	return resp.(*pb.HeartbeatReply), nil
 }
 
 // RenewOnline renew server online.
 func (s *server) RenewOnline(ctx context.Context, req *pb.OnlineReq) (*pb.OnlineReply, error) {
-	allRoomCount, err := s.srv.RenewOnline(ctx, req.Server, req.RoomCount)
+	resp, err := endpoints.RenewOnlineEndpoint(ctx, req)
 	if err != nil {
-		return &pb.OnlineReply{}, err
+		return nil, grpcError(err)
 	}
-	return &pb.OnlineReply{AllRoomCount: allRoomCount}, nil
+	return resp.(*pb.OnlineReply), nil
 }
 
-// Receive receive a message.
+// Receive receive a message, a message refused is replied with the reason.
 func (s *server) Receive(ctx context.Context, req *pb.ReceiveReq) (*pb.ReceiveReply, error) {
-	if err := s.srv.Receive(ctx, req.Mid, req.Room, req.Op, req.Msg); err != nil {
-		return &pb.ReceiveReply{}, err
+	resp, err := endpoints.ReceiveEndpoint(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.ReceiveReply), nil
+}
+
+// JoinRoom check a channel may join a room, a join refused is replied with
+// the reason. The room is scoped to the app of the key.
+func (s *server) JoinRoom(ctx context.Context, req *pb.JoinRoomReq) (*pb.JoinRoomReply, error) {
+	resp, err := endpoints.JoinRoomEndpoint(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.JoinRoomReply), nil
+}
+
+// RoomHistory get the messages of a room after the seq.
+func (s *server) RoomHistory(ctx context.Context, req *pb.RoomHistoryReq) (*pb.RoomHistoryReply, error) {
+	resp, err := endpoints.RoomHistoryEndpoint(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.RoomHistoryReply), nil
+}
+
+// Presence get the online sessions of the mids.
+func (s *server) Presence(ctx context.Context, req *pb.PresenceReq) (*pb.PresenceReply, error) {
+	resp, err := endpoints.PresenceEndpoint(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return resp.(*pb.PresenceReply), nil
+}
+
+// OnlineMids get the mids online of the mids.
+func (s *server) OnlineMids(ctx context.Context, req *pb.OnlineMidsReq) (*pb.OnlineMidsReply, error) {
+	resp, err := endpoints.OnlineMidsEndpoint(ctx, req)
+	if err != nil {
+		return nil, grpcError(err)
 	}
-	return &pb.ReceiveReply{}, nil
+	return resp.(*pb.OnlineMidsReply), nil
 }
@@ -26,3 +26,19 @@ func InterruptHandler(srv *logic.Server, rpcSrv *grpc.Server, httpSrv *http.Serv
 
 	errc <- terminateError
 }
+
+// ReloadHandler reloads the middlewares of the endpoints from the config on
+// SIGHUP, the other settings take a restart.
+func ReloadHandler(chain *Chain) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	for range c {
+		conf, err := g.ReloadConf()
+		if err != nil {
+			g.Logger.Errorf("reload conf error(%v)", err)
+			continue
+		}
+		chain.Reload(conf.Endpoints)
+		g.Logger.Infof("conf reloaded")
+	}
+}
@@ -1,37 +1,203 @@
 package handlers
 
 import (
-	"github.com/go-kit/kit/circuitbreaker"
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/ratelimit"
+	"github.com/sirupsen/logrus"
 	"github.com/sony/gobreaker"
 	"golang.org/x/time/rate"
-	"time"
+
+	"github.com/swanky2009/goim/logic"
+	"github.com/swanky2009/goim/logic/auth"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/model"
 )
 
-// WrapEndpoints accepts the service's entire collection of endpoints, so that a
-// set of middlewares can be wrapped around every middleware (e.g., access
-// logging and instrumentation), and others wrapped selectively around some
-// endpoints and not others (e.g., endpoints requiring authenticated access).
-// Note that the final middleware wrapped will be the outermost middleware
-// (i.e. applied first)
-func WrapEndpoints(in endpoint.Endpoint) endpoint.Endpoint {
-	//全链路追踪
-	in = ZipkinEndpointMiddleware()(in)
+// Chain wraps the endpoints of logic in the middlewares configured per
+// endpoint name, a grpc method or an http path, and the endpoints not
+// configured in the "default" ones. The middlewares are rebuilt on Reload.
+type Chain struct {
+	mu   sync.Mutex
+	conf map[string]*conf.Middleware
+	eps  map[string]*chainEndpoint
+}
 
-	//限频
-	in = ratelimit.NewErroringLimiter(rate.NewLimiter(
-		1000,  //每秒产生的令牌数
-		10000, //令牌池最大值
-	))(in)
+// chainEndpoint an endpoint and its current middlewares.
+type chainEndpoint struct {
+	name    string
+	next    endpoint.Endpoint
+	mw      *conf.Middleware
+	wrapped atomic.Value // endpoint.Endpoint
+}
 
+// NewChain new a chain of the middlewares of the endpoints.
+func NewChain(c map[string]*conf.Middleware) *Chain {
+	return &Chain{conf: c, eps: make(map[string]*chainEndpoint)}
+}
+
+// Wrap returns the endpoint wrapped in the middlewares of the name.
+func (c *Chain) Wrap(name string, next endpoint.Endpoint) endpoint.Endpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ep := &chainEndpoint{name: name, next: next}
+	ep.set(c.middleware(name))
+	c.eps[name] = ep
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return ep.wrapped.Load().(endpoint.Endpoint)(ctx, request)
+	}
+}
+
+// Reload swaps the middlewares of the endpoints, the endpoints whose
+// settings did not change keep their limiters and breakers.
+func (c *Chain) Reload(conf map[string]*conf.Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conf = conf
+	for name, ep := range c.eps {
+		if mw := c.middleware(name); !reflect.DeepEqual(mw, ep.mw) {
+			ep.set(mw)
+			g.Logger.Infof("endpoint %s middlewares reloaded", name)
+		}
+	}
+}
+
+func (c *Chain) middleware(name string) *conf.Middleware {
+	if mw, ok := c.conf[name]; ok && mw != nil {
+		return mw
+	}
+	if mw := c.conf["default"]; mw != nil {
+		return mw
+	}
+	return new(conf.Middleware)
+}
+
+func (ep *chainEndpoint) set(mw *conf.Middleware) {
+	ep.mw = mw
+	ep.wrapped.Store(WrapEndpoint(ep.name, mw, ep.next))
+}
+
+// WrapEndpoint wraps the endpoint in the middlewares. Note that the final
+// middleware wrapped will be the outermost middleware (i.e. applied first).
+func WrapEndpoint(name string, mw *conf.Middleware, in endpoint.Endpoint) endpoint.Endpoint {
+	if mw.Timeout > 0 {
+		in = TimeoutMiddleware(time.Duration(mw.Timeout))(in)
+	}
 	//熔断
-	in = circuitbreaker.Gobreaker(
-		gobreaker.NewCircuitBreaker(gobreaker.Settings{
-			Name:        "logic-rpc",
-			MaxRequests: 5000,
-			Interval:    1 * time.Second,
+	if b := mw.Breaker; b != nil {
+		in = BreakerMiddleware(gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:        name,
+			MaxRequests: b.MaxRequests,
+			Interval:    time.Duration(b.Interval),
+			Timeout:     time.Duration(b.Timeout),
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= b.Failures
+			},
 		}))(in)
-
+	}
+	//限频
+	if l := mw.RateLimit; l != nil {
+		in = ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Limit(l.Rate), l.Burst))(in)
+	}
+	if mw.Metrics {
+		in = MetricsMiddleware(name)(in)
+	}
+	if mw.Logging {
+		in = LoggingMiddleware(name)(in)
+	}
+	//全链路追踪
+	if mw.Tracing {
+		in = ZipkinEndpointMiddleware(name)(in)
+	}
 	return in
 }
+
+// TimeoutMiddleware gives up the calls beyond the timeout.
+func TimeoutMiddleware(timeout time.Duration) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return next(ctx, request)
+		}
+	}
+}
+
+// BreakerMiddleware opens the breaker on the failures of logic, the errors
+// caused by the caller, like a bad token or a push beyond a limit, are
+// returned as is but do not count.
+func BreakerMiddleware(cb *gobreaker.CircuitBreaker) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			var callErr error
+			response, err = cb.Execute(func() (interface{}, error) {
+				var resp interface{}
+				if resp, callErr = next(ctx, request); callErr != nil && !callerError(callErr) {
+					return resp, callErr
+				}
+				return resp, nil
+			})
+			if err == nil {
+				err = callErr
+			}
+			return
+		}
+	}
+}
+
+// callerError reports whether the error is caused by the caller.
+func callerError(err error) bool {
+	switch err {
+	case context.Canceled, auth.ErrUnauthorized, auth.ErrTokenInvalid, auth.ErrTokenExpired,
+		logic.ErrAppQuota, logic.ErrScheduleTooLate, model.ErrInvalidID:
+		return true
+	}
+	return model.AsRateLimited(err) != nil || model.AsReject(err) != nil
+}
+
+// MetricsMiddleware counts the calls and the errors of the endpoint, and
+// observes their latency.
+func MetricsMiddleware(name string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func(begin time.Time) {
+				g.StatMetrics.EndpointRequests.With("endpoint", name, "error", boolLabel(err != nil)).Add(1)
+				g.StatMetrics.EndpointLatency.With("endpoint", name).Observe(time.Since(begin).Seconds())
+			}(time.Now())
+			return next(ctx, request)
+		}
+	}
+}
+
+// LoggingMiddleware logs the calls of the endpoint, the failed at warning.
+func LoggingMiddleware(name string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func(begin time.Time) {
+				entry := g.Logger.WithFields(logrus.Fields{
+					"endpoint": name,
+					"took":     time.Since(begin),
+				})
+				if err != nil {
+					entry.WithField("error", err).Warn("endpoint call")
+					return
+				}
+				entry.Info("endpoint call")
+			}(time.Now())
+			return next(ctx, request)
+		}
+	}
+}
+
+func boolLabel(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/ratelimit"
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/swanky2009/goim/logic/auth"
+	"github.com/swanky2009/goim/logic/g/conf"
+	xtime "github.com/swanky2009/goim/pkg/time"
+)
+
+func okEndpoint(ctx context.Context, request interface{}) (interface{}, error) {
+	return request, nil
+}
+
+func TestChainRateLimit(t *testing.T) {
+	chain := NewChain(map[string]*conf.Middleware{
+		"Connect": {RateLimit: &conf.EndpointLimit{Rate: 0.001, Burst: 1}},
+		"default": {Metrics: true},
+	})
+	connect := chain.Wrap("Connect", okEndpoint)
+	heartbeat := chain.Wrap("Heartbeat", okEndpoint)
+	_, err := connect(context.Background(), 1)
+	assert.Nil(t, err)
+	_, err = connect(context.Background(), 2)
+	assert.Equal(t, ratelimit.ErrLimited, err)
+	// the default middlewares do not limit
+	for i := 0; i < 3; i++ {
+		_, err = heartbeat(context.Background(), i)
+		assert.Nil(t, err)
+	}
+}
+
+func TestChainReload(t *testing.T) {
+	limited := map[string]*conf.Middleware{"default": {RateLimit: &conf.EndpointLimit{Rate: 0.001, Burst: 1}}}
+	chain := NewChain(limited)
+	ep := chain.Wrap("PushAll", okEndpoint)
+	ep(context.Background(), 1)
+	_, err := ep(context.Background(), 2)
+	assert.Equal(t, ratelimit.ErrLimited, err)
+	// the same settings keep the limiter
+	chain.Reload(map[string]*conf.Middleware{"default": {RateLimit: &conf.EndpointLimit{Rate: 0.001, Burst: 1}}})
+	_, err = ep(context.Background(), 3)
+	assert.Equal(t, ratelimit.ErrLimited, err)
+	chain.Reload(map[string]*conf.Middleware{"default": {}})
+	_, err = ep(context.Background(), 4)
+	assert.Nil(t, err)
+}
+
+func TestBreakerMiddleware(t *testing.T) {
+	failed := errors.New("redis down")
+	errc := auth.ErrTokenInvalid
+	ep := WrapEndpoint("Connect", &conf.Middleware{Breaker: &conf.Breaker{Timeout: xtime.Duration(time.Minute), Failures: 2}},
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			return nil, errc
+		})
+	// the errors of the callers do not open it
+	for i := 0; i < 3; i++ {
+		_, err := ep(context.Background(), nil)
+		assert.Equal(t, auth.ErrTokenInvalid, err)
+	}
+	errc = failed
+	for i := 0; i < 2; i++ {
+		_, err := ep(context.Background(), nil)
+		assert.Equal(t, failed, err)
+	}
+	_, err := ep(context.Background(), nil)
+	assert.Equal(t, gobreaker.ErrOpenState, err)
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	ep := WrapEndpoint("Receive", &conf.Middleware{Timeout: xtime.Duration(time.Millisecond)},
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	_, err := ep(context.Background(), nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/swanky2009/goim/logic/g"
 )
 
-func ZipkinEndpointMiddleware() endpoint.Middleware {
+// ZipkinEndpointMiddleware traces the calls of the endpoint in a span child
+// of the b3 span in the incoming metadata.
+func ZipkinEndpointMiddleware(name string) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 
@@ -26,7 +28,7 @@ func ZipkinEndpointMiddleware() endpoint.Middleware {
 			md, _ := metadata.FromIncomingContext(ctx)
 			sc = zipkinTracer.Extract(b3.ExtractGRPC(&md))
 
-			span := zipkinTracer.StartSpan("in service goim-logic", zipkingo.Parent(sc))
+			span := zipkinTracer.StartSpan(name, zipkingo.Parent(sc), zipkingo.Tags(map[string]string{"service": "goim-logic"}))
 			span.Annotate(time.Now(), "in endpoint")
 
 			defer func() {
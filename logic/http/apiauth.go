@@ -0,0 +1,161 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/swanky2009/goim/logic/apikey"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// The headers of an authenticated request, a caller sends its key id and
+// secret as is, or signs the method, request uri and body, see apikey.
+const (
+	headerKeyID     = "X-Goim-Key-Id"
+	headerKey       = "X-Goim-Key"
+	headerTimestamp = "X-Goim-Timestamp"
+	headerNonce     = "X-Goim-Nonce"
+	headerSignature = "X-Goim-Signature"
+)
+
+type callerKey struct{}
+
+// apiAuth authenticates the callers by api keys or signatures.
+type apiAuth struct {
+	*apikey.Keys
+}
+
+func newAPIAuth(c *conf.APIAuth, useNonce func(c context.Context, id, nonce string, expire time.Duration) (bool, error)) (*apiAuth, error) {
+	keys, err := apikey.New(c, useNonce)
+	if err != nil {
+		return nil, err
+	}
+	return &apiAuth{keys}, nil
+}
+
+// authenticate returns the key of the caller.
+func (a *apiAuth) authenticate(r *http.Request) (k *apikey.Key, err error) {
+	id := r.Header.Get(headerKeyID)
+	sig := r.Header.Get(headerSignature)
+	if sig == "" {
+		return a.Authenticate(id, r.Header.Get(headerKey))
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return a.Verify(r.Context(), id, &apikey.Signed{
+		Method:    r.Method,
+		URI:       r.RequestURI,
+		Timestamp: r.Header.Get(headerTimestamp),
+		Nonce:     r.Header.Get(headerNonce),
+		Signature: sig,
+		Body:      body,
+	})
+}
+
+// handler authenticates the requests before the next handler.
+func (a *apiAuth) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k, err := a.authenticate(r)
+		if err != nil {
+			g.Logger.Warningf("api auth %s %s remote:%s key:%s error(%v)", r.Method, r.URL.Path, r.RemoteAddr, r.Header.Get(headerKeyID), err)
+			if strings.HasPrefix(r.URL.Path, "/v2/") {
+				writeJSONV2(w, ErrUnauthorized.withMessage(err.Error()), nil)
+			} else {
+				writeJSON(w, Unauthorized, nil)
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, k)))
+	})
+}
+
+// caller returns the key of the request, nil if the api is not authenticated.
+func caller(r *http.Request) *apikey.Key {
+	k, _ := r.Context().Value(callerKey{}).(*apikey.Key)
+	return k
+}
+
+// callerID returns the key id of the request, empty if the api is not authenticated.
+func callerID(r *http.Request) string {
+	if k := caller(r); k != nil {
+		return k.ID
+	}
+	return ""
+}
+
+// pushCaller returns the caller a push is rate limited as, the id of its
+// api key or the host of an anonymous caller.
+func pushCaller(r *http.Request) string {
+	if id := callerID(r); id != "" {
+		return id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// appOf returns the app the request is scoped to, the app of the caller, or
+// the app asked by a caller of the default app, empty for the ids as is.
+func appOf(r *http.Request) string {
+	if k := caller(r); k != nil && k.App != "" {
+		return k.App
+	}
+	return r.URL.Query().Get("app")
+}
+
+// unscopeRoom returns the room of a room config without the app.
+func unscopeRoom(rc *model.RoomConfig, app string) *model.RoomConfig {
+	if rc != nil && app != "" {
+		_, rc.Room = model.DecodeRoomKey(rc.Room)
+	}
+	return rc
+}
+
+// allow reports whether the caller may push the type, op and room.
+func allow(r *http.Request, typ string, op int32, room string) bool {
+	k := caller(r)
+	return k == nil || k.Allow(typ, op, room)
+}
+
+// allowRoom reports whether the caller may administer the room.
+func allowRoom(r *http.Request, room string) bool {
+	k := caller(r)
+	return k == nil || k.AllowRoom(room)
+}
+
+// allowRead reports whether the caller may read the connections and the
+// messages of the mids of its app, which are not limited by the types, ops or
+// rooms of a key, so only a key without them may.
+func allowRead(r *http.Request) bool {
+	k := caller(r)
+	return k == nil || !k.Scoped()
+}
+
+// audit logs a push naming the caller.
+func audit(r *http.Request, typ string, op int32, target string, err error) {
+	name := callerID(r)
+	if name == "" {
+		name = "anonymous"
+	}
+	g.Logger.WithFields(logrus.Fields{
+		"caller": name,
+		"remote": r.RemoteAddr,
+		"type":   typ,
+		"op":     op,
+		"target": target,
+		"error":  err,
+	}).Info("audit push")
+}
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swanky2009/goim/logic/apikey"
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/model"
+	xtime "github.com/swanky2009/goim/pkg/time"
+)
+
+func testAPIAuth(t *testing.T) *apiAuth {
+	nonces := make(map[string]bool)
+	a, err := newAPIAuth(&conf.APIAuth{
+		Window: xtime.Duration(time.Minute),
+		Keys: []*conf.APIKey{
+			{ID: "admin", Secret: "admin_secret"},
+			{ID: "chat", Secret: "chat_secret", SignOnly: true, Types: []string{model.PushRoom}, Ops: "1000-1099", Rooms: []string{"chat://"}},
+			{ID: "shop", Secret: "shop_secret", App: "shop"},
+		},
+	}, func(c context.Context, id, nonce string, expire time.Duration) (bool, error) {
+		if nonces[id+nonce] {
+			return false, nil
+		}
+		nonces[id+nonce] = true
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// testKey returns the key of the id authenticated by a signature.
+func testKey(t *testing.T, id, secret string) *apikey.Key {
+	k, err := testAPIAuth(t).authenticate(signedRequest(id, secret, "/v2/push", "", "n", time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func signedRequest(id, secret, uri, body, nonce string, ts time.Time) *http.Request {
+	r := httptest.NewRequest("POST", uri, strings.NewReader(body))
+	tsStr := strconv.FormatInt(ts.Unix(), 10)
+	r.Header.Set(headerKeyID, id)
+	r.Header.Set(headerTimestamp, tsStr)
+	r.Header.Set(headerNonce, nonce)
+	r.Header.Set(headerSignature, hex.EncodeToString(apikey.Sign(secret, "POST", uri, tsStr, nonce, []byte(body))))
+	return r
+}
+
+func TestAPIAuthKey(t *testing.T) {
+	a := testAPIAuth(t)
+	r := httptest.NewRequest("POST", "/push/all?op=1000", nil)
+	r.Header.Set(headerKeyID, "admin")
+	r.Header.Set(headerKey, "admin_secret")
+	k, err := a.authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", k.ID)
+	assert.True(t, k.Allow(model.PushAll, 1, ""))
+
+	r.Header.Set(headerKey, "wrong")
+	_, err = a.authenticate(r)
+	assert.NotNil(t, err)
+	// chat signs only
+	r.Header.Set(headerKeyID, "chat")
+	r.Header.Set(headerKey, "chat_secret")
+	_, err = a.authenticate(r)
+	assert.NotNil(t, err)
+}
+
+func TestAPIAuthSignature(t *testing.T) {
+	var (
+		a    = testAPIAuth(t)
+		uri  = "/push/room?op=1000&room=chat://1"
+		body = "hello"
+	)
+	k, err := a.authenticate(signedRequest("chat", "chat_secret", uri, body, "n1", time.Now()))
+	assert.Nil(t, err)
+	assert.Equal(t, "chat", k.ID)
+	// replay
+	_, err = a.authenticate(signedRequest("chat", "chat_secret", uri, body, "n1", time.Now()))
+	assert.NotNil(t, err)
+	// expired
+	_, err = a.authenticate(signedRequest("chat", "chat_secret", uri, body, "n2", time.Now().Add(-time.Hour)))
+	assert.NotNil(t, err)
+	// tampered body
+	r := signedRequest("chat", "chat_secret", uri, body, "n3", time.Now())
+	r.Body = httptest.NewRequest("POST", uri, strings.NewReader("bye")).Body
+	_, err = a.authenticate(r)
+	assert.NotNil(t, err)
+}
+
+func TestAPIAuthHandler(t *testing.T) {
+	var (
+		a      = testAPIAuth(t)
+		called *apikey.Key
+	)
+	h := a.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = caller(r)
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/v2/push", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Nil(t, called)
+
+	r := httptest.NewRequest("POST", "/v2/push", nil)
+	r.Header.Set(headerKeyID, "admin")
+	r.Header.Set(headerKey, "admin_secret")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, "admin", called.ID)
+}
+
+func TestAPIAuthApp(t *testing.T) {
+	var (
+		a   = testAPIAuth(t)
+		app string
+	)
+	h := a.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app = appOf(r)
+	}))
+	for _, c := range []struct {
+		id, secret, uri, app string
+	}{
+		{"admin", "admin_secret", "/v2/push", ""},
+		{"admin", "admin_secret", "/v2/push?app=game", "game"},
+		{"shop", "shop_secret", "/v2/push", "shop"},
+		// a key of an app may not ask another
+		{"shop", "shop_secret", "/v2/push?app=game", "shop"},
+	} {
+		r := httptest.NewRequest("POST", c.uri, nil)
+		r.Header.Set(headerKeyID, c.id)
+		r.Header.Set(headerKey, c.secret)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Equal(t, c.app, app, c.id+" "+c.uri)
+	}
+}
+
+func TestAPIAuthRead(t *testing.T) {
+	var (
+		k = testKey(t, "chat", "chat_secret")
+		s = &Server{}
+	)
+	// the chat key is limited to some rooms, the forbidden reads are replied
+	// before logic is asked
+	for _, c := range []struct {
+		uri string
+		h   http.HandlerFunc
+	}{
+		{"/offline/msgs?mid=1", s.offlineMsgs},
+		{"/offline/clear?mid=1", s.offlineClear},
+		{"/receipt?msg_id=m1", s.receipt},
+		{"/online/top", s.onlineTop},
+		{"/online/app", s.onlineApp},
+		{"/online/presence?mids=1", s.onlinePresence},
+		{"/online/mids?mids=1", s.onlineMids},
+		{"/online/room?room=chat://1,live://1", s.onlineRoom},
+		{"/room/history?room=live://1", s.roomHistory},
+	} {
+		r := httptest.NewRequest("GET", c.uri, nil)
+		w := httptest.NewRecorder()
+		c.h(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, k)))
+		assert.Equal(t, `{"code":-403}`, w.Body.String(), c.uri)
+	}
+}
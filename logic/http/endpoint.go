@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/ratelimit"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+	"github.com/sony/gobreaker"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// httpCall a call of a route as the request of its endpoint.
+type httpCall struct {
+	w *statusWriter
+	r *http.Request
+}
+
+// statusWriter records the status and the error replied by a handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	err    error
+	wrote  bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status, w.wrote = status, true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+// replied records the error replied by a handler, so that the middlewares
+// see the failures of the v1 api replied with 200 and a code.
+func replied(w http.ResponseWriter, err error) {
+	if sw, ok := w.(*statusWriter); ok && sw.err == nil {
+		sw.err = err
+	}
+}
+
+// statusError a reply of a server error already written by the handler,
+// so that the middlewares count it as a failure.
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("http status %d", int(e))
+}
+
+// codeError a v1 reply of a server error code already written by the handler.
+type codeError int
+
+func (e codeError) Error() string {
+	return fmt.Sprintf("code %d", int(e))
+}
+
+// _b3Headers the headers of a zipkin span passed in the metadata.
+var _b3Headers = []string{b3.TraceID, b3.SpanID, b3.ParentSpanID, b3.Sampled, b3.Flags}
+
+// handle routes the path to the handler as an endpoint named by the path,
+// wrapped in the middlewares of the chain.
+func (s *Server) handle(mux *http.ServeMux, path string, h http.HandlerFunc) {
+	ep := s.chain.Wrap(path, handlerEndpoint(h))
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		// the b3 headers are passed as grpc metadata, so that the tracing
+		// middleware finds the parent span the same as an rpc
+		md := metadata.MD{}
+		for _, k := range _b3Headers {
+			if v := r.Header.Get(k); v != "" {
+				md.Set(k, v)
+			}
+		}
+		ctx := metadata.NewIncomingContext(r.Context(), md)
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		if _, err := ep(ctx, &httpCall{w: sw, r: r}); err != nil && !sw.wrote {
+			writeJSONV2(w, endpointError(err), nil)
+		}
+	})
+}
+
+// handlerEndpoint calls the handler in an endpoint, the error replied or a
+// server error status is the error of the call, the reply is written by the
+// handler as is.
+func handlerEndpoint(h http.HandlerFunc) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		call := request.(*httpCall)
+		h(call.w, call.r.WithContext(ctx))
+		if call.w.err != nil {
+			return nil, call.w.err
+		}
+		if call.w.status >= http.StatusInternalServerError {
+			return nil, statusError(call.w.status)
+		}
+		return nil, nil
+	}
+}
+
+// endpointError returns the v2 error of a call refused by a middleware.
+func endpointError(err error) *Error {
+	switch err {
+	case ratelimit.ErrLimited:
+		return ErrTooManyRequests.withMessage(err.Error())
+	case gobreaker.ErrOpenState, gobreaker.ErrTooManyRequests:
+		return ErrUnavailable.withMessage(err.Error())
+	case context.DeadlineExceeded:
+		return ErrTimeout.withMessage(err.Error())
+	}
+	return ErrInternal.withMessage(err.Error())
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/handlers"
+	"github.com/swanky2009/goim/logic/model"
+	xtime "github.com/swanky2009/goim/pkg/time"
+)
+
+func TestHandleEndpoint(t *testing.T) {
+	s := &Server{chain: handlers.NewChain(map[string]*conf.Middleware{
+		"/ok":   {RateLimit: &conf.EndpointLimit{Rate: 0.001, Burst: 1}},
+		"/fail": {Metrics: true},
+	})}
+	mux := http.NewServeMux()
+	s.handle(mux, "/ok", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, OK, nil)
+	})
+	s.handle(mux, "/fail", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONV2(w, ErrInternal, nil)
+	})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	// beyond the limit of the route
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	// a server error is replied once by the handler
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/fail", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `{"code":50000,"message":"internal error"}`, w.Body.String())
+}
+
+func TestHandleEndpointBreaker(t *testing.T) {
+	s := &Server{chain: handlers.NewChain(map[string]*conf.Middleware{
+		"default": {Breaker: &conf.Breaker{Failures: 2, Timeout: xtime.Duration(time.Minute)}},
+	})}
+	var pushErr error
+	mux := http.NewServeMux()
+	s.handle(mux, "/push/keys", func(w http.ResponseWriter, r *http.Request) {
+		if pushErr != nil {
+			writePushError(w, pushErr, nil)
+			return
+		}
+		writeJSON(w, OK, nil)
+	})
+	s.handle(mux, "/online/top", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, ServerErr, nil)
+	})
+	call := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", path, nil))
+		return w
+	}
+	// the pushes beyond a rate limit are the caller's
+	pushErr = &model.RateLimited{Scope: "caller", RetryAfter: time.Second}
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusTooManyRequests, call("/push/keys").Code)
+	}
+	// a v1 push failed is replied with 200 and a code, and trips the breaker
+	pushErr = errors.New("kafka down")
+	for i := 0; i < 2; i++ {
+		w := call("/push/keys")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"code":-400}`, w.Body.String())
+	}
+	pushErr = nil
+	w := call("/push/keys")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, ErrUnavailable.Code, decodeCode(t, w))
+	// a v1 server error code trips the breaker
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, `{"code":-500}`, call("/online/top").Body.String())
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, call("/online/top").Code)
+}
+
+func decodeCode(t *testing.T, w *httptest.ResponseRecorder) int {
+	var ret RetV2
+	if err := json.Unmarshal(w.Body.Bytes(), &ret); err != nil {
+		t.Fatal(err)
+	}
+	return ret.Code
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	// headerIdempotencyKey a retried push with the same key of the caller is
+	// pushed once within the idempotency window.
+	headerIdempotencyKey = "Idempotency-Key"
+	// headerIdempotentReplayed is set on the response of a duplicate push.
+	headerIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// idempotencyKey returns the idempotency key of the request scoped by the
+// caller, the header is used if the key is empty. Empty if none.
+func idempotencyKey(r *http.Request, key string) string {
+	if key == "" {
+		key = r.Header.Get(headerIdempotencyKey)
+	}
+	if key == "" {
+		return ""
+	}
+	return callerID(r) + ":" + key
+}
+
+// idempotent runs the push once per idempotency key, a duplicate returns the
+// data of the first push and dup is true. Without a key the push always runs.
+func (s *Server) idempotent(c context.Context, key string, push func() (interface{}, error)) (data interface{}, dup bool, err error) {
+	if key == "" {
+		data, err = push()
+		return
+	}
+	ok, res, err := s.logic.AcquireIdempotencyKey(c, key)
+	if err != nil {
+		return
+	}
+	if !ok {
+		if len(res) > 0 {
+			data = res
+		}
+		return data, true, nil
+	}
+	data, err = push()
+	s.logic.ReleaseIdempotencyKey(c, key, data, err)
+	return
+}
+
+// replayed marks the response of a duplicate push.
+func replayed(w http.ResponseWriter, dup bool) {
+	if dup {
+		w.Header().Set(headerIdempotentReplayed, "true")
+	}
+}
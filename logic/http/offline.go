@@ -0,0 +1,41 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) offlineMsgs(w http.ResponseWriter, r *http.Request) {
+	mid, err := strconv.ParseInt(r.URL.Query().Get("mid"), 10, 64)
+	if err != nil {
+		writeJSON(w, RequestErr, nil)
+		return
+	}
+	if !allowRead(r) {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	res, err := s.logic.OfflineMsgs(r.Context(), appOf(r), mid)
+	if err != nil {
+		writeJSON(w, ServerErr, nil)
+		return
+	}
+	writeJSON(w, OK, res)
+}
+
+func (s *Server) offlineClear(w http.ResponseWriter, r *http.Request) {
+	mid, err := strconv.ParseInt(r.URL.Query().Get("mid"), 10, 64)
+	if err != nil {
+		writeJSON(w, RequestErr, nil)
+		return
+	}
+	if !allowRead(r) {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	if _, err = s.logic.ClearOfflineMsgs(r.Context(), appOf(r), mid); err != nil {
+		writeJSON(w, ServerErr, nil)
+		return
+	}
+	writeJSON(w, OK, nil)
+}
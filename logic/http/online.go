@@ -1,18 +1,26 @@
 package http
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"strings"
+
+	xstr "github.com/swanky2009/goim/pkg/strings"
 )
 
+// _maxPresenceMids the mids queried at most by a presence request.
+const _maxPresenceMids = 1000
+
 func (s *Server) onlineTop(w http.ResponseWriter, r *http.Request) {
 	var (
 		limit int64
 		err   error
 		res   []string
 	)
+	if !allowRead(r) {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
 	query := r.URL.Query()
 	typeStr := query.Get("type")
 	limitStr := query.Get("limit")
@@ -20,7 +28,7 @@ func (s *Server) onlineTop(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		limit = 2
 	}
-	res, err = s.logic.OnlineTop(context.TODO(), typeStr, int64(limit))
+	res, err = s.logic.OnlineTop(r.Context(), typeStr, int64(limit))
 	if err != nil {
 		writeJSON(w, RequestErr, nil)
 		return
@@ -30,11 +38,76 @@ func (s *Server) onlineTop(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) onlineRoom(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
-	roomStr := query.Get("room")
-	res, err := s.logic.OnlineRoom(context.TODO(), strings.Split(roomStr, ","))
+	rooms := strings.Split(query.Get("room"), ",")
+	for _, room := range rooms {
+		if !allowRoom(r, room) {
+			writeJSON(w, Forbidden, nil)
+			return
+		}
+	}
+	res, err := s.logic.OnlineRoom(r.Context(), appOf(r), rooms)
+	if err != nil {
+		writeJSON(w, RequestErr, nil)
+		return
+	}
+	writeJSON(w, OK, res)
+}
+
+// onlineApp gets the connections and the rooms online of the app.
+func (s *Server) onlineApp(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(r) {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	res, err := s.logic.OnlineApp(r.Context(), appOf(r))
+	if err != nil {
+		writeJSON(w, ServerErr, nil)
+		return
+	}
+	writeJSON(w, OK, res)
+}
+
+// presenceMids parses the mids of a query or a form, separated by commas.
+func presenceMids(r *http.Request) (mids []int64, ok bool) {
+	mids, err := xstr.SplitInt64s(r.FormValue("mids"), ",")
+	if err != nil || len(mids) == 0 || len(mids) > _maxPresenceMids {
+		return nil, false
+	}
+	return mids, true
+}
+
+func (s *Server) onlinePresence(w http.ResponseWriter, r *http.Request) {
+	mids, ok := presenceMids(r)
+	if !ok {
+		writeJSON(w, RequestErr, nil)
+		return
+	}
+	if !allowRead(r) {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	res, err := s.logic.Presence(r.Context(), appOf(r), mids)
 	if err != nil {
+		writeJSON(w, ServerErr, nil)
+		return
+	}
+	writeJSON(w, OK, res)
+}
+
+func (s *Server) onlineMids(w http.ResponseWriter, r *http.Request) {
+	mids, ok := presenceMids(r)
+	if !ok {
 		writeJSON(w, RequestErr, nil)
 		return
 	}
+	if !allowRead(r) {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	res, err := s.logic.OnlineMids(r.Context(), appOf(r), mids)
+	if err != nil {
+		writeJSON(w, ServerErr, nil)
+		return
+	}
 	writeJSON(w, OK, res)
 }
@@ -1,12 +1,12 @@
 package http
 
 import (
-	"context"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/swanky2009/goim/logic/model"
 	xstrings "github.com/swanky2009/goim/pkg/strings"
 )
 
@@ -25,8 +25,22 @@ func (s *Server) pushKeys(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, RequestErr, nil)
 		return
 	}
-	if err = s.logic.PushKeys(context.TODO(), int32(op), strings.Split(keysStr, ","), msg); err != nil {
-		writeJSON(w, RequestErr, nil)
+	if !allow(r, model.PushKeys, int32(op), "") {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	var (
+		c   = r.Context()
+		key = idempotencyKey(r, "")
+		opt = &model.PushOptions{MsgID: key, App: appOf(r), Caller: pushCaller(r)}
+	)
+	_, dup, err := s.idempotent(c, key, func() (interface{}, error) {
+		return s.logic.PushKeysOpt(c, int32(op), strings.Split(keysStr, ","), msg, opt)
+	})
+	replayed(w, dup)
+	audit(r, model.PushKeys, int32(op), keysStr, err)
+	if err != nil {
+		writePushError(w, err, nil)
 		return
 	}
 	writeJSON(w, OK, nil)
@@ -52,8 +66,35 @@ func (s *Server) pushMids(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, RequestErr, nil)
 		return
 	}
-	if err = s.logic.PushMids(context.TODO(), int32(op), mids, msg); err != nil {
-		writeJSON(w, RequestErr, nil)
+	if !allow(r, model.PushMids, int32(op), "") {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	var (
+		c   = r.Context()
+		key = idempotencyKey(r, "")
+		opt = &model.PushOptions{MsgID: key, App: appOf(r), Caller: pushCaller(r)}
+	)
+	// a message pushed with an id and its sender gets receipts
+	if msgID := query.Get("msg_id"); msgID != "" {
+		from, err := strconv.ParseInt(query.Get("from"), 10, 64)
+		if err != nil || from <= 0 {
+			writeJSON(w, RequestErr, nil)
+			return
+		}
+		if err = s.logic.AddReceiptMsg(c, msgID, from, &model.PushItem{Type: model.PushMids, Mids: mids, Options: opt}); err != nil {
+			writeJSON(w, ServerErr, nil)
+			return
+		}
+		opt.MsgID = msgID
+	}
+	_, dup, err := s.idempotent(c, key, func() (interface{}, error) {
+		return s.logic.PushMidsOpt(c, int32(op), mids, msg, opt)
+	})
+	replayed(w, dup)
+	audit(r, model.PushMids, int32(op), midsStr, err)
+	if err != nil {
+		writePushError(w, err, nil)
 		return
 	}
 	writeJSON(w, OK, nil)
@@ -74,8 +115,22 @@ func (s *Server) pushRoom(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, RequestErr, nil)
 		return
 	}
-	if err = s.logic.PushRoom(context.TODO(), int32(op), room, msg); err != nil {
-		writeJSON(w, RequestErr, nil)
+	if !allow(r, model.PushRoom, int32(op), room) {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	var (
+		c   = r.Context()
+		key = idempotencyKey(r, "")
+		opt = &model.PushOptions{MsgID: key, App: appOf(r), Caller: pushCaller(r)}
+	)
+	_, dup, err := s.idempotent(c, key, func() (interface{}, error) {
+		return s.logic.PushRoomOpt(c, int32(op), room, msg, opt)
+	})
+	replayed(w, dup)
+	audit(r, model.PushRoom, int32(op), room, err)
+	if err != nil {
+		writePushError(w, err, nil)
 		return
 	}
 	writeJSON(w, OK, nil)
@@ -102,8 +157,22 @@ func (s *Server) pushAll(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, RequestErr, err)
 		return
 	}
-	if err = s.logic.PushAll(context.TODO(), int32(op), int32(speed), platStr, msg); err != nil {
-		writeJSON(w, RequestErr, err)
+	if !allow(r, model.PushAll, int32(op), "") {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	var (
+		c   = r.Context()
+		key = idempotencyKey(r, "")
+		opt = &model.PushOptions{MsgID: key, App: appOf(r), Caller: pushCaller(r)}
+	)
+	_, dup, err := s.idempotent(c, key, func() (interface{}, error) {
+		return s.logic.PushAllOpt(c, int32(op), int32(speed), platStr, msg, opt)
+	})
+	replayed(w, dup)
+	audit(r, model.PushAll, int32(op), platStr, err)
+	if err != nil {
+		writePushError(w, err, err)
 		return
 	}
 	writeJSON(w, OK, nil)
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// _maxBatchItems the most items of a batch push.
+const _maxBatchItems = 1000
+
+// pushBatchReq is the request of /v2/push/batch, an item is a /v2/push request.
+type pushBatchReq struct {
+	Items []*pushV2Req `json:"items"`
+}
+
+// pushBatchItemRet is the result of an item, code 0 if pushed or scheduled.
+type pushBatchItemRet struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	// Data the push result, or the scheduled push of an item delivered later.
+	Data interface{} `json:"data,omitempty"`
+	// Duplicate the item is pushed before with its idempotency key, data is
+	// the result of the first push.
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// pushBatchRet is the data of a batch push, the results are in the order of the items.
+type pushBatchRet struct {
+	Results []*pushBatchItemRet `json:"results"`
+}
+
+func (s *Server) pushBatch(w http.ResponseWriter, r *http.Request) {
+	var (
+		req   pushBatchReq
+		items []*model.PushItem
+		index []int
+		keys  []string
+		c     = r.Context()
+	)
+	if r.Method != http.MethodPost {
+		writeJSONV2(w, ErrMethod, nil)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONV2(w, ErrInvalidJSON.withMessage(err.Error()), nil)
+		return
+	}
+	if len(req.Items) == 0 || len(req.Items) > _maxBatchItems {
+		writeJSONV2(w, ErrInvalidBatch.withMessage(fmt.Sprintf("%d items, need 1 to %d", len(req.Items), _maxBatchItems)), nil)
+		return
+	}
+	ret := &pushBatchRet{Results: make([]*pushBatchItemRet, len(req.Items))}
+	for i, it := range req.Items {
+		if it == nil {
+			it = new(pushV2Req)
+		}
+		item, e := it.item(r)
+		if e == nil && it.Options.MsgID != "" {
+			if err := s.logic.AddReceiptMsg(c, it.Options.MsgID, it.Options.From, item); err != nil {
+				e = ErrInternal.withMessage(err.Error())
+			}
+		}
+		if e != nil {
+			ret.Results[i] = &pushBatchItemRet{Code: e.Code, Message: e.Message}
+			continue
+		}
+		// an item is deduplicated by its own key, not the header of the batch
+		var key string
+		if it.Options.IdempotencyKey != "" {
+			key = idempotencyKey(r, it.Options.IdempotencyKey)
+			if item.Options.MsgID == "" {
+				item.Options.MsgID = key
+			}
+		}
+		if at := it.deliverAt(); !at.IsZero() {
+			data, dup, err := s.idempotent(c, key, func() (interface{}, error) {
+				return s.logic.SchedulePush(c, item, at, callerID(r))
+			})
+			typ, target := it.target()
+			audit(r, typ, it.Op, target, err)
+			ret.Results[i] = batchItemRet(data, dup, err)
+			continue
+		}
+		if key != "" {
+			ok, res, err := s.logic.AcquireIdempotencyKey(c, key)
+			if err != nil || !ok {
+				var data interface{}
+				if len(res) > 0 {
+					data = res
+				}
+				ret.Results[i] = batchItemRet(data, !ok, err)
+				continue
+			}
+		}
+		items = append(items, item)
+		index = append(index, i)
+		keys = append(keys, key)
+	}
+	if len(items) > 0 {
+		res, errs := s.logic.PushBatch(c, items)
+		for j, i := range index {
+			if keys[j] != "" {
+				s.logic.ReleaseIdempotencyKey(c, keys[j], res[j], errs[j])
+			}
+			typ, target := req.Items[i].target()
+			audit(r, typ, req.Items[i].Op, target, errs[j])
+			ret.Results[i] = batchItemRet(res[j], false, errs[j])
+		}
+	}
+	writeJSONV2(w, nil, ret)
+}
+
+func batchItemRet(data interface{}, dup bool, err error) *pushBatchItemRet {
+	if err != nil {
+		e := pushError(err)
+		return &pushBatchItemRet{Code: e.Code, Message: e.Message, Data: data}
+	}
+	return &pushBatchItemRet{Message: "ok", Data: data, Duplicate: dup}
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+func TestPushV2Item(t *testing.T) {
+	r := httptest.NewRequest("POST", "/v2/push/batch", nil)
+	item, err := decodePushV2(t, `{"op":1000,"room":"chat://1","payload":"hi","options":{"priority":"high"}}`).item(r)
+	assert.Nil(t, err)
+	assert.Equal(t, model.PushRoom, item.Type)
+	assert.Equal(t, "hi", string(item.Msg))
+	assert.Equal(t, model.PriorityHigh, item.Options.Priority)
+	_, err = decodePushV2(t, `{"op":1000,"payload":"hi"}`).item(r)
+	assert.Equal(t, ErrInvalidTarget, err)
+	// the chat key pushes to chat rooms only
+	r = r.WithContext(context.WithValue(r.Context(), callerKey{}, testKey(t, "chat", "chat_secret")))
+	_, err = decodePushV2(t, `{"op":1000,"room":"live://1","payload":"hi"}`).item(r)
+	assert.Equal(t, ErrForbidden.Code, err.Code)
+}
+
+func TestPushBatchInvalid(t *testing.T) {
+	s := new(Server)
+	w := httptest.NewRecorder()
+	s.pushBatch(w, httptest.NewRequest("POST", "/v2/push/batch", strings.NewReader(`{"items":[]}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// invalid items fail alone
+	w = httptest.NewRecorder()
+	s.pushBatch(w, httptest.NewRequest("POST", "/v2/push/batch", strings.NewReader(`{"items":[{"op":1000,"payload":"hi"},null,{"op":1000,"mids":[1]}]}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var ret struct {
+		Data pushBatchRet `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &ret))
+	assert.Equal(t, 3, len(ret.Data.Results))
+	assert.Equal(t, ErrInvalidTarget.Code, ret.Data.Results[0].Code)
+	assert.Equal(t, ErrInvalidOp.Code, ret.Data.Results[1].Code)
+	assert.Equal(t, ErrInvalidPayload.Code, ret.Data.Results[2].Code)
+}
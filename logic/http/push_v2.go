@@ -0,0 +1,285 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	comet "github.com/swanky2009/goim/grpc/comet"
+	"github.com/swanky2009/goim/logic"
+	"github.com/swanky2009/goim/logic/model"
+	xstrings "github.com/swanky2009/goim/pkg/strings"
+)
+
+// pushV2Req is the request of /v2/push, exactly one of keys, mids, room and
+// all is the target. The payload is any json, a json string is pushed as its
+// text, payload_base64 pushes binary.
+type pushV2Req struct {
+	Op            int32           `json:"op"`
+	Keys          []string        `json:"keys"`
+	Mids          []int64         `json:"mids"`
+	Room          string          `json:"room"`
+	All           bool            `json:"all"`
+	Payload       json.RawMessage `json:"payload"`
+	PayloadBase64 []byte          `json:"payload_base64"`
+	Options       pushV2Options   `json:"options"`
+}
+
+type pushV2Options struct {
+	// TTL seconds the message is dropped after if not pushed, 0 never expires.
+	TTL int64 `json:"ttl"`
+	// Priority normal or high.
+	Priority string `json:"priority"`
+	// MsgID and From get the receipts of the message, comet pushes a msg id
+	// once per connection.
+	MsgID string `json:"msg_id"`
+	From  int64  `json:"from"`
+	// IdempotencyKey a retried push with the same key is pushed once within
+	// the idempotency window, the Idempotency-Key header is used if empty.
+	IdempotencyKey string `json:"idempotency_key"`
+	// Speed and Platform of a push to all.
+	Speed    int32  `json:"speed"`
+	Platform string `json:"platform"`
+	// DeliverAt unix seconds or Delay seconds from now the push is scheduled
+	// at, a past time pushes now.
+	DeliverAt int64 `json:"deliver_at"`
+	Delay     int64 `json:"delay"`
+}
+
+// message returns the bytes pushed.
+func (r *pushV2Req) message() (msg []byte, err *Error) {
+	if len(r.PayloadBase64) > 0 {
+		if len(r.Payload) > 0 {
+			return nil, ErrInvalidPayload.withMessage("both payload and payload_base64")
+		}
+		msg = r.PayloadBase64
+	} else if p := bytes.TrimSpace(r.Payload); len(p) > 0 && p[0] == '"' {
+		var s string
+		if e := json.Unmarshal(p, &s); e != nil {
+			return nil, ErrInvalidPayload.withMessage(e.Error())
+		}
+		msg = []byte(s)
+	} else {
+		msg = p
+	}
+	if len(msg) == 0 {
+		return nil, ErrInvalidPayload.withMessage("empty")
+	}
+	if len(msg) > int(comet.MaxBodySize) {
+		return nil, ErrPayloadTooLarge.withMessage(fmt.Sprintf("%d bytes, max %d", len(msg), comet.MaxBodySize))
+	}
+	return
+}
+
+func (r *pushV2Req) validate() *Error {
+	if r.Op <= 0 {
+		return ErrInvalidOp
+	}
+	targets := 0
+	if len(r.Keys) > 0 {
+		targets++
+	}
+	if len(r.Mids) > 0 {
+		targets++
+	}
+	if r.Room != "" {
+		targets++
+	}
+	if r.All {
+		targets++
+	}
+	if targets != 1 {
+		return ErrInvalidTarget
+	}
+	o := &r.Options
+	if o.TTL < 0 {
+		return ErrInvalidOption.withMessage("negative ttl")
+	}
+	switch o.Priority {
+	case "", model.PriorityNormal, model.PriorityHigh:
+	default:
+		return ErrInvalidOption.withMessage("priority is normal or high")
+	}
+	if o.MsgID != "" && o.From <= 0 {
+		return ErrInvalidOption.withMessage("msg_id needs from")
+	}
+	if o.DeliverAt < 0 || o.Delay < 0 {
+		return ErrInvalidOption.withMessage("negative deliver_at or delay")
+	}
+	if o.DeliverAt > 0 && o.Delay > 0 {
+		return ErrInvalidOption.withMessage("both deliver_at and delay")
+	}
+	return nil
+}
+
+// deliverAt returns the time the push is scheduled at, zero if pushed now.
+func (r *pushV2Req) deliverAt() time.Time {
+	now := time.Now()
+	if r.Options.Delay > 0 {
+		return now.Add(time.Duration(r.Options.Delay) * time.Second)
+	}
+	if t := time.Unix(r.Options.DeliverAt, 0); r.Options.DeliverAt > 0 && t.After(now) {
+		return t
+	}
+	return time.Time{}
+}
+
+// target returns the push type and the target of the audit log.
+func (r *pushV2Req) target() (typ, target string) {
+	switch {
+	case len(r.Keys) > 0:
+		return model.PushKeys, strings.Join(r.Keys, ",")
+	case len(r.Mids) > 0:
+		return model.PushMids, xstrings.JoinInt64s(r.Mids, ",")
+	case r.Room != "":
+		return model.PushRoom, r.Room
+	default:
+		return model.PushAll, r.Options.Platform
+	}
+}
+
+// options returns the options of the push scoped to the app of the caller.
+func (r *pushV2Req) options(hr *http.Request) *model.PushOptions {
+	return &model.PushOptions{TTL: time.Duration(r.Options.TTL) * time.Second, Priority: r.Options.Priority, MsgID: r.Options.MsgID, App: appOf(hr), Caller: pushCaller(hr)}
+}
+
+// item validates the request and returns the item of the batch.
+func (r *pushV2Req) item(hr *http.Request) (item *model.PushItem, e *Error) {
+	var msg []byte
+	if e = r.validate(); e != nil {
+		return
+	}
+	if msg, e = r.message(); e != nil {
+		return
+	}
+	typ, _ := r.target()
+	if !allow(hr, typ, r.Op, r.Room) {
+		return nil, ErrForbidden.withMessage(fmt.Sprintf("push %s op %d", typ, r.Op))
+	}
+	return &model.PushItem{
+		Type:     typ,
+		Op:       r.Op,
+		Keys:     r.Keys,
+		Mids:     r.Mids,
+		Room:     r.Room,
+		Speed:    r.Options.Speed,
+		Platform: r.Options.Platform,
+		Msg:      msg,
+		Options:  r.options(hr),
+	}, nil
+}
+
+func (s *Server) pushV2(w http.ResponseWriter, r *http.Request) {
+	var (
+		req pushV2Req
+		err error
+		c   = r.Context()
+	)
+	if r.Method != http.MethodPost {
+		writeJSONV2(w, ErrMethod, nil)
+		return
+	}
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONV2(w, ErrInvalidJSON.withMessage(err.Error()), nil)
+		return
+	}
+	item, e := req.item(r)
+	if e != nil {
+		writeJSONV2(w, e, nil)
+		return
+	}
+	if req.Options.MsgID != "" {
+		if err = s.logic.AddReceiptMsg(c, req.Options.MsgID, req.Options.From, item); err != nil {
+			writeJSONV2(w, ErrInternal.withMessage(err.Error()), nil)
+			return
+		}
+	}
+	key := idempotencyKey(r, req.Options.IdempotencyKey)
+	if item.Options.MsgID == "" {
+		item.Options.MsgID = key
+	}
+	data, dup, err := s.idempotent(c, key, func() (interface{}, error) {
+		if at := req.deliverAt(); !at.IsZero() {
+			return s.logic.SchedulePush(c, item, at, callerID(r))
+		}
+		return s.push(c, item)
+	})
+	replayed(w, dup)
+	typ, target := req.target()
+	audit(r, typ, req.Op, target, err)
+	if err != nil {
+		replied(w, err)
+		retryAfter(w, err)
+		writeJSONV2(w, pushError(err), data)
+		return
+	}
+	writeJSONV2(w, nil, data)
+}
+
+// push pushes the item now.
+func (s *Server) push(c context.Context, item *model.PushItem) (*model.PushResult, error) {
+	switch item.Type {
+	case model.PushKeys:
+		return s.logic.PushKeysOpt(c, item.Op, item.Keys, item.Msg, item.Options)
+	case model.PushMids:
+		return s.logic.PushMidsOpt(c, item.Op, item.Mids, item.Msg, item.Options)
+	case model.PushRoom:
+		return s.logic.PushRoomOpt(c, item.Op, item.Room, item.Msg, item.Options)
+	default:
+		return s.logic.PushAllOpt(c, item.Op, item.Speed, item.Platform, item.Msg, item.Options)
+	}
+}
+
+// pushCode returns the v1 code of a push error.
+func pushCode(err error) int {
+	if model.AsRateLimited(err) != nil {
+		return TooManyRequests
+	}
+	return RequestErr
+}
+
+// writePushError writes the v1 reply of a failed push, a push beyond a rate
+// limit is replied with 429 and when to retry.
+func writePushError(w http.ResponseWriter, err error, data interface{}) {
+	replied(w, err)
+	if model.AsRateLimited(err) != nil {
+		retryAfter(w, err)
+		w.Header()["Content-Type"] = []string{"application/json; charset=utf-8"}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	writeJSON(w, pushCode(err), data)
+}
+
+// retryAfter tells a caller beyond a rate limit the seconds to retry after,
+// rounded up.
+func retryAfter(w http.ResponseWriter, err error) {
+	if rl := model.AsRateLimited(err); rl != nil {
+		secs := int64((rl.RetryAfter + time.Second - 1) / time.Second)
+		if secs <= 0 {
+			secs = 1
+		}
+		w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+	}
+}
+
+// pushError returns the v2 error of a push.
+func pushError(err error) *Error {
+	if model.AsRateLimited(err) != nil {
+		return ErrTooManyRequests.withMessage(err.Error())
+	}
+	switch err {
+	case nil:
+		return nil
+	case logic.ErrScheduleTooLate:
+		return ErrInvalidOption.withMessage(err.Error())
+	case model.ErrInvalidID:
+		return ErrInvalidTarget.withMessage(err.Error())
+	default:
+		return ErrInternal.withMessage(err.Error())
+	}
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+func decodePushV2(t *testing.T, s string) *pushV2Req {
+	req := new(pushV2Req)
+	if err := json.Unmarshal([]byte(s), req); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestPushV2Validate(t *testing.T) {
+	assert.Nil(t, decodePushV2(t, `{"op":1000,"mids":[1,2],"payload":"hi"}`).validate())
+	assert.Equal(t, ErrInvalidOp, decodePushV2(t, `{"mids":[1],"payload":"hi"}`).validate())
+	assert.Equal(t, ErrInvalidTarget, decodePushV2(t, `{"op":1000,"payload":"hi"}`).validate())
+	assert.Equal(t, ErrInvalidTarget, decodePushV2(t, `{"op":1000,"mids":[1],"room":"live://1","payload":"hi"}`).validate())
+	assert.Equal(t, ErrInvalidOption.Code, decodePushV2(t, `{"op":1000,"all":true,"options":{"priority":"urgent"}}`).validate().Code)
+	assert.Equal(t, ErrInvalidOption.Code, decodePushV2(t, `{"op":1000,"all":true,"options":{"ttl":-1}}`).validate().Code)
+	assert.Equal(t, ErrInvalidOption.Code, decodePushV2(t, `{"op":1000,"mids":[1],"options":{"msg_id":"m1"}}`).validate().Code)
+}
+
+func TestPushV2Message(t *testing.T) {
+	msg, err := decodePushV2(t, `{"payload":"hi"}`).message()
+	assert.Nil(t, err)
+	assert.Equal(t, "hi", string(msg))
+	msg, err = decodePushV2(t, `{"payload":{"text":"hi"}}`).message()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"text":"hi"}`, string(msg))
+	msg, err = decodePushV2(t, `{"payload_base64":"AAE="}`).message()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0, 1}, msg)
+	_, err = decodePushV2(t, `{}`).message()
+	assert.Equal(t, ErrInvalidPayload.Code, err.Code)
+	_, err = decodePushV2(t, `{"payload":"`+strings.Repeat("a", 5000)+`"}`).message()
+	assert.Equal(t, ErrPayloadTooLarge.Code, err.Code)
+}
+
+func TestPushV2DeliverAt(t *testing.T) {
+	assert.Equal(t, ErrInvalidOption.Code, decodePushV2(t, `{"op":1000,"all":true,"options":{"delay":-1}}`).validate().Code)
+	assert.Equal(t, ErrInvalidOption.Code, decodePushV2(t, `{"op":1000,"all":true,"options":{"delay":10,"deliver_at":1}}`).validate().Code)
+	assert.True(t, decodePushV2(t, `{"op":1000,"all":true}`).deliverAt().IsZero())
+	// a past time pushes now
+	assert.True(t, decodePushV2(t, `{"op":1000,"all":true,"options":{"deliver_at":1}}`).deliverAt().IsZero())
+	at := decodePushV2(t, `{"op":1000,"all":true,"options":{"delay":600}}`).deliverAt()
+	assert.InDelta(t, 600, time.Until(at).Seconds(), 1)
+	at = decodePushV2(t, `{"op":1000,"all":true,"options":{"deliver_at":`+strconv.FormatInt(time.Now().Unix()+60, 10)+`}}`).deliverAt()
+	assert.InDelta(t, 60, time.Until(at).Seconds(), 2)
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	r := httptest.NewRequest("POST", "/v2/push", nil)
+	assert.Equal(t, "", idempotencyKey(r, ""))
+	assert.Equal(t, ":k1", idempotencyKey(r, "k1"))
+	r.Header.Set(headerIdempotencyKey, "k2")
+	assert.Equal(t, ":k2", idempotencyKey(r, ""))
+	assert.Equal(t, ":k1", idempotencyKey(r, "k1"))
+	// the keys of callers do not collide
+	r = r.WithContext(context.WithValue(r.Context(), callerKey{}, testKey(t, "chat", "chat_secret")))
+	assert.Equal(t, "chat:k2", idempotencyKey(r, ""))
+}
+
+func TestWritePushErrorRateLimited(t *testing.T) {
+	err := &model.RateLimited{Scope: "caller", RetryAfter: 1500 * time.Millisecond}
+	w := httptest.NewRecorder()
+	writePushError(w, err, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), strconv.Itoa(TooManyRequests))
+	assert.Equal(t, ErrTooManyRequests.Code, pushError(err).Code)
+	// other errors keep the v1 reply
+	w = httptest.NewRecorder()
+	writePushError(w, errors.New("kafka down"), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Header().Get("Retry-After"))
+}
+
+func TestPushCaller(t *testing.T) {
+	r := httptest.NewRequest("POST", "/goim/push/all", nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	assert.Equal(t, "10.0.0.1", pushCaller(r))
+	r = r.WithContext(context.WithValue(r.Context(), callerKey{}, testKey(t, "chat", "chat_secret")))
+	assert.Equal(t, "chat", pushCaller(r))
+}
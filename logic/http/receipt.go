@@ -0,0 +1,24 @@
+package http
+
+import (
+	"net/http"
+)
+
+func (s *Server) receipt(w http.ResponseWriter, r *http.Request) {
+	msgID := r.URL.Query().Get("msg_id")
+	if msgID == "" {
+		writeJSON(w, RequestErr, nil)
+		return
+	}
+	if !allowRead(r) {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	// the receipts of the messages of the app of the caller
+	res, err := s.logic.Receipt(r.Context(), appOf(r), msgID)
+	if err != nil {
+		writeJSON(w, ServerErr, nil)
+		return
+	}
+	writeJSON(w, OK, res)
+}
@@ -10,6 +10,12 @@ const (
 	OK = 0
 	// RequestErr request error
 	RequestErr = -400
+	// Unauthorized the caller is not authenticated
+	Unauthorized = -401
+	// Forbidden the caller is not allowed
+	Forbidden = -403
+	// TooManyRequests the push is beyond a rate limit
+	TooManyRequests = -429
 	// ServerErr server error
 	ServerErr = -500
 )
@@ -21,6 +27,9 @@ type Ret struct {
 }
 
 func writeJSON(w http.ResponseWriter, code int, data interface{}) (err error) {
+	if code == ServerErr {
+		replied(w, codeError(code))
+	}
 	// write header
 	header := w.Header()
 	header["Content-Type"] = []string{"application/json; charset=utf-8"}
@@ -36,3 +45,62 @@ func writeJSON(w http.ResponseWriter, code int, data interface{}) (err error) {
 	_, err = w.Write(b)
 	return
 }
+
+// Error is a typed error of the v2 api, the code tells the cause and the
+// message details it.
+type Error struct {
+	Status  int    `json:"-"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// errors of the v2 api.
+var (
+	ErrMethod          = &Error{Status: http.StatusMethodNotAllowed, Code: 40500, Message: "method not allowed"}
+	ErrInvalidJSON     = &Error{Status: http.StatusBadRequest, Code: 40001, Message: "invalid json"}
+	ErrInvalidOp       = &Error{Status: http.StatusBadRequest, Code: 40002, Message: "invalid op"}
+	ErrInvalidTarget   = &Error{Status: http.StatusBadRequest, Code: 40003, Message: "need exactly one of keys, mids, room and all"}
+	ErrInvalidPayload  = &Error{Status: http.StatusBadRequest, Code: 40004, Message: "invalid payload"}
+	ErrPayloadTooLarge = &Error{Status: http.StatusRequestEntityTooLarge, Code: 41300, Message: "payload too large"}
+	ErrInvalidOption   = &Error{Status: http.StatusBadRequest, Code: 40005, Message: "invalid options"}
+	ErrInvalidBatch    = &Error{Status: http.StatusBadRequest, Code: 40006, Message: "invalid batch"}
+	ErrUnauthorized    = &Error{Status: http.StatusUnauthorized, Code: 40100, Message: "unauthorized"}
+	ErrForbidden       = &Error{Status: http.StatusForbidden, Code: 40300, Message: "forbidden"}
+	ErrNotFound        = &Error{Status: http.StatusNotFound, Code: 40400, Message: "not found"}
+	ErrTooManyRequests = &Error{Status: http.StatusTooManyRequests, Code: 42900, Message: "too many requests"}
+	ErrInternal        = &Error{Status: http.StatusInternalServerError, Code: 50000, Message: "internal error"}
+	ErrUnavailable     = &Error{Status: http.StatusServiceUnavailable, Code: 50300, Message: "service unavailable"}
+	ErrTimeout         = &Error{Status: http.StatusGatewayTimeout, Code: 50400, Message: "timeout"}
+)
+
+// withMessage returns a copy of the error detailed by the message.
+func (e *Error) withMessage(msg string) *Error {
+	return &Error{Status: e.Status, Code: e.Code, Message: e.Message + ": " + msg}
+}
+
+// RetV2 is the response of the v2 api.
+type RetV2 struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func writeJSONV2(w http.ResponseWriter, err *Error, data interface{}) error {
+	ret := RetV2{Code: OK, Message: "ok", Data: data}
+	status := http.StatusOK
+	if err != nil {
+		ret.Code, ret.Message, status = err.Code, err.Message, err.Status
+	}
+	b, e := json.Marshal(ret)
+	if e != nil {
+		return e
+	}
+	w.Header()["Content-Type"] = []string{"application/json; charset=utf-8"}
+	w.WriteHeader(status)
+	_, e = w.Write(b)
+	return e
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/swanky2009/goim/logic/model"
+)
+
+func (s *Server) roomHistory(w http.ResponseWriter, r *http.Request) {
+	var (
+		sinceSeq, count int64
+		err             error
+	)
+	query := r.URL.Query()
+	room := query.Get("room")
+	if room == "" {
+		writeJSON(w, RequestErr, nil)
+		return
+	}
+	if sinceStr := query.Get("since_seq"); sinceStr != "" {
+		if sinceSeq, err = strconv.ParseInt(sinceStr, 10, 64); err != nil {
+			writeJSON(w, RequestErr, nil)
+			return
+		}
+	}
+	if countStr := query.Get("count"); countStr != "" {
+		if count, err = strconv.ParseInt(countStr, 10, 32); err != nil {
+			writeJSON(w, RequestErr, nil)
+			return
+		}
+	}
+	if !allowRoom(r, room) {
+		writeJSON(w, Forbidden, nil)
+		return
+	}
+	if room, err = model.ScopeRoom(appOf(r), room); err != nil {
+		writeJSON(w, RequestErr, nil)
+		return
+	}
+	res, err := s.logic.RoomHistory(r.Context(), room, sinceSeq, count)
+	if err != nil {
+		writeJSON(w, ServerErr, nil)
+		return
+	}
+	writeJSON(w, OK, res)
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// _maxBanMids the mids banned or unbanned at most at once.
+const _maxBanMids = 1000
+
+// roomConfigReq is the request of /v2/room/config, the config replaces the
+// one of the room, the bans are kept.
+type roomConfigReq struct {
+	Room     string            `json:"room"`
+	Meta     map[string]string `json:"meta"`
+	Capacity int32             `json:"capacity"`
+	SlowMode int64             `json:"slow_mode"`
+	Muted    bool              `json:"muted"`
+}
+
+func (r *roomConfigReq) validate() *Error {
+	if r.Room == "" {
+		return ErrInvalidJSON.withMessage("need room")
+	}
+	if r.Capacity < 0 {
+		return ErrInvalidJSON.withMessage(fmt.Sprintf("capacity %d", r.Capacity))
+	}
+	if r.SlowMode < 0 {
+		return ErrInvalidJSON.withMessage(fmt.Sprintf("slow_mode %d", r.SlowMode))
+	}
+	return nil
+}
+
+// roomBanReq is the request of /v2/room/ban and /v2/room/unban.
+type roomBanReq struct {
+	Room string  `json:"room"`
+	Mids []int64 `json:"mids"`
+}
+
+func (r *roomBanReq) validate() *Error {
+	if r.Room == "" {
+		return ErrInvalidJSON.withMessage("need room")
+	}
+	if len(r.Mids) == 0 || len(r.Mids) > _maxBanMids {
+		return ErrInvalidJSON.withMessage(fmt.Sprintf("need 1 to %d mids", _maxBanMids))
+	}
+	return nil
+}
+
+// decodeRoomReq decodes and validates the post of a room request.
+func decodeRoomReq(r *http.Request, req interface {
+	validate() *Error
+}) *Error {
+	if r.Method != http.MethodPost {
+		return ErrMethod
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return ErrInvalidJSON.withMessage(err.Error())
+	}
+	return req.validate()
+}
+
+// roomConfig gets the config and the bans of a room.
+func (s *Server) roomConfig(w http.ResponseWriter, r *http.Request) {
+	room := r.URL.Query().Get("room")
+	if room == "" {
+		writeJSONV2(w, ErrInvalidJSON.withMessage("need room"), nil)
+		return
+	}
+	if !allowRoom(r, room) {
+		writeJSONV2(w, ErrForbidden.withMessage(room), nil)
+		return
+	}
+	app := appOf(r)
+	scoped, err := model.ScopeRoom(app, room)
+	if err != nil {
+		writeJSONV2(w, ErrInvalidJSON.withMessage(err.Error()), nil)
+		return
+	}
+	rc, err := s.logic.RoomConfig(r.Context(), scoped)
+	if err != nil {
+		writeJSONV2(w, ErrInternal.withMessage(err.Error()), nil)
+		return
+	}
+	if rc == nil {
+		writeJSONV2(w, ErrNotFound, nil)
+		return
+	}
+	writeJSONV2(w, nil, unscopeRoom(rc, app))
+}
+
+// setRoomConfig creates or replaces the config of a room.
+func (s *Server) setRoomConfig(w http.ResponseWriter, r *http.Request) {
+	req := new(roomConfigReq)
+	if err := decodeRoomReq(r, req); err != nil {
+		writeJSONV2(w, err, nil)
+		return
+	}
+	if !allowRoom(r, req.Room) {
+		writeJSONV2(w, ErrForbidden.withMessage(req.Room), nil)
+		return
+	}
+	app := appOf(r)
+	room, err := model.ScopeRoom(app, req.Room)
+	if err != nil {
+		writeJSONV2(w, ErrInvalidJSON.withMessage(err.Error()), nil)
+		return
+	}
+	rc, err := s.logic.SetRoomConfig(r.Context(), &model.RoomConfig{
+		Room:     room,
+		Meta:     req.Meta,
+		Capacity: req.Capacity,
+		SlowMode: req.SlowMode,
+		Muted:    req.Muted,
+	})
+	if err != nil {
+		writeJSONV2(w, ErrInternal.withMessage(err.Error()), nil)
+		return
+	}
+	writeJSONV2(w, nil, unscopeRoom(rc, app))
+}
+
+// delRoomConfig deletes the config and the bans of a room.
+func (s *Server) delRoomConfig(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Room string `json:"room"`
+	}
+	if r.Method != http.MethodPost {
+		writeJSONV2(w, ErrMethod, nil)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Room == "" {
+		writeJSONV2(w, ErrInvalidJSON.withMessage("need room"), nil)
+		return
+	}
+	if !allowRoom(r, req.Room) {
+		writeJSONV2(w, ErrForbidden.withMessage(req.Room), nil)
+		return
+	}
+	room, err := model.ScopeRoom(appOf(r), req.Room)
+	if err != nil {
+		writeJSONV2(w, ErrInvalidJSON.withMessage(err.Error()), nil)
+		return
+	}
+	has, err := s.logic.DelRoomConfig(r.Context(), room)
+	if err != nil {
+		writeJSONV2(w, ErrInternal.withMessage(err.Error()), nil)
+		return
+	}
+	if !has {
+		writeJSONV2(w, ErrNotFound, nil)
+		return
+	}
+	writeJSONV2(w, nil, nil)
+}
+
+// banRoom bans the mids from a room.
+func (s *Server) banRoom(w http.ResponseWriter, r *http.Request) {
+	s.roomBans(w, r, s.logic.BanRoom)
+}
+
+// unbanRoom lifts the bans of the mids from a room.
+func (s *Server) unbanRoom(w http.ResponseWriter, r *http.Request) {
+	s.roomBans(w, r, s.logic.UnbanRoom)
+}
+
+func (s *Server) roomBans(w http.ResponseWriter, r *http.Request, update func(c context.Context, room string, mids []int64) error) {
+	req := new(roomBanReq)
+	if err := decodeRoomReq(r, req); err != nil {
+		writeJSONV2(w, err, nil)
+		return
+	}
+	if !allowRoom(r, req.Room) {
+		writeJSONV2(w, ErrForbidden.withMessage(req.Room), nil)
+		return
+	}
+	room, err := model.ScopeRoom(appOf(r), req.Room)
+	if err != nil {
+		writeJSONV2(w, ErrInvalidJSON.withMessage(err.Error()), nil)
+		return
+	}
+	if err = update(r.Context(), room, req.Mids); err != nil {
+		writeJSONV2(w, ErrInternal.withMessage(err.Error()), nil)
+		return
+	}
+	writeJSONV2(w, nil, nil)
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoomConfigValidate(t *testing.T) {
+	decode := func(s string) *roomConfigReq {
+		req := new(roomConfigReq)
+		if err := json.Unmarshal([]byte(s), req); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+	assert.Nil(t, decode(`{"room":"live://1","capacity":100,"slow_mode":5,"muted":true,"meta":{"title":"hi"}}`).validate())
+	assert.Equal(t, ErrInvalidJSON.Code, decode(`{"capacity":100}`).validate().Code)
+	assert.Equal(t, ErrInvalidJSON.Code, decode(`{"room":"live://1","capacity":-1}`).validate().Code)
+	assert.Equal(t, ErrInvalidJSON.Code, decode(`{"room":"live://1","slow_mode":-1}`).validate().Code)
+}
+
+func TestRoomBanValidate(t *testing.T) {
+	assert.Nil(t, (&roomBanReq{Room: "live://1", Mids: []int64{1}}).validate())
+	assert.Equal(t, ErrInvalidJSON.Code, (&roomBanReq{Mids: []int64{1}}).validate().Code)
+	assert.Equal(t, ErrInvalidJSON.Code, (&roomBanReq{Room: "live://1"}).validate().Code)
+	assert.Equal(t, ErrInvalidJSON.Code, (&roomBanReq{Room: "live://1", Mids: make([]int64, _maxBanMids+1)}).validate().Code)
+}
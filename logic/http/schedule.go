@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// _maxSchedulePage the most scheduled pushes listed at once.
+const _maxSchedulePage = 1000
+
+// scheduledPushes lists the pushes not fired yet, an authenticated caller
+// only sees its own pushes.
+func (s *Server) scheduledPushes(w http.ResponseWriter, r *http.Request) {
+	var (
+		offset, count int64
+		err           error
+		query         = r.URL.Query()
+	)
+	if offset, err = strconv.ParseInt(query.Get("offset"), 10, 64); err != nil || offset < 0 {
+		offset = 0
+	}
+	if count, err = strconv.ParseInt(query.Get("count"), 10, 64); err != nil || count <= 0 || count > _maxSchedulePage {
+		count = _maxSchedulePage
+	}
+	sps, err := s.logic.ScheduledPushes(r.Context(), callerID(r), offset, count)
+	if err != nil {
+		writeJSONV2(w, ErrInternal.withMessage(err.Error()), nil)
+		return
+	}
+	if sps == nil {
+		sps = []*model.ScheduledPush{}
+	}
+	writeJSONV2(w, nil, sps)
+}
+
+// cancelScheduledPush cancels a push not fired yet, an authenticated caller
+// only cancels its own pushes.
+func (s *Server) cancelScheduledPush(w http.ResponseWriter, r *http.Request) {
+	var (
+		req struct {
+			ID string `json:"id"`
+		}
+		c = r.Context()
+	)
+	if r.Method != http.MethodPost {
+		writeJSONV2(w, ErrMethod, nil)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		writeJSONV2(w, ErrInvalidJSON.withMessage("need id"), nil)
+		return
+	}
+	sp, err := s.logic.ScheduledPush(c, req.ID)
+	if err != nil {
+		writeJSONV2(w, ErrInternal.withMessage(err.Error()), nil)
+		return
+	}
+	if sp == nil {
+		writeJSONV2(w, ErrNotFound, nil)
+		return
+	}
+	if id := callerID(r); id != "" && sp.Caller != id {
+		writeJSONV2(w, ErrForbidden.withMessage("scheduled by another caller"), nil)
+		return
+	}
+	has, err := s.logic.CancelScheduledPush(c, sp)
+	if err != nil {
+		writeJSONV2(w, ErrInternal.withMessage(err.Error()), nil)
+		return
+	}
+	if !has {
+		// fired meanwhile
+		writeJSONV2(w, ErrNotFound, nil)
+		return
+	}
+	writeJSONV2(w, nil, sp)
+}
@@ -7,21 +7,35 @@ import (
 	"github.com/swanky2009/goim/logic"
 	"github.com/swanky2009/goim/logic/g"
 	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/handlers"
 )
 
 // Server is http server.
 type Server struct {
 	logic *logic.Server
+	chain *handlers.Chain
 }
 
-// New new a http server.
-func New(c *conf.HTTPServer, l *logic.Server) *http.Server {
+// New new a http server, the callers are authenticated if api keys are
+// configured, and the routes are wrapped in the middlewares of the chain.
+func New(c *conf.HTTPServer, l *logic.Server, chain *handlers.Chain) *http.Server {
 	s := &Server{
 		logic: l,
+		chain: chain,
+	}
+	var handler http.Handler = s.newHTTPServeMux()
+	if c.APIAuth != nil && len(c.APIAuth.Keys) > 0 {
+		auth, err := newAPIAuth(c.APIAuth, l.UseNonce)
+		if err != nil {
+			panic(err)
+		}
+		handler = auth.handler(handler)
+	} else {
+		g.Logger.Warningf("http api is not authenticated, configure httpserver.api_auth")
 	}
 	srv := &http.Server{
 		Addr:           c.Addr,
-		Handler:        s.newHTTPServeMux(),
+		Handler:        handler,
 		ReadTimeout:    time.Duration(c.ReadTimeout),
 		WriteTimeout:   time.Duration(c.WriteTimeout),
 		MaxHeaderBytes: 1 << 20,
@@ -41,11 +55,27 @@ func Start(s *http.Server, errc chan error) {
 
 func (s *Server) newHTTPServeMux() *http.ServeMux {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/push/keys", s.pushKeys)
-	mux.HandleFunc("/push/mids", s.pushMids)
-	mux.HandleFunc("/push/room", s.pushRoom)
-	mux.HandleFunc("/push/all", s.pushAll)
-	mux.HandleFunc("/online/top", s.onlineTop)
-	mux.HandleFunc("/online/room", s.onlineRoom)
+	s.handle(mux, "/push/keys", s.pushKeys)
+	s.handle(mux, "/push/mids", s.pushMids)
+	s.handle(mux, "/push/room", s.pushRoom)
+	s.handle(mux, "/push/all", s.pushAll)
+	s.handle(mux, "/v2/push", s.pushV2)
+	s.handle(mux, "/v2/push/batch", s.pushBatch)
+	s.handle(mux, "/v2/schedule", s.scheduledPushes)
+	s.handle(mux, "/v2/schedule/cancel", s.cancelScheduledPush)
+	s.handle(mux, "/online/top", s.onlineTop)
+	s.handle(mux, "/online/room", s.onlineRoom)
+	s.handle(mux, "/online/presence", s.onlinePresence)
+	s.handle(mux, "/online/mids", s.onlineMids)
+	s.handle(mux, "/online/app", s.onlineApp)
+	s.handle(mux, "/room/history", s.roomHistory)
+	s.handle(mux, "/v2/room", s.roomConfig)
+	s.handle(mux, "/v2/room/config", s.setRoomConfig)
+	s.handle(mux, "/v2/room/delete", s.delRoomConfig)
+	s.handle(mux, "/v2/room/ban", s.banRoom)
+	s.handle(mux, "/v2/room/unban", s.unbanRoom)
+	s.handle(mux, "/offline/msgs", s.offlineMsgs)
+	s.handle(mux, "/offline/clear", s.offlineClear)
+	s.handle(mux, "/receipt", s.receipt)
 	return mux
 }
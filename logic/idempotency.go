@@ -0,0 +1,40 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/swanky2009/goim/logic/g"
+)
+
+// AcquireIdempotencyKey reserves the key for a push within the idempotency
+// window. If the key is reserved before, ok is false and res is the result of
+// the first push, empty while that push is in flight.
+func (l *Server) AcquireIdempotencyKey(c context.Context, key string) (ok bool, res json.RawMessage, err error) {
+	if ok, err = l.dao.AddIdempotencyKey(c, key, time.Duration(l.c.Idempotency.Window)); err != nil || ok {
+		return
+	}
+	if res, err = l.dao.IdempotencyResult(c, key); err != nil {
+		g.Logger.Errorf("l.dao.IdempotencyResult(%s) error(%v)", key, err)
+	}
+	return
+}
+
+// ReleaseIdempotencyKey keeps the result of the push under the key, or frees
+// the key if the push failed so it can be retried.
+func (l *Server) ReleaseIdempotencyKey(c context.Context, key string, res interface{}, pushErr error) {
+	if pushErr != nil {
+		if err := l.dao.DelIdempotencyKey(c, key); err != nil {
+			g.Logger.Errorf("l.dao.DelIdempotencyKey(%s) error(%v)", key, err)
+		}
+		return
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+	if err = l.dao.SetIdempotencyResult(c, key, b, time.Duration(l.c.Idempotency.Window)); err != nil {
+		g.Logger.Errorf("l.dao.SetIdempotencyResult(%s) error(%v)", key, err)
+	}
+}
@@ -2,33 +2,51 @@ package logic
 
 import (
 	"context"
-	"fmt"
-	"strconv"
-	"strings"
+	"time"
 
 	pb "github.com/swanky2009/goim/grpc/comet"
+	pb_l "github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/logic/auth"
+	"github.com/swanky2009/goim/logic/filter"
 	"github.com/swanky2009/goim/logic/g"
-	xstr "github.com/swanky2009/goim/pkg/strings"
+	"github.com/swanky2009/goim/logic/model"
 )
 
-// Connect connected a conn.
-func (l *Server) Connect(c context.Context, server, serverKey, cookie string, token []byte) (mid int64, key, roomID string, paltform string, accepts []int32, err error) {
-	// TODO test example: mid|key|roomid|platform|accepts
-	params := strings.Split(string(token), "|")
-	if len(params) != 5 {
-		err = fmt.Errorf("invalid token:%s", token)
+// Connect connected a conn, the key and room are scoped to the app of its
+// identity, a conn beyond the connections of the app is refused.
+func (l *Server) Connect(c context.Context, server, serverKey, cookie string, token []byte) (mid int64, key, roomID string, paltform string, accepts []int32, app string, err error) {
+	var id *auth.Identity
+	if id, err = l.auth.Auth(c, server, cookie, token); err != nil {
+		g.Logger.Errorf("l.auth.Auth(%s) error(%v)", server, err)
 		return
 	}
-	if mid, err = strconv.ParseInt(params[0], 10, 64); err != nil {
+	app = id.App
+	if !model.ValidID(app, id.Key, id.RoomID) {
+		err = model.ErrInvalidID
 		return
 	}
-	key = params[1]
-	roomID = params[2]
-	paltform = params[3]
-	if accepts, err = xstr.SplitInt32s(params[4], ","); err != nil {
+	if err = l.allowConn(c, app); err != nil {
 		return
 	}
-	if err = l.dao.AddMapping(c, mid, key, server); err != nil {
+	if key = id.Key; key == "" {
+		key = serverKey
+	}
+	key, roomID = model.EncodeKey(app, key), model.EncodeRoomKey(app, id.RoomID)
+	mid, paltform, accepts = id.Mid, id.Platform, id.Accepts
+	if err = l.JoinRoom(c, mid, key, "", roomID); err != nil {
+		g.Logger.Warningf("conn join room key:%s mid:%d room:%s error(%v)", key, mid, roomID, err)
+		return
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	if err = l.dao.AddMapping(c, &model.Session{
+		Mid:           mid,
+		Key:           key,
+		Server:        server,
+		Platform:      paltform,
+		Room:          roomID,
+		ConnectTime:   now,
+		HeartbeatTime: now,
+	}); err != nil {
 		g.Logger.Errorf("l.dao.AddMapping(%d,%s,%s) error(%v)", mid, key, server, err)
 		return
 	}
@@ -36,12 +54,13 @@ func (l *Server) Connect(c context.Context, server, serverKey, cookie string, to
 		g.Logger.Errorf("l.dao.IncrServerScore(%s) error(%v)", server, err)
 		return
 	}
-	g.Logger.Infof("conn connected key:%s server:%s mid:%d token:%s", key, server, mid, token)
+	g.Logger.Infof("conn connected key:%s server:%s mid:%d", key, server, mid)
 	return
 }
 
-// Disconnect disconnect a conn.
-func (l *Server) Disconnect(c context.Context, mid int64, key, server string) (has bool, err error) {
+// Disconnect disconnect a conn, and takes it out of its room.
+func (l *Server) Disconnect(c context.Context, mid int64, key, server, room string) (has bool, err error) {
+	l.leaveRoom(c, room, key)
 	if has, err = l.dao.DelMapping(c, mid, key, server); err != nil {
 		g.Logger.Errorf("l.dao.DelMapping(%d,%s) error(%v)", mid, key, server)
 		return
@@ -54,26 +73,41 @@ func (l *Server) Disconnect(c context.Context, mid int64, key, server string) (h
 	return
 }
 
-// Heartbeat heartbeat a conn.
-func (l *Server) Heartbeat(c context.Context, mid int64, key, server string) (err error) {
-	has, err := l.dao.ExpireMapping(c, mid, key)
+// Heartbeat heartbeat a conn, and updates the room and platform of its session.
+// The conn is renewed in the members of its room.
+// The first heartbeat of a conn, once it is registered in comet, delivers the
+// offline messages of the mid to it.
+func (l *Server) Heartbeat(c context.Context, mid int64, key, server, room, platform string, connected bool) (err error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	sess := &model.Session{Mid: mid, Key: key, Server: server, Platform: platform, Room: room, HeartbeatTime: now}
+	has, err := l.dao.ExpireMapping(c, sess)
 	if err != nil {
 		g.Logger.Errorf("l.dao.ExpireMapping(%d,%s,%s) error(%v)", mid, key, server, err)
 		return
 	}
 	if !has {
-		if err = l.dao.AddMapping(c, mid, key, server); err != nil {
+		// the connect time is lost with the expired session
+		sess.ConnectTime = now
+		if err = l.dao.AddMapping(c, sess); err != nil {
 			g.Logger.Errorf("l.dao.AddMapping(%d,%s,%s) error(%v)", mid, key, server, err)
 			return
 		}
 	}
+	if room != "" {
+		l.dao.ExpireRoomMember(c, room, key)
+	}
+	if connected && l.c.Offline != nil && mid > 0 {
+		app, _ := model.DecodeKey(key)
+		go l.deliverOfflineMsgs(app, mid, key, server)
+	}
 	g.Logger.Infof("conn heartbeat key:%s server:%s mid:%d", key, server, mid)
 	return
 }
 
-// RenewOnline renew a server online.
-func (l *Server) RenewOnline(c context.Context, server string, roomCount map[string]int32) (allRoomCount map[string]int32, err error) {
-
+// RenewOnline renew a server online, the channels of the apps are counted
+// with the rooms.
+func (l *Server) RenewOnline(c context.Context, server string, roomCount, appCount map[string]int32) (allRoomCount map[string]int32, err error) {
+	roomCount = appRoomCount(roomCount, appCount)
 	if err = l.dao.UpdateRoomCount(c, server, roomCount); err != nil {
 		g.Logger.Errorf("l.dao.UpdateRoomCount(%s) error(%v)", server, err)
 		return
@@ -92,16 +126,46 @@ func (l *Server) RenewOnline(c context.Context, server string, roomCount map[str
 	return
 }
 
-// Receive receive a message.
-func (l *Server) Receive(c context.Context, mid int64, room string, op int32, msg []byte) (err error) {
-	// TODO upstream message
+// Receive receive a message, and routes it to the business systems. A
+// message to a room the mid may not send to, or refused by the filters, is
+// refused with a model.Reject, the filters may also change the message.
+func (l *Server) Receive(c context.Context, mid int64, key, server, platform, room string, op int32, msg []byte) (err error) {
 	g.Logger.Debugf("conn receive a message mid:%d room:%s msg:%s", mid, room, string(msg))
 
-	if op == pb.OpSendMsg {
-		err = l.PushRoom(c, pb.OpSendMsgReply, room, msg)
-		if err != nil {
-			g.Logger.Warningf("push room mid:%d room:%s error(%v)", mid, room, err)
+	if op == pb.OpSendMsg || (op >= pb.MinBusinessOp && op <= pb.MaxBusinessOp) {
+		if err = l.sendRoom(c, mid, room); err != nil {
+			return
+		}
+	}
+	fm := &filter.Message{Mid: mid, Key: key, Room: room, Platform: platform, Op: op, Msg: msg}
+	if err = l.filter.Filter(c, fm); err != nil {
+		g.Logger.Infof("conn message filtered mid:%d room:%s op:%d error(%v)", mid, room, op, err)
+		return
+	}
+	msg = fm.Msg
+
+	l.upstream.Route(&pb_l.UpstreamMsg{
+		Mid:       mid,
+		Key:       key,
+		Room:      room,
+		Platform:  platform,
+		Server:    server,
+		Op:        op,
+		Msg:       msg,
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	})
+
+	switch op {
+	case pb.OpSendMsg:
+		if room != "" {
+			if err = l.PushRoom(c, pb.OpSendMsgReply, room, msg); err != nil {
+				g.Logger.Warningf("push room mid:%d room:%s error(%v)", mid, room, err)
+			}
 		}
+	case pb.OpDelivered:
+		err = l.receipt(c, mid, key, room, model.ReceiptDelivered, string(msg))
+	case pb.OpRead:
+		err = l.receipt(c, mid, key, room, model.ReceiptRead, string(msg))
 	}
 	return
 }
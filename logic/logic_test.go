@@ -4,7 +4,7 @@ import (
 	"context"
 	"testing"
 
-	"github.com/issue9/assert"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestConnect(t *testing.T) {
@@ -15,17 +15,17 @@ func TestConnect(t *testing.T) {
 		c         = context.Background()
 	)
 	// connect
-	mid, key, roomID, _, accepts, err := l.Connect(c, server, serverKey, "", token)
+	mid, key, roomID, _, accepts, _, err := l.Connect(c, server, serverKey, "", token)
 	assert.Nil(t, err)
 	assert.Equal(t, serverKey, key)
 	assert.Equal(t, roomID, "live://test_room")
 	assert.Equal(t, len(accepts), 3)
 	t.Log(mid, key, roomID, accepts, err)
 	// heartbeat
-	err = l.Heartbeat(c, mid, key, server)
+	err = l.Heartbeat(c, mid, key, server, roomID, "web", false)
 	assert.Nil(t, err)
 	// disconnect
-	has, err := l.Disconnect(c, mid, key, server)
+	has, err := l.Disconnect(c, mid, key, server, "")
 	assert.Nil(t, err)
 	assert.Equal(t, true, has)
 }
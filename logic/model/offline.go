@@ -0,0 +1,10 @@
+package model
+
+// OfflineMsg a message kept in the inbox of an offline mid.
+type OfflineMsg struct {
+	Op  int32  `json:"op"`
+	Msg []byte `json:"msg"`
+	Ts  int64  `json:"ts"`
+	// Expire unix milliseconds the message is dropped after, 0 never expires.
+	Expire int64 `json:"expire,omitempty"`
+}
@@ -13,6 +13,13 @@ type Top struct {
 	Count  int32  `json:"count"`
 }
 
+// AppOnline the online of an app.
+type AppOnline struct {
+	App   string           `json:"app"`
+	Conns int32            `json:"conns"`
+	Rooms map[string]int32 `json:"rooms"`
+}
+
 type CometInfo struct {
 	Addr string
 	TCP  []string
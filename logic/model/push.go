@@ -0,0 +1,95 @@
+package model
+
+import "time"
+
+const (
+	// PushKeys push to keys.
+	PushKeys = "keys"
+	// PushMids push to mids.
+	PushMids = "mids"
+	// PushRoom push to a room.
+	PushRoom = "room"
+	// PushAll push to all.
+	PushAll = "all"
+)
+
+const (
+	// PriorityNormal normal priority.
+	PriorityNormal = "normal"
+	// PriorityHigh pushes to keys and mids skip the queue of job.
+	PriorityHigh = "high"
+)
+
+// PushOptions options of a push, the zero value is a normal push without ttl.
+type PushOptions struct {
+	// TTL the message is dropped if not pushed within it, 0 never expires.
+	TTL time.Duration `json:"ttl,omitempty"`
+	// Priority normal or high.
+	Priority string `json:"priority,omitempty"`
+	// MsgID comet pushes a message with an id once per connection, a message
+	// without one is always pushed.
+	MsgID string `json:"msg_id,omitempty"`
+	// App the app the keys, mids and room are scoped to, and a push to all
+	// is limited to, the default app "" pushes to the ids as is and to all.
+	App string `json:"app,omitempty"`
+	// Caller the api key or the address pushing, the pushes are rate limited
+	// per caller, it is not kept with a scheduled push, which is limited as
+	// the caller of the schedule.
+	Caller string `json:"-"`
+}
+
+// AppOf returns the app of the options, "" if none.
+func (o *PushOptions) AppOf() string {
+	if o == nil {
+		return ""
+	}
+	return o.App
+}
+
+// CallerOf returns the caller of the options, "" if none.
+func (o *PushOptions) CallerOf() string {
+	if o == nil {
+		return ""
+	}
+	return o.Caller
+}
+
+// Expire returns the unix milliseconds the message expires at, 0 never expires.
+func (o *PushOptions) Expire() int64 {
+	if o == nil || o.TTL <= 0 {
+		return 0
+	}
+	return time.Now().Add(o.TTL).UnixNano() / int64(time.Millisecond)
+}
+
+// PushResult the result of a push.
+type PushResult struct {
+	// OnlineKeys the keys pushed to.
+	OnlineKeys []string `json:"online_keys,omitempty"`
+	// OfflineKeys the keys without a server.
+	OfflineKeys []string `json:"offline_keys,omitempty"`
+	// OnlineMids the mids having online keys.
+	OnlineMids []int64 `json:"online_mids,omitempty"`
+	// OfflineMids the mids without online keys.
+	OfflineMids []int64 `json:"offline_mids,omitempty"`
+	// StoredMids the offline mids the message is kept in the inbox of.
+	StoredMids []int64 `json:"stored_mids,omitempty"`
+	// Enqueued the number of messages produced to job.
+	Enqueued int `json:"enqueued"`
+	// Seq the room seq of a room push.
+	Seq int64 `json:"seq,omitempty"`
+}
+
+// PushItem a push of a batch, the type tells which of keys, mids, room or
+// speed and platform of a push to all is the target.
+type PushItem struct {
+	Type     string       `json:"type"`
+	Op       int32        `json:"op"`
+	Keys     []string     `json:"keys,omitempty"`
+	Mids     []int64      `json:"mids,omitempty"`
+	Room     string       `json:"room,omitempty"`
+	Speed    int32        `json:"speed,omitempty"`
+	Platform string       `json:"platform,omitempty"`
+	Msg      []byte       `json:"msg"`
+	Options  *PushOptions `json:"options,omitempty"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimited a push refused beyond a rate limit, it may be retried after
+// the window of the limit.
+type RateLimited struct {
+	// Scope the limit passed: app, caller, type or room.
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (r *RateLimited) Error() string {
+	return fmt.Sprintf("beyond the %s rate limit, retry after %s", r.Scope, r.RetryAfter)
+}
+
+// AsRateLimited returns the rate limit of the error, nil if it is not one.
+func AsRateLimited(err error) *RateLimited {
+	r, _ := err.(*RateLimited)
+	return r
+}
@@ -0,0 +1,26 @@
+package model
+
+const (
+	// ReceiptDelivered the message is delivered to a device.
+	ReceiptDelivered = "delivered"
+	// ReceiptRead the message is read.
+	ReceiptRead = "read"
+)
+
+// Receipt a receipt of a message, pushed to the sender with OpReceipt
+// and published to kafka.
+type Receipt struct {
+	MsgID string `json:"msg_id"`
+	From  int64  `json:"from"`
+	Mid   int64  `json:"mid"`
+	Type  string `json:"type"`
+	Ts    int64  `json:"ts"`
+}
+
+// ReceiptState the receipts of a message, mid -> unix milliseconds.
+type ReceiptState struct {
+	MsgID     string          `json:"msg_id"`
+	From      int64           `json:"from"`
+	Delivered map[int64]int64 `json:"delivered"`
+	Read      map[int64]int64 `json:"read"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "fmt"
+
+// The codes of the rejects replied to a client with OpReject.
+const (
+	RejectBanned   = int32(1)
+	RejectRoomFull = int32(2)
+	RejectMuted    = int32(3)
+	RejectSlowMode = int32(4)
+	// RejectFiltered the message is refused by a content filter.
+	RejectFiltered = int32(5)
+	// RejectTooLong the message is longer than allowed.
+	RejectTooLong = int32(6)
+	// RejectRateLimited the mid sends too many messages.
+	RejectRateLimited = int32(7)
+)
+
+// Reject a message or a room join refused, it is replied to the client
+// instead of closing the connection.
+type Reject struct {
+	Code    int32
+	Message string
+}
+
+func (r *Reject) Error() string {
+	return fmt.Sprintf("reject %d: %s", r.Code, r.Message)
+}
+
+// AsReject returns the reject of the error, nil if it is not one.
+func AsReject(err error) *Reject {
+	r, _ := err.(*Reject)
+	return r
+}
@@ -1,20 +1,90 @@
 package model
 
 import (
-	"fmt"
-	"net/url"
+	"errors"
+	"strings"
 )
 
-// EncodeRoomKey encode a room key.
-func EncodeRoomKey(business string, room string) string {
-	return fmt.Sprintf("%s://%s", business, room)
+// _appSep separates the app from the room or key it scopes. It is a control
+// character so that no id holds it, and the ids holding it are refused, or a
+// room of the default app would be taken for a room of another app.
+const _appSep = "\x1f"
+
+// ErrInvalidID an app, a room or a key holds the separator of the apps.
+var ErrInvalidID = errors.New("id holds the app separator")
+
+// ValidID reports whether the ids may be apps, rooms or keys.
+func ValidID(ids ...string) bool {
+	for _, id := range ids {
+		if strings.Contains(id, _appSep) {
+			return false
+		}
+	}
+	return true
+}
+
+// ScopeRoom scopes a room of a caller to the app like EncodeRoomKey, the app
+// and the room are checked first.
+func ScopeRoom(app string, room string) (string, error) {
+	if !ValidID(app, room) {
+		return "", ErrInvalidID
+	}
+	return EncodeRoomKey(app, room), nil
 }
 
-// DecodeRoomKey decode room key.
-func DecodeRoomKey(key string) (string, string, error) {
-	u, err := url.Parse(key)
-	if err != nil {
-		return "", "", err
+// EncodeRoomKey scopes the checked room to the app, the rooms of the default
+// app "" and the empty room are kept as is.
+func EncodeRoomKey(app string, room string) string {
+	if app == "" || room == "" {
+		return room
 	}
-	return u.Scheme, u.Host, nil
+	return app + _appSep + room
+}
+
+// DecodeRoomKey returns the app and the room of a room key.
+func DecodeRoomKey(key string) (app string, room string) {
+	if i := strings.Index(key, _appSep); i >= 0 {
+		return key[:i], key[i+len(_appSep):]
+	}
+	return "", key
+}
+
+// EncodeKey scopes the key of a connection to the app like a room.
+func EncodeKey(app string, key string) string {
+	return EncodeRoomKey(app, key)
+}
+
+// DecodeKey returns the app and the key of a scoped key.
+func DecodeKey(key string) (app string, k string) {
+	return DecodeRoomKey(key)
+}
+
+// AppOnlineKey the room key the connections of the app are counted in with
+// the rooms, no room is named empty.
+func AppOnlineKey(app string) string {
+	return app + _appSep
+}
+
+// RoomMsg a message of the room history.
+type RoomMsg struct {
+	Seq int64  `json:"seq"`
+	Op  int32  `json:"op"`
+	Msg []byte `json:"msg"`
+	Ts  int64  `json:"ts"`
+}
+
+// RoomConfig the settings of a room, a room without them is open to all.
+type RoomConfig struct {
+	Room string            `json:"room"`
+	Meta map[string]string `json:"meta,omitempty"`
+	// Capacity the channels joining a full room are refused, 0 is unlimited.
+	Capacity int32 `json:"capacity,omitempty"`
+	// SlowMode seconds a mid waits between messages to the room, 0 is off.
+	SlowMode int64 `json:"slow_mode,omitempty"`
+	// Muted the messages to the room are refused.
+	Muted bool `json:"muted,omitempty"`
+	// Banned the mids refused to join and send, set by ban and unban.
+	Banned []int64 `json:"banned,omitempty"`
+	Ctime  int64   `json:"ctime"`
+	Mtime  int64   `json:"mtime"`
 }
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoomKey(t *testing.T) {
+	room := EncodeRoomKey("live", "test_room")
+	app, id := DecodeRoomKey(room)
+	assert.Equal(t, "live", app)
+	assert.Equal(t, "test_room", id)
+	assert.Equal(t, "test_room", EncodeRoomKey("", "test_room"))
+
+	// a room of the default app does not collide with a room of an app
+	assert.NotEqual(t, room, "live://test_room")
+	app, id = DecodeRoomKey("live://test_room")
+	assert.Equal(t, "", app)
+	assert.Equal(t, "live://test_room", id)
+	app, _ = DecodeKey("live://key")
+	assert.Equal(t, "", app)
+
+	// the ids holding the separator are refused
+	_, err := ScopeRoom("", room)
+	assert.Equal(t, ErrInvalidID, err)
+	_, err = ScopeRoom("live"+_appSep+"x", "test_room")
+	assert.Equal(t, ErrInvalidID, err)
+	scoped, err := ScopeRoom("live", "test_room")
+	assert.Nil(t, err)
+	assert.Equal(t, room, scoped)
+	assert.False(t, ValidID("key", room))
+}
@@ -0,0 +1,15 @@
+package model
+
+// ScheduledPush a push kept until its deliver time.
+type ScheduledPush struct {
+	ID string `json:"id"`
+	// DeliverAt unix milliseconds the push is fired at.
+	DeliverAt int64 `json:"deliver_at"`
+	// Ctime unix milliseconds the push is scheduled at.
+	Ctime int64 `json:"ctime"`
+	// Caller the api key scheduling the push, empty if anonymous.
+	Caller string    `json:"caller,omitempty"`
+	Item   *PushItem `json:"item"`
+	// Attempts the times the push is claimed to fire, set by the claim.
+	Attempts int64 `json:"-"`
+}
@@ -0,0 +1,13 @@
+package model
+
+// Session an online key of a mid, kept from connect to disconnect or the
+// session expires, the times are unix milliseconds.
+type Session struct {
+	Mid           int64  `json:"mid"`
+	Key           string `json:"key"`
+	Server        string `json:"server"`
+	Platform      string `json:"platform"`
+	Room          string `json:"room"`
+	ConnectTime   int64  `json:"connect_time"`
+	HeartbeatTime int64  `json:"heartbeat_time"`
+}
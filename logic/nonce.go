@@ -0,0 +1,12 @@
+package logic
+
+import (
+	"context"
+	"time"
+)
+
+// UseNonce records a nonce of a signed api request, it returns false if the
+// nonce was used within the expire.
+func (l *Server) UseNonce(c context.Context, id, nonce string, expire time.Duration) (bool, error) {
+	return l.dao.AddNonce(c, id, nonce, expire)
+}
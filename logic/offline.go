@@ -0,0 +1,86 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// offlineMids returns the mids not online.
+func offlineMids(mids, olMids []int64) (res []int64) {
+	online := make(map[int64]struct{}, len(olMids))
+	for _, mid := range olMids {
+		online[mid] = struct{}{}
+	}
+	for _, mid := range mids {
+		if _, ok := online[mid]; !ok && mid > 0 {
+			res = append(res, mid)
+		}
+	}
+	return
+}
+
+func (l *Server) addOfflineMsgs(c context.Context, op int32, mids []int64, msg []byte, opt *model.PushOptions) (err error) {
+	var (
+		app = opt.AppOf()
+		m   = &model.OfflineMsg{Op: op, Msg: msg, Ts: time.Now().UnixNano() / int64(time.Millisecond), Expire: opt.Expire()}
+	)
+	for _, mid := range mids {
+		if err = l.dao.AddOfflineMsg(c, app, mid, m); err != nil {
+			g.Logger.Errorf("l.dao.AddOfflineMsg(%d,%d) error(%v)", mid, op, err)
+			return
+		}
+	}
+	return
+}
+
+// deliverOfflineMsgs pushes the inbox of the mid of the app to the connected
+// key in order, the expired messages are dropped. A message leaves the inbox
+// once pushed, the messages after a failed push stay at its head for the next
+// connect.
+func (l *Server) deliverOfflineMsgs(app string, mid int64, key, server string) {
+	c := context.Background()
+	msgs, err := l.dao.OfflineMsgs(c, app, mid)
+	if err != nil {
+		g.Logger.Errorf("l.dao.OfflineMsgs(%d) error(%v)", mid, err)
+		return
+	}
+	var (
+		done []*model.OfflineMsg
+		now  = time.Now().UnixNano() / int64(time.Millisecond)
+	)
+	for _, m := range msgs {
+		if m.Expire > 0 && m.Expire < now {
+			done = append(done, m)
+			continue
+		}
+		var opt *model.PushOptions
+		if m.Expire > 0 {
+			opt = &model.PushOptions{TTL: time.Duration(m.Expire-now) * time.Millisecond}
+		}
+		if err = l.dao.PushMsg(c, m.Op, server, []string{key}, m.Msg, opt); err != nil {
+			g.Logger.Errorf("l.dao.PushMsg(%d,%s,%s) error(%v)", mid, key, server, err)
+			break
+		}
+		done = append(done, m)
+	}
+	if len(done) == 0 {
+		return
+	}
+	if err = l.dao.AckOfflineMsgs(c, app, mid, done); err != nil {
+		g.Logger.Errorf("l.dao.AckOfflineMsgs(%d) error(%v)", mid, err)
+	}
+	g.Logger.Infof("deliver offline msgs mid:%d key:%s server:%s count:%d left:%d", mid, key, server, len(done), len(msgs)-len(done))
+}
+
+// OfflineMsgs gets the offline inbox of the mid of the app.
+func (l *Server) OfflineMsgs(c context.Context, app string, mid int64) (msgs []*model.OfflineMsg, err error) {
+	return l.dao.OfflineMsgs(c, app, mid)
+}
+
+// ClearOfflineMsgs clears the offline inbox of the mid of the app.
+func (l *Server) ClearOfflineMsgs(c context.Context, app string, mid int64) (has bool, err error) {
+	return l.dao.DelOfflineMsgs(c, app, mid)
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
 )
 
 // OnlineTop get the top online address.
@@ -48,20 +49,46 @@ func (l *Server) OnlineTop(c context.Context, typeStr string, n int64) (addrs []
 	return
 }
 
-// OnlineRoom get rooms online.
-func (l *Server) OnlineRoom(c context.Context, rooms []string) (res map[string]int32, err error) {
-
-	if res, err = l.dao.GetAllRoomCount(c); err != nil {
+// OnlineRoom get the online of the rooms of the app, of all its rooms if none.
+func (l *Server) OnlineRoom(c context.Context, app string, rooms []string) (res map[string]int32, err error) {
+	var ids []string
+	for _, room := range rooms {
+		if room != "" && model.ValidID(room) {
+			ids = append(ids, model.EncodeRoomKey(app, room))
+		}
+	}
+	if len(ids) > 0 {
+		var counts map[string]int32
+		if counts, err = l.dao.RoomCount(c, ids); err != nil {
+			g.Logger.Errorf("RoomCount error(%v)", err)
+			return
+		}
+		res = make(map[string]int32, len(ids))
+		for _, room := range rooms {
+			if room != "" && model.ValidID(room) {
+				res[room] = counts[model.EncodeRoomKey(app, room)]
+			}
+		}
+		return
+	}
+	var all map[string]int32
+	if all, err = l.dao.GetAllRoomCount(c); err != nil {
 		g.Logger.Errorf("GetAllRoomCount error(%v)", err)
 		return
 	}
-	// if len(rooms) > 0 {
-	// 	res = make(map[string]int32, len(rooms))
-	// 	for _, roomID := range rooms {
-	// 		res[roomID] = roomCount[roomID]
-	// 	}
-	// } else {
-	// 	res = roomCount
-	// }
+	// the default app gets the rooms of all apps as is, the connections of
+	// the apps are not rooms
+	res = make(map[string]int32, len(all))
+	for key, n := range all {
+		a, room := model.DecodeRoomKey(key)
+		if room == "" {
+			continue
+		}
+		if app == "" {
+			res[key] = n
+		} else if a == app {
+			res[room] = n
+		}
+	}
 	return
 }
@@ -0,0 +1,35 @@
+package logic
+
+import (
+	"context"
+
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// Presence gets the online sessions of the mids of the app, grouped by mid,
+// the keys and rooms are unscoped.
+func (l *Server) Presence(c context.Context, app string, mids []int64) (res map[int64][]*model.Session, err error) {
+	sessions, err := l.dao.SessionsByMids(c, app, mids)
+	if err != nil {
+		g.Logger.Errorf("l.dao.SessionsByMids(%d mids) error(%v)", len(mids), err)
+		return
+	}
+	res = make(map[int64][]*model.Session)
+	for _, sess := range sessions {
+		if app != "" {
+			_, sess.Key = model.DecodeKey(sess.Key)
+			_, sess.Room = model.DecodeRoomKey(sess.Room)
+		}
+		res[sess.Mid] = append(res[sess.Mid], sess)
+	}
+	return
+}
+
+// OnlineMids gets the mids of the app having online keys.
+func (l *Server) OnlineMids(c context.Context, app string, mids []int64) (olMids []int64, err error) {
+	if _, olMids, err = l.dao.KeysByMids(c, app, mids); err != nil {
+		g.Logger.Errorf("l.dao.KeysByMids(%d mids) error(%v)", len(mids), err)
+	}
+	return
+}
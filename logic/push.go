@@ -4,10 +4,58 @@ import (
 	"context"
 
 	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
 )
 
 // PushKeys push a message by keys.
 func (l *Server) PushKeys(c context.Context, op int32, keys []string, msg []byte) (err error) {
+	_, err = l.PushKeysOpt(c, op, keys, msg, nil)
+	return
+}
+
+// PushKeysOpt push a message by keys with options, and returns the keys online.
+// The keys are scoped to the app of the options.
+func (l *Server) PushKeysOpt(c context.Context, op int32, keys []string, msg []byte, opt *model.PushOptions) (res *model.PushResult, err error) {
+	app := opt.AppOf()
+	if err = l.limitPush(c, model.PushKeys, "", opt); err != nil {
+		return
+	}
+	if keys, err = scopeKeys(app, keys); err != nil {
+		return
+	}
+	pushKeys, res, err := l.serverKeys(c, keys)
+	if err != nil {
+		return
+	}
+	for server := range pushKeys {
+		if err = l.dao.PushMsg(c, op, server, pushKeys[server], msg, opt); err != nil {
+			g.Logger.Errorf("dao.PushMsg error(%v)", err)
+			return
+		}
+		res.Enqueued++
+	}
+	res.OnlineKeys, res.OfflineKeys = unscopeKeys(app, res.OnlineKeys), unscopeKeys(app, res.OfflineKeys)
+	return
+}
+
+// scopeKeys returns the keys scoped to the app, the keys holding the app
+// separator are refused.
+func scopeKeys(app string, keys []string) ([]string, error) {
+	if !model.ValidID(app) || !model.ValidID(keys...) {
+		return nil, model.ErrInvalidID
+	}
+	if app == "" {
+		return keys, nil
+	}
+	res := make([]string, len(keys))
+	for i, key := range keys {
+		res[i] = model.EncodeKey(app, key)
+	}
+	return res, nil
+}
+
+// serverKeys groups the online keys by server.
+func (l *Server) serverKeys(c context.Context, keys []string) (pushKeys map[string][]string, res *model.PushResult, err error) {
 	servers, err := l.dao.ServersByKeys(c, keys)
 	if err != nil {
 		g.Logger.Errorf("dao.ServersByKeys error(%v)", err)
@@ -16,50 +64,135 @@ func (l *Server) PushKeys(c context.Context, op int32, keys []string, msg []byte
 
 	g.Logger.Debugf("dao.ServersByKeys servers(%v)", servers)
 
-	pushKeys := make(map[string][]string)
+	res = new(model.PushResult)
+	pushKeys = make(map[string][]string)
 	for i, key := range keys {
-		server := servers[i]
-		if server != "" && key != "" {
+		if key == "" {
+			continue
+		}
+		if server := servers[i]; server != "" {
 			pushKeys[server] = append(pushKeys[server], key)
+			res.OnlineKeys = append(res.OnlineKeys, key)
+		} else {
+			res.OfflineKeys = append(res.OfflineKeys, key)
 		}
 	}
-	for server := range pushKeys {
-		if err = l.dao.PushMsg(c, op, server, pushKeys[server], msg); err != nil {
-			g.Logger.Errorf("dao.PushMsg error(%v)", err)
+	return
+}
+
+// PushMids push a message by mid, the message is kept in the offline inbox
+// of the mids without online devices if enabled.
+func (l *Server) PushMids(c context.Context, op int32, mids []int64, msg []byte) (err error) {
+	_, err = l.PushMidsOpt(c, op, mids, msg, nil)
+	return
+}
+
+// PushMidsOpt push a message by mid with options, and returns the mids online
+// and the mids the message is kept in the offline inbox of. The mids are
+// scoped to the app of the options.
+func (l *Server) PushMidsOpt(c context.Context, op int32, mids []int64, msg []byte, opt *model.PushOptions) (res *model.PushResult, err error) {
+	if err = l.limitPush(c, model.PushMids, "", opt); err != nil {
+		return
+	}
+	pushKeys, res, err := l.midServerKeys(c, op, mids, msg, opt)
+	if err != nil {
+		return
+	}
+	for server, keys := range pushKeys {
+		if err = l.dao.PushMsg(c, op, server, keys, msg, opt); err != nil {
 			return
 		}
+		res.Enqueued++
 	}
+	res.OnlineKeys = unscopeKeys(opt.AppOf(), res.OnlineKeys)
 	return
 }
 
-// PushMids push a message by mid.
-func (l *Server) PushMids(c context.Context, op int32, mids []int64, msg []byte) (err error) {
-	keyServers, _, err := l.dao.KeysByMids(c, mids)
+// midServerKeys groups the keys of the online mids by server, and keeps the
+// message in the offline inbox of the other mids if enabled.
+func (l *Server) midServerKeys(c context.Context, op int32, mids []int64, msg []byte, opt *model.PushOptions) (pushKeys map[string][]string, res *model.PushResult, err error) {
+	keyServers, olMids, err := l.dao.KeysByMids(c, opt.AppOf(), mids)
 	if err != nil {
 		return
 	}
-	keys := make(map[string][]string)
+	res = &model.PushResult{OnlineMids: olMids, OfflineMids: offlineMids(mids, olMids)}
+	if l.c.Offline != nil {
+		if err = l.addOfflineMsgs(c, op, res.OfflineMids, msg, opt); err != nil {
+			return
+		}
+		res.StoredMids = res.OfflineMids
+	}
+	pushKeys = make(map[string][]string)
 	for key, server := range keyServers {
 		if key == "" || server == "" {
 			g.Logger.Warningf("push key:%s server:%s is empty", key, server)
 			continue
 		}
-		keys[server] = append(keys[server], key)
-	}
-	for server, keys := range keys {
-		if err = l.dao.PushMsg(c, op, server, keys, msg); err != nil {
-			return
-		}
+		pushKeys[server] = append(pushKeys[server], key)
+		res.OnlineKeys = append(res.OnlineKeys, key)
 	}
 	return
 }
 
-// PushRoom push a message by room.
+// PushRoom push a message by room, the message is kept in the room history
+// with a seq increasing by one, so clients can detect and fetch the gaps.
 func (l *Server) PushRoom(c context.Context, op int32, room string, msg []byte) (err error) {
-	return l.dao.BroadcastRoomMsg(c, op, room, msg)
+	_, err = l.PushRoomOpt(c, op, room, msg, nil)
+	return
+}
+
+// PushRoomOpt push a message by room with options, and returns the room seq
+// of the message. The room is scoped to the app of the options.
+func (l *Server) PushRoomOpt(c context.Context, op int32, room string, msg []byte, opt *model.PushOptions) (res *model.PushResult, err error) {
+	if room, err = model.ScopeRoom(opt.AppOf(), room); err != nil {
+		return
+	}
+	if err = l.limitPush(c, model.PushRoom, room, opt); err != nil {
+		return
+	}
+	seq := l.addRoomMsg(c, room, op, msg)
+	if err = l.dao.BroadcastRoomMsg(c, op, room, seq, msg, opt); err != nil {
+		return
+	}
+	return &model.PushResult{Enqueued: 1, Seq: seq}, nil
+}
+
+// addRoomMsg keeps the message in the room history and returns its seq. The
+// history is best effort, a message not kept is still pushed without seq.
+func (l *Server) addRoomMsg(c context.Context, room string, op int32, msg []byte) (seq int64) {
+	seq, err := l.dao.AddRoomMsg(c, room, op, msg)
+	if err != nil {
+		g.Logger.Errorf("l.dao.AddRoomMsg(%s,%d) error(%v)", room, op, err)
+		return 0
+	}
+	return
+}
+
+// RoomHistory gets the messages of the room after the seq, count is capped by the history size.
+func (l *Server) RoomHistory(c context.Context, room string, sinceSeq int64, count int64) (msgs []*model.RoomMsg, err error) {
+	if size := l.c.RoomHistory.Size; count <= 0 || count > size {
+		count = size
+	}
+	if msgs, err = l.dao.RoomHistory(c, room, sinceSeq, count); err != nil {
+		g.Logger.Errorf("l.dao.RoomHistory(%s,%d) error(%v)", room, sinceSeq, err)
+	}
+	return
 }
 
 // PushAll push a message to all.
 func (l *Server) PushAll(c context.Context, op, speed int32, platform string, msg []byte) (err error) {
-	return l.dao.BroadcastMsg(c, op, speed, platform, msg)
+	_, err = l.PushAllOpt(c, op, speed, platform, msg, nil)
+	return
+}
+
+// PushAllOpt push a message to all with options, a push of an app reaches
+// the channels of the app only.
+func (l *Server) PushAllOpt(c context.Context, op, speed int32, platform string, msg []byte, opt *model.PushOptions) (res *model.PushResult, err error) {
+	if err = l.limitPush(c, model.PushAll, "", opt); err != nil {
+		return
+	}
+	if err = l.dao.BroadcastMsg(c, op, speed, platform, msg, opt); err != nil {
+		return
+	}
+	return &model.PushResult{Enqueued: 1}, nil
 }
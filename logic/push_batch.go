@@ -0,0 +1,107 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	pb "github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/logic/dao"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// PushBatch pushes the items in one produce request, the messages of the items
+// are grouped by comet server. The result and the error of an item are at its
+// index, an item fails alone. The targets of an item are scoped to the app of
+// its options.
+func (l *Server) PushBatch(c context.Context, items []*model.PushItem) (res []*model.PushResult, errs []error) {
+	var (
+		msgs   []*pb.PushMsg
+		owners []int
+	)
+	res = make([]*model.PushResult, len(items))
+	errs = make([]error, len(items))
+	// the items are scoped and counted in the rate limits first, in one round
+	// trip
+	rooms := make([]string, len(items))
+	scoped := make([][]string, len(items))
+	counts := make([]pushCount, 0, len(items))
+	counted := make([]int, 0, len(items))
+	for i, item := range items {
+		app := item.Options.AppOf()
+		rooms[i], errs[i] = model.ScopeRoom(app, item.Room)
+		if errs[i] == nil {
+			scoped[i], errs[i] = scopeKeys(app, item.Keys)
+		}
+		if errs[i] == nil {
+			counts = append(counts, pushCount{item.Type, rooms[i], item.Options})
+			counted = append(counted, i)
+		}
+	}
+	for j, err := range l.limitPushes(c, counts) {
+		errs[counted[j]] = err
+	}
+	for i, item := range items {
+		var (
+			pushKeys map[string][]string
+			keys     = scoped[i]
+			room     = rooms[i]
+			app      = item.Options.AppOf()
+		)
+		if errs[i] != nil {
+			continue
+		}
+		switch item.Type {
+		case model.PushKeys:
+			pushKeys, res[i], errs[i] = l.serverKeys(c, keys)
+		case model.PushMids:
+			pushKeys, res[i], errs[i] = l.midServerKeys(c, item.Op, item.Mids, item.Msg, item.Options)
+		case model.PushRoom:
+			seq := l.addRoomMsg(c, room, item.Op, item.Msg)
+			res[i] = &model.PushResult{Seq: seq}
+			msgs = append(msgs, dao.NewRoomMsg(item.Op, room, seq, item.Msg, item.Options))
+			owners = append(owners, i)
+		case model.PushAll:
+			res[i] = new(model.PushResult)
+			msgs = append(msgs, dao.NewBroadcastMsg(item.Op, item.Speed, item.Platform, item.Msg, item.Options))
+			owners = append(owners, i)
+		default:
+			errs[i] = fmt.Errorf("unknown push type %q", item.Type)
+		}
+		if res[i] != nil {
+			res[i].OnlineKeys, res[i].OfflineKeys = unscopeKeys(app, res[i].OnlineKeys), unscopeKeys(app, res[i].OfflineKeys)
+		}
+		for server, keys := range pushKeys {
+			for _, keys := range l.dao.ShardKeys(keys) {
+				msgs = append(msgs, dao.NewPushMsg(item.Op, server, keys, item.Msg, item.Options))
+				owners = append(owners, i)
+			}
+		}
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	// the messages of a comet are adjacent, rooms and broadcasts keep their order
+	idx := make([]int, len(msgs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return msgs[idx[i]].Server < msgs[idx[j]].Server })
+	sorted := make([]*pb.PushMsg, len(msgs))
+	for i, j := range idx {
+		sorted[i] = msgs[j]
+	}
+	for i, err := range l.dao.PushMsgs(c, sorted) {
+		owner := owners[idx[i]]
+		if err != nil {
+			g.Logger.Errorf("l.dao.PushMsgs(item:%d server:%s) error(%v)", owner, sorted[i].Server, err)
+			if errs[owner] == nil {
+				errs[owner] = err
+			}
+			continue
+		}
+		res[owner].Enqueued++
+	}
+	return
+}
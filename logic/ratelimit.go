@@ -0,0 +1,98 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// pushLimit a limit counted under a key in a window.
+type pushLimit struct {
+	scope  string
+	key    string
+	max    int64
+	window time.Duration
+}
+
+// pushLimits returns the limits a push of the type passes, room is the room
+// key of a room push.
+func (l *Server) pushLimits(typ, room string, opt *model.PushOptions) (limits []pushLimit) {
+	app, caller := opt.AppOf(), opt.CallerOf()
+	if q := l.c.Apps[app]; q != nil && q.PushRate > 0 {
+		limits = append(limits, pushLimit{"app", "app_push_" + app, q.PushRate, time.Second})
+	}
+	r := l.c.RateLimit
+	if r == nil {
+		return
+	}
+	window := time.Duration(r.Window)
+	if caller != "" {
+		if r.Caller > 0 {
+			limits = append(limits, pushLimit{"caller", "push_caller_" + caller, r.Caller, window})
+		}
+		if n := r.Types[typ]; n > 0 {
+			limits = append(limits, pushLimit{"type", "push_" + typ + "_" + caller, n, window})
+		}
+	}
+	if typ == model.PushRoom && r.Room > 0 {
+		limits = append(limits, pushLimit{"room", "push_room_" + room, r.Room, window})
+	}
+	return
+}
+
+// pushCount a push counted in its limits.
+type pushCount struct {
+	typ  string
+	room string
+	opt  *model.PushOptions
+}
+
+// limitPush counts a push of the type in the limits of its app, caller, type
+// and room across the logics, a push beyond one of them is refused with a
+// model.RateLimited telling when to retry.
+func (l *Server) limitPush(c context.Context, typ, room string, opt *model.PushOptions) error {
+	return l.limitPushes(c, []pushCount{{typ, room, opt}})[0]
+}
+
+// limitPushes counts the pushes like limitPush in one round trip, the error of
+// a push is at its index. The pushes pass if the counters fail, like the
+// filter a limit is not worth refusing the pushes for.
+func (l *Server) limitPushes(c context.Context, pushes []pushCount) (errs []error) {
+	var (
+		keys    []string
+		windows []time.Duration
+		limits  = make([][]pushLimit, len(pushes))
+	)
+	errs = make([]error, len(pushes))
+	for i, p := range pushes {
+		limits[i] = l.pushLimits(p.typ, p.room, p.opt)
+		for _, lim := range limits[i] {
+			keys = append(keys, lim.key)
+			windows = append(windows, lim.window)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	ns, lefts, err := l.dao.IncrLimits(c, keys, windows)
+	if err != nil {
+		g.Logger.Warningf("push limits of %d pushes not counted, pushed unlimited error(%v)", len(pushes), err)
+		return
+	}
+	j := 0
+	for i, p := range pushes {
+		for _, lim := range limits[i] {
+			n, left := ns[j], lefts[j]
+			j++
+			if errs[i] != nil || n <= lim.max {
+				continue
+			}
+			g.StatMetrics.PushThrottled.With("scope", lim.scope, "type", p.typ).Add(1)
+			g.Logger.Warningf("push %s of app:%s caller:%s beyond the %s limit %d/%s", p.typ, p.opt.AppOf(), p.opt.CallerOf(), lim.scope, lim.max, lim.window)
+			errs[i] = &model.RateLimited{Scope: lim.scope, RetryAfter: left}
+		}
+	}
+	return
+}
@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	pb "github.com/swanky2009/goim/grpc/comet"
+	"github.com/swanky2009/goim/logic/dao"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// AddReceiptMsg records the sender and the recipients of a message pushed
+// with an id by the item, so the receipts of the recipients are aggregated
+// and reported to the sender.
+func (l *Server) AddReceiptMsg(c context.Context, msgID string, from int64, item *model.PushItem) (err error) {
+	var (
+		to  []string
+		app = item.Options.AppOf()
+	)
+	switch item.Type {
+	case model.PushKeys:
+		var keys []string
+		if keys, err = scopeKeys(app, item.Keys); err != nil {
+			return
+		}
+		for _, key := range keys {
+			to = append(to, dao.ReceiptToKey(key))
+		}
+	case model.PushMids:
+		for _, mid := range item.Mids {
+			to = append(to, dao.ReceiptToMid(app, mid))
+		}
+	case model.PushRoom:
+		var room string
+		if room, err = model.ScopeRoom(app, item.Room); err != nil {
+			return
+		}
+		to = append(to, dao.ReceiptToRoom(room))
+	case model.PushAll:
+		to = append(to, dao.ReceiptToAll(app))
+	}
+	if err = l.dao.AddReceiptMsg(c, app, msgID, from, to); err != nil {
+		g.Logger.Errorf("l.dao.AddReceiptMsg(%s,%d) error(%v)", msgID, from, err)
+	}
+	return
+}
+
+// Receipt gets the receipts of a message of the app, nil if the message is
+// unknown or expired.
+func (l *Server) Receipt(c context.Context, app, msgID string) (*model.ReceiptState, error) {
+	return l.dao.Receipt(c, app, msgID)
+}
+
+// receipt aggregates a receipt sent by the mid connected by the key in the
+// room, the first receipt of each type is pushed to the devices of the sender
+// in its app and published to kafka. The receipts of the mids the message is not pushed
+// to are dropped.
+func (l *Server) receipt(c context.Context, mid int64, key, room, typ, msgID string) (err error) {
+	var (
+		b     []byte
+		app   string
+		added bool
+		r     = &model.Receipt{MsgID: msgID, Mid: mid, Type: typ, Ts: time.Now().UnixNano() / int64(time.Millisecond)}
+	)
+	if msgID == "" {
+		return
+	}
+	if r.From, app, added, err = l.dao.AddReceipt(c, r, key, room); err != nil {
+		if err == dao.ErrNotRecipient {
+			g.Logger.Warningf("receipt %s of mid:%d key:%s not pushed to", msgID, mid, key)
+			return nil
+		}
+		g.Logger.Errorf("l.dao.AddReceipt(%s,%d,%s) error(%v)", msgID, mid, typ, err)
+		return
+	}
+	if r.From == 0 || !added {
+		return
+	}
+	if b, err = json.Marshal(r); err != nil {
+		return
+	}
+	if _, err = l.PushMidsOpt(c, pb.OpReceipt, []int64{r.From}, b, &model.PushOptions{App: app}); err != nil {
+		g.Logger.Errorf("l.PushMidsOpt(receipt %s,%d) error(%v)", msgID, r.From, err)
+	}
+	if l.c.Receipt.Topic != "" {
+		if err = l.dao.PublishReceipt(c, r); err != nil {
+			g.Logger.Errorf("l.dao.PublishReceipt(%s,%d) error(%v)", msgID, mid, err)
+		}
+	}
+	return
+}
@@ -0,0 +1,196 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	pb "github.com/swanky2009/goim/grpc/comet"
+	pb_l "github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/logic/dao"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// SetRoomConfig creates or replaces the config of a room, the bans are kept.
+func (l *Server) SetRoomConfig(c context.Context, rc *model.RoomConfig) (res *model.RoomConfig, err error) {
+	old, err := l.dao.RoomConfig(c, rc.Room)
+	if err != nil {
+		return
+	}
+	res = new(model.RoomConfig)
+	*res = *rc
+	res.Mtime = time.Now().Unix()
+	if res.Ctime = res.Mtime; old != nil {
+		res.Ctime = old.Ctime
+		res.Banned = old.Banned
+	}
+	if err = l.dao.SetRoomConfig(c, res); err != nil {
+		g.Logger.Errorf("l.dao.SetRoomConfig(%s) error(%v)", rc.Room, err)
+	}
+	return
+}
+
+// RoomConfig gets the config and the bans of a room, nil if not found.
+func (l *Server) RoomConfig(c context.Context, room string) (rc *model.RoomConfig, err error) {
+	if rc, err = l.dao.RoomConfig(c, room); err != nil {
+		g.Logger.Errorf("l.dao.RoomConfig(%s) error(%v)", room, err)
+	}
+	return
+}
+
+// DelRoomConfig deletes the config and the bans of a room.
+func (l *Server) DelRoomConfig(c context.Context, room string) (has bool, err error) {
+	if has, err = l.dao.DelRoomConfig(c, room); err != nil {
+		g.Logger.Errorf("l.dao.DelRoomConfig(%s) error(%v)", room, err)
+	}
+	return
+}
+
+// BanRoom bans the mids from joining and sending to a room, the channels of
+// the mids in it are kicked out.
+func (l *Server) BanRoom(c context.Context, room string, mids []int64) (err error) {
+	if err = l.dao.BanRoomMids(c, room, mids); err != nil {
+		g.Logger.Errorf("l.dao.BanRoomMids(%s,%v) error(%v)", room, mids, err)
+		return
+	}
+	return l.kickRoom(c, room, mids, model.RejectBanned, "banned from the room")
+}
+
+// roomKick the json body of OpRoomKick, comet takes a channel out of the room
+// if it is still in it, and pushes the code and message to the client.
+type roomKick struct {
+	Room    string `json:"room,omitempty"`
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+// kickRoom takes the channels of the mids out of the room, the comets skip
+// the channels in other rooms.
+func (l *Server) kickRoom(c context.Context, room string, mids []int64, code int32, message string) (err error) {
+	app, _ := model.DecodeRoomKey(room)
+	keyServers, _, err := l.dao.KeysByMids(c, app, mids)
+	if err != nil {
+		g.Logger.Errorf("l.dao.KeysByMids(%v) error(%v)", mids, err)
+		return
+	}
+	if len(keyServers) == 0 {
+		return
+	}
+	var (
+		keys       = make([]string, 0, len(keyServers))
+		serverKeys = make(map[string][]string)
+		msgs       []*pb_l.PushMsg
+	)
+	for key, server := range keyServers {
+		keys = append(keys, key)
+		if server != "" {
+			serverKeys[server] = append(serverKeys[server], key)
+		}
+	}
+	if err = l.dao.DelRoomMembers(c, room, keys); err != nil {
+		return
+	}
+	body, _ := json.Marshal(&roomKick{Room: room, Code: code, Message: message})
+	for server, keys := range serverKeys {
+		msgs = append(msgs, dao.NewPushMsg(pb.OpRoomKick, server, keys, body, nil))
+	}
+	for i, e := range l.dao.PushMsgs(c, msgs) {
+		if e != nil {
+			g.Logger.Errorf("l.dao.PushMsgs(kick %s server:%s) error(%v)", room, msgs[i].Server, e)
+			err = e
+		}
+	}
+	return
+}
+
+// UnbanRoom lifts the bans of the mids from a room.
+func (l *Server) UnbanRoom(c context.Context, room string, mids []int64) (err error) {
+	if err = l.dao.UnbanRoomMids(c, room, mids); err != nil {
+		g.Logger.Errorf("l.dao.UnbanRoomMids(%s,%v) error(%v)", room, mids, err)
+	}
+	return
+}
+
+// JoinRoom checks the mid may join a room and takes the key out of the room
+// it leaves, an empty room only leaves. A banned mid or a full room is refused
+// with a model.Reject. The members of a room with a capacity are counted as
+// they join, a channel joined before the capacity is set is not counted.
+func (l *Server) JoinRoom(c context.Context, mid int64, key, from, room string) (err error) {
+	if err = l.joinRoom(c, mid, key, room); err != nil {
+		return
+	}
+	if from != room {
+		l.leaveRoom(c, from, key)
+	}
+	return
+}
+
+func (l *Server) joinRoom(c context.Context, mid int64, key, room string) (err error) {
+	if room == "" {
+		return
+	}
+	rc, banned, err := l.dao.RoomAccess(c, room, mid)
+	if err != nil {
+		g.Logger.Errorf("l.dao.RoomAccess(%s,%d) error(%v)", room, mid, err)
+		return
+	}
+	if banned {
+		return &model.Reject{Code: model.RejectBanned, Message: "banned from the room"}
+	}
+	if rc == nil || rc.Capacity <= 0 {
+		return
+	}
+	ok, err := l.dao.AddRoomMember(c, room, key, rc.Capacity)
+	if err != nil {
+		return
+	}
+	if !ok {
+		return &model.Reject{Code: model.RejectRoomFull, Message: fmt.Sprintf("the room is full of %d", rc.Capacity)}
+	}
+	return
+}
+
+// leaveRoom takes the key out of the members of the room.
+func (l *Server) leaveRoom(c context.Context, room, key string) {
+	if room == "" {
+		return
+	}
+	if err := l.dao.DelRoomMembers(c, room, []string{key}); err != nil {
+		g.Logger.Errorf("l.dao.DelRoomMembers(%s,%s) error(%v)", room, key, err)
+	}
+}
+
+// sendRoom checks the mid may send a message to a room, a banned mid, a
+// muted room or a message within the slow mode interval is refused with a
+// model.Reject.
+func (l *Server) sendRoom(c context.Context, mid int64, room string) (err error) {
+	if room == "" {
+		return
+	}
+	rc, banned, err := l.dao.RoomAccess(c, room, mid)
+	if err != nil {
+		g.Logger.Errorf("l.dao.RoomAccess(%s,%d) error(%v)", room, mid, err)
+		return
+	}
+	if banned {
+		return &model.Reject{Code: model.RejectBanned, Message: "banned from the room"}
+	}
+	if rc == nil {
+		return
+	}
+	if rc.Muted {
+		return &model.Reject{Code: model.RejectMuted, Message: "the room is muted"}
+	}
+	if rc.SlowMode > 0 {
+		var ok bool
+		if ok, err = l.dao.AllowRoomMsg(c, room, mid, time.Duration(rc.SlowMode)*time.Second); err != nil {
+			return
+		}
+		if !ok {
+			return &model.Reject{Code: model.RejectSlowMode, Message: fmt.Sprintf("one message per %d seconds", rc.SlowMode)}
+		}
+	}
+	return
+}
@@ -0,0 +1,135 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+// ErrScheduleTooLate the deliver time is beyond the max delay.
+var ErrScheduleTooLate = errors.New("deliver time beyond the max delay")
+
+// SchedulePush keeps the push until the deliver time, a scheduled push is
+// fired by one logic only.
+func (l *Server) SchedulePush(c context.Context, item *model.PushItem, deliverAt time.Time, caller string) (sp *model.ScheduledPush, err error) {
+	now := time.Now()
+	if deliverAt.Sub(now) > time.Duration(l.c.Schedule.MaxDelay) {
+		return nil, ErrScheduleTooLate
+	}
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		return
+	}
+	sp = &model.ScheduledPush{
+		ID:        hex.EncodeToString(id),
+		DeliverAt: deliverAt.UnixNano() / int64(time.Millisecond),
+		Ctime:     now.UnixNano() / int64(time.Millisecond),
+		Caller:    caller,
+		Item:      item,
+	}
+	if err = l.dao.AddScheduledPush(c, sp); err != nil {
+		g.Logger.Errorf("l.dao.AddScheduledPush(%s) error(%v)", sp.ID, err)
+	}
+	return
+}
+
+// ScheduledPushes gets the pushes not fired yet of the caller, or of all if
+// empty, in the order of deliver time.
+func (l *Server) ScheduledPushes(c context.Context, caller string, offset, count int64) (sps []*model.ScheduledPush, err error) {
+	if sps, err = l.dao.ScheduledPushes(c, caller, offset, count); err != nil {
+		g.Logger.Errorf("l.dao.ScheduledPushes(%s,%d,%d) error(%v)", caller, offset, count, err)
+	}
+	return
+}
+
+// ScheduledPush gets a push not fired yet, nil if not found.
+func (l *Server) ScheduledPush(c context.Context, id string) (sp *model.ScheduledPush, err error) {
+	if sp, err = l.dao.ScheduledPush(c, id); err != nil {
+		g.Logger.Errorf("l.dao.ScheduledPush(%s) error(%v)", id, err)
+	}
+	return
+}
+
+// CancelScheduledPush cancels a push not fired yet.
+func (l *Server) CancelScheduledPush(c context.Context, sp *model.ScheduledPush) (has bool, err error) {
+	if has, err = l.dao.CancelScheduledPush(c, sp.ID, sp.Caller); err != nil {
+		g.Logger.Errorf("l.dao.CancelScheduledPush(%s) error(%v)", sp.ID, err)
+	}
+	return
+}
+
+// scheduleproc fires the due pushes every interval until closed.
+func (l *Server) scheduleproc() {
+	ticker := time.NewTicker(time.Duration(l.c.Schedule.Interval))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+		}
+		for {
+			n, err := l.fireScheduledPushes(context.Background())
+			if err != nil || int64(n) < l.c.Schedule.Batch {
+				break
+			}
+		}
+	}
+}
+
+// fireScheduledPushes claims the due pushes and pushes them in one batch, a
+// push is removed once fired. A push failing is claimed again when its lease
+// expires, up to the attempts, a push beyond a rate limit is delayed until
+// the limit allows.
+func (l *Server) fireScheduledPushes(c context.Context) (n int, err error) {
+	sc := l.c.Schedule
+	sps, err := l.dao.ClaimScheduledPushes(c, time.Now().UnixNano()/int64(time.Millisecond), int64(time.Duration(sc.Lease)/time.Millisecond), sc.Batch)
+	if err != nil || len(sps) == 0 {
+		return
+	}
+	items := make([]*model.PushItem, 0, len(sps))
+	for _, sp := range sps {
+		// a push fired again after its lease is pushed once by comet
+		if sp.Item.Options == nil {
+			sp.Item.Options = new(model.PushOptions)
+		}
+		if sp.Item.Options.MsgID == "" {
+			sp.Item.Options.MsgID = "schedule:" + sp.ID
+		}
+		// limited as the api key scheduling it, the caller is not kept with the
+		// options
+		sp.Item.Options.Caller = sp.Caller
+		items = append(items, sp.Item)
+	}
+	_, errs := l.PushBatch(c, items)
+	for i, sp := range sps {
+		// a push beyond a rate limit is fired again when the limit allows
+		if rl := model.AsRateLimited(errs[i]); rl != nil {
+			at := time.Now().Add(rl.RetryAfter).UnixNano() / int64(time.Millisecond)
+			if err := l.dao.DelayScheduledPush(c, sp, at); err != nil {
+				g.Logger.Errorf("l.dao.DelayScheduledPush(%s) error(%v)", sp.ID, err)
+				continue
+			}
+			g.Logger.Warningf("scheduled push %s caller:%s beyond the %s limit, delayed %s", sp.ID, sp.Caller, rl.Scope, rl.RetryAfter)
+			continue
+		}
+		if errs[i] != nil {
+			if sp.Attempts < sc.Attempts {
+				g.Logger.Warningf("scheduled push %s caller:%s attempt:%d error(%v), retry in %s", sp.ID, sp.Caller, sp.Attempts, errs[i], time.Duration(sc.Lease))
+				continue
+			}
+			g.Logger.Errorf("scheduled push %s caller:%s given up after %d attempts error(%v)", sp.ID, sp.Caller, sp.Attempts, errs[i])
+		} else {
+			g.Logger.Infof("scheduled push %s caller:%s fired, late %dms", sp.ID, sp.Caller, time.Now().UnixNano()/int64(time.Millisecond)-sp.DeliverAt)
+		}
+		if err := l.dao.AckScheduledPush(c, sp.ID); err != nil {
+			g.Logger.Errorf("l.dao.AckScheduledPush(%s) error(%v)", sp.ID, err)
+		}
+	}
+	return len(sps), nil
+}
@@ -0,0 +1,37 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/swanky2009/goim/logic/model"
+)
+
+func TestScheduledPushCallerLimit(t *testing.T) {
+	var (
+		c    = context.Background()
+		now  = time.Now()
+		item = func() *model.PushItem {
+			return &model.PushItem{Type: model.PushAll, Op: 1000, Msg: []byte("msg"), Options: &model.PushOptions{Caller: "schedule_key"}}
+		}
+	)
+	first, err := l.SchedulePush(c, item(), now, "schedule_key")
+	assert.Nil(t, err)
+	second, err := l.SchedulePush(c, item(), now, "schedule_key")
+	assert.Nil(t, err)
+	n, err := l.fireScheduledPushes(c)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	// one is beyond the limit of one push of the caller a minute, and kept
+	sps, err := l.ScheduledPushes(c, "schedule_key", 0, 10)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(sps)) {
+		assert.Contains(t, []string{first.ID, second.ID}, sps[0].ID)
+	}
+	// delayed, not due now
+	n, err = l.fireScheduledPushes(c)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+}
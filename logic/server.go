@@ -4,9 +4,12 @@ import (
 	"context"
 	"time"
 
+	"github.com/swanky2009/goim/logic/auth"
 	"github.com/swanky2009/goim/logic/dao"
+	"github.com/swanky2009/goim/logic/filter"
 	"github.com/swanky2009/goim/logic/g"
 	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/logic/upstream"
 )
 
 const (
@@ -15,16 +18,44 @@ const (
 
 // Logic Server struct
 type Server struct {
-	c   *conf.Config
-	dao *dao.Dao
+	c    *conf.Config
+	dao  *dao.Dao
+	auth auth.Authenticator
+
+	upstream *upstream.Router
+	filter   *filter.Chain
+	done     chan struct{}
 }
 
 // New server
 func NewServer(c *conf.Config) (l *Server) {
+	a, err := auth.New(c.Auth)
+	if err != nil {
+		panic(err)
+	}
+	up, err := upstream.New(c.Upstream, c.Kafka)
+	if err != nil {
+		panic(err)
+	}
+	d := dao.New(c)
+	// the rates are counted across the logics in redis
+	var incr filter.Counter
+	if c.Redis != nil {
+		incr = d.IncrWindow
+	}
+	fc, err := filter.New(c.Filter, incr)
+	if err != nil {
+		panic(err)
+	}
 	l = &Server{
-		c:   c,
-		dao: dao.New(c),
+		c:        c,
+		dao:      d,
+		auth:     a,
+		upstream: up,
+		filter:   fc,
+		done:     make(chan struct{}),
 	}
+	go l.scheduleproc()
 	// l.loadOnline()
 	// go l.onlineproc()
 	return l
@@ -37,6 +68,8 @@ func (l *Server) Ping(c context.Context) (err error) {
 
 // Close close resources.
 func (l *Server) Close() {
+	close(l.done)
+	l.upstream.Close()
 	l.dao.Close()
 }
 
@@ -0,0 +1,39 @@
+package logic
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/swanky2009/goim/logic/g/conf"
+	"github.com/swanky2009/goim/pkg/bus"
+	xtime "github.com/swanky2009/goim/pkg/time"
+)
+
+var (
+	l  *Server
+	mr *miniredis.Miniredis
+)
+
+// TestMain runs the tests against an in-process redis, and a channel bus.
+// The scheduled pushes are fired by the tests.
+func TestMain(m *testing.M) {
+	var err error
+	if mr, err = miniredis.Run(); err != nil {
+		panic(err)
+	}
+	c := &conf.Config{
+		Bus:       &bus.Config{Kind: bus.KindChannel, Topic: "goim-push-topic"},
+		Redis:     &conf.Redis{Mode: "single", Addrs: []string{mr.Addr()}},
+		Auth:      &conf.Auth{Mode: "dev"},
+		Schedule:  &conf.Schedule{Interval: xtime.Duration(time.Hour)},
+		RateLimit: &conf.RateLimit{Window: xtime.Duration(time.Minute), Caller: 1},
+	}
+	c.Fix()
+	l = NewServer(c)
+	code := m.Run()
+	l.Close()
+	mr.Close()
+	os.Exit(code)
+}
@@ -0,0 +1,140 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gogo/protobuf/proto"
+	pb "github.com/swanky2009/goim/grpc/logic"
+	"google.golang.org/grpc"
+	sarama "gopkg.in/Shopify/sarama.v1"
+)
+
+// kafkaDest produces the protobuf message keyed by mid, so the messages of a user stay in order.
+type kafkaDest struct {
+	topic string
+	pub   sarama.SyncProducer
+}
+
+func newKafka(topic string, brokers []string) (Destination, error) {
+	if topic == "" || len(brokers) == 0 {
+		return nil, errors.New("upstream: kafka route needs topic and brokers")
+	}
+	kc := sarama.NewConfig()
+	kc.Producer.RequiredAcks = sarama.WaitForAll
+	kc.Producer.Return.Successes = true
+	pub, err := sarama.NewSyncProducer(brokers, kc)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaDest{topic: topic, pub: pub}, nil
+}
+
+func (d *kafkaDest) Deliver(c context.Context, m *pb.UpstreamMsg) (err error) {
+	var b []byte
+	if b, err = proto.Marshal(m); err != nil {
+		return
+	}
+	_, _, err = d.pub.SendMessage(&sarama.ProducerMessage{
+		Key:   sarama.StringEncoder(strconv.FormatInt(m.Mid, 10)),
+		Topic: d.topic,
+		Value: sarama.ByteEncoder(b),
+	})
+	return
+}
+
+func (d *kafkaDest) Close() error {
+	return d.pub.Close()
+}
+
+// httpMsg the json body posted to a webhook, msg is base64.
+type httpMsg struct {
+	Mid       int64  `json:"mid"`
+	Key       string `json:"key"`
+	Room      string `json:"room"`
+	Platform  string `json:"platform"`
+	Server    string `json:"server"`
+	Op        int32  `json:"op"`
+	Msg       []byte `json:"msg"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// httpDest posts the json message, any 2xx status is a success.
+type httpDest struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTP(url string) (Destination, error) {
+	if url == "" {
+		return nil, errors.New("upstream: http route needs url")
+	}
+	return &httpDest{url: url, client: &http.Client{}}, nil
+}
+
+func (d *httpDest) Deliver(c context.Context, m *pb.UpstreamMsg) (err error) {
+	var (
+		b    []byte
+		req  *http.Request
+		resp *http.Response
+	)
+	if b, err = json.Marshal(&httpMsg{
+		Mid:       m.Mid,
+		Key:       m.Key,
+		Room:      m.Room,
+		Platform:  m.Platform,
+		Server:    m.Server,
+		Op:        m.Op,
+		Msg:       m.Msg,
+		Timestamp: m.Timestamp,
+	}); err != nil {
+		return
+	}
+	if req, err = http.NewRequest("POST", d.url, bytes.NewReader(b)); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if resp, err = d.client.Do(req.WithContext(c)); err != nil {
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = fmt.Errorf("%s status %d", d.url, resp.StatusCode)
+	}
+	return
+}
+
+func (d *httpDest) Close() error {
+	return nil
+}
+
+// grpcDest calls the Upstream service.
+type grpcDest struct {
+	conn   *grpc.ClientConn
+	client pb.UpstreamClient
+}
+
+func newGRPC(addr string) (Destination, error) {
+	if addr == "" {
+		return nil, errors.New("upstream: grpc route needs addr")
+	}
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	return &grpcDest{conn: conn, client: pb.NewUpstreamClient(conn)}, nil
+}
+
+func (d *grpcDest) Deliver(c context.Context, m *pb.UpstreamMsg) (err error) {
+	_, err = d.client.Deliver(c, m)
+	return
+}
+
+func (d *grpcDest) Close() error {
+	return d.conn.Close()
+}
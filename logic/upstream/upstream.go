@@ -0,0 +1,172 @@
+// Package upstream routes the messages sent by clients to business systems.
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/logic/g"
+	"github.com/swanky2009/goim/logic/g/conf"
+	xstr "github.com/swanky2009/goim/pkg/strings"
+)
+
+const (
+	// KindKafka produces to a kafka topic.
+	KindKafka = "kafka"
+	// KindHTTP posts to a webhook.
+	KindHTTP = "http"
+	// KindGRPC calls an Upstream grpc service.
+	KindGRPC = "grpc"
+)
+
+// Destination delivers messages to a business system.
+type Destination interface {
+	Deliver(c context.Context, m *pb.UpstreamMsg) error
+	Close() error
+}
+
+type route struct {
+	c     *conf.UpstreamRoute
+	name  string
+	ops   []xstr.Int32Range
+	dest  Destination
+	queue chan *pb.UpstreamMsg
+}
+
+func (r *route) match(op int32) bool {
+	return xstr.InInt32Ranges(r.ops, op)
+}
+
+// Router delivers every message to all the routes matching its operation,
+// each route delivers in order from its own queue.
+type Router struct {
+	mu     sync.RWMutex
+	routes []*route
+	wg     sync.WaitGroup
+}
+
+// New returns a router of the configured routes, kafka brokers default to the logic kafka.
+func New(c *conf.Upstream, kafka *conf.Kafka) (r *Router, err error) {
+	r = new(Router)
+	if c == nil {
+		return
+	}
+	for _, rc := range c.Routes {
+		var dest Destination
+		switch rc.Kind {
+		case KindKafka:
+			brokers := rc.Brokers
+			if len(brokers) == 0 && kafka != nil {
+				brokers = kafka.Brokers
+			}
+			dest, err = newKafka(rc.Topic, brokers)
+		case KindHTTP:
+			dest, err = newHTTP(rc.URL)
+		case KindGRPC:
+			dest, err = newGRPC(rc.Addr)
+		default:
+			err = fmt.Errorf("upstream: unknown kind %q, supports:[kafka,http,grpc]", rc.Kind)
+		}
+		if err == nil {
+			if err = r.Add(rc, dest); err != nil {
+				dest.Close()
+			}
+		}
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+	return
+}
+
+// Add adds a route delivering the configured ops to the destination.
+func (r *Router) Add(c *conf.UpstreamRoute, dest Destination) (err error) {
+	var ops []xstr.Int32Range
+	if ops, err = parseOps(c.Ops); err != nil {
+		return
+	}
+	rt := &route{
+		c:     c,
+		name:  fmt.Sprintf("%s(%s)", c.Kind, c.Ops),
+		ops:   ops,
+		dest:  dest,
+		queue: make(chan *pb.UpstreamMsg, c.Queue),
+	}
+	r.mu.Lock()
+	r.routes = append(r.routes, rt)
+	r.mu.Unlock()
+	r.wg.Add(1)
+	go r.deliverproc(rt)
+	return
+}
+
+// Route queues the message to the matching routes, and returns the number of routes.
+func (r *Router) Route(m *pb.UpstreamMsg) (n int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rt := range r.routes {
+		if !rt.match(m.Op) {
+			continue
+		}
+		select {
+		case rt.queue <- m:
+			n++
+		default:
+			g.Logger.Errorf("upstream %s queue full, drop message op:%d mid:%d", rt.name, m.Op, m.Mid)
+		}
+	}
+	return
+}
+
+// Close delivers the queued messages then closes the destinations.
+func (r *Router) Close() {
+	r.mu.Lock()
+	routes := r.routes
+	r.routes = nil
+	r.mu.Unlock()
+	for _, rt := range routes {
+		close(rt.queue)
+	}
+	r.wg.Wait()
+	for _, rt := range routes {
+		rt.dest.Close()
+	}
+}
+
+func (r *Router) deliverproc(rt *route) {
+	defer r.wg.Done()
+	for m := range rt.queue {
+		var (
+			err     error
+			backoff = time.Duration(rt.c.Backoff)
+		)
+		for i := 0; ; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(rt.c.Timeout))
+			err = rt.dest.Deliver(ctx, m)
+			cancel()
+			if err == nil || i >= rt.c.Retry {
+				break
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if err != nil {
+			g.Logger.Errorf("upstream %s deliver(op:%d mid:%d) error(%v)", rt.name, m.Op, m.Mid, err)
+		}
+	}
+}
+
+// parseOps parses operations and ranges, eg. "4,1000-1099".
+func parseOps(s string) (ops []xstr.Int32Range, err error) {
+	if ops, err = xstr.SplitInt32Ranges(s, ","); err != nil {
+		return nil, fmt.Errorf("upstream: invalid ops %q", s)
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("upstream: no ops")
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	pb "github.com/swanky2009/goim/grpc/logic"
+	"github.com/swanky2009/goim/logic/g/conf"
+	xstr "github.com/swanky2009/goim/pkg/strings"
+	xtime "github.com/swanky2009/goim/pkg/time"
+)
+
+type testDest struct {
+	mu    sync.Mutex
+	fails int
+	calls int
+	msgs  []*pb.UpstreamMsg
+}
+
+func (d *testDest) Deliver(c context.Context, m *pb.UpstreamMsg) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.calls++; d.calls <= d.fails {
+		return errors.New("unavailable")
+	}
+	d.msgs = append(d.msgs, m)
+	return nil
+}
+
+func (d *testDest) Close() error { return nil }
+
+func testRoute(ops string, retry int) *conf.UpstreamRoute {
+	return &conf.UpstreamRoute{Ops: ops, Timeout: xtime.Duration(time.Second), Retry: retry, Backoff: xtime.Duration(time.Millisecond), Queue: 10}
+}
+
+func TestParseOps(t *testing.T) {
+	ops, err := parseOps("4, 1000-1099")
+	assert.Nil(t, err)
+	assert.Equal(t, []xstr.Int32Range{{From: 4, To: 4}, {From: 1000, To: 1099}}, ops)
+	_, err = parseOps("1099-1000")
+	assert.NotNil(t, err)
+	_, err = parseOps("a")
+	assert.NotNil(t, err)
+	_, err = parseOps("")
+	assert.NotNil(t, err)
+}
+
+func TestRouter(t *testing.T) {
+	var (
+		r       = new(Router)
+		bots    = &testDest{fails: 2}
+		moderat = &testDest{fails: 5}
+	)
+	assert.Nil(t, r.Add(testRoute("4,1000-1099", 3), bots))
+	assert.Nil(t, r.Add(testRoute("4", 1), moderat))
+	assert.Equal(t, 2, r.Route(&pb.UpstreamMsg{Mid: 1, Op: 4, Msg: []byte("hello")}))
+	assert.Equal(t, 1, r.Route(&pb.UpstreamMsg{Mid: 1, Op: 1001, Msg: []byte("bot")}))
+	assert.Equal(t, 0, r.Route(&pb.UpstreamMsg{Mid: 1, Op: 2000}))
+	r.Close()
+	// bots succeeds on the third try
+	assert.Equal(t, 2, len(bots.msgs))
+	assert.Equal(t, "hello", string(bots.msgs[0].Msg))
+	assert.Equal(t, int32(1001), bots.msgs[1].Op)
+	// moderation gives up after one retry of each message
+	assert.Equal(t, 2, moderat.calls)
+	assert.Equal(t, 0, len(moderat.msgs))
+}
+
+func TestHTTPDest(t *testing.T) {
+	msgs := make(chan *httpMsg, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := new(httpMsg)
+		json.NewDecoder(r.Body).Decode(m)
+		msgs <- m
+	}))
+	defer ts.Close()
+	r, err := New(&conf.Upstream{Routes: []*conf.UpstreamRoute{{Ops: "1000", Kind: KindHTTP, URL: ts.URL, Timeout: xtime.Duration(time.Second), Queue: 1}}}, nil)
+	assert.Nil(t, err)
+	r.Route(&pb.UpstreamMsg{Mid: 1, Key: "key", Room: "live://1000", Platform: "web", Server: "comet", Op: 1000, Msg: []byte("hi"), Timestamp: 1})
+	r.Close()
+	assert.Equal(t, &httpMsg{Mid: 1, Key: "key", Room: "live://1000", Platform: "web", Server: "comet", Op: 1000, Msg: []byte("hi"), Timestamp: 1}, <-msgs)
+
+	_, err = New(&conf.Upstream{Routes: []*conf.UpstreamRoute{{Ops: "1000", Kind: "smtp"}}}, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,266 @@
+// Package bus publishes and subscribes the messages between logic and job,
+// over kafka, redis streams, nats or an in-process channel.
+package bus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	xtime "github.com/swanky2009/goim/pkg/time"
+)
+
+const (
+	// KindKafka kafka topics and consumer groups, the default.
+	KindKafka = "kafka"
+	// KindRedis redis streams and consumer groups.
+	KindRedis = "redis"
+	// KindNATS nats subjects and queue groups, messages are not persisted.
+	KindNATS = "nats"
+	// KindChannel an in-process channel per topic, for tests and the
+	// deployments running logic and job in one process.
+	KindChannel = "channel"
+)
+
+// Config is bus config, the publishers of logic and the subscribers of job
+// must have the same kind and topic.
+type Config struct {
+	// Kind kafka, redis, nats or channel, default kafka.
+	Kind string
+	// Topic the kafka topic, redis stream or nats subject subscribed.
+	Topic string
+	// Group the consumer group of subscribers, a message is consumed by one
+	// subscriber of a group.
+	Group string
+	// Addrs the kafka brokers, redis addrs or nats addrs.
+	Addrs []string
+	// Password and DB of redis.
+	Password string
+	DB       int
+	// MaxLen approximate max messages kept per redis stream, default 1000000.
+	MaxLen int64 `yaml:"max_len"`
+	// Block a redis subscriber waits for messages, default 1s.
+	Block xtime.Duration
+	// Buffer messages of a channel topic, default 1024.
+	Buffer int
+	// Kafka options of the kafka publishers.
+	Kafka *Kafka
+}
+
+// Kafka options of the kafka publishers.
+type Kafka struct {
+	// Acks the ack level of a produce: none, leader or all, default all.
+	Acks string
+	// Retries of a produce, default 10.
+	Retries int
+	// Compression none, gzip, snappy or lz4, default none.
+	Compression string
+	// Async produces in the background, batching the messages of all
+	// publishes.
+	Async bool
+	// Wait an async publish returns once the messages are queued, or acked
+	// at the acks level, default ack. Queued needs a spool, the messages are
+	// journaled in it before a publish returns.
+	Wait string
+	// BatchMessages and BatchBytes a batch is sent at, Linger the longest a
+	// message waits for its batch.
+	BatchMessages int `yaml:"batch_messages"`
+	BatchBytes    int `yaml:"batch_bytes"`
+	Linger        xtime.Duration
+	// MaxInFlight max requests in flight per broker, default 1. The messages
+	// of a key keep their order across retries only with 1, more is faster
+	// but a retried message may land after a newer one of its key.
+	MaxInFlight int `yaml:"max_in_flight"`
+	// Spool the directory the messages failed by an async publisher are kept
+	// in, and republished from every SpoolInterval, default 10s. The messages
+	// failed are dropped if empty. Their order is best effort, they land
+	// after the newer messages of their key published meanwhile, which are
+	// not held so that a failed broker does not stall the publishes.
+	Spool         string
+	SpoolInterval xtime.Duration `yaml:"spool_interval"`
+}
+
+func (k *Kafka) fix() {
+	if k.Acks == "" {
+		k.Acks = KafkaAcksAll
+	}
+	if k.Retries <= 0 {
+		k.Retries = 10
+	}
+	if k.Wait == "" {
+		k.Wait = KafkaWaitAck
+	}
+	if k.MaxInFlight <= 0 {
+		k.MaxInFlight = 1
+	}
+	if k.SpoolInterval <= 0 {
+		k.SpoolInterval = xtime.Duration(10 * time.Second)
+	}
+}
+
+// Fix sets the defaults.
+func (c *Config) Fix() {
+	if c.Kind == "" {
+		c.Kind = KindKafka
+	}
+	if c.MaxLen <= 0 {
+		c.MaxLen = 1000000
+	}
+	if c.Block <= 0 {
+		c.Block = xtime.Duration(time.Second)
+	}
+	if c.Buffer <= 0 {
+		c.Buffer = 1024
+	}
+	if c.Kafka == nil {
+		c.Kafka = new(Kafka)
+	}
+	c.Kafka.fix()
+}
+
+// Message a message of a topic, the messages of a key are kept in order by
+// kafka, except the messages republished from a spool.
+type Message struct {
+	Topic string
+	Key   string
+	Value []byte
+	// ID where a subscriber consumed the message, partition/offset of kafka
+	// and the entry id of redis.
+	ID string
+
+	ack func()
+}
+
+// Ack marks the message consumed, an unacked message of kafka and redis is
+// consumed again after the subscriber restarts.
+func (m *Message) Ack() {
+	if m.ack != nil {
+		m.ack()
+	}
+}
+
+// Publisher publishes messages.
+type Publisher interface {
+	// Publish publishes the messages in one request where the bus supports,
+	// the error of a message is at its index and errs is nil if all are
+	// published.
+	Publish(c context.Context, msgs []*Message) (errs []error)
+	Close() error
+}
+
+// Subscriber consumes the messages of the topic in the group.
+type Subscriber interface {
+	// Messages returns the messages consumed, closed by Close.
+	Messages() <-chan *Message
+	// Errors returns the errors the subscriber retries after.
+	Errors() <-chan error
+	Close() error
+}
+
+// Hooks are called with the result of every message published, a hook of
+// an async publisher is called from its background goroutines.
+type Hooks struct {
+	// Published is called with a message published and the time it took.
+	Published func(m *Message, took time.Duration)
+	// Failed is called with a message failed, spooled tells it is kept to
+	// be republished.
+	Failed func(m *Message, err error, spooled bool)
+}
+
+func (h *Hooks) published(m *Message, took time.Duration) {
+	if h != nil && h.Published != nil {
+		h.Published(m, took)
+	}
+}
+
+func (h *Hooks) failed(m *Message, err error, spooled bool) {
+	if h != nil && h.Failed != nil {
+		h.Failed(m, err, spooled)
+	}
+}
+
+// hookPublisher calls the hooks after the publishes of a publisher.
+type hookPublisher struct {
+	Publisher
+	hooks *Hooks
+}
+
+func (p *hookPublisher) Publish(c context.Context, msgs []*Message) (errs []error) {
+	start := time.Now()
+	errs = p.Publisher.Publish(c, msgs)
+	took := time.Since(start)
+	for i, m := range msgs {
+		if errs != nil && errs[i] != nil {
+			p.hooks.failed(m, errs[i], false)
+		} else {
+			p.hooks.published(m, took)
+		}
+	}
+	return
+}
+
+// PublishOne publishes a message.
+func PublishOne(c context.Context, p Publisher, m *Message) error {
+	if errs := p.Publish(c, []*Message{m}); errs != nil {
+		return errs[0]
+	}
+	return nil
+}
+
+// NewPublisher new a publisher of the kind, the hooks may be nil.
+func NewPublisher(c *Config, h *Hooks) (p Publisher, err error) {
+	c.Fix()
+	switch c.Kind {
+	case KindKafka:
+		if c.Kafka.Async {
+			return NewKafkaAsyncPublisher(c, h)
+		}
+		p, err = NewKafkaPublisher(c)
+	case KindRedis:
+		p, err = NewRedisPublisher(c)
+	case KindNATS:
+		p, err = NewNATSPublisher(c)
+	case KindChannel:
+		p = NewChannelPublisher(c)
+	default:
+		return nil, fmt.Errorf("bus: unknown kind %q", c.Kind)
+	}
+	if err != nil || h == nil {
+		return
+	}
+	return &hookPublisher{Publisher: p, hooks: h}, nil
+}
+
+// NewSubscriber new a subscriber of the kind.
+func NewSubscriber(c *Config) (Subscriber, error) {
+	c.Fix()
+	switch c.Kind {
+	case KindKafka:
+		return NewKafkaSubscriber(c)
+	case KindRedis:
+		return NewRedisSubscriber(c)
+	case KindNATS:
+		return NewNATSSubscriber(c)
+	case KindChannel:
+		return NewChannelSubscriber(c), nil
+	}
+	return nil, fmt.Errorf("bus: unknown kind %q", c.Kind)
+}
+
+// setErr sets the error of the message i of n.
+func setErr(errs []error, n, i int, err error) []error {
+	if errs == nil {
+		errs = make([]error, n)
+	}
+	errs[i] = err
+	return errs
+}
+
+// errsOf returns the errors of n messages failed with err.
+func errsOf(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
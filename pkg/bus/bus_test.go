@@ -0,0 +1,160 @@
+package bus
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPubSub publishes n messages of the topic and checks the subscriber
+// consumes them in order.
+func testPubSub(t *testing.T, c *Config, n int) {
+	sub, err := NewSubscriber(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	pub, err := NewPublisher(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pub.Close()
+	msgs := make([]*Message, n)
+	for i := range msgs {
+		msgs[i] = &Message{Topic: c.Topic, Key: "k", Value: []byte(strconv.Itoa(i))}
+	}
+	if errs := pub.Publish(context.Background(), msgs); errs != nil {
+		t.Fatal(errs)
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case m := <-sub.Messages():
+			if string(m.Value) != strconv.Itoa(i) {
+				t.Fatalf("message %d is %q", i, m.Value)
+			}
+			m.Ack()
+		case err := <-sub.Errors():
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %d is not consumed", i)
+		}
+	}
+}
+
+func TestChannel(t *testing.T) {
+	c := &Config{Kind: KindChannel, Topic: fmt.Sprintf("test-channel-%d", time.Now().UnixNano())}
+	testPubSub(t, c, 10)
+	// a closed subscriber leaves the messages to the others
+	if err := PublishOne(context.Background(), NewChannelPublisher(c), &Message{Topic: c.Topic, Value: []byte("0")}); err != nil {
+		t.Fatal(err)
+	}
+	testPubSub(t, c, 0)
+	testPubSub(t, c, 1)
+}
+
+func TestChannelPublishCanceled(t *testing.T) {
+	c := &Config{Kind: KindChannel, Topic: fmt.Sprintf("test-channel-full-%d", time.Now().UnixNano()), Buffer: 1}
+	c.Fix()
+	pub := NewChannelPublisher(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	errs := pub.Publish(ctx, []*Message{{Topic: c.Topic}, {Topic: c.Topic}})
+	if len(errs) != 2 || errs[0] != nil || errs[1] != context.DeadlineExceeded {
+		t.Fatalf("errs %v", errs)
+	}
+}
+
+func TestUnknownKind(t *testing.T) {
+	if _, err := NewPublisher(&Config{Kind: "mqtt"}, nil); err == nil {
+		t.Fatal("mqtt publisher")
+	}
+}
+
+// fakeNATS serves the core nats protocol enough for a publisher and the
+// subscribers of a queue group, a message goes to the first subscriber.
+func fakeNATS(t *testing.T) (addr string, stop func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type sub struct {
+		w   *bufio.Writer
+		sid string
+	}
+	var (
+		mu   sync.Mutex
+		subs = make(map[string][]*sub)
+	)
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		w := bufio.NewWriter(conn)
+		write := func(s string) {
+			mu.Lock()
+			w.WriteString(s)
+			w.Flush()
+			mu.Unlock()
+		}
+		write("INFO {\"max_payload\":1048576}\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			f := strings.Fields(line)
+			switch f[0] {
+			case "PING":
+				write("PONG\r\n")
+			case "SUB":
+				mu.Lock()
+				subs[f[1]] = append(subs[f[1]], &sub{w: w, sid: f[len(f)-1]})
+				mu.Unlock()
+			case "PUB":
+				n, _ := strconv.Atoi(f[len(f)-1])
+				b := make([]byte, n+2)
+				if _, err = io.ReadFull(r, b); err != nil {
+					return
+				}
+				mu.Lock()
+				if ss := subs[f[1]]; len(ss) > 0 {
+					fmt.Fprintf(ss[0].w, "MSG %s %s %d\r\n%s", f[1], ss[0].sid, n, b)
+					ss[0].w.Flush()
+				}
+				mu.Unlock()
+			}
+		}
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return "nats://" + ln.Addr().String(), func() { ln.Close() }
+}
+
+func TestNATS(t *testing.T) {
+	addr, stop := fakeNATS(t)
+	defer stop()
+	testPubSub(t, &Config{Kind: KindNATS, Topic: "test-nats", Group: "job", Addrs: []string{addr}}, 10)
+}
+
+func TestRedis(t *testing.T) {
+	addrs := os.Getenv("GOIM_TEST_REDIS")
+	if addrs == "" {
+		t.Skip("GOIM_TEST_REDIS is not set")
+	}
+	topic := fmt.Sprintf("test-bus-%d", time.Now().UnixNano())
+	testPubSub(t, &Config{Kind: KindRedis, Topic: topic, Group: "job", Addrs: strings.Split(addrs, ",")}, 10)
+}
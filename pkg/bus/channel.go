@@ -0,0 +1,101 @@
+package bus
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	channelsMu sync.Mutex
+	channels   = make(map[string]chan *Message)
+)
+
+// channelTopic returns the channel of the topic in the process, the first
+// of the publishers and subscribers of the topic makes it.
+func channelTopic(topic string, buffer int) chan *Message {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+	ch, ok := channels[topic]
+	if !ok {
+		ch = make(chan *Message, buffer)
+		channels[topic] = ch
+	}
+	return ch
+}
+
+type channelPublisher struct {
+	buffer int
+}
+
+// NewChannelPublisher new a publisher to the channels of the process, a
+// publish waits while the channel of the topic is full.
+func NewChannelPublisher(c *Config) Publisher {
+	return &channelPublisher{buffer: c.Buffer}
+}
+
+func (p *channelPublisher) Publish(c context.Context, msgs []*Message) (errs []error) {
+	for i, m := range msgs {
+		select {
+		case channelTopic(m.Topic, p.buffer) <- &Message{Topic: m.Topic, Key: m.Key, Value: m.Value}:
+		case <-c.Done():
+			errs = setErr(errs, len(msgs), i, c.Err())
+		}
+	}
+	return
+}
+
+func (p *channelPublisher) Close() error {
+	return nil
+}
+
+type channelSubscriber struct {
+	msgs chan *Message
+	errs chan error
+	done chan struct{}
+	once sync.Once
+}
+
+// NewChannelSubscriber new a subscriber of the channel of the topic in the
+// process, the subscribers of a topic are one group.
+func NewChannelSubscriber(c *Config) Subscriber {
+	s := &channelSubscriber{
+		msgs: make(chan *Message),
+		errs: make(chan error),
+		done: make(chan struct{}),
+	}
+	go s.consume(channelTopic(c.Topic, c.Buffer))
+	return s
+}
+
+func (s *channelSubscriber) consume(ch chan *Message) {
+	defer close(s.msgs)
+	for {
+		select {
+		case m := <-ch:
+			select {
+			case s.msgs <- m:
+			case <-s.done:
+				// keep it for the other subscribers
+				go func() { ch <- m }()
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *channelSubscriber) Messages() <-chan *Message {
+	return s.msgs
+}
+
+func (s *channelSubscriber) Errors() <-chan error {
+	return s.errs
+}
+
+func (s *channelSubscriber) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
@@ -0,0 +1,191 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	cluster "github.com/bsm/sarama-cluster"
+	sarama "gopkg.in/Shopify/sarama.v1"
+)
+
+const (
+	// KafkaAcksNone a produce is not acked.
+	KafkaAcksNone = "none"
+	// KafkaAcksLeader a produce is acked by the leader.
+	KafkaAcksLeader = "leader"
+	// KafkaAcksAll a produce is acked by all in-sync replicas.
+	KafkaAcksAll = "all"
+
+	// KafkaWaitQueued an async publish returns once the messages are queued.
+	KafkaWaitQueued = "queued"
+	// KafkaWaitAck an async publish returns once the messages are acked.
+	KafkaWaitAck = "ack"
+)
+
+// kafkaConfig returns the producer config of the kafka options.
+func kafkaConfig(k *Kafka) (kc *sarama.Config, err error) {
+	kc = sarama.NewConfig()
+	switch k.Acks {
+	case KafkaAcksNone:
+		kc.Producer.RequiredAcks = sarama.NoResponse
+	case KafkaAcksLeader:
+		kc.Producer.RequiredAcks = sarama.WaitForLocal
+	case KafkaAcksAll:
+		kc.Producer.RequiredAcks = sarama.WaitForAll
+	default:
+		return nil, fmt.Errorf("bus: unknown kafka acks %q", k.Acks)
+	}
+	switch k.Compression {
+	case "", "none":
+		kc.Producer.Compression = sarama.CompressionNone
+	case "gzip":
+		kc.Producer.Compression = sarama.CompressionGZIP
+	case "snappy":
+		kc.Producer.Compression = sarama.CompressionSnappy
+	case "lz4":
+		kc.Producer.Compression = sarama.CompressionLZ4
+		kc.Version = sarama.V0_10_0_0
+	default:
+		return nil, fmt.Errorf("bus: unknown kafka compression %q", k.Compression)
+	}
+	switch k.Wait {
+	case KafkaWaitQueued, KafkaWaitAck:
+	default:
+		return nil, fmt.Errorf("bus: unknown kafka wait %q", k.Wait)
+	}
+	kc.Producer.Retry.Max = k.Retries
+	kc.Producer.Return.Successes = true
+	kc.Producer.Flush.Messages = k.BatchMessages
+	kc.Producer.Flush.Bytes = k.BatchBytes
+	kc.Producer.Flush.Frequency = time.Duration(k.Linger)
+	kc.Net.MaxOpenRequests = k.MaxInFlight
+	return
+}
+
+type kafkaPublisher struct {
+	pub sarama.SyncProducer
+}
+
+// NewKafkaPublisher new a publisher waiting for the acks of every publish.
+func NewKafkaPublisher(c *Config) (Publisher, error) {
+	kc, err := kafkaConfig(c.Kafka)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := sarama.NewSyncProducer(c.Addrs, kc)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaPublisher{pub: pub}, nil
+}
+
+func (p *kafkaPublisher) Publish(c context.Context, msgs []*Message) (errs []error) {
+	pms := make([]*sarama.ProducerMessage, 0, len(msgs))
+	for i, m := range msgs {
+		pms = append(pms, &sarama.ProducerMessage{
+			Key:      sarama.StringEncoder(m.Key),
+			Topic:    m.Topic,
+			Value:    sarama.ByteEncoder(m.Value),
+			Metadata: i,
+		})
+	}
+	err := p.pub.SendMessages(pms)
+	if err == nil {
+		return
+	}
+	perrs, ok := err.(sarama.ProducerErrors)
+	if !ok {
+		return errsOf(len(msgs), err)
+	}
+	errs = make([]error, len(msgs))
+	for _, perr := range perrs {
+		errs[perr.Msg.Metadata.(int)] = perr.Err
+	}
+	return
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.pub.Close()
+}
+
+type kafkaSubscriber struct {
+	consumer *cluster.Consumer
+	msgs     chan *Message
+	errs     chan error
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewKafkaSubscriber new a subscriber of the topic in the consumer group.
+func NewKafkaSubscriber(c *Config) (Subscriber, error) {
+	config := cluster.NewConfig()
+	config.Consumer.Return.Errors = true
+	consumer, err := cluster.NewConsumer(c.Addrs, c.Group, []string{c.Topic}, config)
+	if err != nil {
+		return nil, err
+	}
+	s := &kafkaSubscriber{
+		consumer: consumer,
+		msgs:     make(chan *Message),
+		errs:     make(chan error, 16),
+		done:     make(chan struct{}),
+	}
+	go s.consume()
+	return s, nil
+}
+
+func (s *kafkaSubscriber) consume() {
+	defer close(s.msgs)
+	for {
+		select {
+		case err, ok := <-s.consumer.Errors():
+			if ok {
+				sendErr(s.errs, err)
+			}
+		case km, ok := <-s.consumer.Messages():
+			if !ok {
+				return
+			}
+			m := &Message{
+				Topic: km.Topic,
+				Key:   string(km.Key),
+				Value: km.Value,
+				ID:    fmt.Sprintf("%d/%d", km.Partition, km.Offset),
+				ack:   func() { s.consumer.MarkOffset(km, "") },
+			}
+			select {
+			case s.msgs <- m:
+			case <-s.done:
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *kafkaSubscriber) Messages() <-chan *Message {
+	return s.msgs
+}
+
+func (s *kafkaSubscriber) Errors() <-chan error {
+	return s.errs
+}
+
+func (s *kafkaSubscriber) Close() (err error) {
+	s.once.Do(func() {
+		close(s.done)
+		err = s.consumer.Close()
+	})
+	return
+}
+
+// sendErr sends the error unless nobody reads the errors.
+func sendErr(errs chan error, err error) {
+	select {
+	case errs <- err:
+	default:
+	}
+}
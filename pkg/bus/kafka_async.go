@@ -0,0 +1,243 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sarama "gopkg.in/Shopify/sarama.v1"
+)
+
+var errClosed = errors.New("bus: publisher closed")
+
+// _spoolBatch the most messages failed spooled with one sync.
+const _spoolBatch = 256
+
+// asyncMsg a message in the async producer.
+type asyncMsg struct {
+	m     *Message
+	start time.Time
+	// done gets the result of a publish waiting for the ack, nil if the
+	// message is spooled.
+	done chan error
+	// jf the journal file of a queued message, done once acked or spooled.
+	jf *journalFile
+}
+
+type kafkaAsyncPublisher struct {
+	producer sarama.AsyncProducer
+	ack      bool
+	hooks    *Hooks
+	spool    *spool
+	journal  *journal
+	done     chan struct{}
+	replayWg sync.WaitGroup
+	drainWg  sync.WaitGroup
+}
+
+// NewKafkaAsyncPublisher new a publisher producing in the background, the
+// messages of all publishes are batched. The messages failed after the
+// retries are kept in the spool if configured, and republished. A publish
+// not waiting for the acks journals its messages in the spool before it
+// returns, so they are republished after a crash.
+func NewKafkaAsyncPublisher(c *Config, h *Hooks) (Publisher, error) {
+	kc, err := kafkaConfig(c.Kafka)
+	if err != nil {
+		return nil, err
+	}
+	p := &kafkaAsyncPublisher{
+		ack:   c.Kafka.Wait == KafkaWaitAck,
+		hooks: h,
+		done:  make(chan struct{}),
+	}
+	if !p.ack && c.Kafka.Spool == "" {
+		return nil, fmt.Errorf("bus: kafka wait %s needs a spool", KafkaWaitQueued)
+	}
+	if c.Kafka.Spool != "" {
+		if p.spool, err = newSpool(c.Kafka.Spool); err != nil {
+			return nil, err
+		}
+	}
+	if !p.ack {
+		if p.journal, err = newJournal(c.Kafka.Spool); err != nil {
+			return nil, err
+		}
+	}
+	if p.producer, err = sarama.NewAsyncProducer(c.Addrs, kc); err != nil {
+		return nil, err
+	}
+	p.drainWg.Add(2)
+	go p.successes()
+	go p.errors()
+	if p.spool != nil {
+		p.replayWg.Add(1)
+		go p.replayproc(time.Duration(c.Kafka.SpoolInterval))
+	}
+	return p, nil
+}
+
+func producerMsg(am *asyncMsg) *sarama.ProducerMessage {
+	return &sarama.ProducerMessage{
+		Key:      sarama.StringEncoder(am.m.Key),
+		Topic:    am.m.Topic,
+		Value:    sarama.ByteEncoder(am.m.Value),
+		Metadata: am,
+	}
+}
+
+func (p *kafkaAsyncPublisher) Publish(c context.Context, msgs []*Message) (errs []error) {
+	var jf *journalFile
+	if p.journal != nil {
+		var err error
+		if jf, err = p.journal.add(msgs); err != nil {
+			return errsOf(len(msgs), err)
+		}
+	}
+	sent := make([]*asyncMsg, len(msgs))
+	for i, m := range msgs {
+		am := &asyncMsg{m: m, start: time.Now(), jf: jf}
+		if p.ack {
+			am.done = make(chan error, 1)
+		}
+		select {
+		case p.producer.Input() <- producerMsg(am):
+			sent[i] = am
+		case <-c.Done():
+			errs = setErr(errs, len(msgs), i, c.Err())
+			if jf != nil {
+				p.journal.done(jf)
+			}
+		}
+	}
+	if !p.ack {
+		return
+	}
+	for i, am := range sent {
+		if am == nil {
+			continue
+		}
+		select {
+		case err := <-am.done:
+			if err != nil {
+				errs = setErr(errs, len(msgs), i, err)
+			}
+		case <-c.Done():
+			// still produced or spooled
+			errs = setErr(errs, len(msgs), i, c.Err())
+		}
+	}
+	return
+}
+
+func (p *kafkaAsyncPublisher) successes() {
+	defer p.drainWg.Done()
+	for pm := range p.producer.Successes() {
+		am := pm.Metadata.(*asyncMsg)
+		p.hooks.published(am.m, time.Since(am.start))
+		if am.jf != nil {
+			p.journal.done(am.jf)
+		}
+		if am.done != nil {
+			am.done <- nil
+		}
+	}
+}
+
+// errors spools the messages failed, the errors at hand are spooled with
+// one sync.
+func (p *kafkaAsyncPublisher) errors() {
+	defer p.drainWg.Done()
+	var (
+		errs  = p.producer.Errors()
+		batch = make([]*sarama.ProducerError, 0, _spoolBatch)
+	)
+	for perr := range errs {
+		batch = append(batch[:0], perr)
+	more:
+		for len(batch) < _spoolBatch {
+			select {
+			case perr, ok := <-errs:
+				if !ok {
+					break more
+				}
+				batch = append(batch, perr)
+			default:
+				break more
+			}
+		}
+		p.failed(batch)
+	}
+}
+
+func (p *kafkaAsyncPublisher) failed(batch []*sarama.ProducerError) {
+	var serr error
+	if p.spool != nil {
+		msgs := make([]*Message, len(batch))
+		for i, perr := range batch {
+			msgs[i] = perr.Msg.Metadata.(*asyncMsg).m
+		}
+		serr = p.spool.add(msgs...)
+	}
+	spooled := p.spool != nil && serr == nil
+	for _, perr := range batch {
+		am := perr.Msg.Metadata.(*asyncMsg)
+		err := perr.Err
+		if serr != nil {
+			err = fmt.Errorf("%v, spool error(%v)", err, serr)
+		}
+		p.hooks.failed(am.m, err, spooled)
+		if am.jf != nil {
+			p.journal.done(am.jf)
+		}
+		if am.done == nil {
+			continue
+		}
+		if spooled {
+			am.done <- nil
+		} else {
+			am.done <- err
+		}
+	}
+}
+
+// replayproc republishes the messages of the spool every interval, the
+// first time at start for the messages spooled before a restart.
+func (p *kafkaAsyncPublisher) replayproc(interval time.Duration) {
+	defer p.replayWg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.spool.replay(func(m *Message) error {
+			select {
+			case p.producer.Input() <- producerMsg(&asyncMsg{m: m, start: time.Now()}):
+				return nil
+			case <-p.done:
+				return errClosed
+			}
+		})
+		select {
+		case <-ticker.C:
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// Close flushes the messages queued, the messages failed are spooled.
+func (p *kafkaAsyncPublisher) Close() error {
+	close(p.done)
+	p.replayWg.Wait()
+	p.producer.AsyncClose()
+	p.drainWg.Wait()
+	if p.journal != nil {
+		if err := p.journal.close(); err != nil {
+			return err
+		}
+	}
+	if p.spool != nil {
+		return p.spool.close()
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package bus
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	xtime "github.com/swanky2009/goim/pkg/time"
+	sarama "gopkg.in/Shopify/sarama.v1"
+)
+
+// testBroker a mock broker leading the partition 0 of the topic, the produce
+// requests are failed with the error.
+func testBroker(t *testing.T, topic string, kerr sarama.KError) *sarama.MockBroker {
+	b := sarama.NewMockBroker(t, 1)
+	setProduceError(t, b, topic, kerr)
+	return b
+}
+
+func setProduceError(t *testing.T, b *sarama.MockBroker, topic string, kerr sarama.KError) {
+	b.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(b.Addr(), b.BrokerID()).
+			SetLeader(topic, 0, b.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).SetError(topic, 0, kerr),
+	})
+}
+
+func testMessages(topic string, n int) []*Message {
+	msgs := make([]*Message, n)
+	for i := range msgs {
+		msgs[i] = &Message{Topic: topic, Key: "k", Value: []byte(strconv.Itoa(i))}
+	}
+	return msgs
+}
+
+func TestKafkaAsync(t *testing.T) {
+	topic := "test-async"
+	b := testBroker(t, topic, sarama.ErrNoError)
+	defer b.Close()
+	var published int32
+	p, err := NewPublisher(&Config{
+		Topic: topic,
+		Addrs: []string{b.Addr()},
+		Kafka: &Kafka{Async: true, Linger: xtime.Duration(10 * time.Millisecond), BatchMessages: 5, Compression: "gzip"},
+	}, &Hooks{
+		Published: func(m *Message, took time.Duration) { atomic.AddInt32(&published, 1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := p.Publish(context.Background(), testMessages(topic, 10)); errs != nil {
+		t.Fatal(errs)
+	}
+	if n := atomic.LoadInt32(&published); n != 10 {
+		t.Fatalf("published %d", n)
+	}
+	if err = p.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKafkaAsyncSpool(t *testing.T) {
+	topic := "test-spool"
+	dir, err := ioutil.TempDir("", "bus-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	b := testBroker(t, topic, sarama.ErrInvalidMessage)
+	defer b.Close()
+	var (
+		spooled   int32
+		published = make(chan *Message, 10)
+	)
+	p, err := NewPublisher(&Config{
+		Topic: topic,
+		Addrs: []string{b.Addr()},
+		Kafka: &Kafka{Async: true, Retries: 1, Spool: dir, SpoolInterval: xtime.Duration(50 * time.Millisecond)},
+	}, &Hooks{
+		Published: func(m *Message, took time.Duration) { published <- m },
+		Failed: func(m *Message, err error, ok bool) {
+			if ok {
+				atomic.AddInt32(&spooled, 1)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	// the messages spooled are durably queued
+	if errs := p.Publish(context.Background(), testMessages(topic, 3)); errs != nil {
+		t.Fatal(errs)
+	}
+	if n := atomic.LoadInt32(&spooled); n != 3 {
+		t.Fatalf("spooled %d", n)
+	}
+	setProduceError(t, b, topic, sarama.ErrNoError)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-published:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %d is not republished", i)
+		}
+	}
+}
+
+func TestSpoolReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bus-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := newSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range testMessages("t", 3) {
+		if err = s.add(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a message torn by a crash
+	f, err := os.OpenFile(s.name, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 1, 0, 0})
+	f.Close()
+	var msgs []*Message
+	n, err := s.replay(func(m *Message) error {
+		msgs = append(msgs, m)
+		return nil
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("replayed %d error(%v)", n, err)
+	}
+	for i, m := range msgs {
+		if m.Topic != "t" || m.Key != "k" || string(m.Value) != strconv.Itoa(i) {
+			t.Fatalf("message %d is %+v", i, m)
+		}
+	}
+	if names, _ := filepath.Glob(filepath.Join(dir, "*")); len(names) != 0 {
+		t.Fatalf("files %v are left", names)
+	}
+}
+
+func TestKafkaConfig(t *testing.T) {
+	for _, k := range []*Kafka{{Acks: "some"}, {Compression: "zip"}, {Wait: "flushed"}} {
+		k.fix()
+		if _, err := kafkaConfig(k); err == nil {
+			t.Fatalf("kafka config %+v", k)
+		}
+	}
+}
+
+func TestKafkaAsyncJournal(t *testing.T) {
+	topic := "test-journal"
+	dir, err := ioutil.TempDir("", "bus-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the messages of a publisher crashed before the acks
+	j, err := newJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = j.add(testMessages(topic, 3)); err != nil {
+		t.Fatal(err)
+	}
+	b := testBroker(t, topic, sarama.ErrNoError)
+	defer b.Close()
+	published := make(chan *Message, 20)
+	c := &Config{
+		Topic: topic,
+		Addrs: []string{b.Addr()},
+		Kafka: &Kafka{Async: true, Wait: KafkaWaitQueued, SpoolInterval: xtime.Duration(50 * time.Millisecond)},
+	}
+	if _, err = NewPublisher(c, nil); err == nil {
+		t.Fatal("queued without a spool")
+	}
+	c.Kafka.Spool = dir
+	p, err := NewPublisher(c, &Hooks{
+		Published: func(m *Message, took time.Duration) { published <- m },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-published:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %d journaled is not republished", i)
+		}
+	}
+	if errs := p.Publish(context.Background(), testMessages(topic, 10)); errs != nil {
+		t.Fatal(errs)
+	}
+	if err = p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 10 {
+		t.Fatalf("published %d", len(published))
+	}
+	// the journal is removed once acked
+	if names, _ := filepath.Glob(filepath.Join(dir, _journalDir, "*")); len(names) != 0 {
+		t.Fatalf("files %v are left", names)
+	}
+}
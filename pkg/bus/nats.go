@@ -0,0 +1,156 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	_natsDialTimeout  = 2 * time.Second
+	_natsFlushTimeout = 5 * time.Second
+)
+
+// connectNATS connects to the nats servers, reconnecting for ever once
+// connected. The errors of the connection are sent to errs if not nil.
+func connectNATS(addrs []string, errs chan error) (*nats.Conn, error) {
+	opts := []nats.Option{
+		nats.Name("goim"),
+		nats.Timeout(_natsDialTimeout),
+		nats.MaxReconnects(-1),
+	}
+	if errs != nil {
+		opts = append(opts,
+			nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+				if err != nil {
+					sendErr(errs, fmt.Errorf("bus: nats disconnected error(%v)", err))
+				}
+			}),
+			nats.ErrorHandler(func(_ *nats.Conn, sub *nats.Subscription, err error) {
+				sendErr(errs, fmt.Errorf("bus: nats subscribe %s error(%v)", sub.Subject, err))
+			}),
+		)
+	}
+	return nats.Connect(strings.Join(addrs, ","), opts...)
+}
+
+type natsPublisher struct {
+	nc *nats.Conn
+}
+
+// NewNATSPublisher new a publisher to the nats subject of the topic, the key
+// of a message is not sent. A publish is confirmed by a flush, the messages
+// published while reconnecting are buffered by the client.
+func NewNATSPublisher(c *Config) (Publisher, error) {
+	nc, err := connectNATS(c.Addrs, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &natsPublisher{nc: nc}, nil
+}
+
+func (p *natsPublisher) Publish(c context.Context, msgs []*Message) (errs []error) {
+	for _, m := range msgs {
+		if err := p.nc.Publish(m.Topic, m.Value); err != nil {
+			return errsOf(len(msgs), err)
+		}
+	}
+	if err := p.flush(c); err != nil {
+		return errsOf(len(msgs), err)
+	}
+	return
+}
+
+// flush waits for the server to process the messages published, within the
+// deadline of the context or the flush timeout.
+func (p *natsPublisher) flush(c context.Context) error {
+	if _, ok := c.Deadline(); ok {
+		return p.nc.FlushWithContext(c)
+	}
+	return p.nc.FlushTimeout(_natsFlushTimeout)
+}
+
+func (p *natsPublisher) Close() error {
+	p.nc.Close()
+	return nil
+}
+
+type natsSubscriber struct {
+	nc     *nats.Conn
+	sub    *nats.Subscription
+	msgs   chan *Message
+	errs   chan error
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewNATSSubscriber new a subscriber of the nats subject of the topic in the
+// queue group, the client resubscribes after it reconnects. The messages
+// published while no subscriber is connected are lost.
+func NewNATSSubscriber(c *Config) (Subscriber, error) {
+	s := &natsSubscriber{
+		msgs: make(chan *Message),
+		errs: make(chan error, 16),
+		done: make(chan struct{}),
+	}
+	nc, err := connectNATS(c.Addrs, s.errs)
+	if err != nil {
+		return nil, err
+	}
+	if c.Group != "" {
+		s.sub, err = nc.QueueSubscribeSync(c.Topic, c.Group)
+	} else {
+		s.sub, err = nc.SubscribeSync(c.Topic)
+	}
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	s.nc = nc
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.consume()
+	return s, nil
+}
+
+func (s *natsSubscriber) consume() {
+	defer close(s.done)
+	defer close(s.msgs)
+	for {
+		m, err := s.sub.NextMsgWithContext(s.ctx)
+		if s.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			sendErr(s.errs, fmt.Errorf("bus: nats subscribe %s error(%v)", s.sub.Subject, err))
+			if err == nats.ErrConnectionClosed || err == nats.ErrBadSubscription {
+				return
+			}
+			// the messages beyond the pending limits of a slow consumer are dropped
+			continue
+		}
+		select {
+		case s.msgs <- &Message{Topic: m.Subject, Value: m.Data}:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *natsSubscriber) Messages() <-chan *Message {
+	return s.msgs
+}
+
+func (s *natsSubscriber) Errors() <-chan error {
+	return s.errs
+}
+
+func (s *natsSubscriber) Close() error {
+	s.cancel()
+	<-s.done
+	s.nc.Close()
+	return nil
+}
@@ -0,0 +1,196 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	_redisFieldKey   = "key"
+	_redisFieldValue = "value"
+	_redisReadCount  = 100
+)
+
+func newRedisClient(c *Config) redis.UniversalClient {
+	return redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:    c.Addrs,
+		Password: c.Password,
+		DB:       c.DB,
+	})
+}
+
+type redisPublisher struct {
+	client redis.UniversalClient
+	maxLen int64
+}
+
+// NewRedisPublisher new a publisher adding to a redis stream per topic, the
+// streams are trimmed to about max len messages.
+func NewRedisPublisher(c *Config) (Publisher, error) {
+	client := newRedisClient(c)
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &redisPublisher{client: client, maxLen: c.MaxLen}, nil
+}
+
+func (p *redisPublisher) Publish(c context.Context, msgs []*Message) (errs []error) {
+	cmds, err := p.client.Pipelined(func(pipe redis.Pipeliner) error {
+		for _, m := range msgs {
+			pipe.XAddExt(&redis.XAddExt{
+				Stream:       m.Topic,
+				MaxLenApprox: p.maxLen,
+				ID:           "*",
+				Values:       map[string]interface{}{_redisFieldKey: m.Key, _redisFieldValue: m.Value},
+			})
+		}
+		return nil
+	})
+	if err == nil {
+		return
+	}
+	if len(cmds) != len(msgs) {
+		return errsOf(len(msgs), err)
+	}
+	errs = make([]error, len(msgs))
+	for i, cmd := range cmds {
+		errs[i] = cmd.Err()
+	}
+	return
+}
+
+func (p *redisPublisher) Close() error {
+	return p.client.Close()
+}
+
+type redisSubscriber struct {
+	client   redis.UniversalClient
+	topic    string
+	group    string
+	consumer string
+	block    time.Duration
+	msgs     chan *Message
+	errs     chan error
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewRedisSubscriber new a subscriber of the redis stream in the consumer
+// group, the consumer is named by the host so the messages pending on a
+// restarted subscriber are consumed first.
+func NewRedisSubscriber(c *Config) (Subscriber, error) {
+	client := newRedisClient(c)
+	err := client.Process(redis.NewStatusCmd("XGROUP", "CREATE", c.Topic, c.Group, "0", "MKSTREAM"))
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		client.Close()
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	s := &redisSubscriber{
+		client:   client,
+		topic:    c.Topic,
+		group:    c.Group,
+		consumer: host,
+		block:    time.Duration(c.Block),
+		msgs:     make(chan *Message),
+		errs:     make(chan error, 16),
+		done:     make(chan struct{}),
+	}
+	go s.consume()
+	return s, nil
+}
+
+func (s *redisSubscriber) consume() {
+	defer close(s.msgs)
+	// the messages pending on the consumer first, then the new ones
+	id := "0"
+	for {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		cmd := redis.NewXStreamSliceCmd("XREADGROUP", "GROUP", s.group, s.consumer,
+			"COUNT", _redisReadCount, "BLOCK", int64(s.block/time.Millisecond), "STREAMS", s.topic, id)
+		err := s.client.Process(cmd)
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			sendErr(s.errs, fmt.Errorf("bus: redis XREADGROUP %s error(%v)", s.topic, err))
+			if id != ">" {
+				id = ">"
+				continue
+			}
+			select {
+			case <-time.After(time.Second):
+			case <-s.done:
+				return
+			}
+			continue
+		}
+		n := 0
+		for _, st := range cmd.Val() {
+			for _, xm := range st.Messages {
+				n++
+				if !s.deliver(xm) {
+					return
+				}
+			}
+		}
+		if n == 0 && id != ">" {
+			id = ">"
+		}
+	}
+}
+
+func (s *redisSubscriber) deliver(xm *redis.XMessage) bool {
+	key, _ := xm.Values[_redisFieldKey].(string)
+	value, _ := xm.Values[_redisFieldValue].(string)
+	entry := xm.ID
+	m := &Message{
+		Topic: s.topic,
+		Key:   key,
+		Value: []byte(value),
+		ID:    entry,
+		ack: func() {
+			if err := s.client.Process(redis.NewIntCmd("XACK", s.topic, s.group, entry)); err != nil {
+				sendErr(s.errs, fmt.Errorf("bus: redis XACK %s %s error(%v)", s.topic, entry, err))
+			}
+		},
+	}
+	select {
+	case s.msgs <- m:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *redisSubscriber) Messages() <-chan *Message {
+	return s.msgs
+}
+
+func (s *redisSubscriber) Errors() <-chan error {
+	return s.errs
+}
+
+func (s *redisSubscriber) Close() (err error) {
+	s.once.Do(func() {
+		close(s.done)
+		err = s.client.Close()
+	})
+	return
+}
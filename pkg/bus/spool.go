@@ -0,0 +1,255 @@
+package bus
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	_spoolExt        = ".spool"
+	_spoolHeaderSize = 10 // topic uint16, key uint32, value uint32
+	// _journalDir the subdirectory of the spool the queued messages are
+	// journaled in, and _journalMessages the messages a file is rotated at.
+	_journalDir      = "journal"
+	_journalMessages = 10000
+)
+
+// spool keeps messages in the files of a directory, appended to one file at
+// a time. A replay republishes the files not appended to, so a message may be
+// republished twice if a replay fails in the middle of a file.
+type spool struct {
+	dir  string
+	mu   sync.Mutex
+	f    *os.File
+	name string
+}
+
+func newSpool(dir string) (*spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &spool{dir: dir}, nil
+}
+
+// add appends the messages and syncs the file once.
+func (s *spool) add(msgs ...*Message) (err error) {
+	b, err := encodeMessages(msgs)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		name := filepath.Join(s.dir, fmt.Sprintf("%d%s", time.Now().UnixNano(), _spoolExt))
+		if s.f, err = os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err != nil {
+			return
+		}
+		s.name = name
+	}
+	if _, err = s.f.Write(b); err != nil {
+		return
+	}
+	return s.f.Sync()
+}
+
+// encodeMessages returns the messages in the format of the spool.
+func encodeMessages(msgs []*Message) ([]byte, error) {
+	size := 0
+	for _, m := range msgs {
+		if len(m.Topic) > 0xffff {
+			return nil, fmt.Errorf("bus: spool topic of %d bytes", len(m.Topic))
+		}
+		size += _spoolHeaderSize + len(m.Topic) + len(m.Key) + len(m.Value)
+	}
+	b := make([]byte, 0, size)
+	for _, m := range msgs {
+		var header [_spoolHeaderSize]byte
+		binary.BigEndian.PutUint16(header[0:], uint16(len(m.Topic)))
+		binary.BigEndian.PutUint32(header[2:], uint32(len(m.Key)))
+		binary.BigEndian.PutUint32(header[6:], uint32(len(m.Value)))
+		b = append(b, header[:]...)
+		b = append(b, m.Topic...)
+		b = append(b, m.Key...)
+		b = append(b, m.Value...)
+	}
+	return b, nil
+}
+
+// rotate closes the file appended to, the next add appends to a new file.
+func (s *spool) rotate() (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f != nil {
+		err = s.f.Close()
+		s.f = nil
+		s.name = ""
+	}
+	return
+}
+
+// replay republishes the messages of the files spooled before, a file is
+// removed once its messages are republished.
+func (s *spool) replay(publish func(m *Message) error) (n int, err error) {
+	if err = s.rotate(); err != nil {
+		return
+	}
+	names, err := filepath.Glob(filepath.Join(s.dir, "*"+_spoolExt))
+	if err != nil {
+		return
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.mu.Lock()
+		appending := name == s.name
+		s.mu.Unlock()
+		if appending {
+			continue
+		}
+		var c int
+		c, err = replayFile(name, publish)
+		n += c
+		if err != nil {
+			return
+		}
+		if err = os.Remove(name); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// replayFile publishes the messages of the file, a message torn by a crash
+// while it was appended ends the file.
+func replayFile(name string, publish func(m *Message) error) (n int, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	header := make([]byte, _spoolHeaderSize)
+	for {
+		if _, err = io.ReadFull(r, header); err != nil {
+			break
+		}
+		tl := int(binary.BigEndian.Uint16(header[0:]))
+		kl := int(binary.BigEndian.Uint32(header[2:]))
+		vl := int(binary.BigEndian.Uint32(header[6:]))
+		b := make([]byte, tl+kl+vl)
+		if _, err = io.ReadFull(r, b); err != nil {
+			break
+		}
+		m := &Message{Topic: string(b[:tl]), Key: string(b[tl : tl+kl]), Value: b[tl+kl:]}
+		if err = publish(m); err != nil {
+			return
+		}
+		n++
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return
+}
+
+func (s *spool) close() error {
+	return s.rotate()
+}
+
+// journal keeps the messages of the queued publishes until they are acked
+// or spooled, a file is removed once all its messages are. The files left
+// by a crash are moved to the spool at start, so their messages are
+// republished, twice for the ones acked before the crash.
+type journal struct {
+	dir string
+	mu  sync.Mutex
+	cur *journalFile
+}
+
+// journalFile a file of the journal and its messages not done yet.
+type journalFile struct {
+	name    string
+	f       *os.File
+	n       int
+	pending int
+}
+
+// newJournal new a journal in the directory of the spool.
+func newJournal(spoolDir string) (*journal, error) {
+	dir := filepath.Join(spoolDir, _journalDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*"+_spoolExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if err = os.Rename(name, filepath.Join(spoolDir, filepath.Base(name))); err != nil {
+			return nil, err
+		}
+	}
+	return &journal{dir: dir}, nil
+}
+
+// add appends the messages and syncs the file once, every message is done
+// with the file returned.
+func (j *journal) add(msgs []*Message) (jf *journalFile, err error) {
+	b, err := encodeMessages(msgs)
+	if err != nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.cur == nil {
+		name := filepath.Join(j.dir, fmt.Sprintf("%d%s", time.Now().UnixNano(), _spoolExt))
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		j.cur = &journalFile{name: name, f: f}
+	}
+	jf = j.cur
+	if _, err = jf.f.Write(b); err != nil {
+		return nil, err
+	}
+	if err = jf.f.Sync(); err != nil {
+		return nil, err
+	}
+	jf.n += len(msgs)
+	jf.pending += len(msgs)
+	if jf.n >= _journalMessages {
+		j.cur = nil
+		jf.f.Close()
+	}
+	return
+}
+
+// done marks a message of the file acked or spooled, a file rotated is
+// removed once all its messages are done.
+func (j *journal) done(jf *journalFile) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if jf.pending--; jf.pending <= 0 && jf != j.cur {
+		os.Remove(jf.name)
+	}
+}
+
+// close closes the file appended to, removed if its messages are done.
+func (j *journal) close() (err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if jf := j.cur; jf != nil {
+		j.cur = nil
+		if err = jf.f.Close(); err == nil && jf.pending <= 0 {
+			err = os.Remove(jf.name)
+		}
+	}
+	return
+}
@@ -0,0 +1,592 @@
+// Package client is a goim client speaking the comet TCP and WebSocket protocol,
+// it authenticates, keeps the connection alive with heartbeats and reconnects
+// with backoff until closed.
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	grpc "github.com/swanky2009/goim/grpc/comet"
+	"github.com/swanky2009/goim/pkg/bufio"
+	"github.com/swanky2009/goim/pkg/encoding/binary"
+)
+
+const (
+	// ProtoTCP comet tcp protocol.
+	ProtoTCP = "tcp"
+	// ProtoWebsocket comet websocket protocol.
+	ProtoWebsocket = "websocket"
+
+	_protoVer = 1
+)
+
+var (
+	// ErrClosed the client is closed.
+	ErrClosed = errors.New("client closed")
+	// ErrDisconnected the connection is lost before the reply.
+	ErrDisconnected = errors.New("client disconnected")
+	// ErrTimeout no reply in time.
+	ErrTimeout = errors.New("client reply timeout")
+	// ErrProto unknown protocol.
+	ErrProto = errors.New("client unknown protocol")
+)
+
+// Config client config.
+type Config struct {
+	// Proto tcp or websocket, default tcp.
+	Proto string
+	// Addr comet host:port.
+	Addr string
+	// URI websocket request uri, default /sub.
+	URI string
+	// Header websocket handshake header, eg. Cookie.
+	Header http.Header
+	// Token auth body sent with OpAuth.
+	Token []byte
+	// Heartbeat heartbeat interval, default 30s.
+	Heartbeat time.Duration
+	// Timeout dial, auth and reply timeout, default 5s.
+	Timeout time.Duration
+	// MinBackoff and MaxBackoff bound the reconnect delay, default 1s and 1m.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func (c *Config) fix() {
+	if c.Proto == "" {
+		c.Proto = ProtoTCP
+	}
+	if c.URI == "" {
+		c.URI = "/sub"
+	}
+	if c.Heartbeat <= 0 {
+		c.Heartbeat = 30 * time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 5 * time.Second
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = time.Second
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		c.MaxBackoff = time.Minute
+		if c.MaxBackoff < c.MinBackoff {
+			c.MaxBackoff = c.MinBackoff
+		}
+	}
+}
+
+// Handler handles the events of a client.
+// Handlers are called from the read goroutine, they must not block.
+type Handler interface {
+	// OnConnect called after every successful auth, including reconnects.
+	OnConnect()
+	// OnPush called for every proto pushed by the server, the body is owned by the handler.
+	// Room messages carry the room seq in Seq, the ones missed while disconnected
+	// are fetched and pushed after reconnecting.
+	OnPush(p *grpc.Proto)
+	// OnOnline called with the room online of every heartbeat reply.
+	OnOnline(online int32)
+	// OnDisconnect called when the connection is lost, the client reconnects unless closed.
+	OnDisconnect(err error)
+}
+
+// NopHandler ignores all events, embed it to implement only some handlers.
+type NopHandler struct{}
+
+// OnConnect .
+func (NopHandler) OnConnect() {}
+
+// OnPush .
+func (NopHandler) OnPush(p *grpc.Proto) {}
+
+// OnOnline .
+func (NopHandler) OnOnline(online int32) {}
+
+// OnDisconnect .
+func (NopHandler) OnDisconnect(err error) {}
+
+// Client a comet client.
+type Client struct {
+	c *Config
+	h Handler
+
+	mu      sync.Mutex
+	conn    conn
+	seq     int64
+	waiters map[int64]*waiter
+	room    string
+	roomSeq int64
+	ops     map[int32]struct{}
+	closed  bool
+	done    chan struct{}
+}
+
+// waiter waits for the reply of a request.
+type waiter struct {
+	op int32
+	ch chan *grpc.Proto
+}
+
+// Dial connects and authenticates with the comet server, then serves the
+// connection in background until Close.
+func Dial(c *Config, h Handler) (cli *Client, err error) {
+	c.fix()
+	if c.Proto != ProtoTCP && c.Proto != ProtoWebsocket {
+		return nil, ErrProto
+	}
+	if h == nil {
+		h = NopHandler{}
+	}
+	cli = &Client{
+		c:       c,
+		h:       h,
+		waiters: make(map[int64]*waiter),
+		ops:     make(map[int32]struct{}),
+		done:    make(chan struct{}),
+	}
+	var cn conn
+	if cn, err = cli.connect(); err != nil {
+		return nil, err
+	}
+	go cli.serve(cn)
+	return
+}
+
+// connect dials and authenticates a new connection.
+func (c *Client) connect() (cn conn, err error) {
+	switch c.c.Proto {
+	case ProtoWebsocket:
+		cn, err = dialWebsocket(c.c.Addr, c.c.URI, c.c.Header, c.c.Timeout)
+	default:
+		cn, err = dialTCP(c.c.Addr, c.c.Timeout)
+	}
+	if err != nil {
+		return
+	}
+	p := &grpc.Proto{Ver: _protoVer, Op: grpc.OpAuth, Body: c.c.Token}
+	cn.SetReadDeadline(time.Now().Add(c.c.Timeout))
+	if err = cn.WriteProto(p); err != nil {
+		cn.Close()
+		return
+	}
+	for {
+		if err = cn.ReadProto(p); err != nil {
+			cn.Close()
+			return
+		}
+		if p.Op == grpc.OpAuthReply {
+			break
+		}
+	}
+	cn.SetReadDeadline(time.Now().Add(c.c.Heartbeat + c.c.Timeout))
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		cn.Close()
+		return nil, ErrClosed
+	}
+	c.conn = cn
+	c.mu.Unlock()
+	return
+}
+
+// serve reads the connection, and reconnects with backoff when it is lost.
+func (c *Client) serve(cn conn) {
+	var (
+		err     error
+		backoff time.Duration
+	)
+	for {
+		c.resume(cn)
+		c.h.OnConnect()
+		hbDone := make(chan struct{})
+		go c.heartbeat(cn, hbDone)
+		err = c.read(cn)
+		close(hbDone)
+		cn.Close()
+		c.mu.Lock()
+		closed := c.closed
+		c.conn = nil
+		for seq, w := range c.waiters {
+			close(w.ch)
+			delete(c.waiters, seq)
+		}
+		c.mu.Unlock()
+		if closed {
+			return
+		}
+		c.h.OnDisconnect(err)
+		for backoff = c.c.MinBackoff; ; {
+			select {
+			case <-c.done:
+				return
+			case <-time.After(backoff):
+			}
+			if cn, err = c.connect(); err == nil {
+				break
+			}
+			if err == ErrClosed {
+				return
+			}
+			if backoff *= 2; backoff > c.c.MaxBackoff {
+				backoff = c.c.MaxBackoff
+			}
+		}
+	}
+}
+
+// resume restores the room and operations of a reconnected session,
+// and fetches the room messages missed while disconnected.
+func (c *Client) resume(cn conn) {
+	c.mu.Lock()
+	room, roomSeq := c.room, c.roomSeq
+	ops := make([]int32, 0, len(c.ops))
+	for op := range c.ops {
+		ops = append(ops, op)
+	}
+	c.mu.Unlock()
+	// replies are read by read() and discarded since no one waits for them
+	if room != "" {
+		c.write(cn, &grpc.Proto{Ver: _protoVer, Op: grpc.OpChangeRoom, Body: []byte(room)})
+		if roomSeq > 0 {
+			c.write(cn, historyProto(roomSeq))
+		}
+	}
+	if len(ops) > 0 {
+		c.write(cn, &grpc.Proto{Ver: _protoVer, Op: grpc.OpRegister, Body: []byte(joinOps(ops))})
+	}
+}
+
+func (c *Client) heartbeat(cn conn, done chan struct{}) {
+	ticker := time.NewTicker(c.c.Heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := c.write(cn, &grpc.Proto{Ver: _protoVer, Op: grpc.OpHeartbeat}); err != nil {
+				cn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) read(cn conn) (err error) {
+	p := new(grpc.Proto)
+	for {
+		if err = cn.ReadProto(p); err != nil {
+			return
+		}
+		cn.SetReadDeadline(time.Now().Add(c.c.Heartbeat + c.c.Timeout))
+		switch p.Op {
+		case grpc.OpHeartbeatReply:
+			if len(p.Body) >= 4 {
+				c.h.OnOnline(binary.BigEndian.Int32(p.Body))
+			}
+		case grpc.OpRaw:
+			if err = c.readRaw(p.Body); err != nil {
+				return
+			}
+		case grpc.OpRoomHistoryReply:
+			if c.reply(p) {
+				break
+			}
+			// the history fetched by resume, ask for more until caught up
+			var n int
+			if n, err = c.readHistory(p.Body); err != nil {
+				return
+			}
+			if n > 0 {
+				c.mu.Lock()
+				roomSeq := c.roomSeq
+				c.mu.Unlock()
+				c.write(cn, historyProto(roomSeq))
+			}
+		default:
+			c.dispatch(p)
+		}
+	}
+}
+
+// readRaw splits the protos merged into a raw message.
+func (c *Client) readRaw(body []byte) (err error) {
+	var ps []*grpc.Proto
+	if ps, err = splitProtos(body); err != nil {
+		return
+	}
+	for _, p := range ps {
+		c.dispatch(p)
+	}
+	return
+}
+
+// readHistory pushes the history messages newer than the last room seq
+// to the handler, and returns the number of messages.
+func (c *Client) readHistory(body []byte) (n int, err error) {
+	var ps []*grpc.Proto
+	if ps, err = splitProtos(body); err != nil {
+		return
+	}
+	for _, p := range ps {
+		if c.advance(p.Seq) {
+			c.h.OnPush(p)
+		}
+	}
+	return len(ps), nil
+}
+
+// dispatch delivers a reply to its waiter, or a push to the handler.
+func (c *Client) dispatch(p *grpc.Proto) {
+	if c.reply(p) {
+		return
+	}
+	switch p.Op {
+	case grpc.OpSendMsgReply, grpc.OpChangeRoomReply, grpc.OpRegisterReply, grpc.OpUnregisterReply,
+		grpc.OpDeliveredReply, grpc.OpReadReply:
+		// reply of a resumed session or a timed out request
+	default:
+		// room messages already pushed from the history are dropped
+		if c.advance(p.Seq) {
+			c.h.OnPush(copyProto(p))
+		}
+	}
+}
+
+// reply delivers the proto to the waiter of the same seq and reply operation.
+func (c *Client) reply(p *grpc.Proto) bool {
+	if p.Seq == 0 {
+		return false
+	}
+	c.mu.Lock()
+	w, ok := c.waiters[p.Seq]
+	if ok = ok && w.op == p.Op; ok {
+		delete(c.waiters, p.Seq)
+	}
+	c.mu.Unlock()
+	if ok {
+		w.ch <- copyProto(p)
+	}
+	return ok
+}
+
+// advance records the seq of a room message, it returns false if the message
+// is not newer than the last one. Protos without seq are not room messages.
+func (c *Client) advance(seq int64) bool {
+	if seq <= 0 {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if seq <= c.roomSeq {
+		return false
+	}
+	c.roomSeq = seq
+	return true
+}
+
+func (c *Client) write(cn conn, p *grpc.Proto) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return cn.WriteProto(p)
+}
+
+// call sends a proto and waits for the reply operation with the same seq.
+func (c *Client) call(op, replyOp int32, body []byte) (reply *grpc.Proto, err error) {
+	ch := make(chan *grpc.Proto, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if c.conn == nil {
+		c.mu.Unlock()
+		return nil, ErrDisconnected
+	}
+	// seq 0 is left for server pushes
+	if c.seq++; c.seq <= 0 {
+		c.seq = 1
+	}
+	seq := c.seq
+	c.waiters[seq] = &waiter{op: replyOp, ch: ch}
+	err = c.conn.WriteProto(&grpc.Proto{Ver: _protoVer, Op: op, Seq: seq, Body: body})
+	c.mu.Unlock()
+	if err != nil {
+		c.cancel(seq)
+		return
+	}
+	select {
+	case reply = <-ch:
+		if reply == nil {
+			err = ErrDisconnected
+		}
+	case <-time.After(c.c.Timeout):
+		c.cancel(seq)
+		err = ErrTimeout
+	}
+	return
+}
+
+func (c *Client) cancel(seq int64) {
+	c.mu.Lock()
+	delete(c.waiters, seq)
+	c.mu.Unlock()
+}
+
+// SendMsg sends a message to logic and waits for the reply.
+func (c *Client) SendMsg(body []byte) (err error) {
+	_, err = c.call(grpc.OpSendMsg, grpc.OpSendMsgReply, body)
+	return
+}
+
+// Send sends a business operation and waits for the reply.
+func (c *Client) Send(op int32, body []byte) (reply *grpc.Proto, err error) {
+	if op < grpc.MinBusinessOp || op > grpc.MaxBusinessOp {
+		return nil, fmt.Errorf("client: operation %d out of business range [%d,%d]", op, grpc.MinBusinessOp, grpc.MaxBusinessOp)
+	}
+	return c.call(op, op, body)
+}
+
+// Delivered sends the delivered receipt of a message, the receipts are
+// pushed to the sender with OpReceipt.
+func (c *Client) Delivered(msgID string) (err error) {
+	_, err = c.call(grpc.OpDelivered, grpc.OpDeliveredReply, []byte(msgID))
+	return
+}
+
+// Read sends the read receipt of a message.
+func (c *Client) Read(msgID string) (err error) {
+	_, err = c.call(grpc.OpRead, grpc.OpReadReply, []byte(msgID))
+	return
+}
+
+// ChangeRoom moves the connection into the room, it is kept across reconnects.
+func (c *Client) ChangeRoom(room string) (err error) {
+	if _, err = c.call(grpc.OpChangeRoom, grpc.OpChangeRoomReply, []byte(room)); err != nil {
+		return
+	}
+	c.mu.Lock()
+	if c.room != room {
+		c.room = room
+		c.roomSeq = 0
+	}
+	c.mu.Unlock()
+	return
+}
+
+// RoomHistory gets the messages of the current room after the seq, a reply
+// carries as many as fit in a proto body, ask again from the last seq for more.
+func (c *Client) RoomHistory(since int64) (ps []*grpc.Proto, err error) {
+	var reply *grpc.Proto
+	if reply, err = c.call(grpc.OpRoomHistory, grpc.OpRoomHistoryReply, []byte(strconv.FormatInt(since, 10))); err != nil {
+		return
+	}
+	if ps, err = splitProtos(reply.Body); err != nil {
+		return
+	}
+	for _, p := range ps {
+		c.advance(p.Seq)
+	}
+	return
+}
+
+// RoomSeq returns the seq of the last room message received.
+func (c *Client) RoomSeq() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.roomSeq
+}
+
+// Register watches the operations, they are kept across reconnects.
+func (c *Client) Register(ops ...int32) (err error) {
+	if _, err = c.call(grpc.OpRegister, grpc.OpRegisterReply, []byte(joinOps(ops))); err != nil {
+		return
+	}
+	c.mu.Lock()
+	for _, op := range ops {
+		c.ops[op] = struct{}{}
+	}
+	c.mu.Unlock()
+	return
+}
+
+// Unregister unwatches the operations.
+func (c *Client) Unregister(ops ...int32) (err error) {
+	if _, err = c.call(grpc.OpUnregister, grpc.OpUnregisterReply, []byte(joinOps(ops))); err != nil {
+		return
+	}
+	c.mu.Lock()
+	for _, op := range ops {
+		delete(c.ops, op)
+	}
+	c.mu.Unlock()
+	return
+}
+
+// Close closes the connection and stops reconnecting.
+func (c *Client) Close() (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.done)
+	if c.conn != nil {
+		err = c.conn.Close()
+	}
+	return
+}
+
+func joinOps(ops []int32) string {
+	sort.Slice(ops, func(i, j int) bool { return ops[i] < ops[j] })
+	strs := make([]string, 0, len(ops))
+	for _, op := range ops {
+		strs = append(strs, fmt.Sprint(op))
+	}
+	return strings.Join(strs, ",")
+}
+
+func historyProto(since int64) *grpc.Proto {
+	return &grpc.Proto{Ver: _protoVer, Op: grpc.OpRoomHistory, Body: []byte(strconv.FormatInt(since, 10))}
+}
+
+// splitProtos splits the protos written into a body.
+func splitProtos(body []byte) (ps []*grpc.Proto, err error) {
+	var (
+		p  = new(grpc.Proto)
+		rd = bufio.NewReaderSize(bytes.NewReader(body), len(body))
+	)
+	if len(body) == 0 {
+		return
+	}
+	for {
+		if err = p.ReadTCP(rd); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		ps = append(ps, copyProto(p))
+	}
+}
+
+func copyProto(p *grpc.Proto) *grpc.Proto {
+	cp := &grpc.Proto{Ver: p.Ver, Op: p.Op, Seq: p.Seq}
+	if len(p.Body) > 0 {
+		cp.Body = make([]byte, len(p.Body))
+		copy(cp.Body, p.Body)
+	}
+	return cp
+}
@@ -0,0 +1,253 @@
+package client
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	grpc "github.com/swanky2009/goim/grpc/comet"
+	"github.com/swanky2009/goim/pkg/bufio"
+	"github.com/swanky2009/goim/pkg/bytes"
+	"github.com/swanky2009/goim/pkg/websocket"
+)
+
+// testServer a comet speaking just enough protocol to exercise the client.
+type testServer struct {
+	lis   net.Listener
+	proto string
+	ops   chan *grpc.Proto
+	conns chan net.Conn
+
+	mu      sync.Mutex
+	history []*grpc.Proto
+}
+
+// addRoomMsg keeps a room message with the next seq.
+func (s *testServer) addRoomMsg(body string) *grpc.Proto {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := &grpc.Proto{Ver: 1, Op: 1000, Seq: int64(len(s.history) + 1), Body: []byte(body)}
+	s.history = append(s.history, p)
+	return p
+}
+
+func (s *testServer) roomHistory(since int64) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := bytes.NewWriterSize(64)
+	for _, p := range s.history {
+		if p.Seq > since {
+			p.WriteTo(w)
+		}
+	}
+	return w.Buffer()
+}
+
+func newTestServer(t *testing.T, proto string) *testServer {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{lis: lis, proto: proto, ops: make(chan *grpc.Proto, 100), conns: make(chan net.Conn, 10)}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			s.conns <- conn
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn) {
+	defer conn.Close()
+	var (
+		err   error
+		ws    *websocket.Conn
+		rd    = bufio.NewReader(conn)
+		wr    = bufio.NewWriter(conn)
+		read  = func(p *grpc.Proto) error { return p.ReadTCP(rd) }
+		write = func(p *grpc.Proto) error {
+			if p.Op == grpc.OpHeartbeatReply {
+				p.WriteTCPHeart(wr, 7)
+			} else {
+				p.WriteTCP(wr)
+			}
+			return wr.Flush()
+		}
+	)
+	if s.proto == ProtoWebsocket {
+		var req *websocket.Request
+		if req, err = websocket.ReadRequest(rd); err != nil || req.RequestURI != "/sub" {
+			return
+		}
+		if ws, err = websocket.Upgrade(conn, rd, wr, req); err != nil {
+			return
+		}
+		read = func(p *grpc.Proto) error { return p.ReadWebsocket(ws) }
+		write = func(p *grpc.Proto) error {
+			if p.Op == grpc.OpHeartbeatReply {
+				p.WriteWebsocketHeart(ws, 7)
+			} else {
+				p.WriteWebsocket(ws)
+			}
+			return ws.Flush()
+		}
+	}
+	for {
+		p := new(grpc.Proto)
+		if err = read(p); err != nil {
+			return
+		}
+		p.Body = append([]byte(nil), p.Body...)
+		s.ops <- p
+		reply := &grpc.Proto{Ver: p.Ver, Op: p.Op + 1, Seq: p.Seq}
+		if p.Op >= grpc.MinBusinessOp {
+			reply.Op = p.Op
+		}
+		if p.Op == grpc.OpRoomHistory {
+			since, _ := strconv.ParseInt(string(p.Body), 10, 64)
+			reply.Body = s.roomHistory(since)
+		}
+		if err = write(reply); err != nil {
+			return
+		}
+		if p.Op == grpc.OpSendMsg {
+			if err = write(s.addRoomMsg(string(p.Body))); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// expect the next op received by the server, heartbeats are skipped unless expected.
+func (s *testServer) expect(t *testing.T, op int32, body string) {
+	for {
+		select {
+		case p := <-s.ops:
+			if p.Op == grpc.OpHeartbeat && op != grpc.OpHeartbeat {
+				continue
+			}
+			if p.Op != op || string(p.Body) != body {
+				t.Fatalf("server got op:%d body:%s want op:%d body:%s", p.Op, p.Body, op, body)
+			}
+			return
+		case <-time.After(time.Second * 3):
+			t.Fatalf("server op %d timeout", op)
+		}
+	}
+}
+
+type testHandler struct {
+	NopHandler
+	connects chan struct{}
+	pushes   chan *grpc.Proto
+	onlines  chan int32
+}
+
+func (h *testHandler) OnConnect()           { h.connects <- struct{}{} }
+func (h *testHandler) OnPush(p *grpc.Proto) { h.pushes <- p }
+func (h *testHandler) OnOnline(online int32) {
+	select {
+	case h.onlines <- online:
+	default:
+	}
+}
+
+func expectPush(t *testing.T, h *testHandler, seq int64, body string) {
+	select {
+	case p := <-h.pushes:
+		if p.Op != 1000 || p.Seq != seq || string(p.Body) != body {
+			t.Fatalf("push %v want seq:%d body:%s", p, seq, body)
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatal("push timeout")
+	}
+}
+
+func testClient(t *testing.T, proto string) {
+	s := newTestServer(t, proto)
+	defer s.lis.Close()
+	h := &testHandler{connects: make(chan struct{}, 10), pushes: make(chan *grpc.Proto, 10), onlines: make(chan int32, 10)}
+	c, err := Dial(&Config{
+		Proto:      proto,
+		Addr:       s.lis.Addr().String(),
+		Token:      []byte("1|key|live://1000|web|1000"),
+		Heartbeat:  time.Millisecond * 100,
+		Timeout:    time.Second,
+		MinBackoff: time.Millisecond * 10,
+	}, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	s.expect(t, grpc.OpAuth, "1|key|live://1000|web|1000")
+	<-h.connects
+
+	if err = c.ChangeRoom("live://1001"); err != nil {
+		t.Fatal(err)
+	}
+	s.expect(t, grpc.OpChangeRoom, "live://1001")
+	if err = c.Register(1002, 1001); err != nil {
+		t.Fatal(err)
+	}
+	s.expect(t, grpc.OpRegister, "1001,1002")
+	if err = c.SendMsg([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	s.expect(t, grpc.OpSendMsg, "hello")
+	expectPush(t, h, 1, "hello")
+	if seq := c.RoomSeq(); seq != 1 {
+		t.Fatalf("room seq got %d want 1", seq)
+	}
+	if err = c.Delivered("msg1"); err != nil {
+		t.Fatal(err)
+	}
+	s.expect(t, grpc.OpDelivered, "msg1")
+	if err = c.Read("msg1"); err != nil {
+		t.Fatal(err)
+	}
+	s.expect(t, grpc.OpRead, "msg1")
+	if _, err = c.Send(1, nil); err == nil {
+		t.Fatal("send non business op")
+	}
+	// heartbeat with room online
+	s.expect(t, grpc.OpHeartbeat, "")
+	if online := <-h.onlines; online != 7 {
+		t.Fatalf("online got %d want 7", online)
+	}
+	// reconnect resumes the room and operations, and pushes the missed room messages
+	(<-s.conns).Close()
+	s.addRoomMsg("missed1")
+	s.addRoomMsg("missed2")
+	s.expect(t, grpc.OpAuth, "1|key|live://1000|web|1000")
+	<-h.connects
+	s.expect(t, grpc.OpChangeRoom, "live://1001")
+	s.expect(t, grpc.OpRoomHistory, "1")
+	s.expect(t, grpc.OpRegister, "1001,1002")
+	expectPush(t, h, 2, "missed1")
+	expectPush(t, h, 3, "missed2")
+	s.expect(t, grpc.OpRoomHistory, "3")
+	// fetch the history explicitly
+	ps, err := c.RoomHistory(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.expect(t, grpc.OpRoomHistory, "1")
+	if len(ps) != 2 || ps[0].Seq != 2 || string(ps[1].Body) != "missed2" {
+		t.Fatalf("room history %v", ps)
+	}
+}
+
+func TestClientTCP(t *testing.T) {
+	testClient(t, ProtoTCP)
+}
+
+func TestClientWebsocket(t *testing.T) {
+	testClient(t, ProtoWebsocket)
+}
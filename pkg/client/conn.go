@@ -0,0 +1,100 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	grpc "github.com/swanky2009/goim/grpc/comet"
+	"github.com/swanky2009/goim/pkg/bufio"
+	"github.com/swanky2009/goim/pkg/websocket"
+)
+
+const (
+	_readBufSize  = 8192
+	_writeBufSize = 4096
+)
+
+// conn a framed connection to a comet server.
+type conn interface {
+	ReadProto(p *grpc.Proto) error
+	WriteProto(p *grpc.Proto) error
+	SetReadDeadline(t time.Time) error
+	Close() error
+}
+
+type tcpConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+	wr   *bufio.Writer
+}
+
+func dialTCP(addr string, timeout time.Duration) (conn, error) {
+	c, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &tcpConn{
+		conn: c,
+		rd:   bufio.NewReaderSize(c, _readBufSize),
+		wr:   bufio.NewWriterSize(c, _writeBufSize),
+	}, nil
+}
+
+func (c *tcpConn) ReadProto(p *grpc.Proto) error {
+	return p.ReadTCP(c.rd)
+}
+
+func (c *tcpConn) WriteProto(p *grpc.Proto) (err error) {
+	if err = p.WriteTCP(c.wr); err != nil {
+		return
+	}
+	return c.wr.Flush()
+}
+
+func (c *tcpConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *tcpConn) Close() error {
+	return c.conn.Close()
+}
+
+type wsConn struct {
+	conn net.Conn
+	ws   *websocket.Conn
+}
+
+func dialWebsocket(addr, uri string, header http.Header, timeout time.Duration) (conn, error) {
+	c, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c.SetDeadline(time.Now().Add(timeout))
+	ws, err := websocket.Dial(c, bufio.NewReaderSize(c, _readBufSize), bufio.NewWriterSize(c, _writeBufSize), addr, uri, header)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.SetDeadline(time.Time{})
+	return &wsConn{conn: c, ws: ws}, nil
+}
+
+func (c *wsConn) ReadProto(p *grpc.Proto) error {
+	return p.ReadWebsocket(c.ws)
+}
+
+func (c *wsConn) WriteProto(p *grpc.Proto) (err error) {
+	if err = p.WriteWebsocket(c.ws); err != nil {
+		return
+	}
+	return c.ws.Flush()
+}
+
+func (c *wsConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *wsConn) Close() error {
+	return c.ws.Close()
+}
@@ -3,9 +3,21 @@ package hash
 import (
 	"crypto/sha1"
 	"encoding/hex"
+	"hash/fnv"
 )
 
 func Sha1s(s string) string {
 	r := sha1.Sum([]byte(s))
 	return hex.EncodeToString(r[:])
 }
+
+// Index returns the index of s in [0, n), the same s always gets the same
+// index, so the work of a key is done by one of n workers in order.
+func Index(s string, n int) int {
+	if n <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return int(h.Sum32() % uint32(n))
+}
@@ -2,6 +2,7 @@ package strings
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -92,3 +93,44 @@ func SplitInt64s(s, p string) ([]int64, error) {
 	}
 	return res, nil
 }
+
+// Int32Range a closed range of int32.
+type Int32Range struct {
+	From, To int32
+}
+
+// SplitInt32Ranges split string like:n1,n2-n3 into int32 ranges.
+func SplitInt32Ranges(s, p string) ([]Int32Range, error) {
+	var res []Int32Range
+	for _, sc := range strings.Split(s, p) {
+		if sc = strings.TrimSpace(sc); sc == "" {
+			continue
+		}
+		bounds := strings.SplitN(sc, "-", 2)
+		from, err := strconv.ParseInt(strings.TrimSpace(bounds[0]), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.ParseInt(strings.TrimSpace(bounds[1]), 10, 32); err != nil {
+				return nil, err
+			}
+			if to < from {
+				return nil, fmt.Errorf("invalid range %s", sc)
+			}
+		}
+		res = append(res, Int32Range{From: int32(from), To: int32(to)})
+	}
+	return res, nil
+}
+
+// InInt32Ranges reports whether i is in one of the ranges.
+func InInt32Ranges(rs []Int32Range, i int32) bool {
+	for _, r := range rs {
+		if i >= r.From && i <= r.To {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/swanky2009/goim/pkg/bufio"
+)
+
+var (
+	// ErrBadHandshake bad handshake response
+	ErrBadHandshake = errors.New("bad handshake")
+)
+
+// Dial sends the opening handshake of a client connection on rwc, host and
+// uri are used in the request line, header is added to the request.
+func Dial(rwc io.ReadWriteCloser, rr *bufio.Reader, wr *bufio.Writer, host, uri string, header http.Header) (conn *Conn, err error) {
+	var (
+		b    []byte
+		resp = &Request{reader: rr}
+		key  = make([]byte, 16)
+	)
+	if _, err = rand.Read(key); err != nil {
+		return
+	}
+	challengeKey := base64.StdEncoding.EncodeToString(key)
+	wr.WriteString("GET " + uri + " HTTP/1.1\r\nHost: " + host + "\r\n")
+	wr.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\n")
+	wr.WriteString("Sec-WebSocket-Key: " + challengeKey + "\r\n")
+	for k, vs := range header {
+		for _, v := range vs {
+			wr.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	wr.WriteString("\r\n")
+	if err = wr.Flush(); err != nil {
+		return
+	}
+	// status line: HTTP/1.1 101 Switching Protocols
+	if b, err = resp.readLine(); err != nil {
+		return
+	}
+	if status := strings.SplitN(string(b), " ", 3); len(status) < 2 || status[1] != "101" {
+		return nil, ErrBadHandshake
+	}
+	if resp.Header, err = resp.readMIMEHeader(); err != nil {
+		return
+	}
+	if strings.ToLower(resp.Header.Get("Upgrade")) != "websocket" {
+		return nil, ErrNotWebSocket
+	}
+	if resp.Header.Get("Sec-Websocket-Accept") != computeAcceptKey(challengeKey) {
+		return nil, ErrChallengeResponse
+	}
+	conn = newConn(rwc, rr, wr)
+	conn.client = true
+	return
+}
@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"crypto/rand"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -60,6 +61,12 @@ type Conn struct {
 	rwc io.ReadWriteCloser
 	r   *bufio.Reader
 	w   *bufio.Writer
+
+	// client side frames must be masked
+	client  bool
+	maskKey [4]byte
+	maskPos int
+	pending []byte // peeked payload not masked yet
 }
 
 // new connection
@@ -79,6 +86,7 @@ func (c *Conn) WriteMessage(msgType int, msg []byte) (err error) {
 // WriteHeader write header frame.
 func (c *Conn) WriteHeader(msgType int, length int) (err error) {
 	var h []byte
+	c.maskPending()
 	if h, err = c.w.Peek(2); err != nil {
 		return
 	}
@@ -106,27 +114,78 @@ func (c *Conn) WriteHeader(msgType int, length int) (err error) {
 		}
 		binary.BigEndian.PutUint64(h, uint64(length))
 	}
+	if c.client {
+		// 3.Masking key.
+		h[1] |= maskBit
+		if h, err = c.w.Peek(4); err != nil {
+			return
+		}
+		if _, err = rand.Read(c.maskKey[:]); err != nil {
+			return
+		}
+		copy(h, c.maskKey[:])
+		c.maskPos = 0
+	}
 	return
 }
 
 // WriteBody write a message body.
 func (c *Conn) WriteBody(b []byte) (err error) {
-	if len(b) > 0 {
+	if len(b) == 0 {
+		return
+	}
+	if !c.client {
 		_, err = c.w.Write(b)
+		return
+	}
+	// copy into the writer buffer and mask it there, b is left untouched
+	var (
+		n   int
+		buf []byte
+	)
+	c.maskPending()
+	for len(b) > 0 {
+		if c.w.Available() == 0 {
+			if err = c.w.Flush(); err != nil {
+				return
+			}
+		}
+		if n = c.w.Available(); n > len(b) {
+			n = len(b)
+		}
+		if buf, err = c.w.Peek(n); err != nil {
+			return
+		}
+		copy(buf, b[:n])
+		c.maskPos = maskBytes(c.maskKey[:], c.maskPos, buf)
+		b = b[n:]
 	}
 	return
 }
 
 // Peek write peek.
-func (c *Conn) Peek(n int) ([]byte, error) {
-	return c.w.Peek(n)
+func (c *Conn) Peek(n int) (b []byte, err error) {
+	c.maskPending()
+	if b, err = c.w.Peek(n); err == nil && c.client {
+		c.pending = b
+	}
+	return
 }
 
 // Flush flush writer buffer
 func (c *Conn) Flush() error {
+	c.maskPending()
 	return c.w.Flush()
 }
 
+// maskPending masks the payload filled by the caller after the last peek.
+func (c *Conn) maskPending() {
+	if c.pending != nil {
+		c.maskPos = maskBytes(c.maskKey[:], c.maskPos, c.pending)
+		c.pending = nil
+	}
+}
+
 // ReadMessage read a message.
 func (c *Conn) ReadMessage() (op int, payload []byte, err error) {
 	var (